@@ -0,0 +1,71 @@
+// Package main is the entry point of the command, that reconciles merch catalog with the catalog file.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/database"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/catalog"
+)
+
+func main() {
+	// Catalog flags are parsed together with the application configuration flags
+	file := flag.String("f", "", "merch catalog file, CATALOG_FILE by default")
+	dryRun := flag.Bool("dry-run", false, "print the changes without making them")
+
+	// Get application cofiguration
+	cfg, err := config.Get()
+	if err != nil {
+		log.Fatalf("config building: %s", err)
+	}
+
+	path := *file
+	if path == "" {
+		path = cfg.CatalogFile
+	}
+	if path == "" {
+		log.Fatal("catalog file is not set")
+	}
+
+	// Read the declared catalog
+	declared, err := catalog.Load(path)
+	if err != nil {
+		log.Fatalf("catalog: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Create connection pool
+	dbpool, err := database.NewConnectionPool(ctx, cfg.DatabaseURI)
+	if err != nil {
+		log.Fatalf("connection pool: %s", err)
+	}
+	defer dbpool.Close()
+
+	// Create repository
+	repo, err := repository.New(dbpool)
+	if err != nil {
+		log.Fatalf("repository: %s", err)
+	}
+
+	// Create shop service
+	shopService, err := shop.NewService(repo, cfg)
+	if err != nil {
+		log.Fatalf("shop service: %s", err)
+	}
+
+	// Reconcile the catalog and print the changes, made before a failure as well
+	changes, err := shopService.ReconcileCatalog(ctx, declared, *dryRun)
+	fmt.Print(catalog.Format(changes))
+	if err != nil {
+		log.Fatalf("catalog reconciliation: %s", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Package main is the entry point of the command, that provisions accounts of users.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/database"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+func main() {
+	// User flags are parsed together with the application configuration flags
+	userName := flag.String("u", "", "name of the user")
+	password := flag.String("p", "", "password of the user, read from standard input by default")
+
+	// Get application cofiguration
+	cfg, err := config.Get()
+	if err != nil {
+		log.Fatalf("config building: %s", err)
+	}
+
+	if *userName == "" {
+		log.Fatal("user name is not set")
+	}
+
+	// Read the password, so it doesn't have to be left in the shell history
+	if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatalf("password: %s", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+	if *password == "" {
+		log.Fatal("password is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Create connection pool
+	dbpool, err := database.NewConnectionPool(ctx, cfg.DatabaseURI)
+	if err != nil {
+		log.Fatalf("connection pool: %s", err)
+	}
+	defer dbpool.Close()
+
+	// Create repository
+	repo, err := repository.New(dbpool)
+	if err != nil {
+		log.Fatalf("repository: %s", err)
+	}
+
+	// Create shop service
+	shopService, err := shop.NewService(repo, cfg)
+	if err != nil {
+		log.Fatalf("shop service: %s", err)
+	}
+
+	// Provision the account
+	err = shopService.ProvisionUser(ctx, model.User{UserName: *userName, Password: *password})
+	if err != nil {
+		log.Fatalf("user provisioning: %s", err)
+	}
+
+	fmt.Printf("user %s has been provisioned\n", *userName)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// Operations handles request of user transfers and purchases, that can be reversed.
+func (h *Handler) Operations(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	userName := r.URL.Query().Get("username")
+	if userName == "" {
+		_ = render.Render(w, r, ErrEmptyUser)
+		return
+	}
+
+	user := model.User{
+		UserName: userName,
+	}
+
+	operations, err := h.service.UserOperations(ctx, user)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgUserOperations, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the operations to response
+	if err = render.Render(w, r, &operations); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// ReverseTransfer handles reverse transfer request.
+func (h *Handler) ReverseTransfer(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get reversal request struct from request
+	var reversalRequest model.ReversalRequest
+	if err := render.Bind(r, &reversalRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	reversal, err := h.service.ReverseTransfer(ctx, reversalRequest.ID, reversalRequest.Force)
+	// Check if there is no such transfer
+	if err != nil && errors.Is(err, shop.ErrNoSuchTransfer) {
+		slog.Info(msgReverseTransfer, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownTransfer)
+		return
+	}
+	// Check if the transfer has already been reversed
+	if err != nil && errors.Is(err, shop.ErrAlreadyReversed) {
+		slog.Info(msgReverseTransfer, argError, err.Error())
+		_ = render.Render(w, r, ErrAlreadyReversed)
+		return
+	}
+	// Check if the receiver balance is enough
+	if err != nil && errors.Is(err, shop.ErrNotEnoughBalance) {
+		slog.Info(msgReverseTransfer, argError, err.Error())
+		_ = render.Render(w, r, ErrReversalNegativeBalance)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgReverseTransfer, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the reversal to response
+	if err = render.Render(w, r, &reversal); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// ReversePurchase handles reverse purchase request.
+func (h *Handler) ReversePurchase(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get reversal request struct from request
+	var reversalRequest model.ReversalRequest
+	if err := render.Bind(r, &reversalRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	reversal, err := h.service.ReversePurchase(ctx, reversalRequest.ID)
+	// Check if there is no such purchase
+	if err != nil && errors.Is(err, shop.ErrNoSuchPurchase) {
+		slog.Info(msgReversePurchase, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownPurchase)
+		return
+	}
+	// Check if the purchase has already been reversed
+	if err != nil && errors.Is(err, shop.ErrAlreadyReversed) {
+		slog.Info(msgReversePurchase, argError, err.Error())
+		_ = render.Render(w, r, ErrAlreadyReversed)
+		return
+	}
+	// Check if the purchased items have been transferred, listed or returned
+	if err != nil && errors.Is(err, shop.ErrNotEnoughItems) {
+		slog.Info(msgReversePurchase, argError, err.Error())
+		_ = render.Render(w, r, ErrPurchaseItemsGone)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgReversePurchase, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the reversal to response
+	if err = render.Render(w, r, &reversal); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
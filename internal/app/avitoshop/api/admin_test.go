@@ -0,0 +1,288 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Admin handler", func() {
+	var (
+		err                 error
+		errSomethingStrange error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		endpoint string
+
+		reversalRequest      model.ReversalRequest
+		reversalRequestBytes []byte
+
+		expectReversal   model.Reversal
+		expectOperations model.Operations
+	)
+
+	BeforeEach(func() {
+		errSomethingStrange = errors.New("something strange")
+
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("Receiving request at the /api/admin/reversal/transfer endpoint", func() {
+		BeforeEach(func() {
+			endpoint = "/api/admin/reversal/transfer"
+			server.AppendHandlers(handler.ReverseTransfer)
+
+			reversalRequest = model.ReversalRequest{
+				ID: 1,
+			}
+
+			reversalRequestBytes, err = json.Marshal(&reversalRequest)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		When("the method is POST and the transfer can be reversed", func() {
+			BeforeEach(func() {
+				expectReversal = model.Reversal{
+					ID:         3,
+					ReversalOf: 1,
+					Kind:       model.ReversalKindTransfer,
+					FromUser:   "user1",
+					ToUser:     "user",
+					Amount:     100,
+				}
+
+				repo.EXPECT().ReverseTransfer(gomock.Any(), gomock.Any(), 1, false).Return(expectReversal, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the reversal", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var reversal model.Reversal
+				err = json.NewDecoder(response.Body).Decode(&reversal)
+				DeferCleanup(response.Body.Close)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reversal.ReversalOf).To(Equal(1))
+				Expect(reversal.Amount).To(Equal(int64(100)))
+			})
+		})
+
+		When("the method is POST and there is no such transfer", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ReverseTransfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Reversal{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+
+		When("the method is POST and the transfer has already been reversed", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ReverseTransfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Reversal{}, repository.ErrAlreadyReversed).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the method is POST and the reversal would make the balance negative", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ReverseTransfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Reversal{}, repository.ErrNegativeBalance).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the method is POST and payload is invalid", func() {
+			BeforeEach(func() {
+				reversalRequestBytes, err = json.Marshal(&model.ReversalRequest{})
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/reversal/purchase endpoint", func() {
+		BeforeEach(func() {
+			endpoint = "/api/admin/reversal/purchase"
+			server.AppendHandlers(handler.ReversePurchase)
+
+			reversalRequest = model.ReversalRequest{
+				ID: 2,
+			}
+
+			reversalRequestBytes, err = json.Marshal(&reversalRequest)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		When("the method is POST and the purchase can be reversed", func() {
+			BeforeEach(func() {
+				expectReversal = model.Reversal{
+					ID:         4,
+					ReversalOf: 2,
+					Kind:       model.ReversalKindPurchase,
+					ToUser:     "user",
+					Item:       "book",
+					Quantity:   1,
+					Amount:     50,
+				}
+
+				repo.EXPECT().ReversePurchase(gomock.Any(), gomock.Any(), 2).Return(expectReversal, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the reversal", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var reversal model.Reversal
+				err = json.NewDecoder(response.Body).Decode(&reversal)
+				DeferCleanup(response.Body.Close)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reversal.Item).To(Equal("book"))
+				Expect(reversal.Amount).To(Equal(int64(50)))
+			})
+		})
+
+		When("the method is POST and there is no such purchase", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ReversePurchase(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Reversal{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+
+		When("the method is POST and the purchased items are no longer in the inventory", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ReversePurchase(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Reversal{}, repository.ErrNotEnoughItems).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the method is POST, but something has gone wrong with the service", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ReversePurchase(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Reversal{}, errSomethingStrange).Times(1)
+			})
+
+			It("returns status 'Internal server error' (500)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(reversalRequestBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusInternalServerError))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/operations endpoint", func() {
+		BeforeEach(func() {
+			endpoint = "/api/admin/operations"
+			server.AppendHandlers(handler.Operations)
+		})
+
+		When("the method is GET and user is given", func() {
+			BeforeEach(func() {
+				expectOperations = model.Operations{
+					Transfers: []model.Transfer{
+						{ID: 1, FromUser: "user", ToUser: "user1", Amount: 100},
+					},
+					Purchases: []model.Purchase{
+						{ID: 2, Type: "book", Quantity: 1, PricePaid: 50},
+					},
+				}
+
+				repo.EXPECT().GetOperations(gomock.Any(), gomock.Any(), model.User{UserName: "user"}).Return(expectOperations, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the operations", func() {
+				response, err := http.Get(server.URL() + endpoint + "?username=user")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var operations model.Operations
+				err = json.NewDecoder(response.Body).Decode(&operations)
+				DeferCleanup(response.Body.Close)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(operations.Transfers).Should(HaveLen(1))
+				Expect(operations.Purchases).Should(HaveLen(1))
+			})
+		})
+
+		When("the method is GET and user is empty", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response, err := http.Get(server.URL() + endpoint)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+})
@@ -3,10 +3,13 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 
 	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
@@ -25,6 +28,89 @@ const (
 	msgSendCoins   = "send coins"
 	msgBuyItem     = "buy item"
 	msgUserInfo    = "user info"
+
+	msgQuoteBuy       = "quote buy"
+	msgQuoteSendCoins = "quote send coins"
+
+	msgReverseTransfer = "reverse transfer"
+	msgReversePurchase = "reverse purchase"
+	msgUserOperations  = "user operations"
+
+	msgMintCoins   = "mint coins"
+	msgBurnCoins   = "burn coins"
+	msgMoneySupply = "money supply"
+
+	msgSettings       = "settings"
+	msgUpdateSettings = "update settings"
+
+	msgCreateWallet       = "create wallet"
+	msgWallets            = "wallets"
+	msgWallet             = "wallet"
+	msgSetWalletMember    = "set wallet member"
+	msgRemoveWalletMember = "remove wallet member"
+	msgFundWallet         = "fund wallet"
+	msgWalletSendCoins    = "wallet send coins"
+	msgWalletBuyItem      = "wallet buy item"
+
+	msgBuyGift     = "buy gift"
+	msgDeclineGift = "decline gift"
+
+	msgTransferItems = "transfer items"
+	msgReturnItem    = "return item"
+
+	msgCreateListing = "create listing"
+	msgCancelListing = "cancel listing"
+	msgBuyListing    = "buy listing"
+	msgListings      = "listings"
+	msgUserListings  = "user listings"
+
+	msgCatalog     = "catalog"
+	msgCatalogItem = "catalog item"
+
+	msgCreateMerch  = "create merch"
+	msgUpdateMerch  = "update merch"
+	msgArchiveMerch = "archive merch"
+	msgRestoreMerch = "restore merch"
+	msgMerchAudit   = "merch audit"
+	msgRestockMerch = "restock merch"
+	msgLowStock     = "low stock"
+	msgSetPrice     = "set merch price"
+	msgMerchPrices  = "merch prices"
+	msgPurchases    = "purchases"
+
+	msgCart           = "cart"
+	msgAddToCart      = "add to cart"
+	msgRemoveFromCart = "remove from cart"
+	msgCheckout       = "checkout"
+
+	msgCreatePromotion = "create promotion"
+	msgPromotions      = "promotions"
+
+	msgMerchVariants       = "merch variants"
+	msgCreateMerchVariant  = "create merch variant"
+	msgRestockMerchVariant = "restock merch variant"
+	msgInventory           = "inventory"
+
+	msgSetPurchaseLimit    = "set purchase limit"
+	msgRemovePurchaseLimit = "remove purchase limit"
+	msgPurchaseLimits      = "purchase limits"
+
+	msgUserOrders   = "user orders"
+	msgOrders       = "orders"
+	msgAdvanceOrder = "advance order"
+	msgCancelOrder  = "cancel order"
+
+	msgWishlist           = "wishlist"
+	msgAddToWishlist      = "add to wishlist"
+	msgRemoveFromWishlist = "remove from wishlist"
+
+	msgMerchDrops      = "merch drops"
+	msgCreateMerchDrop = "create merch drop"
+
+	msgCreateHold  = "create hold"
+	msgHold        = "hold"
+	msgCaptureHold = "capture hold"
+	msgReleaseHold = "release hold"
 )
 
 // Handler handles all HTTP requests.
@@ -138,6 +224,12 @@ func (h *Handler) SendCoins(w http.ResponseWriter, r *http.Request) {
 		_ = render.Render(w, r, ErrNotEnoughCoins)
 		return
 	}
+	// Check if amount is not less than the minimum
+	if err != nil && errors.Is(err, shop.ErrAmountBelowMinimum) {
+		slog.Info(msgSendCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrAmountBelowMinimum)
+		return
+	}
 
 	if err != nil {
 		// Something has gone wrong
@@ -149,40 +241,63 @@ func (h *Handler) SendCoins(w http.ResponseWriter, r *http.Request) {
 	render.Status(r, http.StatusOK)
 }
 
-// BuyItem handles buy item request.
+// BuyItem handles legacy buy item request, that buys one item given in the path.
 func (h *Handler) BuyItem(w http.ResponseWriter, r *http.Request) {
-	// Get context from request
-	ctx := r.Context()
-
 	itemType := strings.TrimPrefix(r.URL.Path, "/api/buy/")
 	if itemType == "" {
 		_ = render.Render(w, r, ErrEmptyItem)
 		return
 	}
 
-	// Get user from request
-	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
-	if err != nil {
+	item := model.InventoryItem{
+		Type:     itemType,
+		Quantity: 1,
+	}
+
+	h.buy(w, r, item, r.URL.Query().Get("promoCode"), "")
+}
+
+// Buy handles request to buy a quantity of an item.
+func (h *Handler) Buy(w http.ResponseWriter, r *http.Request) {
+	// Get buy request struct from request
+	var buyRequest model.BuyRequest
+	if err := render.Bind(r, &buyRequest); err != nil {
 		_ = render.Render(w, r, ErrorRenderer(err))
 		return
 	}
 
 	item := model.InventoryItem{
-		Type:     itemType,
-		Quantity: 1,
+		Type:     buyRequest.Item,
+		Size:     buyRequest.Size,
+		Color:    buyRequest.Color,
+		Quantity: buyRequest.Quantity,
 	}
 
-	err = h.service.BuyItem(ctx, user, item)
-	// Check if there is no such item
-	if err != nil && errors.Is(err, shop.ErrNoSuchItem) {
-		slog.Info(msgBuyItem, argError, err.Error())
-		_ = render.Render(w, r, ErrUnknownMerch)
+	h.buy(w, r, item, buyRequest.PromoCode, buyRequest.QuoteToken)
+}
+
+// buy buys an item for the user of the request with an optional promo code,
+// at the price of the quote with a given token, if the token is given.
+func (h *Handler) buy(w http.ResponseWriter, r *http.Request, item model.InventoryItem, promoCode string, quoteToken string) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
 		return
 	}
-	// Check if user enough balance
-	if err != nil && errors.Is(err, shop.ErrNotEnoughBalance) {
+
+	if quoteToken != "" {
+		err = h.service.BuyQuotedItem(ctx, user, item, promoCode, quoteToken)
+	} else {
+		err = h.service.BuyItem(ctx, user, item, promoCode)
+	}
+	// Check purchase errors
+	if errResponse := buyErrorResponse(err); errResponse != nil {
 		slog.Info(msgBuyItem, argError, err.Error())
-		_ = render.Render(w, r, ErrNotEnoughCoins)
+		_ = render.Render(w, r, errResponse)
 		return
 	}
 
@@ -196,6 +311,33 @@ func (h *Handler) BuyItem(w http.ResponseWriter, r *http.Request) {
 	render.Status(r, http.StatusOK)
 }
 
+// buyErrorResponse returns the response to errors of a purchase, or nil for other errors.
+func buyErrorResponse(err error) *ErrorResponse {
+	switch {
+	case errors.Is(err, shop.ErrNoSuchItem):
+		return ErrUnknownMerch
+	case errors.Is(err, shop.ErrNotEnoughBalance):
+		return ErrNotEnoughCoins
+	case errors.Is(err, shop.ErrAmountOutOfRange):
+		return ErrorRenderer(err)
+	case errors.Is(err, shop.ErrOutOfStock):
+		return ErrOutOfStock
+	case errors.Is(err, shop.ErrNoSuchVariant):
+		return ErrUnknownVariant
+	case errors.Is(err, shop.ErrPurchaseLimitReached):
+		return ErrPurchaseLimitReached
+	case errors.Is(err, shop.ErrMerchNotOnSale):
+		return ErrMerchNotOnSale
+	case errors.Is(err, shop.ErrInvalidPromoCode):
+		return ErrInvalidPromoCode
+	case errors.Is(err, shop.ErrPromoCodeUsedUp):
+		return ErrPromoCodeUsedUp
+	case errors.Is(err, shop.ErrInvalidQuote):
+		return ErrInvalidQuote
+	}
+	return nil
+}
+
 // Info handles info request.
 func (h *Handler) Info(w http.ResponseWriter, r *http.Request) {
 	// Get context from request
@@ -226,3 +368,12 @@ func (h *Handler) Info(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// pathID returns id of an entity from the request path.
+func pathID(r *http.Request, entity string) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("wrong %s id", entity)
+	}
+	return id, nil
+}
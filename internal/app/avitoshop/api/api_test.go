@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/jwtauth/v5"
 	. "github.com/onsi/ginkgo/v2"
@@ -52,9 +54,13 @@ var _ = Describe("Handler", func() {
 		ja *jwtauth.JWTAuth
 
 		expectAuthResponse model.AuthResponse
-		expectBalance      int
+		expectBalance      int64
 		expectInventory    []model.InventoryItem
 		expectHistory      model.CoinsHistory
+		expectReversals    []model.Reversal
+		expectExpiring     []model.ExpiringCoins
+		expectGifts        model.Gifts
+		expectItemsHistory model.ItemsHistory
 
 		username   string
 		toUsername string
@@ -78,6 +84,12 @@ var _ = Describe("Handler", func() {
 		repo = mock.NewMockRepository(ctrl)
 		Expect(repo).ShouldNot(BeNil())
 
+		// Settings are not changed by administrators
+		repo.EXPECT().GetSettings(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_, _ any, defaults model.Settings) (model.Settings, error) {
+				return defaults, nil
+			}).AnyTimes()
+
 		service, err = shop.NewService(repo, cfg)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(service).ShouldNot(BeNil())
@@ -109,7 +121,7 @@ var _ = Describe("Handler", func() {
 				Expect(err).ShouldNot(HaveOccurred())
 
 				repo.EXPECT().CreateUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(user, nil).Times(1)
-				repo.EXPECT().CreateBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				repo.EXPECT().CreateBalance(gomock.Any(), gomock.Any(), gomock.Any(), cfg.WelcomeBonus).Return(nil).Times(1)
 			})
 
 			It("returns status 'OK' (200) and a token", func() {
@@ -127,6 +139,57 @@ var _ = Describe("Handler", func() {
 			})
 		})
 
+		When("the user is an administrator, that has not been provisioned", func() {
+			BeforeEach(func() {
+				cfg.Admins = []string{"admin"}
+
+				user = model.User{
+					UserName: "admin",
+					Password: "password",
+				}
+
+				userBytes, err = json.Marshal(&user)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				repo.EXPECT().GetUser(gomock.Any(), gomock.Any(), user.UserName).Return(model.User{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Unauthorized' (401) and doesn't register the user", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(userBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				DeferCleanup(response.Body.Close)
+
+				Expect(response.StatusCode).Should(Equal(http.StatusUnauthorized))
+			})
+		})
+
+		When("the user is a provisioned administrator", func() {
+			BeforeEach(func() {
+				cfg.Admins = []string{"admin"}
+
+				user = model.User{
+					UserName: "admin",
+					Password: "password",
+				}
+
+				userBytes, err = json.Marshal(&user)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				hash, err := auth.HashPassword(user.Password)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				repo.EXPECT().GetUser(gomock.Any(), gomock.Any(), user.UserName).Return(model.User{UserName: user.UserName, Password: hash}, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and a token", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(userBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				DeferCleanup(response.Body.Close)
+
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
 		When("the method is POST, content type is right but payload is wrong", func() {
 			BeforeEach(func() {
 				user = model.User{
@@ -296,7 +359,7 @@ var _ = Describe("Handler", func() {
 				Expect(err).ShouldNot(HaveOccurred())
 
 				repo.EXPECT().CreateUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(user, nil).Times(1)
-				repo.EXPECT().CreateBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(errSomethingStrange).Times(1)
+				repo.EXPECT().CreateBalance(gomock.Any(), gomock.Any(), gomock.Any(), cfg.WelcomeBonus).Return(errSomethingStrange).Times(1)
 			})
 
 			It("returns status 'Internal server error' (500)", func() {
@@ -462,7 +525,7 @@ var _ = Describe("Handler", func() {
 			BeforeEach(func() {
 				itemType = "book"
 
-				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").Return(nil).Times(1)
 			})
 
 			It("returns status 'OK' (200)", func() {
@@ -477,6 +540,25 @@ var _ = Describe("Handler", func() {
 			})
 		})
 
+		When("the method is GET and the item is out of stock", func() {
+			BeforeEach(func() {
+				itemType = "pink-hoody"
+
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").Return(repository.ErrOutOfStock).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				request, err := http.NewRequest(http.MethodGet, server.URL()+endpoint+itemType, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				request.Header.Add("Authorization", "Bearer "+token)
+
+				response, err := http.DefaultClient.Do(request)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
 		When("the method is GET and item is empty", func() {
 			BeforeEach(func() {
 				itemType = ""
@@ -495,6 +577,138 @@ var _ = Describe("Handler", func() {
 		})
 	})
 
+	Context("Receiving request at the /api/buy endpoint", func() {
+		BeforeEach(func() {
+			endpoint = "/api/buy"
+
+			secretKey = "secret"
+			username = "user"
+
+			ja = auth.NewAuth(secretKey)
+			Expect(ja).ShouldNot(BeNil())
+
+			_, token, err = auth.NewJWTToken(ja, username)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).NotTo(BeEmpty())
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+		})
+
+		do := func(method string, path string, body string) *http.Response {
+			request, err := http.NewRequest(method, server.URL()+path, strings.NewReader(body))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			request.Header.Add("Content-Type", ContentTypeJSON)
+			request.Header.Add("Authorization", "Bearer "+token)
+
+			response, err := http.DefaultClient.Do(request)
+			Expect(err).ShouldNot(HaveOccurred())
+			DeferCleanup(response.Body.Close)
+
+			return response
+		}
+
+		When("the method is POST and the quantity is given", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "book", Quantity: 3}
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), item, "").Return(nil).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response := do(http.MethodPost, endpoint, `{"item": "book", "quantity": 3}`)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("the method is POST and the quantity is not given", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "book", Quantity: 1}
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), item, "").Return(nil).Times(1)
+			})
+
+			It("buys one item and returns status 'OK' (200)", func() {
+				response := do(http.MethodPost, endpoint, `{"item": "book"}`)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("the method is POST and the quantity is negative", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, endpoint, `{"item": "book", "quantity": -1}`)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is POST and the cost is out of range", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").Return(repository.ErrOutOfRange).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, endpoint, `{"item": "book", "quantity": 2000000000}`)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is POST and the promo code is given", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "hoody", Quantity: 1}
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), item, "HOODY20").Return(nil).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response := do(http.MethodPost, endpoint, `{"item": "hoody", "promoCode": "HOODY20"}`)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("the method is POST and the promo code is not valid for the item", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "HOODY20").Return(repository.ErrPromoNotApplicable).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, endpoint, `{"item": "book", "promoCode": "HOODY20"}`)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is POST and the promo code has been used up", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "HOODY20").Return(repository.ErrPromoUsedUp).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, endpoint, `{"item": "hoody", "promoCode": "HOODY20"}`)
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the method is GET and the promo code is given in the query", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "HOODY20").Return(nil).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response := do(http.MethodGet, endpoint+"/hoody?promoCode=HOODY20", "")
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("the legacy route is disabled", func() {
+			BeforeEach(func() {
+				cfg.LegacyBuyRoute = false
+			})
+
+			It("returns status 'Bad request' (400) for GET request", func() {
+				response := do(http.MethodGet, endpoint+"/book", "")
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
 	Context("Receiving request at the /api/info endpoint", func() {
 		BeforeEach(func() {
 			endpoint = "/api/info"
@@ -517,6 +731,19 @@ var _ = Describe("Handler", func() {
 				expectInventory = []model.InventoryItem{
 					{Type: "book", Quantity: 1},
 				}
+				expectExpiring = []model.ExpiringCoins{
+					{Amount: 100, ExpiresAt: time.Now().Add(24 * time.Hour)},
+				}
+				expectGifts = model.Gifts{
+					Received: []model.Gift{
+						{ID: 1, FromUser: "user1", ToUser: username, Item: "cup", PricePaid: 20, Status: model.GiftStatusSent},
+					},
+				}
+				expectItemsHistory = model.ItemsHistory{
+					Sent: []model.ItemTransfer{
+						{ID: 1, FromUser: username, ToUser: "user2", Item: "book", Quantity: 1},
+					},
+				}
 				expectHistory = model.CoinsHistory{
 					Received: []model.CoinsReceiving{
 						{FromUser: "user1", Amount: 100},
@@ -526,9 +753,13 @@ var _ = Describe("Handler", func() {
 					},
 				}
 
-				repo.EXPECT().GetBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectBalance, nil).Times(1)
+				repo.EXPECT().GetBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Balance{Coins: expectBalance, Held: 30}, nil).Times(1)
 				repo.EXPECT().GetInventory(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectInventory, nil).Times(1)
 				repo.EXPECT().GetHistory(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectHistory, nil).Times(1)
+				repo.EXPECT().GetReversals(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectReversals, nil).Times(1)
+				repo.EXPECT().GetExpiringCoins(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(expectExpiring, nil).Times(1)
+				repo.EXPECT().GetGifts(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectGifts, nil).Times(1)
+				repo.EXPECT().GetItemsHistory(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectItemsHistory, nil).Times(1)
 			})
 
 			It("returns status 'OK' (200) and an info", func() {
@@ -546,9 +777,14 @@ var _ = Describe("Handler", func() {
 				DeferCleanup(response.Body.Close)
 
 				Expect(info.Coins).To(Equal(expectBalance))
+				Expect(info.AvailableCoins).To(Equal(expectBalance - 30))
 				Expect(info.Inventory).Should(HaveLen(len(expectInventory)))
 				Expect(info.CoinsHistory.Received).Should(HaveLen(len(expectHistory.Received)))
 				Expect(info.CoinsHistory.Sent).Should(HaveLen(len(expectHistory.Sent)))
+				Expect(info.Reversals).Should(HaveLen(len(expectReversals)))
+				Expect(info.ExpiringCoins).Should(HaveLen(len(expectExpiring)))
+				Expect(info.Gifts.Received).Should(HaveLen(len(expectGifts.Received)))
+				Expect(info.ItemsHistory.Sent).Should(HaveLen(len(expectItemsHistory.Sent)))
 			})
 		})
 
@@ -561,9 +797,13 @@ var _ = Describe("Handler", func() {
 					Sent:     []model.CoinsSending{},
 				}
 
-				repo.EXPECT().GetBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectBalance, nil).Times(1)
+				repo.EXPECT().GetBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Balance{Coins: expectBalance}, nil).Times(1)
 				repo.EXPECT().GetInventory(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectInventory, nil).Times(1)
 				repo.EXPECT().GetHistory(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectHistory, nil).Times(1)
+				repo.EXPECT().GetReversals(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectReversals, nil).Times(1)
+				repo.EXPECT().GetExpiringCoins(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+				repo.EXPECT().GetGifts(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Gifts{}, nil).Times(1)
+				repo.EXPECT().GetItemsHistory(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.ItemsHistory{}, nil).Times(1)
 			})
 
 			It("returns status 'OK' (200) and no info", func() {
@@ -584,6 +824,7 @@ var _ = Describe("Handler", func() {
 				Expect(info.Inventory).Should(HaveLen(len(expectInventory)))
 				Expect(info.CoinsHistory.Received).Should(HaveLen(len(expectHistory.Received)))
 				Expect(info.CoinsHistory.Sent).Should(HaveLen(len(expectHistory.Sent)))
+				Expect(info.Reversals).Should(HaveLen(len(expectReversals)))
 			})
 		})
 
@@ -591,7 +832,7 @@ var _ = Describe("Handler", func() {
 			BeforeEach(func() {
 				expectBalance = 0
 
-				repo.EXPECT().GetBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectBalance, errSomethingStrange).Times(1)
+				repo.EXPECT().GetBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Balance{}, errSomethingStrange).Times(1)
 			})
 
 			It("returns status 'Internal server error' (500)", func() {
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// Cart handles request to view the cart of the user.
+func (h *Handler) Cart(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	cart, err := h.service.Cart(ctx, user)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCart, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderCart(w, r, &cart)
+}
+
+// AddToCart handles request to add a quantity of an item to the cart of the user.
+func (h *Handler) AddToCart(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get buy request struct from request
+	var buyRequest model.BuyRequest
+	if err = render.Bind(r, &buyRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	item := model.InventoryItem{
+		Type:     buyRequest.Item,
+		Size:     buyRequest.Size,
+		Color:    buyRequest.Color,
+		Quantity: buyRequest.Quantity,
+	}
+
+	cart, err := h.service.AddToCart(ctx, user, item)
+	// Check if there is no such item
+	if err != nil && errors.Is(err, shop.ErrNoSuchItem) {
+		slog.Info(msgAddToCart, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownMerch)
+		return
+	}
+	// Check if there is no such variant of the item
+	if err != nil && errors.Is(err, shop.ErrNoSuchVariant) {
+		slog.Info(msgAddToCart, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownVariant)
+		return
+	}
+	// Check if the quantity in the cart is too large
+	if err != nil && errors.Is(err, shop.ErrAmountOutOfRange) {
+		slog.Info(msgAddToCart, argError, err.Error())
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgAddToCart, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderCart(w, r, &cart)
+}
+
+// RemoveFromCart handles request to remove an item from the cart of the user.
+func (h *Handler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	cart, err := h.service.RemoveFromCart(ctx, user, chi.URLParam(r, "item"))
+	// Check if the item is in the cart
+	if err != nil && errors.Is(err, shop.ErrNotInCart) {
+		slog.Info(msgRemoveFromCart, argError, err.Error())
+		_ = render.Render(w, r, ErrNotInCart)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgRemoveFromCart, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderCart(w, r, &cart)
+}
+
+// Checkout handles request to buy all items of the cart of the user at once.
+func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	cart, err := h.service.Checkout(ctx, user)
+	// Check if there is anything to buy
+	if err != nil && errors.Is(err, shop.ErrEmptyCart) {
+		slog.Info(msgCheckout, argError, err.Error())
+		_ = render.Render(w, r, ErrEmptyCart)
+		return
+	}
+	// Check if any line of the cart can't be bought
+	if err != nil && errors.Is(err, shop.ErrCheckoutFailed) {
+		slog.Info(msgCheckout, argError, err.Error())
+		_ = render.Render(w, r, &CheckoutErrorResponse{
+			ErrorResponse: ErrorResponse{StatusCode: http.StatusConflict, Message: "Checkout failed"},
+			Lines:         cart.Lines,
+		})
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCheckout, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderCart(w, r, &cart)
+}
+
+// renderCart renders the cart to response.
+func (h *Handler) renderCart(w http.ResponseWriter, r *http.Request, cart *model.Cart) {
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the cart to response
+	if err := render.Render(w, r, cart); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
@@ -0,0 +1,202 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Cart handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		user = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, user.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/cart/items endpoint", func() {
+		When("the item is added to the cart", func() {
+			BeforeEach(func() {
+				cart := model.Cart{
+					Lines:   []model.CartLine{{Item: "book", Quantity: 2, Price: 50, Cost: 100, Total: 100}},
+					Total:   100,
+					Balance: 1000,
+				}
+				repo.EXPECT().AddCartItem(gomock.Any(), gomock.Any(), user, model.InventoryItem{Type: "book", Quantity: 2}).Return(nil).Times(1)
+				repo.EXPECT().GetCart(gomock.Any(), gomock.Any(), user).Return(cart, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the cart", func() {
+				response := do(http.MethodPost, "/api/cart/items", []byte(`{"item": "book", "quantity": 2}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var cart model.Cart
+				err = json.NewDecoder(response.Body).Decode(&cart)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cart.Lines).Should(HaveLen(1))
+				Expect(cart.Total).To(Equal(int64(100)))
+			})
+		})
+
+		When("the item is unknown", func() {
+			BeforeEach(func() {
+				repo.EXPECT().AddCartItem(gomock.Any(), gomock.Any(), user, gomock.Any()).Return(repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/cart/items", []byte(`{"item": "unknown"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the variant of the item is unknown", func() {
+			BeforeEach(func() {
+				repo.EXPECT().AddCartItem(gomock.Any(), gomock.Any(), user, model.InventoryItem{Type: "hoody", Size: "XXL", Quantity: 1}).Return(repository.ErrNoVariant).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/cart/items", []byte(`{"item": "hoody", "size": "XXL", "quantity": 1}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the item is not in the cart", func() {
+			BeforeEach(func() {
+				repo.EXPECT().RemoveCartItem(gomock.Any(), gomock.Any(), user, "book").Return(repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodDelete, "/api/cart/items/book", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/checkout endpoint", func() {
+		When("all lines of the cart are bought", func() {
+			BeforeEach(func() {
+				cart := model.Cart{
+					Lines:   []model.CartLine{{Item: "book", Quantity: 1, Price: 50, Cost: 50, Total: 50}},
+					Total:   50,
+					Balance: 1000,
+				}
+				repo.EXPECT().Checkout(gomock.Any(), gomock.Any(), user).Return(cart, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the bought cart", func() {
+				response := do(http.MethodPost, "/api/checkout", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("some lines of the cart can't be bought", func() {
+			BeforeEach(func() {
+				cart := model.Cart{
+					Lines: []model.CartLine{
+						{Item: "book", Quantity: 1, Price: 50, Cost: 50, Total: 50},
+						{Item: "cup", Quantity: 3, Price: 20, Reason: model.CartReasonOutOfStock, Total: 50},
+					},
+					Total:   50,
+					Balance: 1000,
+				}
+				repo.EXPECT().Checkout(gomock.Any(), gomock.Any(), user).Return(cart, repository.ErrCheckoutFailed).Times(1)
+			})
+
+			It("returns status 'Conflict' (409) and the reasons of the failed lines", func() {
+				response := do(http.MethodPost, "/api/checkout", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+
+				var checkoutError api.CheckoutErrorResponse
+				err = json.NewDecoder(response.Body).Decode(&checkoutError)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(checkoutError.Lines).Should(HaveLen(2))
+				Expect(checkoutError.Lines[1].Reason).To(Equal(model.CartReasonOutOfStock))
+			})
+		})
+
+		When("the cart is empty", func() {
+			BeforeEach(func() {
+				repo.EXPECT().Checkout(gomock.Any(), gomock.Any(), user).Return(model.Cart{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/checkout", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+})
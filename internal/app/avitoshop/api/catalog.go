@@ -0,0 +1,493 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/coins"
+)
+
+// Catalog handles request of merch catalog.
+func (h *Handler) Catalog(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get catalog filter from request
+	filter, err := catalogFilter(r)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	catalog, err := h.service.Catalog(ctx, filter)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCatalog, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Render the catalog to response
+	if err = respondWithETag(w, r, model.Catalog(catalog)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// CatalogItem handles request of merch catalog item.
+func (h *Handler) CatalogItem(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	item, err := h.service.CatalogItem(ctx, chi.URLParam(r, "type"))
+	// Check if there is no such item
+	if err != nil && errors.Is(err, shop.ErrNoSuchItem) {
+		slog.Info(msgCatalogItem, argError, err.Error())
+		_ = render.Render(w, r, ErrMerchNotFound)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCatalogItem, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Render the item to response
+	if err = respondWithETag(w, r, &item); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// CreateMerch handles request to add new merch to the catalog.
+func (h *Handler) CreateMerch(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get merch request struct from request
+	var merchRequest model.MerchRequest
+	if err = render.Bind(r, &merchRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	item, err := h.service.CreateMerch(ctx, model.MerchItem{
+		Type:        merchRequest.Type,
+		Price:       merchRequest.Price,
+		Description: merchRequest.Description,
+	}, admin)
+	// Check if merch of the type already exists
+	if err != nil && errors.Is(err, shop.ErrMerchAlreadyExists) {
+		slog.Info(msgCreateMerch, argError, err.Error())
+		_ = render.Render(w, r, ErrMerchAlreadyExists)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCreateMerch, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the merch to response
+	if err = render.Render(w, r, &item); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// UpdateMerch handles request to change price or description of merch.
+func (h *Handler) UpdateMerch(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get merch update struct from request
+	var update model.MerchUpdate
+	if err = render.Bind(r, &update); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	item, err := h.service.UpdateMerch(ctx, chi.URLParam(r, "type"), update, admin)
+	// Check merch errors
+	if errResponse := merchErrorResponse(err); errResponse != nil {
+		slog.Info(msgUpdateMerch, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgUpdateMerch, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the merch to response
+	if err = render.Render(w, r, &item); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// ArchiveMerch handles request to make merch unavailable for purchase.
+func (h *Handler) ArchiveMerch(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	item, err := h.service.ArchiveMerch(ctx, chi.URLParam(r, "type"), admin)
+	// Check merch errors
+	if errResponse := merchErrorResponse(err); errResponse != nil {
+		slog.Info(msgArchiveMerch, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgArchiveMerch, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the merch to response
+	if err = render.Render(w, r, &item); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// RestoreMerch handles request to make archived merch available for purchase again.
+func (h *Handler) RestoreMerch(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	item, err := h.service.RestoreMerch(ctx, chi.URLParam(r, "type"), admin)
+	// Check merch errors
+	if errResponse := merchErrorResponse(err); errResponse != nil {
+		slog.Info(msgRestoreMerch, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgRestoreMerch, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the merch to response
+	if err = render.Render(w, r, &item); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// MerchAudit handles request of merch catalog changes, optionally of a given merch type only.
+func (h *Handler) MerchAudit(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	audit, err := h.service.MerchAudit(ctx, r.URL.Query().Get("type"))
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgMerchAudit, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the audit to response
+	if err = render.Render(w, r, model.MerchAudit(audit)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// RestockMerch handles request to add a quantity of merch to its stock.
+func (h *Handler) RestockMerch(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get restock request struct from request
+	var restock model.RestockRequest
+	if err = render.Bind(r, &restock); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	item, err := h.service.RestockMerch(ctx, chi.URLParam(r, "type"), restock.Quantity, admin)
+	// Check if the stock would overflow
+	if err != nil && errors.Is(err, shop.ErrAmountOutOfRange) {
+		slog.Info(msgRestockMerch, argError, err.Error())
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+	// Check merch errors
+	if errResponse := merchErrorResponse(err); errResponse != nil {
+		slog.Info(msgRestockMerch, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgRestockMerch, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the merch to response
+	if err = render.Render(w, r, &item); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// LowStock handles request of available merch running out of stock.
+// The threshold query parameter overrides the configured low stock threshold.
+func (h *Handler) LowStock(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	threshold := -1
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		var err error
+		threshold, err = strconv.Atoi(value)
+		if err != nil || threshold < 0 {
+			_ = render.Render(w, r, ErrorRenderer(fmt.Errorf("wrong threshold: %s", value)))
+			return
+		}
+	}
+
+	items, err := h.service.LowStockMerch(ctx, threshold)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgLowStock, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the merch to response
+	if err = render.Render(w, r, model.Catalog(items)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// SetMerchPrice handles request to change price of merch from a given time.
+func (h *Handler) SetMerchPrice(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get merch price request struct from request
+	var priceRequest model.MerchPriceRequest
+	if err = render.Bind(r, &priceRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	merchPrice, err := h.service.SetMerchPrice(ctx, chi.URLParam(r, "type"), priceRequest, admin)
+	// Check merch errors
+	if errResponse := merchErrorResponse(err); errResponse != nil {
+		slog.Info(msgSetPrice, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgSetPrice, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the price to response
+	if err = render.Render(w, r, &merchPrice); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// MerchPrices handles request of the price history of merch, including scheduled prices.
+func (h *Handler) MerchPrices(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	prices, err := h.service.MerchPrices(ctx, chi.URLParam(r, "type"))
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgMerchPrices, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the prices to response
+	if err = render.Render(w, r, model.MerchPrices(prices)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// catalogFilter returns catalog filter from the request query parameters.
+func catalogFilter(r *http.Request) (model.CatalogFilter, error) {
+	query := r.URL.Query()
+
+	filter := model.CatalogFilter{
+		MaxPrice: coins.MaxAmount,
+		Sort:     query.Get("sort"),
+	}
+
+	if minPrice := query.Get("minPrice"); minPrice != "" {
+		value, err := strconv.ParseInt(minPrice, 10, 64)
+		if err != nil {
+			return model.CatalogFilter{}, fmt.Errorf("wrong minPrice: %s", minPrice)
+		}
+		filter.MinPrice = value
+	}
+
+	if maxPrice := query.Get("maxPrice"); maxPrice != "" {
+		value, err := strconv.ParseInt(maxPrice, 10, 64)
+		if err != nil {
+			return model.CatalogFilter{}, fmt.Errorf("wrong maxPrice: %s", maxPrice)
+		}
+		filter.MaxPrice = value
+	}
+
+	if err := filter.Validate(); err != nil {
+		return model.CatalogFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// respondWithETag writes a value as JSON to response with ETag header,
+// or responds with status 'Not modified' (304), if the client already has the same value.
+func respondWithETag(w http.ResponseWriter, r *http.Request, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-type", contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+	return err
+}
+
+// merchErrorResponse returns the response to errors common for merch operations, or nil for other errors.
+func merchErrorResponse(err error) *ErrorResponse {
+	switch {
+	case errors.Is(err, shop.ErrNoSuchItem):
+		return ErrMerchNotFound
+	case errors.Is(err, shop.ErrMerchArchived):
+		return ErrMerchArchived
+	case errors.Is(err, shop.ErrMerchNotArchived):
+		return ErrMerchNotArchived
+	case errors.Is(err, shop.ErrPriceAlreadyScheduled):
+		return ErrPriceAlreadyScheduled
+	}
+	return nil
+}
@@ -0,0 +1,467 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/coins"
+)
+
+var _ = Describe("Catalog handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		admin = model.User{UserName: "admin"}
+
+		catalog = []model.MerchItem{
+			{Type: "cup", Price: 20, Description: "Ceramic mug", Available: true},
+			{Type: "pen", Price: 10, Description: "Ballpoint pen", Available: true},
+		}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		cfg.Admins = []string{admin.UserName}
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		// Catalog routes have path parameters and admin routes, so requests go through the router
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, admin.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func(endpoint string, etag string) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+endpoint, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		if etag != "" {
+			request.Header.Add("If-None-Match", etag)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/merch endpoint", func() {
+		When("the filter is given", func() {
+			BeforeEach(func() {
+				filter := model.CatalogFilter{MinPrice: 10, MaxPrice: 50, Sort: "-price"}
+				repo.EXPECT().GetCatalog(gomock.Any(), gomock.Any(), filter).Return(catalog, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200), the catalog and ETag header", func() {
+				response := get("/api/merch?minPrice=10&maxPrice=50&sort=-price", "")
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+				Expect(response.Header.Get("ETag")).NotTo(BeEmpty())
+
+				var items []model.MerchItem
+				err = json.NewDecoder(response.Body).Decode(&items)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(items).To(Equal(catalog))
+			})
+		})
+
+		When("the client already has the catalog", func() {
+			BeforeEach(func() {
+				filter := model.CatalogFilter{MaxPrice: coins.MaxAmount}
+				repo.EXPECT().GetCatalog(gomock.Any(), gomock.Any(), filter).Return(catalog, nil).Times(2)
+			})
+
+			It("returns status 'Not modified' (304)", func() {
+				response := get("/api/merch", "")
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				etag := response.Header.Get("ETag")
+				Expect(etag).NotTo(BeEmpty())
+
+				// The server handles the second request too
+				server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+				response = get("/api/merch", etag)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotModified))
+			})
+		})
+
+		When("the sort is unknown", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := get("/api/merch?sort=name", "")
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the price range is wrong", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := get("/api/merch?minPrice=100&maxPrice=10", "")
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/merch/{type} endpoint", func() {
+		When("the item exists", func() {
+			BeforeEach(func() {
+				repo.EXPECT().GetCatalogItem(gomock.Any(), gomock.Any(), "cup").Return(catalog[0], nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the item", func() {
+				response := get("/api/merch/cup", "")
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var item model.MerchItem
+				err = json.NewDecoder(response.Body).Decode(&item)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(item).To(Equal(catalog[0]))
+			})
+		})
+
+		When("there is no such item", func() {
+			BeforeEach(func() {
+				repo.EXPECT().GetCatalogItem(gomock.Any(), gomock.Any(), "unknown").Return(model.MerchItem{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := get("/api/merch/unknown", "")
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch endpoint", func() {
+		When("the merch is new", func() {
+			BeforeEach(func() {
+				item := model.MerchItem{Type: "cap", Price: 150, Description: "Baseball cap"}
+				repo.EXPECT().CreateMerch(gomock.Any(), gomock.Any(), item, admin.UserName).DoAndReturn(
+					func(_, _ any, item model.MerchItem, _ string) (model.MerchItem, error) {
+						item.Available = true
+						return item, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the merch", func() {
+				response := do(http.MethodPost, "/api/admin/merch", []byte(`{"type": "cap", "price": 150, "description": "Baseball cap"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var item model.MerchItem
+				err = json.NewDecoder(response.Body).Decode(&item)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(item.Available).To(BeTrue())
+			})
+		})
+
+		When("the merch of the type already exists", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreateMerch(gomock.Any(), gomock.Any(), gomock.Any(), admin.UserName).Return(model.MerchItem{}, repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/admin/merch", []byte(`{"type": "cup", "price": 20}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the price is negative", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/admin/merch", []byte(`{"type": "cap", "price": -1}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/{type} endpoint", func() {
+		When("the price is given", func() {
+			BeforeEach(func() {
+				price := int64(30)
+				repo.EXPECT().UpdateMerch(gomock.Any(), gomock.Any(), "cup", model.MerchUpdate{Price: &price}, admin.UserName).Return(
+					model.MerchItem{Type: "cup", Price: price, Available: true}, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the changed merch", func() {
+				response := do(http.MethodPatch, "/api/admin/merch/cup", []byte(`{"price": 30}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var item model.MerchItem
+				err = json.NewDecoder(response.Body).Decode(&item)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(item.Price).To(Equal(int64(30)))
+			})
+		})
+
+		When("nothing is given", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPatch, "/api/admin/merch/cup", []byte(`{}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("there is no such merch", func() {
+			BeforeEach(func() {
+				repo.EXPECT().UpdateMerch(gomock.Any(), gomock.Any(), "unknown", gomock.Any(), admin.UserName).Return(model.MerchItem{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodPatch, "/api/admin/merch/unknown", []byte(`{"description": "Unknown"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/{type}/archive endpoint", func() {
+		When("the merch is available", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ArchiveMerch(gomock.Any(), gomock.Any(), "cup", admin.UserName).Return(model.MerchItem{Type: "cup", Price: 20}, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the archived merch", func() {
+				response := do(http.MethodPost, "/api/admin/merch/cup/archive", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var item model.MerchItem
+				err = json.NewDecoder(response.Body).Decode(&item)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(item.Available).To(BeFalse())
+			})
+		})
+
+		When("the merch has already been archived", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ArchiveMerch(gomock.Any(), gomock.Any(), "cup", admin.UserName).Return(model.MerchItem{}, repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/cup/archive", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the user is not an administrator", func() {
+			BeforeEach(func() {
+				_, token, err = auth.NewJWTToken(ja, "user")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns status 'Forbidden' (403)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/cup/archive", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusForbidden))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/{type}/restore endpoint", func() {
+		When("the merch is not archived", func() {
+			BeforeEach(func() {
+				repo.EXPECT().RestoreMerch(gomock.Any(), gomock.Any(), "cup", admin.UserName).Return(model.MerchItem{}, repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/cup/restore", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/audit endpoint", func() {
+		BeforeEach(func() {
+			audit := []model.MerchAuditEntry{
+				{ID: 2, Type: "cup", Action: model.MerchActionArchive, Price: 20, Actor: admin.UserName},
+				{ID: 1, Type: "cup", Action: model.MerchActionUpdate, Price: 20, Available: true, Actor: admin.UserName},
+			}
+			repo.EXPECT().GetMerchAudit(gomock.Any(), gomock.Any(), "cup").Return(audit, nil).Times(1)
+		})
+
+		It("returns status 'OK' (200) and the changes of the merch", func() {
+			response := do(http.MethodGet, "/api/admin/merch/audit?type=cup", nil)
+			Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+			var audit []model.MerchAuditEntry
+			err = json.NewDecoder(response.Body).Decode(&audit)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(audit).Should(HaveLen(2))
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/{type}/restock endpoint", func() {
+		When("the quantity is given", func() {
+			BeforeEach(func() {
+				stock := 30
+				repo.EXPECT().RestockMerch(gomock.Any(), gomock.Any(), "pink-hoody", 30, admin.UserName).Return(
+					model.MerchItem{Type: "pink-hoody", Price: 500, Available: true, Stock: &stock}, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the restocked merch", func() {
+				response := do(http.MethodPost, "/api/admin/merch/pink-hoody/restock", []byte(`{"quantity": 30}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var item model.MerchItem
+				err = json.NewDecoder(response.Body).Decode(&item)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(item.Stock).NotTo(BeNil())
+				Expect(*item.Stock).To(Equal(30))
+			})
+		})
+
+		When("the quantity is not positive", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/pink-hoody/restock", []byte(`{"quantity": 0}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/low-stock endpoint", func() {
+		When("the threshold is not given", func() {
+			BeforeEach(func() {
+				repo.EXPECT().GetLowStockMerch(gomock.Any(), gomock.Any(), cfg.LowStockThreshold).Return(catalog[:1], nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the merch below the configured threshold", func() {
+				response := do(http.MethodGet, "/api/admin/merch/low-stock", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var items []model.MerchItem
+				err = json.NewDecoder(response.Body).Decode(&items)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(items).Should(HaveLen(1))
+			})
+		})
+
+		When("the threshold is given", func() {
+			BeforeEach(func() {
+				repo.EXPECT().GetLowStockMerch(gomock.Any(), gomock.Any(), 0).Return(nil, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response := do(http.MethodGet, "/api/admin/merch/low-stock?threshold=0", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/{type}/prices endpoint", func() {
+		When("the method is POST and the price is scheduled", func() {
+			BeforeEach(func() {
+				repo.EXPECT().SetMerchPrice(gomock.Any(), gomock.Any(), "cup", int64(15), time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), admin.UserName).Return(
+					model.MerchPrice{Type: "cup", Price: 15, ValidFrom: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), Actor: admin.UserName}, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the scheduled price", func() {
+				response := do(http.MethodPost, "/api/admin/merch/cup/prices", []byte(`{"price": 15, "validFrom": "2100-01-01T00:00:00Z"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var merchPrice model.MerchPrice
+				err = json.NewDecoder(response.Body).Decode(&merchPrice)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(merchPrice.Price).To(Equal(int64(15)))
+			})
+		})
+
+		When("the method is POST and there is a price from the same time", func() {
+			BeforeEach(func() {
+				repo.EXPECT().SetMerchPrice(gomock.Any(), gomock.Any(), "cup", int64(15), gomock.Any(), admin.UserName).Return(model.MerchPrice{}, repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/cup/prices", []byte(`{"price": 15, "validFrom": "2100-01-01T00:00:00Z"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the method is POST and the time is in the past", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/cup/prices", []byte(`{"price": 15, "validFrom": "2000-01-01T00:00:00Z"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is GET", func() {
+			BeforeEach(func() {
+				validTo := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+				repo.EXPECT().GetMerchPrices(gomock.Any(), gomock.Any(), "cup").Return([]model.MerchPrice{
+					{Type: "cup", Price: 20, ValidFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), ValidTo: &validTo},
+					{Type: "cup", Price: 15, ValidFrom: validTo, Actor: admin.UserName},
+				}, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the price history", func() {
+				response := do(http.MethodGet, "/api/admin/merch/cup/prices", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var prices []model.MerchPrice
+				err = json.NewDecoder(response.Body).Decode(&prices)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(prices).Should(HaveLen(2))
+				Expect(prices[1].ValidTo).To(BeNil())
+			})
+		})
+	})
+})
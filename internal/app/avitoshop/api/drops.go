@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// MerchDrops handles request of current and upcoming drops of merch.
+func (h *Handler) MerchDrops(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	drops, err := h.service.MerchDrops(ctx)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgMerchDrops, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the drops to response
+	if err = render.Render(w, r, model.MerchDrops(drops)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// CreateMerchDrop handles request to schedule a drop of merch.
+func (h *Handler) CreateMerchDrop(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get drop request struct from request
+	var dropRequest model.MerchDropRequest
+	if err = render.Bind(r, &dropRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	drop, err := h.service.CreateMerchDrop(ctx, chi.URLParam(r, "type"), dropRequest, admin)
+	// Check if the drop overlaps another drop of the merch
+	if err != nil && errors.Is(err, shop.ErrDropOverlaps) {
+		slog.Info(msgCreateMerchDrop, argError, err.Error())
+		_ = render.Render(w, r, ErrDropOverlaps)
+		return
+	}
+	// Check merch errors
+	if errResponse := merchErrorResponse(err); errResponse != nil {
+		slog.Info(msgCreateMerchDrop, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCreateMerchDrop, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the drop to response
+	if err = render.Render(w, r, &drop); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
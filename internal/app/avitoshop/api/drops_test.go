@@ -0,0 +1,178 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Merch drops handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		admin = model.User{UserName: "admin"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		cfg.Admins = []string{admin.UserName}
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, admin.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+	Context("Receiving request at the /api/admin/merch/{type}/drops endpoint", func() {
+		When("the drop is created", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreateMerchDrop(gomock.Any(), gomock.Any(), gomock.Any(), admin.UserName).DoAndReturn(
+					func(_ any, _ any, drop model.MerchDrop, actor string) (model.MerchDrop, error) {
+						drop.ID = 1
+						drop.Actor = actor
+						return drop, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the drop", func() {
+				startsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+				endsAt := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+				response := do(http.MethodPost, "/api/admin/merch/pink-hoody/drops",
+					[]byte(`{"startsAt": "`+startsAt+`", "endsAt": "`+endsAt+`", "price": 250, "stock": 100}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var drop model.MerchDrop
+				err = json.NewDecoder(response.Body).Decode(&drop)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(drop.Type).To(Equal("pink-hoody"))
+				Expect(*drop.Price).To(Equal(int64(250)))
+				Expect(*drop.Stock).To(Equal(100))
+			})
+		})
+
+		When("the drop ends before it starts", func() {
+			It("returns status 'Bad request' (400)", func() {
+				startsAt := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+				endsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+				response := do(http.MethodPost, "/api/admin/merch/pink-hoody/drops",
+					[]byte(`{"startsAt": "`+startsAt+`", "endsAt": "`+endsAt+`"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the drop overlaps another drop of the merch", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreateMerchDrop(gomock.Any(), gomock.Any(), gomock.Any(), admin.UserName).Return(model.MerchDrop{}, repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				startsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+				endsAt := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+				response := do(http.MethodPost, "/api/admin/merch/pink-hoody/drops",
+					[]byte(`{"startsAt": "`+startsAt+`", "endsAt": "`+endsAt+`"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/merch/drops endpoint", func() {
+		When("there are upcoming drops", func() {
+			BeforeEach(func() {
+				drops := []model.MerchDrop{{ID: 1, Type: "pink-hoody", StartsAt: time.Now().Add(time.Hour), EndsAt: time.Now().Add(2 * time.Hour)}}
+				repo.EXPECT().GetMerchDrops(gomock.Any(), gomock.Any()).Return(drops, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the drops", func() {
+				response := do(http.MethodGet, "/api/merch/drops", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var drops []model.MerchDrop
+				err = json.NewDecoder(response.Body).Decode(&drops)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(drops).To(HaveLen(1))
+				Expect(drops[0].Type).To(Equal("pink-hoody"))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/buy endpoint for merch sold in drops only", func() {
+		When("there is no drop going on", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "pink-hoody", Quantity: 1}
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), admin, item, "").Return(repository.ErrNotOnSale).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/buy", []byte(`{"item": "pink-hoody"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+})
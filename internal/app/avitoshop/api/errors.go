@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
 )
 
 var (
@@ -13,12 +15,68 @@ var (
 	ErrUnknownMerch             = &ErrorResponse{StatusCode: 400, Message: "Unkown merch"}
 	ErrUnknownUser              = &ErrorResponse{StatusCode: 400, Message: "Unkown user to send coins"}
 	ErrNotEnoughCoins           = &ErrorResponse{StatusCode: 400, Message: "Not enough coins"}
+	ErrEmptyUser                = &ErrorResponse{StatusCode: 400, Message: "Empty user"}
+	ErrAmountOutOfRange         = &ErrorResponse{StatusCode: 400, Message: "Amount of coins is out of range"}
+	ErrAmountBelowMinimum       = &ErrorResponse{StatusCode: 400, Message: "Amount of coins is less than the minimum transfer amount"}
+	ErrGiftToSelf               = &ErrorResponse{StatusCode: 400, Message: "The buyer and the recipient of gift are the same"}
+	ErrUnknownRecipient         = &ErrorResponse{StatusCode: 400, Message: "Unknown recipient of gift"}
+	ErrItemsToSelf              = &ErrorResponse{StatusCode: 400, Message: "The sender and the receiver of items are the same"}
+	ErrUnknownItemsReceiver     = &ErrorResponse{StatusCode: 400, Message: "Unknown user to send items"}
+	ErrNotEnoughItems           = &ErrorResponse{StatusCode: 400, Message: "Not enough items"}
+	ErrEmptyCart                = &ErrorResponse{StatusCode: 400, Message: "Cart is empty"}
+	ErrInvalidPromoCode         = &ErrorResponse{StatusCode: 400, Message: "Promo code is not valid for the purchase"}
+	ErrUnknownVariant           = &ErrorResponse{StatusCode: 400, Message: "Unknown variant of merch"}
+	ErrOwnListing               = &ErrorResponse{StatusCode: 400, Message: "The seller can't buy own listing"}
 	ErrWrongLoginPassword       = &ErrorResponse{StatusCode: 401, Message: "Wrong login/password"}
+	ErrForbidden                = &ErrorResponse{StatusCode: 403, Message: "Forbidden"}
+	ErrWalletPermissionDenied   = &ErrorResponse{StatusCode: 403, Message: "Wallet role doesn't allow the operation"}
 	ErrNotFound                 = &ErrorResponse{StatusCode: 404, Message: "Resource not found"}
+	ErrUnknownTransfer          = &ErrorResponse{StatusCode: 404, Message: "Unknown transfer"}
+	ErrUnknownPurchase          = &ErrorResponse{StatusCode: 404, Message: "Unknown purchase"}
+	ErrUnknownAccount           = &ErrorResponse{StatusCode: 404, Message: "Unknown user"}
+	ErrUnknownWallet            = &ErrorResponse{StatusCode: 404, Message: "Unknown wallet"}
+	ErrUnknownWalletMember      = &ErrorResponse{StatusCode: 404, Message: "Unknown wallet member"}
+	ErrUnknownGift              = &ErrorResponse{StatusCode: 404, Message: "Unknown gift"}
+	ErrUnknownListing           = &ErrorResponse{StatusCode: 404, Message: "Unknown listing"}
+	ErrNoReturnablePurchase     = &ErrorResponse{StatusCode: 404, Message: "No purchase of the item to return"}
+	ErrMerchNotFound            = &ErrorResponse{StatusCode: 404, Message: "Unknown merch"}
+	ErrNotInCart                = &ErrorResponse{StatusCode: 404, Message: "Item is not in the cart"}
+	ErrNotInWishlist            = &ErrorResponse{StatusCode: 404, Message: "Item is not in the wishlist"}
+	ErrVariantNotFound          = &ErrorResponse{StatusCode: 404, Message: "Unknown variant of merch"}
+	ErrPurchaseLimitNotFound    = &ErrorResponse{StatusCode: 404, Message: "No purchase limit of the merch"}
+	ErrUnknownOrder             = &ErrorResponse{StatusCode: 404, Message: "Unknown order"}
+	ErrUnknownHold              = &ErrorResponse{StatusCode: 404, Message: "Unknown hold"}
 	ErrMethodNotAllowed         = &ErrorResponse{StatusCode: 405, Message: "Method not allowed"}
 	ErrLoginIsAlreadyTaken      = &ErrorResponse{StatusCode: 409, Message: "Login has already been taken"}
+	ErrAlreadyReversed          = &ErrorResponse{StatusCode: 409, Message: "Operation has already been reversed"}
+	ErrReversalNegativeBalance  = &ErrorResponse{StatusCode: 409, Message: "Reversal would make the balance negative"}
+	ErrWalletWithoutAdmin       = &ErrorResponse{StatusCode: 409, Message: "Wallet must have an administrator"}
+	ErrGiftAlreadyDeclined      = &ErrorResponse{StatusCode: 409, Message: "Gift has already been declined"}
+	ErrGiftItemTransferred      = &ErrorResponse{StatusCode: 409, Message: "Gift item is no longer in the inventory"}
+	ErrPurchaseItemsGone        = &ErrorResponse{StatusCode: 409, Message: "Purchased items are no longer in the inventory"}
+	ErrListingClosed            = &ErrorResponse{StatusCode: 409, Message: "Listing has already been sold or cancelled"}
+	ErrMerchAlreadyExists       = &ErrorResponse{StatusCode: 409, Message: "Merch of the type already exists"}
+	ErrMerchArchived            = &ErrorResponse{StatusCode: 409, Message: "Merch has already been archived"}
+	ErrMerchNotArchived         = &ErrorResponse{StatusCode: 409, Message: "Merch is not archived"}
+	ErrOutOfStock               = &ErrorResponse{StatusCode: 409, Message: "Merch is out of stock"}
+	ErrPriceAlreadyScheduled    = &ErrorResponse{StatusCode: 409, Message: "Price of the merch is already set from the time"}
+	ErrPromoCodeUsedUp          = &ErrorResponse{StatusCode: 409, Message: "Promo code has been used up"}
+	ErrPromoCodeAlreadyExists   = &ErrorResponse{StatusCode: 409, Message: "Promo code already exists"}
+	ErrVariantAlreadyExists     = &ErrorResponse{StatusCode: 409, Message: "Variant of merch already exists"}
+	ErrPurchaseLimitReached     = &ErrorResponse{StatusCode: 409, Message: "Purchase limit of the merch has been reached"}
+	ErrMerchNotOnSale           = &ErrorResponse{StatusCode: 409, Message: "Merch is sold in drops only and is not on sale now"}
+	ErrDropOverlaps             = &ErrorResponse{StatusCode: 409, Message: "Drop overlaps another drop of the merch"}
+	ErrInvalidQuote             = &ErrorResponse{StatusCode: 409, Message: "Quote is unknown, expired or doesn't match the purchase"}
+	ErrHoldClosed               = &ErrorResponse{StatusCode: 409, Message: "Hold has already been captured, released or has expired"}
+	ErrInvalidOrderTransition   = &ErrorResponse{StatusCode: 409, Message: "Order can't be moved to the status"}
 )
 
+// CheckoutErrorResponse is the error response of failed checkout with the reasons of the failed lines.
+type CheckoutErrorResponse struct {
+	ErrorResponse
+	Lines []model.CartLine `json:"lines"`
+}
+
 type ErrorResponse struct {
 	Err        error  `json:"-"`
 	StatusCode int    `json:"-"`
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// BuyGift handles request to buy an item as a gift for another user.
+func (h *Handler) BuyGift(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	buyer, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get gift request struct from request
+	var giftRequest model.GiftRequest
+	if err = render.Bind(r, &giftRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Check if buyer and recipient of gift are the same
+	if buyer.UserName == giftRequest.ToUser {
+		_ = render.Render(w, r, ErrGiftToSelf)
+		return
+	}
+
+	recipient := model.User{
+		UserName: giftRequest.ToUser,
+	}
+
+	item := model.InventoryItem{
+		Type:     giftRequest.Item,
+		Size:     giftRequest.Size,
+		Color:    giftRequest.Color,
+		Quantity: 1,
+	}
+
+	gift, err := h.service.BuyGift(ctx, buyer, recipient, item, giftRequest.Message)
+	// Check if there is no such item
+	if err != nil && errors.Is(err, shop.ErrNoSuchItem) {
+		slog.Info(msgBuyGift, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownMerch)
+		return
+	}
+	// Check if recipient does not exist
+	if err != nil && errors.Is(err, shop.ErrNoSuchUser) {
+		slog.Info(msgBuyGift, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownRecipient)
+		return
+	}
+	// Check if user enough balance
+	if err != nil && errors.Is(err, shop.ErrNotEnoughBalance) {
+		slog.Info(msgBuyGift, argError, err.Error())
+		_ = render.Render(w, r, ErrNotEnoughCoins)
+		return
+	}
+	// Check if the item is out of stock
+	if err != nil && errors.Is(err, shop.ErrOutOfStock) {
+		slog.Info(msgBuyGift, argError, err.Error())
+		_ = render.Render(w, r, ErrOutOfStock)
+		return
+	}
+	// Check if there is no such variant of the item
+	if err != nil && errors.Is(err, shop.ErrNoSuchVariant) {
+		slog.Info(msgBuyGift, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownVariant)
+		return
+	}
+	// Check if the buyer has reached the purchase limit of the item
+	if err != nil && errors.Is(err, shop.ErrPurchaseLimitReached) {
+		slog.Info(msgBuyGift, argError, err.Error())
+		_ = render.Render(w, r, ErrPurchaseLimitReached)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgBuyGift, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the gift to response
+	if err = render.Render(w, r, &gift); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// DeclineGift handles request of gift recipient to decline the gift.
+func (h *Handler) DeclineGift(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	recipient, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "gift")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	gift, err := h.service.DeclineGift(ctx, recipient, id)
+	// Check if there is no such gift
+	if err != nil && errors.Is(err, shop.ErrNoSuchGift) {
+		slog.Info(msgDeclineGift, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownGift)
+		return
+	}
+	// Check if the gift has already been declined
+	if err != nil && errors.Is(err, shop.ErrGiftAlreadyDeclined) {
+		slog.Info(msgDeclineGift, argError, err.Error())
+		_ = render.Render(w, r, ErrGiftAlreadyDeclined)
+		return
+	}
+	// Check if the gift item has been transferred to someone else
+	if err != nil && errors.Is(err, shop.ErrNotEnoughItems) {
+		slog.Info(msgDeclineGift, argError, err.Error())
+		_ = render.Render(w, r, ErrGiftItemTransferred)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgDeclineGift, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the gift to response
+	if err = render.Render(w, r, &gift); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
@@ -0,0 +1,183 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Gifts handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		buyer = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		// Gift routes have path parameters, so requests go through the router
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, buyer.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/gifts endpoint", func() {
+		When("the method is POST and the balance of the buyer is enough", func() {
+			BeforeEach(func() {
+				recipient := model.User{UserName: "user1"}
+				item := model.InventoryItem{Type: "cup", Quantity: 1}
+				gift := model.Gift{ID: 1, FromUser: buyer.UserName, ToUser: recipient.UserName, Item: "cup", Message: "Happy birthday!", PricePaid: 20, Status: model.GiftStatusSent}
+				repo.EXPECT().BuyGift(gomock.Any(), gomock.Any(), buyer, recipient, item, "Happy birthday!").Return(gift, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the gift", func() {
+				response := do(http.MethodPost, "/api/gifts", []byte(`{"toUser": "user1", "item": "cup", "message": "Happy birthday!"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var gift model.Gift
+				err = json.NewDecoder(response.Body).Decode(&gift)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(gift.ID).To(Equal(1))
+				Expect(gift.Status).To(Equal(model.GiftStatusSent))
+			})
+		})
+
+		When("the method is POST and the recipient is the buyer", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/gifts", []byte(`{"toUser": "user", "item": "cup"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is POST and the recipient doesn't exist", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BuyGift(gomock.Any(), gomock.Any(), buyer, gomock.Any(), gomock.Any(), "").Return(model.Gift{}, repository.ErrNoUser).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/gifts", []byte(`{"toUser": "unknown", "item": "cup"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is POST and the balance of the buyer is not enough", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BuyGift(gomock.Any(), gomock.Any(), buyer, gomock.Any(), gomock.Any(), "").Return(model.Gift{}, repository.ErrNegativeBalance).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/gifts", []byte(`{"toUser": "user1", "item": "pink-hoody"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/gifts/{id}/decline endpoint", func() {
+		When("the gift has been received by the user", func() {
+			BeforeEach(func() {
+				gift := model.Gift{ID: 1, FromUser: "user1", ToUser: buyer.UserName, Item: "cup", PricePaid: 20, Status: model.GiftStatusDeclined}
+				repo.EXPECT().DeclineGift(gomock.Any(), gomock.Any(), 1, buyer).Return(gift, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the declined gift", func() {
+				response := do(http.MethodPost, "/api/gifts/1/decline", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var gift model.Gift
+				err = json.NewDecoder(response.Body).Decode(&gift)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(gift.Status).To(Equal(model.GiftStatusDeclined))
+			})
+		})
+
+		When("the gift has not been received by the user", func() {
+			BeforeEach(func() {
+				repo.EXPECT().DeclineGift(gomock.Any(), gomock.Any(), 2, buyer).Return(model.Gift{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodPost, "/api/gifts/2/decline", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+
+		When("the gift has already been declined", func() {
+			BeforeEach(func() {
+				repo.EXPECT().DeclineGift(gomock.Any(), gomock.Any(), 1, buyer).Return(model.Gift{}, repository.ErrAlreadyReversed).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/gifts/1/decline", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+})
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// CreateHold handles request of an integration to hold coins on the balance of a user.
+func (h *Handler) CreateHold(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get integration from request
+	integration, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get hold request struct from request
+	var holdRequest model.HoldRequest
+	if err = render.Bind(r, &holdRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	hold, err := h.service.CreateHold(ctx, holdRequest, integration)
+	// Check if user does not exist
+	if err != nil && errors.Is(err, shop.ErrNoSuchUser) {
+		slog.Info(msgCreateHold, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownAccount)
+		return
+	}
+	// Check if the available balance of the user is enough
+	if err != nil && errors.Is(err, shop.ErrNotEnoughBalance) {
+		slog.Info(msgCreateHold, argError, err.Error())
+		_ = render.Render(w, r, ErrNotEnoughCoins)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCreateHold, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderHold(w, r, hold)
+}
+
+// Hold handles request of an integration for a hold.
+func (h *Handler) Hold(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get integration from request
+	integration, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get hold ID from request
+	id, err := pathID(r, "hold")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	hold, err := h.service.Hold(ctx, id, integration)
+	// Check hold errors
+	if errResponse := holdErrorResponse(err); errResponse != nil {
+		slog.Info(msgHold, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgHold, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderHold(w, r, hold)
+}
+
+// CaptureHold handles request of an integration to capture coins of a hold.
+func (h *Handler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get integration from request
+	integration, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get hold ID from request
+	id, err := pathID(r, "hold")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get capture request struct from request, the whole hold is captured without the request body
+	var captureRequest model.CaptureRequest
+	if err = render.Bind(r, &captureRequest); err != nil && !errors.Is(err, io.EOF) {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	hold, err := h.service.CaptureHold(ctx, id, captureRequest.Amount, integration)
+	// Check hold errors
+	if errResponse := holdErrorResponse(err); errResponse != nil {
+		slog.Info(msgCaptureHold, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCaptureHold, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderHold(w, r, hold)
+}
+
+// ReleaseHold handles request of an integration to release a hold.
+func (h *Handler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get integration from request
+	integration, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get hold ID from request
+	id, err := pathID(r, "hold")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	hold, err := h.service.ReleaseHold(ctx, id, integration)
+	// Check hold errors
+	if errResponse := holdErrorResponse(err); errResponse != nil {
+		slog.Info(msgReleaseHold, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgReleaseHold, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderHold(w, r, hold)
+}
+
+// renderHold renders a hold to response.
+func (h *Handler) renderHold(w http.ResponseWriter, r *http.Request, hold model.Hold) {
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the hold to response
+	if err := render.Render(w, r, &hold); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// holdErrorResponse returns the response to errors common for hold operations, or nil for other errors.
+func holdErrorResponse(err error) *ErrorResponse {
+	switch {
+	case errors.Is(err, shop.ErrUnknownHold):
+		return ErrUnknownHold
+	case errors.Is(err, shop.ErrHoldClosed):
+		return ErrHoldClosed
+	case errors.Is(err, shop.ErrAmountOutOfRange):
+		return ErrAmountOutOfRange
+	case errors.Is(err, shop.ErrNotEnoughBalance):
+		return ErrNotEnoughCoins
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Holds handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		integration = model.User{UserName: "taxi"}
+
+		expiresAt = time.Now().Add(time.Hour)
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+		cfg.Integrations = []string{integration.UserName}
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		// Settings are not changed by administrators
+		repo.EXPECT().GetSettings(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_, _ any, defaults model.Settings) (model.Settings, error) {
+				return defaults, nil
+			}).AnyTimes()
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, integration.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+	Context("Receiving request at the /api/holds endpoint", func() {
+		When("the available balance of the user is enough", func() {
+			BeforeEach(func() {
+				hold := model.Hold{ID: 1, UserName: "user", Amount: 300, Status: model.HoldStatusHeld, Reason: "ride", CreatedBy: integration.UserName, ExpiresAt: expiresAt}
+				repo.EXPECT().CreateHold(gomock.Any(), gomock.Any(), gomock.Any()).Return(hold, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the hold", func() {
+				response := do(http.MethodPost, "/api/holds", []byte(`{"username": "user", "amount": 300, "reason": "ride"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var hold model.Hold
+				err = json.NewDecoder(response.Body).Decode(&hold)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(hold.ID).To(Equal(1))
+				Expect(hold.Status).To(Equal(model.HoldStatusHeld))
+			})
+		})
+
+		When("the user is not an integration", func() {
+			BeforeEach(func() {
+				_, token, err = auth.NewJWTToken(ja, "user")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns status 'Forbidden' (403)", func() {
+				response := do(http.MethodPost, "/api/holds", []byte(`{"username": "user", "amount": 300}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusForbidden))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/holds/{id}/capture endpoint", func() {
+		When("a part of the hold is captured", func() {
+			BeforeEach(func() {
+				hold := model.Hold{ID: 1, UserName: "user", Amount: 300, Status: model.HoldStatusHeld, CreatedBy: integration.UserName, ExpiresAt: expiresAt}
+				captured := hold
+				captured.Status = model.HoldStatusCaptured
+				captured.Captured = 200
+				repo.EXPECT().GetHold(gomock.Any(), gomock.Any(), 1).Return(hold, nil).Times(1)
+				repo.EXPECT().CaptureHold(gomock.Any(), gomock.Any(), 1, int64(200)).Return(captured, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the captured hold", func() {
+				response := do(http.MethodPost, "/api/holds/1/capture", []byte(`{"amount": 200}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var hold model.Hold
+				err = json.NewDecoder(response.Body).Decode(&hold)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(hold.Status).To(Equal(model.HoldStatusCaptured))
+				Expect(hold.Captured).To(Equal(int64(200)))
+			})
+		})
+
+		When("the hold has been created by another integration", func() {
+			BeforeEach(func() {
+				hold := model.Hold{ID: 1, UserName: "user", Amount: 300, Status: model.HoldStatusHeld, CreatedBy: "delivery", ExpiresAt: expiresAt}
+				repo.EXPECT().GetHold(gomock.Any(), gomock.Any(), 1).Return(hold, nil).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodPost, "/api/holds/1/capture", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/holds/{id}/release endpoint", func() {
+		When("the hold has already been captured", func() {
+			BeforeEach(func() {
+				hold := model.Hold{ID: 1, UserName: "user", Amount: 300, Captured: 300, Status: model.HoldStatusCaptured, CreatedBy: integration.UserName, ExpiresAt: expiresAt}
+				repo.EXPECT().GetHold(gomock.Any(), gomock.Any(), 1).Return(hold, nil).Times(1)
+				repo.EXPECT().ReleaseHold(gomock.Any(), gomock.Any(), 1).Return(model.Hold{}, repository.ErrHoldClosed).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/holds/1/release", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+})
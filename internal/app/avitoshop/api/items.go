@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// TransferItems handles request to transfer owned items to another user.
+func (h *Handler) TransferItems(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	fromUser, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get item transfer request struct from request
+	var transferRequest model.ItemTransferRequest
+	if err = render.Bind(r, &transferRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Check if sender and receiver of items are the same
+	if fromUser.UserName == transferRequest.ToUser {
+		_ = render.Render(w, r, ErrItemsToSelf)
+		return
+	}
+
+	toUser := model.User{
+		UserName: transferRequest.ToUser,
+	}
+
+	item := model.InventoryItem{
+		Type:     transferRequest.Item,
+		Size:     transferRequest.Size,
+		Color:    transferRequest.Color,
+		Quantity: transferRequest.Quantity,
+	}
+
+	transfer, err := h.service.TransferItems(ctx, fromUser, toUser, item)
+	// Check if receiver does not exist
+	if err != nil && errors.Is(err, shop.ErrNoSuchUser) {
+		slog.Info(msgTransferItems, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownItemsReceiver)
+		return
+	}
+	// Check if sender owns enough items
+	if err != nil && errors.Is(err, shop.ErrNotEnoughItems) {
+		slog.Info(msgTransferItems, argError, err.Error())
+		_ = render.Render(w, r, ErrNotEnoughItems)
+		return
+	}
+	// Check if there is no such variant of the items
+	if err != nil && errors.Is(err, shop.ErrNoSuchVariant) {
+		slog.Info(msgTransferItems, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownVariant)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgTransferItems, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the transfer to response
+	if err = render.Render(w, r, &transfer); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// ReturnItem handles request to return a purchased item.
+func (h *Handler) ReturnItem(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get item return request struct from request
+	var returnRequest model.ItemReturnRequest
+	if err = render.Bind(r, &returnRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	itemReturn, err := h.service.ReturnItem(ctx, user, returnRequest.Item)
+	// Check if there is a purchase to return
+	if err != nil && errors.Is(err, shop.ErrNoReturnablePurchase) {
+		slog.Info(msgReturnItem, argError, err.Error())
+		_ = render.Render(w, r, ErrNoReturnablePurchase)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgReturnItem, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the return to response
+	if err = render.Render(w, r, &itemReturn); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// Purchases handles request of the user purchases with the prices paid.
+func (h *Handler) Purchases(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	purchases, err := h.service.UserPurchases(ctx, user)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgPurchases, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the purchases to response
+	if err = render.Render(w, r, model.Purchases(purchases)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
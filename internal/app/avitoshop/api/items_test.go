@@ -0,0 +1,197 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Items handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		sender = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, sender.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/inventory/transfer endpoint", func() {
+		When("the sender owns enough items", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "book", Quantity: 2}
+				transfer := model.ItemTransfer{ID: 1, FromUser: sender.UserName, ToUser: "user1", Item: "book", Quantity: 2}
+				repo.EXPECT().TransferItems(gomock.Any(), gomock.Any(), sender, model.User{UserName: "user1"}, item).Return(transfer, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the transfer", func() {
+				response := do(http.MethodPost, "/api/inventory/transfer", []byte(`{"toUser": "user1", "item": "book", "quantity": 2}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var transfer model.ItemTransfer
+				err = json.NewDecoder(response.Body).Decode(&transfer)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(transfer.Quantity).To(Equal(2))
+			})
+		})
+
+		When("the sender doesn't own enough items", func() {
+			BeforeEach(func() {
+				repo.EXPECT().TransferItems(gomock.Any(), gomock.Any(), sender, gomock.Any(), gomock.Any()).Return(model.ItemTransfer{}, repository.ErrNotEnoughItems).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/inventory/transfer", []byte(`{"toUser": "user1", "item": "book", "quantity": 5}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the variant of the items doesn't exist", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "hoody", Size: "XXL", Quantity: 1}
+				repo.EXPECT().TransferItems(gomock.Any(), gomock.Any(), sender, gomock.Any(), item).Return(model.ItemTransfer{}, repository.ErrNoVariant).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/inventory/transfer", []byte(`{"toUser": "user1", "item": "hoody", "size": "XXL", "quantity": 1}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the receiver is the sender", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/inventory/transfer", []byte(`{"toUser": "user", "item": "book", "quantity": 1}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the quantity is negative", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/inventory/transfer", []byte(`{"toUser": "user1", "item": "book", "quantity": -1}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/inventory/return endpoint", func() {
+		When("there is a purchase of the item to return", func() {
+			BeforeEach(func() {
+				itemReturn := model.ItemReturn{ID: 7, PurchaseID: 5, Item: "cup", Refund: 20}
+				repo.EXPECT().ReturnItem(gomock.Any(), gomock.Any(), sender, "cup", gomock.Any()).Return(itemReturn, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the return", func() {
+				response := do(http.MethodPost, "/api/inventory/return", []byte(`{"item": "cup"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var itemReturn model.ItemReturn
+				err = json.NewDecoder(response.Body).Decode(&itemReturn)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(itemReturn.Refund).To(Equal(int64(20)))
+			})
+		})
+
+		When("there is no purchase of the item to return", func() {
+			BeforeEach(func() {
+				repo.EXPECT().ReturnItem(gomock.Any(), gomock.Any(), sender, "cup", gomock.Any()).Return(model.ItemReturn{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodPost, "/api/inventory/return", []byte(`{"item": "cup"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/purchases endpoint", func() {
+		BeforeEach(func() {
+			repo.EXPECT().GetPurchases(gomock.Any(), gomock.Any(), sender).Return([]model.Purchase{
+				{ID: 1, Type: "cup", Quantity: 1, PricePaid: 20},
+				{ID: 2, Type: "cup", Quantity: 1, PricePaid: 15},
+			}, nil).Times(1)
+		})
+
+		It("returns status 'OK' (200) and the purchases with the prices paid", func() {
+			response := do(http.MethodGet, "/api/purchases", nil)
+			Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+			var purchases []model.Purchase
+			err = json.NewDecoder(response.Body).Decode(&purchases)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(purchases).Should(HaveLen(2))
+			Expect(purchases[1].PricePaid).To(Equal(int64(15)))
+		})
+	})
+})
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// SetPurchaseLimit handles request to set the purchase limit of merch.
+func (h *Handler) SetPurchaseLimit(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get purchase limit request struct from request
+	var limitRequest model.PurchaseLimitRequest
+	if err = render.Bind(r, &limitRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	limit, err := h.service.SetPurchaseLimit(ctx, chi.URLParam(r, "type"), limitRequest, admin)
+	// Check merch errors
+	if errResponse := merchErrorResponse(err); errResponse != nil {
+		slog.Info(msgSetPurchaseLimit, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgSetPurchaseLimit, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the limit to response
+	if err = render.Render(w, r, &limit); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// RemovePurchaseLimit handles request to remove the purchase limit of merch.
+func (h *Handler) RemovePurchaseLimit(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	err := h.service.RemovePurchaseLimit(ctx, chi.URLParam(r, "type"))
+	// Check if there is no limit to remove
+	if err != nil && errors.Is(err, shop.ErrNoSuchPurchaseLimit) {
+		slog.Info(msgRemovePurchaseLimit, argError, err.Error())
+		_ = render.Render(w, r, ErrPurchaseLimitNotFound)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgRemovePurchaseLimit, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// PurchaseLimits handles request of purchase limits of all merch.
+func (h *Handler) PurchaseLimits(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	limits, err := h.service.PurchaseLimits(ctx)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgPurchaseLimits, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the limits to response
+	if err = render.Render(w, r, model.PurchaseLimits(limits)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
@@ -0,0 +1,159 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Purchase limits handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		admin = model.User{UserName: "admin"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		cfg.Admins = []string{admin.UserName}
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, admin.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/admin/merch/{type}/limit endpoint", func() {
+		When("the limit is set", func() {
+			BeforeEach(func() {
+				repo.EXPECT().SetPurchaseLimit(gomock.Any(), gomock.Any(), gomock.Any(), admin.UserName).DoAndReturn(
+					func(_ any, _ any, limit model.PurchaseLimit, actor string) (model.PurchaseLimit, error) {
+						limit.Actor = actor
+						return limit, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the limit", func() {
+				response := do(http.MethodPut, "/api/admin/merch/pink-hoody/limit", []byte(`{"maxQuantity": 1, "periodDays": 30}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var limit model.PurchaseLimit
+				err = json.NewDecoder(response.Body).Decode(&limit)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(limit.Type).To(Equal("pink-hoody"))
+				Expect(limit.MaxQuantity).To(Equal(1))
+				Expect(*limit.PeriodDays).To(Equal(30))
+			})
+		})
+
+		When("the maximum quantity is not positive", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPut, "/api/admin/merch/pink-hoody/limit", []byte(`{"maxQuantity": 0}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the merch doesn't exist", func() {
+			BeforeEach(func() {
+				repo.EXPECT().SetPurchaseLimit(gomock.Any(), gomock.Any(), gomock.Any(), admin.UserName).Return(model.PurchaseLimit{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodPut, "/api/admin/merch/unknown/limit", []byte(`{"maxQuantity": 1}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+
+		When("there is no limit to remove", func() {
+			BeforeEach(func() {
+				repo.EXPECT().DeletePurchaseLimit(gomock.Any(), gomock.Any(), "pink-hoody").Return(repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodDelete, "/api/admin/merch/pink-hoody/limit", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/buy endpoint for merch with a purchase limit", func() {
+		When("the limit has been reached", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "pink-hoody", Quantity: 1}
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), admin, item, "").Return(repository.ErrLimitReached).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/buy", []byte(`{"item": "pink-hoody"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+})
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// CreateListing handles request to put an owned item up for sale on the marketplace.
+func (h *Handler) CreateListing(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	seller, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get listing request struct from request
+	var listingRequest model.ListingRequest
+	if err = render.Bind(r, &listingRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	item := model.InventoryItem{
+		Type:     listingRequest.Item,
+		Size:     listingRequest.Size,
+		Color:    listingRequest.Color,
+		Quantity: 1,
+	}
+
+	listing, err := h.service.CreateListing(ctx, seller, item, listingRequest.Price)
+	// Check if seller owns the item
+	if err != nil && errors.Is(err, shop.ErrNotEnoughItems) {
+		slog.Info(msgCreateListing, argError, err.Error())
+		_ = render.Render(w, r, ErrNotEnoughItems)
+		return
+	}
+	// Check if there is no such variant of the item
+	if err != nil && errors.Is(err, shop.ErrNoSuchVariant) {
+		slog.Info(msgCreateListing, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownVariant)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCreateListing, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the listing to response
+	if err = render.Render(w, r, &listing); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// CancelListing handles request of a seller to cancel the listing.
+func (h *Handler) CancelListing(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	seller, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "listing")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	listing, err := h.service.CancelListing(ctx, seller, id)
+	// Check listing errors
+	if errResponse := listingErrorResponse(err); errResponse != nil {
+		slog.Info(msgCancelListing, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCancelListing, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the listing to response
+	if err = render.Render(w, r, &listing); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// BuyListing handles request to buy a listing of the marketplace.
+func (h *Handler) BuyListing(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	buyer, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "listing")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	listing, err := h.service.BuyListing(ctx, buyer, id)
+	// Check if the buyer is the seller
+	if err != nil && errors.Is(err, shop.ErrOwnListing) {
+		slog.Info(msgBuyListing, argError, err.Error())
+		_ = render.Render(w, r, ErrOwnListing)
+		return
+	}
+	// Check if user enough balance
+	if err != nil && errors.Is(err, shop.ErrNotEnoughBalance) {
+		slog.Info(msgBuyListing, argError, err.Error())
+		_ = render.Render(w, r, ErrNotEnoughCoins)
+		return
+	}
+	// Check listing errors
+	if errResponse := listingErrorResponse(err); errResponse != nil {
+		slog.Info(msgBuyListing, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgBuyListing, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the listing to response
+	if err = render.Render(w, r, &listing); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// Listings handles request of active listings of the marketplace.
+func (h *Handler) Listings(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	listings, err := h.service.Listings(ctx, r.URL.Query().Get("item"))
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgListings, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the listings to response
+	if err = render.Render(w, r, model.Listings(listings)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// UserListings handles request of all listings of the user.
+func (h *Handler) UserListings(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	seller, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	listings, err := h.service.UserListings(ctx, seller)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgUserListings, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the listings to response
+	if err = render.Render(w, r, model.Listings(listings)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// listingErrorResponse returns the response to errors common for listing operations, or nil for other errors.
+func listingErrorResponse(err error) *ErrorResponse {
+	switch {
+	case errors.Is(err, shop.ErrNoSuchListing):
+		return ErrUnknownListing
+	case errors.Is(err, shop.ErrListingClosed):
+		return ErrListingClosed
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Market handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		user = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		// Listing routes have path parameters, so requests go through the router
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, user.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/market/listings endpoint", func() {
+		When("the method is POST and the seller owns the item", func() {
+			BeforeEach(func() {
+				listing := model.Listing{ID: 1, Seller: user.UserName, Item: "socks", Price: 5, Status: model.ListingStatusActive}
+				repo.EXPECT().CreateListing(gomock.Any(), gomock.Any(), user, model.InventoryItem{Type: "socks", Quantity: 1}, int64(5)).Return(listing, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the listing", func() {
+				response := do(http.MethodPost, "/api/market/listings", []byte(`{"item": "socks", "price": 5}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var listing model.Listing
+				err = json.NewDecoder(response.Body).Decode(&listing)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(listing.Status).To(Equal(model.ListingStatusActive))
+			})
+		})
+
+		When("the method is POST and the seller doesn't own the item", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreateListing(gomock.Any(), gomock.Any(), user, model.InventoryItem{Type: "socks", Quantity: 1}, int64(5)).Return(model.Listing{}, repository.ErrNotEnoughItems).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/market/listings", []byte(`{"item": "socks", "price": 5}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is POST and the price is negative", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/market/listings", []byte(`{"item": "socks", "price": -5}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is GET and the item is given", func() {
+			BeforeEach(func() {
+				listings := []model.Listing{{ID: 1, Seller: "user1", Item: "cup", Price: 10, Status: model.ListingStatusActive}}
+				repo.EXPECT().GetListings(gomock.Any(), gomock.Any(), "cup").Return(listings, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and active listings of the item", func() {
+				response := do(http.MethodGet, "/api/market/listings?item=cup", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var listings []model.Listing
+				err = json.NewDecoder(response.Body).Decode(&listings)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(listings).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/market/listings/my endpoint", func() {
+		When("the method is GET", func() {
+			BeforeEach(func() {
+				listings := []model.Listing{{ID: 1, Seller: user.UserName, Item: "cup", Price: 10, Status: model.ListingStatusSold, Buyer: "user1"}}
+				repo.EXPECT().GetUserListings(gomock.Any(), gomock.Any(), user).Return(listings, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and listings of the user", func() {
+				response := do(http.MethodGet, "/api/market/listings/my", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var listings []model.Listing
+				err = json.NewDecoder(response.Body).Decode(&listings)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(listings).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/market/listings/{id}/buy endpoint", func() {
+		When("the listing is active", func() {
+			BeforeEach(func() {
+				listing := model.Listing{ID: 1, Seller: "user1", Item: "cup", Price: 10, Status: model.ListingStatusSold, Buyer: user.UserName}
+				repo.EXPECT().BuyListing(gomock.Any(), gomock.Any(), 1, user).Return(listing, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the sold listing", func() {
+				response := do(http.MethodPost, "/api/market/listings/1/buy", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var listing model.Listing
+				err = json.NewDecoder(response.Body).Decode(&listing)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(listing.Status).To(Equal(model.ListingStatusSold))
+			})
+		})
+
+		When("the listing has already been sold", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BuyListing(gomock.Any(), gomock.Any(), 1, user).Return(model.Listing{}, repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/market/listings/1/buy", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the listing is of the user", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BuyListing(gomock.Any(), gomock.Any(), 1, user).Return(model.Listing{}, repository.ErrPermissionDenied).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/market/listings/1/buy", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/market/listings/{id} endpoint", func() {
+		When("the method is DELETE and the listing is not of the user", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CancelListing(gomock.Any(), gomock.Any(), 2, user).Return(model.Listing{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodDelete, "/api/market/listings/2", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// AdminOnly lets through only requests of administrators.
+func AdminOnly(cfg *config.Config) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get user from request
+			user, err := auth.UserFromRequest(r, cfg.SecretKey)
+			if err != nil {
+				_ = render.Render(w, r, ErrorRenderer(err))
+				return
+			}
+
+			// Check if user is an administrator
+			if !cfg.IsAdmin(user.UserName) {
+				_ = render.Render(w, r, ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WarehouseOnly lets through only requests of warehouse staff and administrators.
+func WarehouseOnly(cfg *config.Config) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get user from request
+			user, err := auth.UserFromRequest(r, cfg.SecretKey)
+			if err != nil {
+				_ = render.Render(w, r, ErrorRenderer(err))
+				return
+			}
+
+			// Check if user can fulfil orders
+			if !cfg.IsWarehouse(user.UserName) {
+				_ = render.Render(w, r, ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IntegrationOnly lets through only requests of integrations and administrators.
+func IntegrationOnly(cfg *config.Config) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get user from request
+			user, err := auth.UserFromRequest(r, cfg.SecretKey)
+			if err != nil {
+				_ = render.Render(w, r, ErrorRenderer(err))
+				return
+			}
+
+			// Check if user can hold coins
+			if !cfg.IsIntegration(user.UserName) {
+				_ = render.Render(w, r, ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// MintCoins handles mint coins request.
+// If the user is not given, coins are granted to every user.
+func (h *Handler) MintCoins(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get coin operation request struct from request
+	var opRequest model.CoinOperationRequest
+	if err = render.Bind(r, &opRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	op, err := h.service.MintCoins(ctx, model.CoinOperation{
+		UserName: opRequest.UserName,
+		Amount:   opRequest.Amount,
+		Actor:    admin.UserName,
+		Reason:   opRequest.Reason,
+	})
+	// Check if there is no such user
+	if err != nil && errors.Is(err, shop.ErrNoSuchAccount) {
+		slog.Info(msgMintCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownAccount)
+		return
+	}
+	// Check if the balance would overflow
+	if err != nil && errors.Is(err, shop.ErrAmountOutOfRange) {
+		slog.Info(msgMintCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrAmountOutOfRange)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgMintCoins, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the coin operation to response
+	if err = render.Render(w, r, &op); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// BurnCoins handles burn coins request.
+func (h *Handler) BurnCoins(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get coin operation request struct from request
+	var opRequest model.CoinOperationRequest
+	if err = render.Bind(r, &opRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Coins can be burned only from a given user
+	if opRequest.UserName == "" {
+		_ = render.Render(w, r, ErrEmptyUser)
+		return
+	}
+
+	op, err := h.service.BurnCoins(ctx, model.CoinOperation{
+		UserName: opRequest.UserName,
+		Amount:   opRequest.Amount,
+		Actor:    admin.UserName,
+		Reason:   opRequest.Reason,
+	})
+	// Check if there is no such user
+	if err != nil && errors.Is(err, shop.ErrNoSuchAccount) {
+		slog.Info(msgBurnCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownAccount)
+		return
+	}
+	// Check if user enough balance
+	if err != nil && errors.Is(err, shop.ErrNotEnoughBalance) {
+		slog.Info(msgBurnCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrNotEnoughCoins)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgBurnCoins, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the coin operation to response
+	if err = render.Render(w, r, &op); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// MoneySupply handles money supply request.
+func (h *Handler) MoneySupply(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	supply, err := h.service.MoneySupply(ctx)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgMoneySupply, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the money supply to response
+	if err = render.Render(w, r, &supply); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
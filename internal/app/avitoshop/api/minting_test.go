@@ -0,0 +1,295 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Minting handler", func() {
+	var (
+		err                 error
+		errSomethingStrange error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		endpoint string
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		opRequest      model.CoinOperationRequest
+		opRequestBytes []byte
+	)
+
+	BeforeEach(func() {
+		errSomethingStrange = errors.New("something strange")
+
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, "admin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	post := func(body []byte) *http.Response {
+		request, err := http.NewRequest(http.MethodPost, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/admin/coins/mint endpoint", func() {
+		BeforeEach(func() {
+			endpoint = "/api/admin/coins/mint"
+			server.AppendHandlers(handler.MintCoins)
+		})
+
+		When("the method is POST and the user is given", func() {
+			BeforeEach(func() {
+				opRequest = model.CoinOperationRequest{
+					UserName: "user",
+					Amount:   100,
+					Reason:   "hackathon winner",
+				}
+				opRequestBytes, err = json.Marshal(&opRequest)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				expectOp := model.CoinOperation{
+					Kind:     model.CoinOperationMint,
+					UserName: "user",
+					Amount:   100,
+					Actor:    "admin",
+					Reason:   "hackathon winner",
+				}
+				repo.EXPECT().MintCoins(gomock.Any(), gomock.Any(), expectOp).DoAndReturn(
+					func(_, _ any, op model.CoinOperation) (model.CoinOperation, error) {
+						op.ID = 1
+						op.Recipients = 1
+						return op, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the coin operation", func() {
+				response := post(opRequestBytes)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var op model.CoinOperation
+				err = json.NewDecoder(response.Body).Decode(&op)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(op.ID).To(Equal(1))
+				Expect(op.Actor).To(Equal("admin"))
+				Expect(op.Recipients).To(Equal(1))
+			})
+		})
+
+		When("the method is POST and the user is not given", func() {
+			BeforeEach(func() {
+				opRequest = model.CoinOperationRequest{
+					Amount: 50,
+					Reason: "holiday bonus",
+				}
+				opRequestBytes, err = json.Marshal(&opRequest)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				repo.EXPECT().GrantCoins(gomock.Any(), gomock.Any(), gomock.Any(), cfg.GrantBatchSize).DoAndReturn(
+					func(_, _ any, op model.CoinOperation, _ int) (model.CoinOperation, error) {
+						op.ID = 2
+						op.Recipients = 3
+						return op, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the grant to every user", func() {
+				response := post(opRequestBytes)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var op model.CoinOperation
+				err = json.NewDecoder(response.Body).Decode(&op)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(op.Kind).To(Equal(model.CoinOperationMint))
+				Expect(op.Recipients).To(Equal(3))
+			})
+		})
+
+		When("the method is POST and there is no such user", func() {
+			BeforeEach(func() {
+				opRequestBytes, err = json.Marshal(&model.CoinOperationRequest{UserName: "nobody", Amount: 10, Reason: "bonus"})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				repo.EXPECT().MintCoins(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.CoinOperation{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := post(opRequestBytes)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+
+		When("the method is POST and the reason is empty", func() {
+			BeforeEach(func() {
+				opRequestBytes, err = json.Marshal(&model.CoinOperationRequest{UserName: "user", Amount: 10})
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := post(opRequestBytes)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/coins/burn endpoint", func() {
+		BeforeEach(func() {
+			endpoint = "/api/admin/coins/burn"
+			server.AppendHandlers(handler.BurnCoins)
+
+			opRequest = model.CoinOperationRequest{
+				UserName: "user",
+				Amount:   100,
+				Reason:   "duplicate account",
+			}
+			opRequestBytes, err = json.Marshal(&opRequest)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		When("the method is POST and the balance is enough", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BurnCoins(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_, _ any, op model.CoinOperation) (model.CoinOperation, error) {
+						op.ID = 3
+						op.Recipients = 1
+						return op, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the coin operation", func() {
+				response := post(opRequestBytes)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var op model.CoinOperation
+				err = json.NewDecoder(response.Body).Decode(&op)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(op.Kind).To(Equal(model.CoinOperationBurn))
+				Expect(op.Amount).To(Equal(int64(100)))
+			})
+		})
+
+		When("the method is POST and the balance is not enough", func() {
+			BeforeEach(func() {
+				repo.EXPECT().BurnCoins(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.CoinOperation{}, repository.ErrNegativeBalance).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := post(opRequestBytes)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is POST and the user is not given", func() {
+			BeforeEach(func() {
+				opRequestBytes, err = json.Marshal(&model.CoinOperationRequest{Amount: 10, Reason: "cleanup"})
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := post(opRequestBytes)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/coins/supply endpoint", func() {
+		BeforeEach(func() {
+			endpoint = "/api/admin/coins/supply"
+			server.AppendHandlers(handler.MoneySupply)
+		})
+
+		When("the method is GET", func() {
+			BeforeEach(func() {
+				expectSupply := model.MoneySupply{Coins: 3000, Users: 3, Minted: 150, Burned: 50}
+				repo.EXPECT().GetMoneySupply(gomock.Any(), gomock.Any()).Return(expectSupply, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the money supply", func() {
+				response, err := http.Get(server.URL() + endpoint)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var supply model.MoneySupply
+				err = json.NewDecoder(response.Body).Decode(&supply)
+				DeferCleanup(response.Body.Close)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(supply.Coins).To(Equal(int64(3000)))
+				Expect(supply.Minted).To(Equal(int64(150)))
+			})
+		})
+
+		When("the method is GET, but something has gone wrong with the service", func() {
+			BeforeEach(func() {
+				repo.EXPECT().GetMoneySupply(gomock.Any(), gomock.Any()).Return(model.MoneySupply{}, errSomethingStrange).Times(1)
+			})
+
+			It("returns status 'Internal server error' (500)", func() {
+				response, err := http.Get(server.URL() + endpoint)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusInternalServerError))
+			})
+		})
+	})
+})
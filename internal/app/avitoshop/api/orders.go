@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// orderStatuses are the statuses, that orders can be filtered by.
+var orderStatuses = []string{model.OrderStatusPlaced, model.OrderStatusReady, model.OrderStatusDelivered, model.OrderStatusCancelled}
+
+// UserOrders handles request of orders of the user.
+func (h *Handler) UserOrders(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	orders, err := h.service.UserOrders(ctx, user)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgUserOrders, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the orders to response
+	if err = render.Render(w, r, model.Orders(orders)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// Orders handles request of orders of all users.
+// The status query parameter filters the orders by status.
+func (h *Handler) Orders(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(orderStatuses, status) {
+		_ = render.Render(w, r, ErrorRenderer(fmt.Errorf("wrong order status: %s", status)))
+		return
+	}
+
+	orders, err := h.service.Orders(ctx, status)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgOrders, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the orders to response
+	if err = render.Render(w, r, model.Orders(orders)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// ReadyOrder handles request to mark an order as ready for pickup.
+func (h *Handler) ReadyOrder(w http.ResponseWriter, r *http.Request) {
+	h.advanceOrder(w, r, model.OrderStatusReady)
+}
+
+// DeliverOrder handles request to mark an order as handed over to the user.
+func (h *Handler) DeliverOrder(w http.ResponseWriter, r *http.Request) {
+	h.advanceOrder(w, r, model.OrderStatusDelivered)
+}
+
+// advanceOrder moves an order of the request to a given status.
+func (h *Handler) advanceOrder(w http.ResponseWriter, r *http.Request, status string) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get warehouse keeper from request
+	keeper, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get order ID from request
+	id, err := pathID(r, "order")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	order, err := h.service.AdvanceOrder(ctx, id, status, keeper)
+	// Check order errors
+	if errResponse := orderErrorResponse(err); errResponse != nil {
+		slog.Info(msgAdvanceOrder, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgAdvanceOrder, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the order to response
+	if err = render.Render(w, r, &order); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// CancelOrder handles request to cancel an order with refund.
+func (h *Handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get order ID from request
+	id, err := pathID(r, "order")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	order, err := h.service.CancelOrder(ctx, id, admin)
+	// Check if the purchase of the order has already been reversed
+	if err != nil && errors.Is(err, shop.ErrAlreadyReversed) {
+		slog.Info(msgCancelOrder, argError, err.Error())
+		_ = render.Render(w, r, ErrAlreadyReversed)
+		return
+	}
+	// Check if the purchased items have been transferred, listed or returned
+	if err != nil && errors.Is(err, shop.ErrNotEnoughItems) {
+		slog.Info(msgCancelOrder, argError, err.Error())
+		_ = render.Render(w, r, ErrPurchaseItemsGone)
+		return
+	}
+	// Check order errors
+	if errResponse := orderErrorResponse(err); errResponse != nil {
+		slog.Info(msgCancelOrder, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCancelOrder, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the order to response
+	if err = render.Render(w, r, &order); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// orderErrorResponse returns the response for service errors common for order operations.
+func orderErrorResponse(err error) *ErrorResponse {
+	switch {
+	case errors.Is(err, shop.ErrNoSuchOrder):
+		return ErrUnknownOrder
+	case errors.Is(err, shop.ErrInvalidOrderTransition):
+		return ErrInvalidOrderTransition
+	}
+	return nil
+}
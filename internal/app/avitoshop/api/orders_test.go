@@ -0,0 +1,175 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Orders handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja *jwtauth.JWTAuth
+
+		keeper = model.User{UserName: "keeper"}
+		user   = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		cfg.Warehouse = []string{keeper.UserName}
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, from model.User) *http.Response {
+		_, token, err := auth.NewJWTToken(ja, from.UserName)
+		Expect(err).NotTo(HaveOccurred())
+
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(nil))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/orders endpoint", func() {
+		BeforeEach(func() {
+			orders := []model.Order{{ID: 1, PurchaseID: 5, User: user.UserName, Item: "pink-hoody", Quantity: 1, Status: model.OrderStatusPlaced}}
+			repo.EXPECT().GetUserOrders(gomock.Any(), gomock.Any(), user).Return(orders, nil).Times(1)
+		})
+
+		It("returns status 'OK' (200) and orders of the user", func() {
+			response := do(http.MethodGet, "/api/orders", user)
+			Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+			var orders []model.Order
+			err = json.NewDecoder(response.Body).Decode(&orders)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(orders).To(HaveLen(1))
+			Expect(orders[0].Status).To(Equal(model.OrderStatusPlaced))
+		})
+	})
+
+	Context("Receiving request at the /api/warehouse/orders endpoint", func() {
+		When("the status is unknown", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodGet, "/api/warehouse/orders?status=lost", keeper)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the request comes from a user out of warehouse staff", func() {
+			It("returns status 'Forbidden' (403)", func() {
+				response := do(http.MethodGet, "/api/warehouse/orders", user)
+				Expect(response.StatusCode).Should(Equal(http.StatusForbidden))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/warehouse/orders/{id}/ready endpoint", func() {
+		When("the order is placed", func() {
+			BeforeEach(func() {
+				order := model.Order{ID: 1, Status: model.OrderStatusReady, UpdatedBy: keeper.UserName}
+				repo.EXPECT().AdvanceOrder(gomock.Any(), gomock.Any(), 1, model.OrderStatusReady, keeper.UserName).Return(order, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the order", func() {
+				response := do(http.MethodPost, "/api/warehouse/orders/1/ready", keeper)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var order model.Order
+				err = json.NewDecoder(response.Body).Decode(&order)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(order.Status).To(Equal(model.OrderStatusReady))
+			})
+		})
+
+		When("the request comes from a user out of warehouse staff", func() {
+			It("returns status 'Forbidden' (403)", func() {
+				response := do(http.MethodPost, "/api/warehouse/orders/1/ready", user)
+				Expect(response.StatusCode).Should(Equal(http.StatusForbidden))
+			})
+		})
+
+		When("the order doesn't exist", func() {
+			BeforeEach(func() {
+				repo.EXPECT().AdvanceOrder(gomock.Any(), gomock.Any(), 1, model.OrderStatusReady, keeper.UserName).Return(model.Order{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodPost, "/api/warehouse/orders/1/ready", keeper)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/warehouse/orders/{id}/deliver endpoint", func() {
+		When("the order is not ready for pickup", func() {
+			BeforeEach(func() {
+				repo.EXPECT().AdvanceOrder(gomock.Any(), gomock.Any(), 1, model.OrderStatusDelivered, keeper.UserName).Return(model.Order{}, repository.ErrInvalidTransition).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/warehouse/orders/1/deliver", keeper)
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+})
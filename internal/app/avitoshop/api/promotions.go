@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// CreatePromotion handles request to create a promotion with its promo codes.
+func (h *Handler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get promotion request struct from request
+	var promotionRequest model.PromotionRequest
+	if err = render.Bind(r, &promotionRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	promotion, err := h.service.CreatePromotion(ctx, promotionRequest, admin)
+	// Check if any of the codes already exists
+	if err != nil && errors.Is(err, shop.ErrPromoCodeAlreadyExists) {
+		slog.Info(msgCreatePromotion, argError, err.Error())
+		_ = render.Render(w, r, ErrPromoCodeAlreadyExists)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCreatePromotion, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the promotion to response
+	if err = render.Render(w, r, &promotion); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// Promotions handles request of all promotions with their codes and number of uses.
+func (h *Handler) Promotions(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	promotions, err := h.service.Promotions(ctx)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgPromotions, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the promotions to response
+	if err = render.Render(w, r, model.Promotions(promotions)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
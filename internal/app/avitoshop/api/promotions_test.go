@@ -0,0 +1,152 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Promotions handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		admin = model.User{UserName: "admin"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		cfg.Admins = []string{admin.UserName}
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, admin.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/admin/promotions endpoint", func() {
+		When("the promotion is created", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreatePromotion(gomock.Any(), gomock.Any(), gomock.Any(), admin.UserName).DoAndReturn(
+					func(_ any, _ any, promotion model.Promotion, actor string) (model.Promotion, error) {
+						promotion.ID = 1
+						promotion.Actor = actor
+						return promotion, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the promotion with single-use codes", func() {
+				response := do(http.MethodPost, "/api/admin/promotions", []byte(`{"name": "Hoodies week", "kind": "percent", "value": 20, "item": "hoody", "codes": ["H1", "H2"], "singleUse": true}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var promotion model.Promotion
+				err = json.NewDecoder(response.Body).Decode(&promotion)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(promotion.ID).To(Equal(1))
+				Expect(promotion.Codes).To(Equal([]model.PromoCode{{Code: "H1", SingleUse: true}, {Code: "H2", SingleUse: true}}))
+			})
+		})
+
+		When("the percent value is out of range", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/admin/promotions", []byte(`{"name": "Too much", "kind": "percent", "value": 120, "codes": ["X"]}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("a code already exists", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreatePromotion(gomock.Any(), gomock.Any(), gomock.Any(), admin.UserName).Return(model.Promotion{}, repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/admin/promotions", []byte(`{"name": "Socks", "kind": "fixed", "value": 10, "codes": ["SOCKS"]}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the promotions are requested", func() {
+			BeforeEach(func() {
+				promotions := []model.Promotion{{ID: 1, Name: "Socks", Kind: model.PromotionFixed, Value: 10, Uses: 3}}
+				repo.EXPECT().GetPromotions(gomock.Any(), gomock.Any()).Return(promotions, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the promotions", func() {
+				response := do(http.MethodGet, "/api/admin/promotions", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var promotions model.Promotions
+				err = json.NewDecoder(response.Body).Decode(&promotions)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(promotions).Should(HaveLen(1))
+				Expect(promotions[0].Uses).To(Equal(3))
+			})
+		})
+	})
+})
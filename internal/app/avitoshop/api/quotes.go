@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// QuoteBuy handles request to quote a purchase of a quantity of an item.
+func (h *Handler) QuoteBuy(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get buy request struct from request
+	var buyRequest model.BuyRequest
+	if err = render.Bind(r, &buyRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	item := model.InventoryItem{
+		Type:     buyRequest.Item,
+		Size:     buyRequest.Size,
+		Color:    buyRequest.Color,
+		Quantity: buyRequest.Quantity,
+	}
+
+	quote, err := h.service.QuoteItem(ctx, user, item, buyRequest.PromoCode)
+	// Check purchase errors
+	if errResponse := buyErrorResponse(err); errResponse != nil {
+		slog.Info(msgQuoteBuy, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgQuoteBuy, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the quote to response
+	if err = render.Render(w, r, &quote); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// QuoteSendCoins handles request to quote sending of coins to another user.
+func (h *Handler) QuoteSendCoins(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	fromUser, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get coins sending struct from request
+	var coinsSending model.CoinsSending
+	if err = render.Bind(r, &coinsSending); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Check if sender and receiver of coins are the same
+	if fromUser.UserName == coinsSending.ToUser {
+		_ = render.Render(w, r, ErrSenderAndReceiverTheSame)
+		return
+	}
+
+	quote, err := h.service.QuoteSendCoins(ctx, fromUser, model.User{UserName: coinsSending.ToUser}, coinsSending.Amount)
+	// Check if user does not exist
+	if err != nil && errors.Is(err, shop.ErrNoSuchUser) {
+		slog.Info(msgQuoteSendCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownUser)
+		return
+	}
+	// Check if amount is not less than the minimum
+	if err != nil && errors.Is(err, shop.ErrAmountBelowMinimum) {
+		slog.Info(msgQuoteSendCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrAmountBelowMinimum)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgQuoteSendCoins, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the quote to response
+	if err = render.Render(w, r, &quote); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
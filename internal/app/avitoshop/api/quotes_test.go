@@ -0,0 +1,192 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Quotes handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		buyer = model.User{UserName: "buyer"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		// Settings are not changed by administrators
+		repo.EXPECT().GetSettings(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_, _ any, defaults model.Settings) (model.Settings, error) {
+				return defaults, nil
+			}).AnyTimes()
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, buyer.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+	Context("Receiving request at the /api/buy/quote endpoint", func() {
+		When("the purchase passes all checks", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "pink-hoody", Quantity: 2}
+				quote := model.Quote{Item: "pink-hoody", Quantity: 2, Price: 500, Cost: 1000, Total: 1000, Balance: 1000, Token: "token"}
+				repo.EXPECT().QuoteItem(gomock.Any(), gomock.Any(), buyer, item, "", cfg.QuoteTTL).Return(quote, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the quote", func() {
+				response := do(http.MethodPost, "/api/buy/quote", []byte(`{"item": "pink-hoody", "quantity": 2}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var quote model.Quote
+				err = json.NewDecoder(response.Body).Decode(&quote)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(quote.Total).To(Equal(int64(1000)))
+				Expect(quote.BalanceAfter).To(Equal(int64(0)))
+				Expect(quote.Token).To(Equal("token"))
+			})
+		})
+
+		When("balance is not enough to buy", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "pink-hoody", Quantity: 3}
+				quote := model.Quote{Item: "pink-hoody", Quantity: 3, Price: 500, Cost: 1500, Total: 1500, Balance: 1000, BalanceAfter: -500, Shortfall: 500, Token: "token"}
+				repo.EXPECT().QuoteItem(gomock.Any(), gomock.Any(), buyer, item, "", cfg.QuoteTTL).Return(quote, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the quote with the shortfall", func() {
+				response := do(http.MethodPost, "/api/buy/quote", []byte(`{"item": "pink-hoody", "quantity": 3}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var quote model.Quote
+				err = json.NewDecoder(response.Body).Decode(&quote)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(quote.Total).To(Equal(int64(1500)))
+				Expect(quote.Shortfall).To(Equal(int64(500)))
+			})
+		})
+
+		When("the merch is out of stock", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "pink-hoody", Quantity: 3}
+				repo.EXPECT().QuoteItem(gomock.Any(), gomock.Any(), buyer, item, "", cfg.QuoteTTL).Return(model.Quote{}, repository.ErrOutOfStock).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/buy/quote", []byte(`{"item": "pink-hoody", "quantity": 3}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/buy endpoint with a quote token", func() {
+		When("the quote is expired", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "pink-hoody", Quantity: 1}
+				repo.EXPECT().BuyQuotedItem(gomock.Any(), gomock.Any(), buyer, item, "", "token").Return(repository.ErrInvalidQuote).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/buy", []byte(`{"item": "pink-hoody", "quoteToken": "token"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/sendCoin/quote endpoint", func() {
+		When("the sending passes all checks", func() {
+			BeforeEach(func() {
+				quote := model.TransferQuote{ToUser: "user", Amount: 100, Balance: 1000, BalanceAfter: 900}
+				repo.EXPECT().QuoteSendCoins(gomock.Any(), gomock.Any(), buyer, model.User{UserName: "user"}, int64(100)).Return(quote, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the quote", func() {
+				response := do(http.MethodPost, "/api/sendCoin/quote", []byte(`{"toUser": "user", "amount": 100}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var quote model.TransferQuote
+				err = json.NewDecoder(response.Body).Decode(&quote)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(quote.BalanceAfter).To(Equal(int64(900)))
+			})
+		})
+
+		When("the user to send coins doesn't exist", func() {
+			BeforeEach(func() {
+				repo.EXPECT().QuoteSendCoins(gomock.Any(), gomock.Any(), buyer, model.User{UserName: "unknown"}, int64(100)).Return(model.TransferQuote{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/sendCoin/quote", []byte(`{"toUser": "unknown", "amount": 100}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+})
@@ -32,6 +32,10 @@ func NewRouter(cfg *config.Config, handle *Handler) *chi.Mux {
 	// Public routes
 	router.Group(func(r chi.Router) {
 		r.Post("/api/auth", handle.Auth)
+		r.Get("/api/merch", handle.Catalog)
+		r.Get("/api/merch/drops", handle.MerchDrops)
+		r.Get("/api/merch/{type}", handle.CatalogItem)
+		r.Get("/api/merch/{type}/variants", handle.MerchVariants)
 	})
 	// Protected routes
 	router.Group(func(r chi.Router) {
@@ -40,8 +44,103 @@ func NewRouter(cfg *config.Config, handle *Handler) *chi.Mux {
 		r.Use(jwtauth.Authenticator(tokenAuth))
 
 		r.Post("/api/sendCoin", handle.SendCoins)
-		r.Get("/api/buy/{item}", handle.BuyItem)
+		r.Post("/api/sendCoin/quote", handle.QuoteSendCoins)
+		r.Post("/api/buy", handle.Buy)
+		r.Post("/api/buy/quote", handle.QuoteBuy)
+		if cfg.LegacyBuyRoute {
+			r.Get("/api/buy/{item}", handle.BuyItem)
+		}
 		r.Get("/api/info", handle.Info)
+		r.Get("/api/inventory", handle.Inventory)
+
+		r.Post("/api/wallets", handle.CreateWallet)
+		r.Get("/api/wallets", handle.Wallets)
+		r.Get("/api/wallets/{id}", handle.Wallet)
+		r.Put("/api/wallets/{id}/members", handle.SetWalletMember)
+		r.Delete("/api/wallets/{id}/members/{username}", handle.RemoveWalletMember)
+		r.Post("/api/wallets/{id}/fund", handle.FundWallet)
+		r.Post("/api/wallets/{id}/sendCoin", handle.WalletSendCoins)
+		r.Get("/api/wallets/{id}/buy/{item}", handle.WalletBuyItem)
+
+		r.Post("/api/gifts", handle.BuyGift)
+		r.Post("/api/gifts/{id}/decline", handle.DeclineGift)
+
+		r.Post("/api/inventory/transfer", handle.TransferItems)
+		r.Post("/api/inventory/return", handle.ReturnItem)
+		r.Get("/api/purchases", handle.Purchases)
+		r.Get("/api/orders", handle.UserOrders)
+
+		r.Get("/api/cart", handle.Cart)
+		r.Post("/api/cart/items", handle.AddToCart)
+		r.Delete("/api/cart/items/{item}", handle.RemoveFromCart)
+		r.Post("/api/checkout", handle.Checkout)
+
+		r.Get("/api/wishlist", handle.Wishlist)
+		r.Post("/api/wishlist", handle.AddToWishlist)
+		r.Delete("/api/wishlist/{item}", handle.RemoveFromWishlist)
+
+		r.Post("/api/market/listings", handle.CreateListing)
+		r.Get("/api/market/listings", handle.Listings)
+		r.Get("/api/market/listings/my", handle.UserListings)
+		r.Post("/api/market/listings/{id}/buy", handle.BuyListing)
+		r.Delete("/api/market/listings/{id}", handle.CancelListing)
+	})
+	// Warehouse routes
+	router.Group(func(r chi.Router) {
+		tokenAuth := auth.NewAuth(cfg.SecretKey)
+		r.Use(jwtauth.Verifier(tokenAuth))
+		r.Use(jwtauth.Authenticator(tokenAuth))
+		r.Use(WarehouseOnly(cfg))
+
+		r.Get("/api/warehouse/orders", handle.Orders)
+		r.Post("/api/warehouse/orders/{id}/ready", handle.ReadyOrder)
+		r.Post("/api/warehouse/orders/{id}/deliver", handle.DeliverOrder)
+	})
+	// Integration routes
+	router.Group(func(r chi.Router) {
+		tokenAuth := auth.NewAuth(cfg.SecretKey)
+		r.Use(jwtauth.Verifier(tokenAuth))
+		r.Use(jwtauth.Authenticator(tokenAuth))
+		r.Use(IntegrationOnly(cfg))
+
+		r.Post("/api/holds", handle.CreateHold)
+		r.Get("/api/holds/{id}", handle.Hold)
+		r.Post("/api/holds/{id}/capture", handle.CaptureHold)
+		r.Post("/api/holds/{id}/release", handle.ReleaseHold)
+	})
+	// Admin routes
+	router.Group(func(r chi.Router) {
+		tokenAuth := auth.NewAuth(cfg.SecretKey)
+		r.Use(jwtauth.Verifier(tokenAuth))
+		r.Use(jwtauth.Authenticator(tokenAuth))
+		r.Use(AdminOnly(cfg))
+
+		r.Get("/api/admin/operations", handle.Operations)
+		r.Post("/api/admin/reversal/transfer", handle.ReverseTransfer)
+		r.Post("/api/admin/reversal/purchase", handle.ReversePurchase)
+		r.Post("/api/admin/coins/mint", handle.MintCoins)
+		r.Post("/api/admin/coins/burn", handle.BurnCoins)
+		r.Get("/api/admin/coins/supply", handle.MoneySupply)
+		r.Get("/api/admin/settings", handle.Settings)
+		r.Put("/api/admin/settings", handle.UpdateSettings)
+		r.Post("/api/admin/merch", handle.CreateMerch)
+		r.Get("/api/admin/merch/audit", handle.MerchAudit)
+		r.Get("/api/admin/merch/low-stock", handle.LowStock)
+		r.Get("/api/admin/merch/limits", handle.PurchaseLimits)
+		r.Patch("/api/admin/merch/{type}", handle.UpdateMerch)
+		r.Post("/api/admin/merch/{type}/archive", handle.ArchiveMerch)
+		r.Post("/api/admin/merch/{type}/restore", handle.RestoreMerch)
+		r.Post("/api/admin/merch/{type}/restock", handle.RestockMerch)
+		r.Post("/api/admin/merch/{type}/prices", handle.SetMerchPrice)
+		r.Get("/api/admin/merch/{type}/prices", handle.MerchPrices)
+		r.Post("/api/admin/merch/{type}/variants", handle.CreateMerchVariant)
+		r.Post("/api/admin/merch/{type}/variants/{id}/restock", handle.RestockMerchVariant)
+		r.Put("/api/admin/merch/{type}/limit", handle.SetPurchaseLimit)
+		r.Delete("/api/admin/merch/{type}/limit", handle.RemovePurchaseLimit)
+		r.Post("/api/admin/merch/{type}/drops", handle.CreateMerchDrop)
+		r.Post("/api/admin/promotions", handle.CreatePromotion)
+		r.Get("/api/admin/promotions", handle.Promotions)
+		r.Post("/api/admin/orders/{id}/cancel", handle.CancelOrder)
 	})
 
 	return router
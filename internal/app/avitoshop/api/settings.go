@@ -0,0 +1,73 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// Settings handles request of economy settings.
+func (h *Handler) Settings(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	settings, err := h.service.Settings(ctx)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgSettings, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the settings to response
+	if err = render.Render(w, r, &settings); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// UpdateSettings handles economy settings update request.
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get administrator from request
+	admin, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get settings update struct from request
+	var update model.SettingsUpdate
+	if err = render.Bind(r, &update); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(ctx, update, admin)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgUpdateSettings, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the settings to response
+	if err = render.Render(w, r, &settings); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
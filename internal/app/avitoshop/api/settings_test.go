@@ -0,0 +1,238 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Settings handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		endpoint string
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		settings model.Settings
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, "admin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+
+		settings = model.Settings{
+			WelcomeBonus:      500,
+			ReferralBonus:     100,
+			MinTransferAmount: 10,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("Receiving request at the /api/admin/settings endpoint", func() {
+		When("the method is GET", func() {
+			BeforeEach(func() {
+				endpoint = "/api/admin/settings"
+				server.AppendHandlers(handler.Settings)
+
+				defaults := model.Settings{
+					WelcomeBonus:      cfg.WelcomeBonus,
+					ReferralBonus:     cfg.ReferralBonus,
+					MinTransferAmount: cfg.MinTransferAmount,
+				}
+				repo.EXPECT().GetSettings(gomock.Any(), gomock.Any(), defaults).Return(settings, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the settings", func() {
+				response, err := http.Get(server.URL() + endpoint)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var result model.Settings
+				err = json.NewDecoder(response.Body).Decode(&result)
+				DeferCleanup(response.Body.Close)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(result).To(Equal(settings))
+			})
+		})
+
+		When("the method is PUT and payload is right", func() {
+			var updateBytes []byte
+
+			BeforeEach(func() {
+				endpoint = "/api/admin/settings"
+				server.AppendHandlers(handler.UpdateSettings)
+
+				welcomeBonus := settings.WelcomeBonus
+				update := model.SettingsUpdate{WelcomeBonus: &welcomeBonus}
+				updateBytes, err = json.Marshal(&update)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				repo.EXPECT().UpdateSettings(gomock.Any(), gomock.Any(), update, "admin").Return(nil).Times(1)
+				repo.EXPECT().GetSettings(gomock.Any(), gomock.Any(), gomock.Any()).Return(settings, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the updated settings", func() {
+				request, err := http.NewRequest(http.MethodPut, server.URL()+endpoint, bytes.NewReader(updateBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				request.Header.Add("Content-Type", ContentTypeJSON)
+				request.Header.Add("Authorization", "Bearer "+token)
+
+				response, err := http.DefaultClient.Do(request)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var result model.Settings
+				err = json.NewDecoder(response.Body).Decode(&result)
+				DeferCleanup(response.Body.Close)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(result.WelcomeBonus).To(Equal(settings.WelcomeBonus))
+			})
+		})
+
+		When("the method is PUT and the minimum transfer amount is zero", func() {
+			var updateBytes []byte
+
+			BeforeEach(func() {
+				endpoint = "/api/admin/settings"
+				server.AppendHandlers(handler.UpdateSettings)
+
+				updateBytes = []byte(`{"minTransferAmount": 0}`)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				request, err := http.NewRequest(http.MethodPut, server.URL()+endpoint, bytes.NewReader(updateBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				request.Header.Add("Content-Type", ContentTypeJSON)
+				request.Header.Add("Authorization", "Bearer "+token)
+
+				response, err := http.DefaultClient.Do(request)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/auth endpoint with a referrer", func() {
+		var userBytes []byte
+
+		BeforeEach(func() {
+			endpoint = "/api/auth"
+			server.AppendHandlers(handler.Auth)
+
+			user := model.User{
+				UserName: "user",
+				Password: "password",
+				Referrer: "user1",
+			}
+			userBytes, err = json.Marshal(&user)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			repo.EXPECT().CreateUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(user, nil).Times(1)
+			repo.EXPECT().GetSettings(gomock.Any(), gomock.Any(), gomock.Any()).Return(settings, nil).Times(1)
+			repo.EXPECT().CreateBalance(gomock.Any(), gomock.Any(), gomock.Any(), settings.WelcomeBonus).Return(nil).Times(1)
+		})
+
+		When("the referrer exists", func() {
+			BeforeEach(func() {
+				repo.EXPECT().GrantReferralBonus(gomock.Any(), gomock.Any(), model.User{UserName: "user1"}, settings.ReferralBonus).Return(nil).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(userBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("the referrer doesn't exist", func() {
+			BeforeEach(func() {
+				repo.EXPECT().GrantReferralBonus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response, err := http.Post(server.URL()+endpoint, ContentTypeJSON, bytes.NewReader(userBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/sendCoin endpoint with the minimum transfer amount", func() {
+		BeforeEach(func() {
+			endpoint = "/api/sendCoin"
+			server.AppendHandlers(handler.SendCoins)
+
+			repo.EXPECT().GetSettings(gomock.Any(), gomock.Any(), gomock.Any()).Return(settings, nil).Times(1)
+		})
+
+		When("the amount is less than the minimum", func() {
+			It("returns status 'Bad request' (400)", func() {
+				coinsSendingBytes, err := json.Marshal(&model.CoinsSending{ToUser: "user1", Amount: 5})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				request, err := http.NewRequest(http.MethodPost, server.URL()+endpoint, bytes.NewReader(coinsSendingBytes))
+				Expect(err).ShouldNot(HaveOccurred())
+				request.Header.Add("Content-Type", ContentTypeJSON)
+				request.Header.Add("Authorization", "Bearer "+token)
+
+				response, err := http.DefaultClient.Do(request)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+})
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// MerchVariants handles request of variants of merch.
+func (h *Handler) MerchVariants(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	variants, err := h.service.MerchVariants(ctx, chi.URLParam(r, "type"))
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgMerchVariants, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the variants to response
+	if err = render.Render(w, r, model.MerchVariants(variants)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// CreateMerchVariant handles request to add a variant to merch.
+func (h *Handler) CreateMerchVariant(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get variant request struct from request
+	var variantRequest model.MerchVariantRequest
+	if err := render.Bind(r, &variantRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	variant, err := h.service.CreateMerchVariant(ctx, chi.URLParam(r, "type"), variantRequest)
+	// Check if the variant already exists
+	if err != nil && errors.Is(err, shop.ErrVariantAlreadyExists) {
+		slog.Info(msgCreateMerchVariant, argError, err.Error())
+		_ = render.Render(w, r, ErrVariantAlreadyExists)
+		return
+	}
+	// Check merch errors
+	if errResponse := merchErrorResponse(err); errResponse != nil {
+		slog.Info(msgCreateMerchVariant, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCreateMerchVariant, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the variant to response
+	if err = render.Render(w, r, &variant); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// RestockMerchVariant handles request to add a quantity of a variant of merch to its stock.
+func (h *Handler) RestockMerchVariant(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get variant ID from request
+	id, err := pathID(r, "variant")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get restock request struct from request
+	var restock model.RestockRequest
+	if err = render.Bind(r, &restock); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	variant, err := h.service.RestockMerchVariant(ctx, chi.URLParam(r, "type"), id, restock.Quantity)
+	// Check if there is no such variant
+	if err != nil && errors.Is(err, shop.ErrNoSuchVariant) {
+		slog.Info(msgRestockMerchVariant, argError, err.Error())
+		_ = render.Render(w, r, ErrVariantNotFound)
+		return
+	}
+	// Check if the stock would overflow
+	if err != nil && errors.Is(err, shop.ErrAmountOutOfRange) {
+		slog.Info(msgRestockMerchVariant, argError, err.Error())
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgRestockMerchVariant, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the variant to response
+	if err = render.Render(w, r, &variant); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// Inventory handles request of the inventory of a user by type and variant of merch.
+func (h *Handler) Inventory(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	inventory, err := h.service.UserInventory(ctx, user)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgInventory, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the inventory to response
+	if err = render.Render(w, r, model.Inventory(inventory)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
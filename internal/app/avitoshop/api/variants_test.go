@@ -0,0 +1,199 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Variants handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		admin = model.User{UserName: "admin"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		cfg.Admins = []string{admin.UserName}
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, admin.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/merch/{type}/variants endpoint", func() {
+		When("the variants are requested", func() {
+			BeforeEach(func() {
+				stock := 2
+				variants := []model.MerchVariant{{ID: 4, Type: "hoody", Size: "M", Color: "black", Stock: &stock}}
+				repo.EXPECT().GetMerchVariants(gomock.Any(), gomock.Any(), "hoody").Return(variants, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the variants", func() {
+				response := do(http.MethodGet, "/api/merch/hoody/variants", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var variants model.MerchVariants
+				err = json.NewDecoder(response.Body).Decode(&variants)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(variants).Should(HaveLen(1))
+				Expect(*variants[0].Stock).To(Equal(2))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/{type}/variants endpoint", func() {
+		When("the variant is created", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreateMerchVariant(gomock.Any(), gomock.Any(), model.MerchVariant{Type: "hoody", Size: "M", Color: "black"}).DoAndReturn(
+					func(_ any, _ any, variant model.MerchVariant) (model.MerchVariant, error) {
+						variant.ID = 4
+						return variant, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the variant", func() {
+				response := do(http.MethodPost, "/api/admin/merch/hoody/variants", []byte(`{"size": "M", "color": "black"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var variant model.MerchVariant
+				err = json.NewDecoder(response.Body).Decode(&variant)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(variant.ID).To(Equal(4))
+			})
+		})
+
+		When("neither size nor color is given", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/hoody/variants", []byte(`{}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the variant already exists", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreateMerchVariant(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.MerchVariant{}, repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/hoody/variants", []byte(`{"size": "M"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/admin/merch/{type}/variants/{id}/restock endpoint", func() {
+		When("the variant doesn't exist", func() {
+			BeforeEach(func() {
+				repo.EXPECT().RestockMerchVariant(gomock.Any(), gomock.Any(), "hoody", 9, 5).Return(model.MerchVariant{}, repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodPost, "/api/admin/merch/hoody/variants/9/restock", []byte(`{"quantity": 5}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/buy endpoint with a variant", func() {
+		When("the variant doesn't exist", func() {
+			BeforeEach(func() {
+				item := model.InventoryItem{Type: "hoody", Size: "XXL", Quantity: 1}
+				repo.EXPECT().BuyItem(gomock.Any(), gomock.Any(), admin, item, "").Return(repository.ErrNoVariant).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/buy", []byte(`{"item": "hoody", "size": "XXL"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/inventory endpoint", func() {
+		When("the inventory is requested", func() {
+			BeforeEach(func() {
+				inventory := []model.InventoryItem{{Type: "hoody", Size: "M", Color: "black", Quantity: 2}}
+				repo.EXPECT().GetInventoryVariants(gomock.Any(), gomock.Any(), admin).Return(inventory, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the inventory by variant", func() {
+				response := do(http.MethodGet, "/api/inventory", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var inventory model.Inventory
+				err = json.NewDecoder(response.Body).Decode(&inventory)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(inventory).To(Equal(model.Inventory{{Type: "hoody", Size: "M", Color: "black", Quantity: 2}}))
+			})
+		})
+	})
+})
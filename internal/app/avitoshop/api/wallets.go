@@ -0,0 +1,414 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// CreateWallet handles create wallet request.
+func (h *Handler) CreateWallet(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get wallet request struct from request
+	var walletRequest model.WalletRequest
+	if err = render.Bind(r, &walletRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	wallet, err := h.service.CreateWallet(ctx, user, walletRequest.Name)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgCreateWallet, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the wallet to response
+	if err = render.Render(w, r, &wallet); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// Wallets handles request of wallets, that the user is a member of.
+func (h *Handler) Wallets(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	wallets, err := h.service.Wallets(ctx, user)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgWallets, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the wallets to response
+	if err = render.Render(w, r, model.Wallets(wallets)); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// Wallet handles request of a wallet with its members and history.
+func (h *Handler) Wallet(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "wallet")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	wallet, err := h.service.Wallet(ctx, user, id)
+	// Check if there is no such wallet
+	if err != nil && errors.Is(err, shop.ErrNoSuchWallet) {
+		slog.Info(msgWallet, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownWallet)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgWallet, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the wallet to response
+	if err = render.Render(w, r, &wallet); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
+
+// SetWalletMember handles request to add a member to a wallet or to change the role of the member.
+func (h *Handler) SetWalletMember(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "wallet")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get wallet member struct from request
+	var member model.WalletMember
+	if err = render.Bind(r, &member); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	err = h.service.SetWalletMember(ctx, user, id, member)
+	// Check if there is no such user
+	if err != nil && errors.Is(err, shop.ErrNoSuchAccount) {
+		slog.Info(msgSetWalletMember, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownAccount)
+		return
+	}
+	// Check wallet errors
+	if errResponse := walletErrorResponse(err); errResponse != nil {
+		slog.Info(msgSetWalletMember, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgSetWalletMember, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// RemoveWalletMember handles request to remove a member from a wallet.
+func (h *Handler) RemoveWalletMember(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "wallet")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	username := chi.URLParam(r, "username")
+	if username == "" {
+		_ = render.Render(w, r, ErrEmptyUser)
+		return
+	}
+
+	err = h.service.RemoveWalletMember(ctx, user, id, username)
+	// Check if there is no such member
+	if err != nil && errors.Is(err, shop.ErrNoSuchMember) {
+		slog.Info(msgRemoveWalletMember, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownWalletMember)
+		return
+	}
+	// Check wallet errors
+	if errResponse := walletErrorResponse(err); errResponse != nil {
+		slog.Info(msgRemoveWalletMember, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgRemoveWalletMember, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// FundWallet handles request to fund a wallet from the balance of the user.
+func (h *Handler) FundWallet(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "wallet")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get wallet funding struct from request
+	var funding model.WalletFunding
+	if err = render.Bind(r, &funding); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	err = h.service.FundWallet(ctx, user, id, funding.Amount)
+	// Check wallet errors
+	if errResponse := walletErrorResponse(err); errResponse != nil {
+		slog.Info(msgFundWallet, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgFundWallet, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// WalletSendCoins handles request to send coins from a wallet.
+func (h *Handler) WalletSendCoins(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	member, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "wallet")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get coins sending struct from request
+	var coinsSending model.CoinsSending
+	if err = render.Bind(r, &coinsSending); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	toUser := model.User{
+		UserName: coinsSending.ToUser,
+	}
+
+	err = h.service.WalletSendCoins(ctx, member, id, toUser, coinsSending.Amount)
+	// Check if user does not exist
+	if err != nil && errors.Is(err, shop.ErrNoSuchUser) {
+		slog.Info(msgWalletSendCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownUser)
+		return
+	}
+	// Check if amount is not less than the minimum
+	if err != nil && errors.Is(err, shop.ErrAmountBelowMinimum) {
+		slog.Info(msgWalletSendCoins, argError, err.Error())
+		_ = render.Render(w, r, ErrAmountBelowMinimum)
+		return
+	}
+	// Check wallet errors
+	if errResponse := walletErrorResponse(err); errResponse != nil {
+		slog.Info(msgWalletSendCoins, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgWalletSendCoins, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// WalletBuyItem handles request to buy an item with coins of a wallet.
+func (h *Handler) WalletBuyItem(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	member, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	id, err := pathID(r, "wallet")
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	itemType := chi.URLParam(r, "item")
+	if itemType == "" {
+		_ = render.Render(w, r, ErrEmptyItem)
+		return
+	}
+
+	item := model.InventoryItem{
+		Type:     itemType,
+		Size:     r.URL.Query().Get("size"),
+		Color:    r.URL.Query().Get("color"),
+		Quantity: 1,
+	}
+
+	err = h.service.WalletBuyItem(ctx, member, id, item)
+	// Check if there is no such item
+	if err != nil && errors.Is(err, shop.ErrNoSuchItem) {
+		slog.Info(msgWalletBuyItem, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownMerch)
+		return
+	}
+	// Check if the item is out of stock
+	if err != nil && errors.Is(err, shop.ErrOutOfStock) {
+		slog.Info(msgWalletBuyItem, argError, err.Error())
+		_ = render.Render(w, r, ErrOutOfStock)
+		return
+	}
+	// Check if there is no such variant of the item
+	if err != nil && errors.Is(err, shop.ErrNoSuchVariant) {
+		slog.Info(msgWalletBuyItem, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownVariant)
+		return
+	}
+	// Check if the member has reached the purchase limit of the item
+	if err != nil && errors.Is(err, shop.ErrPurchaseLimitReached) {
+		slog.Info(msgWalletBuyItem, argError, err.Error())
+		_ = render.Render(w, r, ErrPurchaseLimitReached)
+		return
+	}
+	// Check wallet errors
+	if errResponse := walletErrorResponse(err); errResponse != nil {
+		slog.Info(msgWalletBuyItem, argError, err.Error())
+		_ = render.Render(w, r, errResponse)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgWalletBuyItem, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// walletErrorResponse returns the response to errors common for wallet operations, or nil for other errors.
+func walletErrorResponse(err error) *ErrorResponse {
+	switch {
+	case errors.Is(err, shop.ErrNoSuchWallet):
+		return ErrUnknownWallet
+	case errors.Is(err, shop.ErrWalletPermissionDenied):
+		return ErrWalletPermissionDenied
+	case errors.Is(err, shop.ErrWalletWithoutAdmin):
+		return ErrWalletWithoutAdmin
+	case errors.Is(err, shop.ErrNotEnoughBalance):
+		return ErrNotEnoughCoins
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Wallets handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		member = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		// Wallet routes have path parameters, so requests go through the router
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, member.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/wallets endpoint", func() {
+		When("the method is POST and the name is given", func() {
+			BeforeEach(func() {
+				repo.EXPECT().CreateWallet(gomock.Any(), gomock.Any(), model.Wallet{Name: "offsite", CreatedBy: member.UserName}).DoAndReturn(
+					func(_, _ any, wallet model.Wallet) (model.Wallet, error) {
+						wallet.ID = 1
+						wallet.Role = model.WalletRoleAdmin
+						return wallet, nil
+					}).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the wallet", func() {
+				response := do(http.MethodPost, "/api/wallets", []byte(`{"name": "offsite"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var wallet model.Wallet
+				err = json.NewDecoder(response.Body).Decode(&wallet)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(wallet.ID).To(Equal(1))
+				Expect(wallet.Role).To(Equal(model.WalletRoleAdmin))
+			})
+		})
+
+		When("the method is POST and the name is empty", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/wallets", []byte(`{"name": ""}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the method is GET", func() {
+			BeforeEach(func() {
+				wallets := []model.Wallet{{ID: 1, Name: "offsite", Coins: 300, Role: model.WalletRoleSpend}}
+				repo.EXPECT().GetWallets(gomock.Any(), gomock.Any(), member).Return(wallets, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the wallets of the user", func() {
+				response := do(http.MethodGet, "/api/wallets", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var wallets []model.Wallet
+				err = json.NewDecoder(response.Body).Decode(&wallets)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(wallets).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/wallets/{id} endpoint", func() {
+		When("the user is not a member of the wallet", func() {
+			BeforeEach(func() {
+				repo.EXPECT().GetWallet(gomock.Any(), gomock.Any(), 7, member).Return(model.Wallet{}, repository.ErrNoWallet).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodGet, "/api/wallets/7", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+
+		When("the wallet id is wrong", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodGet, "/api/wallets/abc", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/wallets/{id}/members endpoint", func() {
+		When("the last administrator is made a spender", func() {
+			BeforeEach(func() {
+				walletMember := model.WalletMember{UserName: member.UserName, Role: model.WalletRoleSpend}
+				repo.EXPECT().SetWalletMember(gomock.Any(), gomock.Any(), 1, walletMember, member.UserName).Return(repository.ErrConflict).Times(1)
+			})
+
+			It("returns status 'Conflict' (409)", func() {
+				response := do(http.MethodPut, "/api/wallets/1/members", []byte(`{"username": "user", "role": "spend"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusConflict))
+			})
+		})
+
+		When("the role is unknown", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPut, "/api/wallets/1/members", []byte(`{"username": "user1", "role": "owner"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/wallets/{id}/members/{username} endpoint", func() {
+		When("the method is DELETE and the user is not an administrator", func() {
+			BeforeEach(func() {
+				repo.EXPECT().RemoveWalletMember(gomock.Any(), gomock.Any(), 1, "user1", member.UserName).Return(repository.ErrPermissionDenied).Times(1)
+			})
+
+			It("returns status 'Forbidden' (403)", func() {
+				response := do(http.MethodDelete, "/api/wallets/1/members/user1", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusForbidden))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/wallets/{id}/fund endpoint", func() {
+		When("the balance of the user is enough", func() {
+			BeforeEach(func() {
+				repo.EXPECT().FundWallet(gomock.Any(), gomock.Any(), 1, member, int64(100)).Return(nil).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response := do(http.MethodPost, "/api/wallets/1/fund", []byte(`{"amount": 100}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("the balance of the user is not enough", func() {
+			BeforeEach(func() {
+				repo.EXPECT().FundWallet(gomock.Any(), gomock.Any(), 1, member, int64(100)).Return(repository.ErrNegativeBalance).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/wallets/1/fund", []byte(`{"amount": 100}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/wallets/{id}/sendCoin endpoint", func() {
+		BeforeEach(func() {
+			repo.EXPECT().GetSettings(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_, _ any, defaults model.Settings) (model.Settings, error) {
+					return defaults, nil
+				}).Times(1)
+		})
+
+		When("the member is allowed to spend", func() {
+			BeforeEach(func() {
+				repo.EXPECT().WalletSendCoins(gomock.Any(), gomock.Any(), 1, member, model.User{UserName: "user1"}, int64(50)).Return(nil).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response := do(http.MethodPost, "/api/wallets/1/sendCoin", []byte(`{"toUser": "user1", "amount": 50}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("the member is not allowed to spend", func() {
+			BeforeEach(func() {
+				repo.EXPECT().WalletSendCoins(gomock.Any(), gomock.Any(), 1, member, gomock.Any(), int64(50)).Return(repository.ErrPermissionDenied).Times(1)
+			})
+
+			It("returns status 'Forbidden' (403)", func() {
+				response := do(http.MethodPost, "/api/wallets/1/sendCoin", []byte(`{"toUser": "user1", "amount": 50}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusForbidden))
+			})
+		})
+	})
+
+	Context("Receiving request at the /api/wallets/{id}/buy/{item} endpoint", func() {
+		When("the wallet is enough to buy", func() {
+			BeforeEach(func() {
+				repo.EXPECT().WalletBuyItem(gomock.Any(), gomock.Any(), 1, member, model.InventoryItem{Type: "cup", Quantity: 1}).Return(nil).Times(1)
+			})
+
+			It("returns status 'OK' (200)", func() {
+				response := do(http.MethodGet, "/api/wallets/1/buy/cup", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+			})
+		})
+
+		When("there is no such item", func() {
+			BeforeEach(func() {
+				repo.EXPECT().WalletBuyItem(gomock.Any(), gomock.Any(), 1, member, gomock.Any()).Return(repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodGet, "/api/wallets/1/buy/unknown", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+})
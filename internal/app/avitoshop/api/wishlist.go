@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+// Wishlist handles request to view the wishlist of the user.
+func (h *Handler) Wishlist(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	wishlist, err := h.service.Wishlist(ctx, user)
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgWishlist, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderWishlist(w, r, &wishlist)
+}
+
+// AddToWishlist handles request to add an item to the wishlist of the user.
+func (h *Handler) AddToWishlist(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	// Get wishlist request struct from request
+	var wishlistRequest model.WishlistRequest
+	if err = render.Bind(r, &wishlistRequest); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	wishlist, err := h.service.AddToWishlist(ctx, user, wishlistRequest.Item)
+	// Check if there is no such item
+	if err != nil && errors.Is(err, shop.ErrNoSuchItem) {
+		slog.Info(msgAddToWishlist, argError, err.Error())
+		_ = render.Render(w, r, ErrUnknownMerch)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgAddToWishlist, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderWishlist(w, r, &wishlist)
+}
+
+// RemoveFromWishlist handles request to remove an item from the wishlist of the user.
+func (h *Handler) RemoveFromWishlist(w http.ResponseWriter, r *http.Request) {
+	// Get context from request
+	ctx := r.Context()
+
+	// Get user from request
+	user, err := auth.UserFromRequest(r, h.cfg.SecretKey)
+	if err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+
+	wishlist, err := h.service.RemoveFromWishlist(ctx, user, chi.URLParam(r, "item"))
+	// Check if the item is in the wishlist
+	if err != nil && errors.Is(err, shop.ErrNotInWishlist) {
+		slog.Info(msgRemoveFromWishlist, argError, err.Error())
+		_ = render.Render(w, r, ErrNotInWishlist)
+		return
+	}
+
+	if err != nil {
+		// Something has gone wrong
+		slog.Info(msgRemoveFromWishlist, argError, err.Error())
+		_ = render.Render(w, r, ServerErrorRenderer(err))
+		return
+	}
+
+	h.renderWishlist(w, r, &wishlist)
+}
+
+// renderWishlist renders the wishlist to response.
+func (h *Handler) renderWishlist(w http.ResponseWriter, r *http.Request, wishlist *model.Wishlist) {
+	// Set header
+	w.Header().Set("Content-type", contentTypeJSON)
+	render.Status(r, http.StatusOK)
+
+	// Render the wishlist to response
+	if err := render.Render(w, r, wishlist); err != nil {
+		_ = render.Render(w, r, ErrorRenderer(err))
+		return
+	}
+}
@@ -0,0 +1,143 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/api"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/shop"
+	"github.com/RomanAgaltsev/avito-shop/internal/config"
+	"github.com/RomanAgaltsev/avito-shop/internal/mock"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/auth"
+)
+
+var _ = Describe("Wishlist handler", func() {
+	var (
+		err error
+
+		cfg *config.Config
+
+		server *ghttp.Server
+
+		service shop.Service
+		ctrl    *gomock.Controller
+		repo    *mock.MockRepository
+
+		handler *api.Handler
+
+		ja    *jwtauth.JWTAuth
+		token string
+
+		user = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		cfg, err = config.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).ShouldNot(BeNil())
+
+		server = ghttp.NewServer()
+
+		ctrl = gomock.NewController(GinkgoT())
+		Expect(ctrl).ShouldNot(BeNil())
+
+		repo = mock.NewMockRepository(ctrl)
+		Expect(repo).ShouldNot(BeNil())
+
+		service, err = shop.NewService(repo, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service).ShouldNot(BeNil())
+
+		handler = api.NewHandler(cfg, service)
+		Expect(handler).ShouldNot(BeNil())
+
+		server.AppendHandlers(api.NewRouter(cfg, handler).ServeHTTP)
+
+		ja = auth.NewAuth(cfg.SecretKey)
+		Expect(ja).ShouldNot(BeNil())
+
+		_, token, err = auth.NewJWTToken(ja, user.UserName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method string, endpoint string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL()+endpoint, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		request.Header.Add("Content-Type", ContentTypeJSON)
+		request.Header.Add("Authorization", "Bearer "+token)
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(response.Body.Close)
+
+		return response
+	}
+
+	Context("Receiving request at the /api/wishlist endpoint", func() {
+		When("the item is added to the wishlist", func() {
+			BeforeEach(func() {
+				wishlist := model.Wishlist{
+					Items:   []model.WishlistItem{{Item: "pink-hoody", Price: 500, Available: true, Missing: 200, Events: []model.WishlistEvent{}}},
+					Balance: 300,
+				}
+				repo.EXPECT().AddWishlistItem(gomock.Any(), gomock.Any(), user, "pink-hoody").Return(nil).Times(1)
+				repo.EXPECT().GetWishlist(gomock.Any(), gomock.Any(), user).Return(wishlist, nil).Times(1)
+			})
+
+			It("returns status 'OK' (200) and the wishlist", func() {
+				response := do(http.MethodPost, "/api/wishlist", []byte(`{"item": "pink-hoody"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusOK))
+
+				var wishlist model.Wishlist
+				err = json.NewDecoder(response.Body).Decode(&wishlist)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(wishlist.Items).Should(HaveLen(1))
+				Expect(wishlist.Items[0].Missing).To(Equal(int64(200)))
+			})
+		})
+
+		When("the item is not given", func() {
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/wishlist", []byte(`{}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the item is unknown", func() {
+			BeforeEach(func() {
+				repo.EXPECT().AddWishlistItem(gomock.Any(), gomock.Any(), user, "unknown").Return(repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Bad request' (400)", func() {
+				response := do(http.MethodPost, "/api/wishlist", []byte(`{"item": "unknown"}`))
+				Expect(response.StatusCode).Should(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the item is not in the wishlist", func() {
+			BeforeEach(func() {
+				repo.EXPECT().RemoveWishlistItem(gomock.Any(), gomock.Any(), user, "cup").Return(repository.ErrNoData).Times(1)
+			})
+
+			It("returns status 'Not found' (404)", func() {
+				response := do(http.MethodDelete, "/api/wishlist/cup", nil)
+				Expect(response.StatusCode).Should(Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
@@ -15,6 +15,7 @@ import (
 	"github.com/RomanAgaltsev/avito-shop/internal/config"
 	"github.com/RomanAgaltsev/avito-shop/internal/database"
 	"github.com/RomanAgaltsev/avito-shop/internal/logger"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/catalog"
 	"github.com/RomanAgaltsev/avito-shop/internal/pkg/httpserver"
 )
 
@@ -39,7 +40,7 @@ func Run(cfg *config.Config) error {
 	defer dbpool.Close()
 
 	// Create repository
-	repo, err := repository.New(dbpool)
+	repo, err := repository.New(dbpool, repository.WithCoinTTL(cfg.CoinTTL))
 	if err != nil {
 		return err
 	}
@@ -50,12 +51,25 @@ func Run(cfg *config.Config) error {
 		return nil
 	}
 
+	// Bring merch catalog to the state of the catalog file
+	if cfg.CatalogFile != "" {
+		if err = reconcileCatalog(ctx, shopService, cfg.CatalogFile); err != nil {
+			return fmt.Errorf("catalog: %w", err)
+		}
+	}
+
 	// HTTP server
 	server, err := httpserver.New(cfg, shopService)
 	if err != nil {
 		return err
 	}
 
+	// Run coin and hold expiry in the background until shutdown
+	expiryCtx, expiryCancel := context.WithCancel(context.Background())
+	defer expiryCancel()
+	go runCoinExpiry(expiryCtx, shopService, cfg.CoinExpiryInterval)
+	go runHoldExpiry(expiryCtx, shopService, cfg.HoldExpiryInterval)
+
 	// Create channels for graceful shutdown
 	done := make(chan bool, 1)
 	quit := make(chan os.Signal, 1)
@@ -69,6 +83,9 @@ func Run(cfg *config.Config) error {
 
 		slog.Info("shutting down HTTP server")
 
+		// Stop coin and hold expiry
+		expiryCancel()
+
 		// Shutdown HTTP server
 		if err = server.Shutdown(ctx); err != nil {
 			slog.Error("HTTP server shutdown error", slog.String("error", err.Error()))
@@ -89,3 +106,62 @@ func Run(cfg *config.Config) error {
 	slog.Info("HTTP server stopped")
 	return nil
 }
+
+// runCoinExpiry expires unspent coins with a given interval until the context is done.
+func runCoinExpiry(ctx context.Context, service shop.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := service.ExpireCoins(ctx)
+			if err != nil {
+				slog.Error("coin expiry error", slog.String("error", err.Error()))
+				continue
+			}
+			if expired > 0 {
+				slog.Info("coins expired", slog.Int64("amount", expired))
+			}
+		}
+	}
+}
+
+// runHoldExpiry releases expired holds of coins with a given interval until the context is done.
+func runHoldExpiry(ctx context.Context, service shop.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := service.ExpireHolds(ctx)
+			if err != nil {
+				slog.Error("hold expiry error", slog.String("error", err.Error()))
+				continue
+			}
+			if expired > 0 {
+				slog.Info("holds expired", slog.Int("count", expired))
+			}
+		}
+	}
+}
+
+// reconcileCatalog brings merch catalog to the state of a given catalog file.
+func reconcileCatalog(ctx context.Context, service shop.Service, path string) error {
+	declared, err := catalog.Load(path)
+	if err != nil {
+		return err
+	}
+
+	changes, err := service.ReconcileCatalog(ctx, declared, false)
+	for _, change := range changes {
+		slog.Info("catalog changed", slog.String("action", change.Action), slog.String("type", change.Type))
+	}
+
+	return err
+}
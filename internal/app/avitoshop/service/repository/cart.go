@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/coins"
+)
+
+// AddCartItem adds a quantity of an item to the cart of a given user.
+// The variant of merch is selected by size and color of the item.
+func (r *Repository) AddCartItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem) error {
+	// Check that the merch can be bought
+	_, err := backoff.RetryWithData(func() (queries.MerchCurrent, error) {
+		return noRetryOnNoRows(r.q.GetMerch(ctx, item.Type))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Check that the variant of the merch can be bought
+	if _, err = r.selectVariant(ctx, bo, item); err != nil {
+		return err
+	}
+
+	// Add the item to the cart, DB returns the out of range error if the quantity overflows
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return noRetryOnViolation(r.q.AddCartItem(ctx, queries.AddCartItemParams{
+			Username: user.UserName,
+			Type:     item.Type,
+			Size:     item.Size,
+			Color:    item.Color,
+			Quantity: int32(item.Quantity),
+		}))
+	}, bo)
+	return err
+}
+
+// RemoveCartItem removes an item from the cart of a given user together with all its variants.
+func (r *Repository) RemoveCartItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, itemType string) error {
+	removed, err := backoff.RetryWithData(func() (int64, error) {
+		return r.q.RemoveCartItem(ctx, queries.RemoveCartItemParams{
+			Username: user.UserName,
+			Type:     itemType,
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// GetCart returns the cart of a given user with the running total against the available balance of the user.
+func (r *Repository) GetCart(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Cart, error) {
+	// Get cart from DB
+	cartQuery, err := backoff.RetryWithData(func() ([]queries.GetCartRow, error) {
+		return r.q.GetCart(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	balance, err := r.GetBalance(ctx, bo, user)
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	// Held coins can't be spent, so the cart is checked against the coins available
+	return toCart(cartQuery, balance.Coins-balance.Held), nil
+}
+
+// Checkout buys all lines of the cart of a given user in one transaction and empties the cart.
+// If any line can't be bought, nothing is bought and the cart is returned with the reasons of the failed lines.
+func (r *Repository) Checkout(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Cart, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Cart{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the cart, so it is checked out once
+	cartQuery, err := backoff.RetryWithData(func() ([]queries.GetCartForUpdateRow, error) {
+		return qtx.GetCartForUpdate(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.Cart{}, err
+	}
+	if len(cartQuery) == 0 {
+		return model.Cart{}, ErrNoData
+	}
+
+	// Lock the balance, so the check of the balance holds until the commit
+	balance, err := backoff.RetryWithData(func() (queries.LockBalanceRow, error) {
+		return noRetryOnNoRows(qtx.LockBalance(ctx, user.UserName))
+	}, bo)
+	if err != nil {
+		return model.Cart{}, noDataOnNoRows(err)
+	}
+
+	rows := make([]queries.GetCartRow, 0, len(cartQuery))
+	for _, rec := range cartQuery {
+		rows = append(rows, queries.GetCartRow(rec))
+	}
+
+	// Held coins can't be spent, so the cart is checked against the coins available
+	cart := toCart(rows, balance.Coins-balance.Held)
+	if cart.Failed() {
+		return cart, ErrCheckoutFailed
+	}
+
+	for i, line := range cart.Lines {
+		// Select the variant of the line, the variants could have changed since the line was added
+		var variantID pgtype.Int4
+		variantID, err = r.selectVariant(ctx, bo, model.InventoryItem{Type: line.Item, Size: line.Size, Color: line.Color})
+		if errors.Is(err, ErrNoVariant) {
+			cart.Lines[i].Reason = model.CartReasonUnknownVariant
+			return cart, ErrCheckoutFailed
+		}
+		if err != nil {
+			return model.Cart{}, err
+		}
+
+		// Withdraw the cost of the line from the balance of the user
+		err = r.debit(ctx, bo, qtx, user.UserName, line.Cost)
+		if errors.Is(err, ErrNegativeBalance) {
+			cart.Lines[i].Reason = model.CartReasonInsufficientBalance
+			return cart, ErrCheckoutFailed
+		}
+		if err != nil {
+			return model.Cart{}, err
+		}
+
+		// Check the purchase limit of the merch
+		err = r.checkPurchaseLimit(ctx, bo, qtx, user.UserName, line.Item, line.Quantity)
+		if errors.Is(err, ErrLimitReached) {
+			cart.Lines[i].Reason = model.CartReasonLimitReached
+			return cart, ErrCheckoutFailed
+		}
+		if err != nil {
+			return model.Cart{}, err
+		}
+
+		// Take the items out of stock, the stock could have changed since the cart was read
+		err = r.reserveStock(ctx, bo, qtx, line.Item, int32(line.Quantity))
+		if err == nil {
+			err = r.reserveVariantStock(ctx, bo, qtx, variantID, int32(line.Quantity))
+		}
+		if errors.Is(err, ErrOutOfStock) {
+			cart.Lines[i].Reason = model.CartReasonOutOfStock
+			return cart, ErrCheckoutFailed
+		}
+		if err != nil {
+			return model.Cart{}, err
+		}
+
+		// Add the items to the user inventory
+		var inventoryID int32
+		inventoryID, err = backoff.RetryWithData(func() (int32, error) {
+			return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+				Username:  user.UserName,
+				Type:      line.Item,
+				Quantity:  int32(line.Quantity),
+				Kind:      kindPurchase,
+				PricePaid: line.Price,
+				VariantID: variantID,
+			})
+		}, bo)
+		if err != nil {
+			return model.Cart{}, err
+		}
+
+		// Place the order to hand over the items
+		if err = r.createOrder(ctx, bo, qtx, inventoryID, user.UserName, line.Item, int32(line.Quantity)); err != nil {
+			return model.Cart{}, err
+		}
+	}
+
+	// Empty the cart
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, qtx.ClearCart(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Cart{}, err
+	}
+
+	return cart, nil
+}
+
+// toCart converts cart records of DB to the model and checks, whether every line of the cart can be bought
+// with a given available balance.
+func toCart(cartQuery []queries.GetCartRow, balance int64) model.Cart {
+	cart := model.Cart{
+		Lines:   make([]model.CartLine, 0, len(cartQuery)),
+		Balance: balance,
+	}
+
+	for _, rec := range cartQuery {
+		line := model.CartLine{
+			Item:     rec.Type,
+			Size:     rec.Size,
+			Color:    rec.Color,
+			Quantity: int(rec.Quantity),
+			Price:    rec.Price.Int64,
+		}
+
+		switch {
+		case !rec.Price.Valid || !rec.Available.Bool:
+			line.Reason = model.CartReasonUnknownItem
+		case rec.Stock.Valid && rec.Stock.Int32 < rec.Quantity:
+			line.Reason = model.CartReasonOutOfStock
+		case rec.VariantStock.Valid && rec.VariantStock.Int32 < rec.Quantity:
+			line.Reason = model.CartReasonOutOfStock
+		}
+
+		// The cost of the line has to fit into the balance together with the lines above it
+		if line.Reason == "" {
+			line.Reason = model.CartReasonInsufficientBalance
+			if cost, err := coins.Mul(line.Price, int64(line.Quantity)); err == nil {
+				line.Cost = cost
+				if total, err := coins.Add(cart.Total, cost); err == nil && total <= balance {
+					cart.Total = total
+					line.Reason = ""
+				}
+			}
+		}
+
+		line.Total = cart.Total
+		cart.Lines = append(cart.Lines, line)
+	}
+
+	return cart
+}
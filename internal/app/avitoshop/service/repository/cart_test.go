@@ -0,0 +1,296 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository cart", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		user = model.User{UserName: "user"}
+
+		cartColumns = []string{"type", "size", "color", "quantity", "price", "available", "stock", "variant_stock"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling AddCartItem method", func() {
+		When("the item is unknown", func() {
+			BeforeEach(func() {
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("book").WillReturnRows(rsGet).Times(1)
+
+				err = repo.AddCartItem(ctx, bo, user, model.InventoryItem{Type: "book", Quantity: 1})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+			})
+		})
+
+		When("no variant of the merch with variants is selected", func() {
+			BeforeEach(func() {
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(true)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("hoody").WillReturnRows(rsVariants).Times(1)
+
+				err = repo.AddCartItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Quantity: 1})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no variant error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoVariant))
+			})
+		})
+
+		When("the variant of the merch is selected", func() {
+			BeforeEach(func() {
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnRows(rsGet).Times(1)
+
+				rsVariant := pgxmock.NewRows([]string{"id", "type", "size", "color", "stock", "created_at"}).AddRow(int32(4), "hoody", "M", "black", pgtype.Int4{}, time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM merch_variants .+").WithArgs("hoody", "M", "black").WillReturnRows(rsVariant).Times(1)
+
+				rsAdd := pgxmock.NewRows([]string{"quantity"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO cart_items .+").WithArgs(user.UserName, "hoody", "M", "black", int32(1)).WillReturnRows(rsAdd).Times(1)
+
+				err = repo.AddCartItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Size: "M", Color: "black", Quantity: 1})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("adds the variant to the cart and returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Calling GetCart method", func() {
+		var cart model.Cart
+
+		When("the available balance is enough for a part of the cart", func() {
+			BeforeEach(func() {
+				rsCart := pgxmock.NewRows(cartColumns).
+					AddRow("book", "", "", int32(2), pgtype.Int8{Int64: 50, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{}, pgtype.Int4{}).
+					AddRow("cup", "", "", int32(1), pgtype.Int8{Int64: 20, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM cart_items .+").WithArgs(user.UserName).WillReturnRows(rsCart).Times(1)
+
+				rsBalance := pgxmock.NewRows([]string{"id", "username", "coins", "held"}).AddRow(int32(1), user.UserName, int64(150), int64(40))
+				mockPool.ExpectQuery("SELECT .+ FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsBalance).Times(1)
+
+				cart, err = repo.GetCart(ctx, bo, user)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the cart with the running total and the reason of the failed line", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cart.Balance).To(Equal(int64(110)))
+				Expect(cart.Total).To(Equal(int64(100)))
+				Expect(cart.Lines).Should(HaveLen(2))
+				Expect(cart.Lines[0].Cost).To(Equal(int64(100)))
+				Expect(cart.Lines[0].Reason).To(BeEmpty())
+				Expect(cart.Lines[1].Reason).To(Equal(model.CartReasonInsufficientBalance))
+				Expect(cart.Failed()).To(BeTrue())
+			})
+		})
+	})
+
+	Context("Calling Checkout method", func() {
+		var cart model.Cart
+
+		BeforeEach(func() {
+			mockPool.ExpectBegin()
+		})
+
+		When("all lines of the cart can be bought", func() {
+			BeforeEach(func() {
+				rsCart := pgxmock.NewRows(cartColumns).
+					AddRow("book", "", "", int32(2), pgtype.Int8{Int64: 50, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{}, pgtype.Int4{}).
+					AddRow("cup", "", "white", int32(1), pgtype.Int8{Int64: 20, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{Int32: 5, Valid: true}, pgtype.Int4{Int32: 3, Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM cart_items .+ FOR UPDATE .+").WithArgs(user.UserName).WillReturnRows(rsCart).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsLock).Times(1)
+
+				rsBookVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("book").WillReturnRows(rsBookVariants).Times(1)
+
+				rsBook := pgxmock.NewRows([]string{"balance"}).AddRow(int64(400))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-100)).WillReturnRows(rsBook).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(100)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("book").WillReturnError(sql.ErrNoRows)
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("book", pgtype.Int4{Int32: 2, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				rsCreateBook := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, "book", int32(2), "purchase", int64(50), pgtype.Int4{}, pgtype.Int4{}).WillReturnRows(rsCreateBook).Times(1)
+
+				rsOrderBook := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(1), user.UserName, "book", int32(2)).WillReturnRows(rsOrderBook).Times(1)
+
+				rsCupVariant := pgxmock.NewRows([]string{"id", "type", "size", "color", "stock", "created_at"}).AddRow(int32(3), "cup", "", "white", pgtype.Int4{Int32: 3, Valid: true}, time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM merch_variants .+").WithArgs("cup", "", "white").WillReturnRows(rsCupVariant).Times(1)
+
+				rsCup := pgxmock.NewRows([]string{"balance"}).AddRow(int64(380))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-20)).WillReturnRows(rsCup).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(20)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("cup").WillReturnError(sql.ErrNoRows)
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("cup", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				mockPool.ExpectExec("UPDATE merch_variants SET stock .+").WithArgs(int32(3), pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				rsCreateCup := pgxmock.NewRows([]string{"id"}).AddRow(int32(2))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, "cup", int32(1), "purchase", int64(20), pgtype.Int4{}, pgtype.Int4{Int32: 3, Valid: true}).WillReturnRows(rsCreateCup).Times(1)
+
+				rsOrderCup := pgxmock.NewRows([]string{"id"}).AddRow(int32(2))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(2), user.UserName, "cup", int32(1)).WillReturnRows(rsOrderCup).Times(1)
+
+				mockPool.ExpectExec("DELETE FROM cart_items .+").WithArgs(user.UserName).WillReturnResult(pgxmock.NewResult("DELETE", 2)).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				cart, err = repo.Checkout(ctx, bo, user)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the bought cart and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cart.Total).To(Equal(int64(120)))
+				Expect(cart.Failed()).To(BeFalse())
+			})
+		})
+
+		When("some lines of the cart can't be bought", func() {
+			BeforeEach(func() {
+				rsCart := pgxmock.NewRows(cartColumns).
+					AddRow("book", "", "", int32(2), pgtype.Int8{Int64: 50, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{}, pgtype.Int4{}).
+					AddRow("hoody", "", "", int32(1), pgtype.Int8{}, pgtype.Bool{}, pgtype.Int4{}, pgtype.Int4{}).
+					AddRow("cup", "", "", int32(3), pgtype.Int8{Int64: 20, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{Int32: 2, Valid: true}, pgtype.Int4{}).
+					AddRow("t-shirt", "M", "", int32(1), pgtype.Int8{Int64: 10, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{}, pgtype.Int4{Int32: 0, Valid: true}).
+					AddRow("pen", "", "", int32(1), pgtype.Int8{Int64: 80, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM cart_items .+ FOR UPDATE .+").WithArgs(user.UserName).WillReturnRows(rsCart).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(150), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsLock).Times(1)
+
+				mockPool.ExpectRollback()
+
+				cart, err = repo.Checkout(ctx, bo, user)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the cart with the reasons of the failed lines and checkout failed error", func() {
+				Expect(err).Should(MatchError(repository.ErrCheckoutFailed))
+				Expect(cart.Lines).Should(HaveLen(5))
+				Expect(cart.Lines[0].Reason).To(BeEmpty())
+				Expect(cart.Lines[1].Reason).To(Equal(model.CartReasonUnknownItem))
+				Expect(cart.Lines[2].Reason).To(Equal(model.CartReasonOutOfStock))
+				Expect(cart.Lines[3].Reason).To(Equal(model.CartReasonOutOfStock))
+				Expect(cart.Lines[4].Reason).To(Equal(model.CartReasonInsufficientBalance))
+			})
+		})
+
+		When("the variant of a line is unknown", func() {
+			BeforeEach(func() {
+				rsCart := pgxmock.NewRows(cartColumns).
+					AddRow("hoody", "XXL", "", int32(1), pgtype.Int8{Int64: 300, Valid: true}, pgtype.Bool{Bool: true, Valid: true}, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM cart_items .+ FOR UPDATE .+").WithArgs(user.UserName).WillReturnRows(rsCart).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsLock).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM merch_variants .+").WithArgs("hoody", "XXL", "").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectRollback()
+
+				cart, err = repo.Checkout(ctx, bo, user)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the cart with the reason of the line and checkout failed error", func() {
+				Expect(err).Should(MatchError(repository.ErrCheckoutFailed))
+				Expect(cart.Lines).Should(HaveLen(1))
+				Expect(cart.Lines[0].Reason).To(Equal(model.CartReasonUnknownVariant))
+			})
+		})
+
+		When("the cart is empty", func() {
+			BeforeEach(func() {
+				rsCart := pgxmock.NewRows(cartColumns)
+				mockPool.ExpectQuery("SELECT .+ FROM cart_items .+ FOR UPDATE .+").WithArgs(user.UserName).WillReturnRows(rsCart).Times(1)
+
+				mockPool.ExpectRollback()
+
+				cart, err = repo.Checkout(ctx, bo, user)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+			})
+		})
+	})
+})
@@ -0,0 +1,434 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// GetCatalog returns merch catalog items filtered by price range in a given order.
+func (r *Repository) GetCatalog(ctx context.Context, bo *backoff.ExponentialBackOff, filter model.CatalogFilter) ([]model.MerchItem, error) {
+	// Get catalog from DB
+	catalogQuery, err := backoff.RetryWithData(func() ([]queries.MerchCurrent, error) {
+		return r.q.GetCatalog(ctx, queries.GetCatalogParams{
+			MinPrice: filter.MinPrice,
+			MaxPrice: filter.MaxPrice,
+			Sort:     filter.Sort,
+		})
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := make([]model.MerchItem, 0, len(catalogQuery))
+	for _, merch := range catalogQuery {
+		catalog = append(catalog, toMerchItem(merch))
+	}
+
+	return catalog, nil
+}
+
+// GetCatalogItem returns merch catalog item of a given type, including archived merch.
+func (r *Repository) GetCatalogItem(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) (model.MerchItem, error) {
+	// Get merch from DB
+	merch, err := backoff.RetryWithData(func() (queries.MerchCurrent, error) {
+		return noRetryOnNoRows(r.q.GetCatalogItem(ctx, itemType))
+	}, bo)
+	if err != nil {
+		return model.MerchItem{}, noDataOnNoRows(err)
+	}
+
+	return toMerchItem(merch), nil
+}
+
+// CreateMerch adds new merch to the catalog on behalf of a given user.
+func (r *Repository) CreateMerch(ctx context.Context, bo *backoff.ExponentialBackOff, item model.MerchItem, actor string) (model.MerchItem, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.MerchItem{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Create merch, DB returns no rows if merch of the type already exists
+	created, err := backoff.RetryWithData(func() (queries.Merch, error) {
+		return noRetryOnNoRows(qtx.CreateMerch(ctx, queries.CreateMerchParams{
+			Type:        item.Type,
+			Price:       item.Price,
+			Description: item.Description,
+			Stock:       fromStock(item.Stock),
+		}))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.MerchItem{}, ErrConflict
+	}
+	if err != nil {
+		return model.MerchItem{}, err
+	}
+	merch := queries.MerchCurrent(created)
+
+	// Start the price history of the merch
+	if _, err = r.setPrice(ctx, bo, qtx, merch.Type, merch.Price, time.Now(), actor); err != nil {
+		return model.MerchItem{}, err
+	}
+
+	if err = r.createMerchAuditEntry(ctx, bo, qtx, merch, model.MerchActionCreate, actor); err != nil {
+		return model.MerchItem{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.MerchItem{}, err
+	}
+
+	return toMerchItem(merch), nil
+}
+
+// UpdateMerch changes price or description of merch on behalf of a given user.
+func (r *Repository) UpdateMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, update model.MerchUpdate, actor string) (model.MerchItem, error) {
+	return r.changeMerch(ctx, bo, itemType, model.MerchActionUpdate, actor, func(merch *queries.MerchCurrent) error {
+		if update.Price != nil {
+			merch.Price = *update.Price
+		}
+		if update.Description != nil {
+			merch.Description = *update.Description
+		}
+		return nil
+	})
+}
+
+// ArchiveMerch makes merch unavailable for purchase on behalf of a given user.
+// The merch stays in the catalog, so the items already owned by users are still resolved.
+func (r *Repository) ArchiveMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, actor string) (model.MerchItem, error) {
+	return r.changeMerch(ctx, bo, itemType, model.MerchActionArchive, actor, func(merch *queries.MerchCurrent) error {
+		if !merch.Available {
+			return ErrConflict
+		}
+		merch.Available = false
+		return nil
+	})
+}
+
+// RestoreMerch makes archived merch available for purchase again on behalf of a given user.
+func (r *Repository) RestoreMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, actor string) (model.MerchItem, error) {
+	return r.changeMerch(ctx, bo, itemType, model.MerchActionRestore, actor, func(merch *queries.MerchCurrent) error {
+		if merch.Available {
+			return ErrConflict
+		}
+		merch.Available = true
+		return nil
+	})
+}
+
+// RestockMerch adds a quantity of merch to its stock on behalf of a given user.
+// Stock of merch with unlimited supply starts being tracked from the given quantity.
+func (r *Repository) RestockMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, quantity int, actor string) (model.MerchItem, error) {
+	return r.changeMerch(ctx, bo, itemType, model.MerchActionRestock, actor, func(merch *queries.MerchCurrent) error {
+		stock := int64(merch.Stock.Int32) + int64(quantity)
+		if stock > math.MaxInt32 {
+			return ErrOutOfRange
+		}
+		merch.Stock = pgtype.Int4{Int32: int32(stock), Valid: true}
+		return nil
+	})
+}
+
+// GetLowStockMerch returns available merch with no more than a given quantity in stock, the scarcest first.
+func (r *Repository) GetLowStockMerch(ctx context.Context, bo *backoff.ExponentialBackOff, threshold int) ([]model.MerchItem, error) {
+	// Get low stock merch from DB
+	merchQuery, err := backoff.RetryWithData(func() ([]queries.MerchCurrent, error) {
+		return r.q.GetLowStockMerch(ctx, pgtype.Int4{Int32: int32(threshold), Valid: true})
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.MerchItem, 0, len(merchQuery))
+	for _, merch := range merchQuery {
+		items = append(items, toMerchItem(merch))
+	}
+
+	return items, nil
+}
+
+// SetMerchPrice schedules a price of merch valid from a given time on behalf of a given user.
+// The price is valid until the next scheduled price of the merch, if there is one.
+func (r *Repository) SetMerchPrice(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, price int64, validFrom time.Time, actor string) (model.MerchPrice, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.MerchPrice{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Lock the merch, so the price history is changed by one user at a time
+	_, err = backoff.RetryWithData(func() (queries.MerchCurrent, error) {
+		return noRetryOnNoRows(qtx.GetMerchForUpdate(ctx, itemType))
+	}, bo)
+	if err != nil {
+		return model.MerchPrice{}, noDataOnNoRows(err)
+	}
+
+	merchPrice, err := r.setPrice(ctx, bo, qtx, itemType, price, validFrom, actor)
+	if err != nil {
+		return model.MerchPrice{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.MerchPrice{}, err
+	}
+
+	return merchPrice, nil
+}
+
+// GetMerchPrices returns the price history of merch of a given type, including scheduled prices, earliest first.
+func (r *Repository) GetMerchPrices(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) ([]model.MerchPrice, error) {
+	// Get merch prices from DB
+	pricesQuery, err := backoff.RetryWithData(func() ([]queries.MerchPrice, error) {
+		return r.q.GetMerchPrices(ctx, itemType)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make([]model.MerchPrice, 0, len(pricesQuery))
+	for _, rec := range pricesQuery {
+		prices = append(prices, toMerchPrice(rec))
+	}
+
+	return prices, nil
+}
+
+// GetMerchAudit returns merch catalog changes, optionally of a given merch type only, latest first.
+func (r *Repository) GetMerchAudit(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) ([]model.MerchAuditEntry, error) {
+	// Get merch audit from DB
+	auditQuery, err := backoff.RetryWithData(func() ([]queries.MerchAudit, error) {
+		return r.q.GetMerchAudit(ctx, itemType)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := make([]model.MerchAuditEntry, 0, len(auditQuery))
+	for _, rec := range auditQuery {
+		audit = append(audit, model.MerchAuditEntry{
+			ID:          int(rec.ID),
+			Type:        rec.Type,
+			Action:      rec.Action,
+			Price:       rec.Price,
+			Description: rec.Description,
+			Available:   rec.Available,
+			Stock:       toStock(rec.Stock),
+			Actor:       rec.Actor,
+			CreatedAt:   rec.CreatedAt,
+		})
+	}
+
+	return audit, nil
+}
+
+// changeMerch locks merch of a given type, applies a change to it and records the change in the audit.
+func (r *Repository) changeMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, action string, actor string, change func(merch *queries.MerchCurrent) error) (model.MerchItem, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.MerchItem{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the merch
+	merch, err := backoff.RetryWithData(func() (queries.MerchCurrent, error) {
+		return noRetryOnNoRows(qtx.GetMerchForUpdate(ctx, itemType))
+	}, bo)
+	if err != nil {
+		return model.MerchItem{}, noDataOnNoRows(err)
+	}
+
+	price, wasPurchasable := merch.Price, purchasable(merch)
+	if err = change(&merch); err != nil {
+		return model.MerchItem{}, err
+	}
+
+	// Changed price is valid from now on
+	if merch.Price != price {
+		if _, err = r.setPrice(ctx, bo, qtx, merch.Type, merch.Price, time.Now(), actor); err != nil {
+			return model.MerchItem{}, err
+		}
+	}
+
+	// Save the merch
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return noRetryOnViolation(struct{}{}, qtx.UpdateMerch(ctx, queries.UpdateMerchParams{
+			Type:        merch.Type,
+			Price:       merch.Price,
+			Description: merch.Description,
+			Available:   merch.Available,
+			Stock:       merch.Stock,
+		}))
+	}, bo)
+	if err != nil {
+		return model.MerchItem{}, err
+	}
+
+	if err = r.createMerchAuditEntry(ctx, bo, qtx, merch, action, actor); err != nil {
+		return model.MerchItem{}, err
+	}
+
+	// Merch, that was archived or out of stock, is back for the users, who wish it
+	if !wasPurchasable && purchasable(merch) {
+		if err = r.markBackInStock(ctx, bo, qtx, merch); err != nil {
+			return model.MerchItem{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.MerchItem{}, err
+	}
+
+	return toMerchItem(merch), nil
+}
+
+// createMerchAuditEntry records the state of merch after a change.
+func (r *Repository) createMerchAuditEntry(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, merch queries.MerchCurrent, action string, actor string) error {
+	_, err := backoff.RetryWithData(func() (int32, error) {
+		return noRetryOnViolation(qtx.CreateMerchAuditEntry(ctx, queries.CreateMerchAuditEntryParams{
+			Type:        merch.Type,
+			Action:      action,
+			Price:       merch.Price,
+			Description: merch.Description,
+			Available:   merch.Available,
+			Actor:       actor,
+			Stock:       merch.Stock,
+		}))
+	}, bo)
+	return err
+}
+
+// setPrice inserts a price of merch valid from a given time into the price history.
+// The price, valid at the time before, becomes valid until the time.
+func (r *Repository) setPrice(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, itemType string, price int64, validFrom time.Time, actor string) (model.MerchPrice, error) {
+	// The price is valid until the next scheduled price
+	next, err := backoff.RetryWithData(func() (time.Time, error) {
+		return noRetryOnNoRows(qtx.GetNextMerchPriceStart(ctx, queries.GetNextMerchPriceStartParams{
+			Type:      itemType,
+			ValidFrom: validFrom,
+		}))
+	}, bo)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.MerchPrice{}, err
+	}
+	validTo := pgtype.Timestamp{Time: next, Valid: err == nil}
+
+	// Create the price, DB returns no rows if there is a price valid from the same time
+	merchPrice, err := backoff.RetryWithData(func() (queries.MerchPrice, error) {
+		return noRetryOnNoRows(qtx.CreateMerchPrice(ctx, queries.CreateMerchPriceParams{
+			Type:      itemType,
+			Price:     price,
+			ValidFrom: validFrom,
+			ValidTo:   validTo,
+			Actor:     actor,
+		}))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.MerchPrice{}, ErrConflict
+	}
+	if err != nil {
+		return model.MerchPrice{}, err
+	}
+
+	// End the period of the previous price
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, qtx.CloseMerchPrice(ctx, queries.CloseMerchPriceParams{
+			ValidFrom: validFrom,
+			Type:      itemType,
+		})
+	}, bo)
+	if err != nil {
+		return model.MerchPrice{}, err
+	}
+
+	return toMerchPrice(merchPrice), nil
+}
+
+// reserveStock takes a quantity of merch out of stock, if the stock of the merch is tracked.
+func (r *Repository) reserveStock(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, itemType string, quantity int32) error {
+	_, err := backoff.RetryWithData(func() (struct{}, error) {
+		return noRetryOnViolation(struct{}{}, qtx.ReserveMerchStock(ctx, queries.ReserveMerchStockParams{
+			Type:  itemType,
+			Stock: pgtype.Int4{Int32: quantity, Valid: true},
+		}))
+	}, bo)
+	return err
+}
+
+// releaseStock puts a quantity of merch back in stock, if the stock of the merch is tracked.
+func (r *Repository) releaseStock(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, itemType string, quantity int32) error {
+	_, err := backoff.RetryWithData(func() (struct{}, error) {
+		return noRetryOnViolation(struct{}{}, qtx.ReleaseMerchStock(ctx, queries.ReleaseMerchStockParams{
+			Type:  itemType,
+			Stock: pgtype.Int4{Int32: quantity, Valid: true},
+		}))
+	}, bo)
+	return err
+}
+
+// toMerchItem converts merch record of DB to the model.
+func toMerchItem(merch queries.MerchCurrent) model.MerchItem {
+	return model.MerchItem{
+		Type:        merch.Type,
+		Price:       merch.Price,
+		Description: merch.Description,
+		Available:   merch.Available,
+		Stock:       toStock(merch.Stock),
+	}
+}
+
+// toMerchPrice converts merch price record of DB to the model.
+func toMerchPrice(rec queries.MerchPrice) model.MerchPrice {
+	merchPrice := model.MerchPrice{
+		Type:      rec.Type,
+		Price:     rec.Price,
+		ValidFrom: rec.ValidFrom,
+		Actor:     rec.Actor,
+	}
+	if rec.ValidTo.Valid {
+		merchPrice.ValidTo = &rec.ValidTo.Time
+	}
+	return merchPrice
+}
+
+// toStock converts stock of merch in DB to the model.
+func toStock(stock pgtype.Int4) *int {
+	if !stock.Valid {
+		return nil
+	}
+	value := int(stock.Int32)
+	return &value
+}
+
+// fromStock converts stock of merch in the model to DB.
+func fromStock(stock *int) pgtype.Int4 {
+	if stock == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(*stock), Valid: true}
+}
@@ -0,0 +1,239 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository catalog", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling GetCatalog method", func() {
+		var (
+			catalog []model.MerchItem
+			filter  = model.CatalogFilter{MinPrice: 10, MaxPrice: 100, Sort: "-price"}
+			stock   = 4
+		)
+
+		BeforeEach(func() {
+			rsCatalog := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).
+				AddRow(int32(3), "cup", int64(20), "Ceramic mug", true, pgtype.Int4{Int32: 4, Valid: true}).
+				AddRow(int32(2), "pen", int64(10), "Ballpoint pen", false, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(filter.MinPrice, filter.MaxPrice, filter.Sort).WillReturnRows(rsCatalog).Times(1)
+
+			catalog, err = repo.GetCatalog(ctx, bo, filter)
+		})
+		AfterEach(func() {
+			err = mockPool.ExpectationsWereMet()
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns the catalog items and nil error", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(catalog).Should(Equal([]model.MerchItem{
+				{Type: "cup", Price: 20, Description: "Ceramic mug", Available: true, Stock: &stock},
+				{Type: "pen", Price: 10, Description: "Ballpoint pen", Available: false},
+			}))
+		})
+	})
+
+	Context("Calling GetCatalogItem method", func() {
+		var item model.MerchItem
+
+		When("there is no such item", func() {
+			BeforeEach(func() {
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("unknown").WillReturnError(sql.ErrNoRows)
+
+				item, err = repo.GetCatalogItem(ctx, bo, "unknown")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+				Expect(item).To(Equal(model.MerchItem{}))
+			})
+		})
+	})
+
+	Context("Calling ArchiveMerch method", func() {
+		var item model.MerchItem
+
+		BeforeEach(func() {
+			mockPool.ExpectBegin()
+		})
+
+		When("the merch is available", func() {
+			BeforeEach(func() {
+				rsMerch := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(2), "cup", int64(20), "Ceramic mug", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+ FOR UPDATE").WithArgs("cup").WillReturnRows(rsMerch)
+
+				mockPool.ExpectExec("UPDATE merch .+").WithArgs("cup", int64(20), "Ceramic mug", false, pgtype.Int4{}).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				rsAudit := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO merch_audit .+").WithArgs("cup", model.MerchActionArchive, int64(20), "Ceramic mug", false, "admin", pgtype.Int4{}).WillReturnRows(rsAudit)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				item, err = repo.ArchiveMerch(ctx, bo, "cup", "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the archived merch and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(item.Type).To(Equal("cup"))
+				Expect(item.Available).To(BeFalse())
+			})
+		})
+
+		When("the merch has already been archived", func() {
+			BeforeEach(func() {
+				rsMerch := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(2), "cup", int64(20), "Ceramic mug", false, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+ FOR UPDATE").WithArgs("cup").WillReturnRows(rsMerch)
+
+				mockPool.ExpectRollback()
+
+				item, err = repo.ArchiveMerch(ctx, bo, "cup", "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns conflict error", func() {
+				Expect(err).Should(MatchError(repository.ErrConflict))
+			})
+		})
+	})
+
+	Context("Calling CreateMerch method", func() {
+		When("the merch of the type already exists", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery("INSERT INTO merch .+").WithArgs("cup", int64(20), "", pgtype.Int4{}).WillReturnError(sql.ErrNoRows)
+				mockPool.ExpectRollback()
+
+				_, err = repo.CreateMerch(ctx, bo, model.MerchItem{Type: "cup", Price: 20}, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns conflict error", func() {
+				Expect(err).Should(MatchError(repository.ErrConflict))
+			})
+		})
+	})
+
+	Context("Calling SetMerchPrice method", func() {
+		var (
+			merchPrice model.MerchPrice
+			validFrom  = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+			next       = time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)
+		)
+
+		BeforeEach(func() {
+			mockPool.ExpectBegin()
+
+			rsMerch := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(2), "cup", int64(20), "Ceramic mug", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+ FOR UPDATE").WithArgs("cup").WillReturnRows(rsMerch)
+
+			rsNext := pgxmock.NewRows([]string{"valid_from"}).AddRow(next)
+			mockPool.ExpectQuery("SELECT valid_from FROM merch_prices .+").WithArgs("cup", validFrom).WillReturnRows(rsNext)
+		})
+
+		When("there is no price from the same time", func() {
+			BeforeEach(func() {
+				validTo := pgtype.Timestamp{Time: next, Valid: true}
+				rsPrice := pgxmock.NewRows([]string{"id", "type", "price", "valid_from", "valid_to", "actor", "created_at"}).
+					AddRow(int32(3), "cup", int64(15), validFrom, validTo, "admin", time.Now())
+				mockPool.ExpectQuery("INSERT INTO merch_prices .+").WithArgs("cup", int64(15), validFrom, validTo, "admin").WillReturnRows(rsPrice)
+
+				mockPool.ExpectExec("UPDATE merch_prices SET valid_to .+").WithArgs(validFrom, "cup").WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				merchPrice, err = repo.SetMerchPrice(ctx, bo, "cup", 15, validFrom, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the price valid until the next scheduled price and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(merchPrice.Price).To(Equal(int64(15)))
+				Expect(merchPrice.ValidFrom).To(Equal(validFrom))
+				Expect(merchPrice.ValidTo).NotTo(BeNil())
+				Expect(*merchPrice.ValidTo).To(Equal(next))
+			})
+		})
+
+		When("there is a price from the same time", func() {
+			BeforeEach(func() {
+				mockPool.ExpectQuery("INSERT INTO merch_prices .+").WithArgs("cup", int64(15), validFrom, pgtype.Timestamp{Time: next, Valid: true}, "admin").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectRollback()
+
+				merchPrice, err = repo.SetMerchPrice(ctx, bo, "cup", 15, validFrom, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns conflict error", func() {
+				Expect(err).Should(MatchError(repository.ErrConflict))
+				Expect(merchPrice).To(Equal(model.MerchPrice{}))
+			})
+		})
+	})
+})
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// CreateMerchDrop schedules a drop of merch on behalf of a given user.
+// Merch with drops is sold during its drops only, so drops of the same merch can't overlap.
+func (r *Repository) CreateMerchDrop(ctx context.Context, bo *backoff.ExponentialBackOff, drop model.MerchDrop, actor string) (model.MerchDrop, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.MerchDrop{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Lock the merch, so drops of the merch are scheduled by one user at a time
+	_, err = backoff.RetryWithData(func() (queries.MerchCurrent, error) {
+		return noRetryOnNoRows(qtx.GetMerchForUpdate(ctx, drop.Type))
+	}, bo)
+	if err != nil {
+		return model.MerchDrop{}, noDataOnNoRows(err)
+	}
+
+	// Check that the drop doesn't overlap other drops of the merch
+	overlapping, err := backoff.RetryWithData(func() (bool, error) {
+		return qtx.HasOverlappingMerchDrop(ctx, queries.HasOverlappingMerchDropParams{
+			Type:     drop.Type,
+			EndsAt:   drop.EndsAt,
+			StartsAt: drop.StartsAt,
+		})
+	}, bo)
+	if err != nil {
+		return model.MerchDrop{}, err
+	}
+	if overlapping {
+		return model.MerchDrop{}, ErrConflict
+	}
+
+	// Create the drop
+	created, err := backoff.RetryWithData(func() (queries.MerchDrop, error) {
+		return noRetryOnViolation(qtx.CreateMerchDrop(ctx, queries.CreateMerchDropParams{
+			Type:     drop.Type,
+			StartsAt: drop.StartsAt,
+			EndsAt:   drop.EndsAt,
+			Price:    fromPrice(drop.Price),
+			Stock:    fromCap(drop.Stock),
+			Actor:    actor,
+		}))
+	}, bo)
+	if err != nil {
+		return model.MerchDrop{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.MerchDrop{}, err
+	}
+
+	return toMerchDrop(created), nil
+}
+
+// GetMerchDrops returns current and upcoming drops of merch, the earliest first.
+func (r *Repository) GetMerchDrops(ctx context.Context, bo *backoff.ExponentialBackOff) ([]model.MerchDrop, error) {
+	// Get drops from DB
+	dropsQuery, err := backoff.RetryWithData(func() ([]queries.MerchDrop, error) {
+		return r.q.GetMerchDrops(ctx)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	drops := make([]model.MerchDrop, 0, len(dropsQuery))
+	for _, rec := range dropsQuery {
+		drops = append(drops, toMerchDrop(rec))
+	}
+
+	return drops, nil
+}
+
+// currentDrop returns the drop of merch sold in drops only, that is going on at the moment.
+func (r *Repository) currentDrop(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) (queries.GetCurrentMerchDropRow, error) {
+	drop, err := backoff.RetryWithData(func() (queries.GetCurrentMerchDropRow, error) {
+		return noRetryOnNoRows(r.q.GetCurrentMerchDrop(ctx, itemType))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return queries.GetCurrentMerchDropRow{}, ErrNoData
+	}
+	if err != nil {
+		return queries.GetCurrentMerchDropRow{}, err
+	}
+
+	now := time.Now()
+	if now.Before(drop.StartsAt) || !now.Before(drop.EndsAt) {
+		return queries.GetCurrentMerchDropRow{}, ErrNotOnSale
+	}
+
+	return drop, nil
+}
+
+// reserveDropStock takes a quantity of merch out of the stock of a drop, if the stock of the drop is capped.
+// The row of the drop stays locked until the end of the transaction, so a burst of purchases
+// can't sell more than the stock of the drop, and the drop can't end in the middle of the purchase.
+func (r *Repository) reserveDropStock(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, dropID int32, quantity int32) error {
+	reserved, err := backoff.RetryWithData(func() (int64, error) {
+		return noRetryOnViolation(qtx.ReserveMerchDropStock(ctx, queries.ReserveMerchDropStockParams{
+			ID:    dropID,
+			Stock: pgtype.Int4{Int32: quantity, Valid: true},
+		}))
+	}, bo)
+	if err != nil {
+		return err
+	}
+	if reserved == 0 {
+		return ErrNotOnSale
+	}
+
+	return nil
+}
+
+// toMerchDrop converts merch drop record of DB to the model.
+func toMerchDrop(rec queries.MerchDrop) model.MerchDrop {
+	drop := model.MerchDrop{
+		ID:        int(rec.ID),
+		Type:      rec.Type,
+		StartsAt:  rec.StartsAt,
+		EndsAt:    rec.EndsAt,
+		Stock:     toCap(rec.Stock),
+		Actor:     rec.Actor,
+		CreatedAt: rec.CreatedAt,
+	}
+	if rec.Price.Valid {
+		price := rec.Price.Int64
+		drop.Price = &price
+	}
+	return drop
+}
+
+// fromPrice converts optional price in the model to DB.
+func fromPrice(price *int64) pgtype.Int8 {
+	if price == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *price, Valid: true}
+}
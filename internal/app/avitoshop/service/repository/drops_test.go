@@ -0,0 +1,208 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository drops", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		user = model.User{UserName: "user"}
+
+		merchColumns = []string{"id", "type", "price", "description", "available", "stock"}
+		dropColumns  = []string{"id", "type", "starts_at", "ends_at", "price", "stock"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling BuyItem method for merch sold in drops only", func() {
+		JustBeforeEach(func() {
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+		})
+
+		When("the drop is going on", func() {
+			JustBeforeEach(func() {
+				rsDrop := pgxmock.NewRows(dropColumns).AddRow(int32(3), "hoody", time.Now().Add(-time.Hour), time.Now().Add(time.Hour), int64(200), pgtype.Int4{Int32: 5, Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_drops .+").WithArgs("hoody").WillReturnRows(rsDrop).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("hoody").WillReturnRows(rsVariants).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(600))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-400)).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(400)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 2, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				mockPool.ExpectExec("UPDATE merch_drops .+").WithArgs(int32(3), pgtype.Int4{Int32: 2, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, "hoody", int32(2), int64(200), int64(0), pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(7), user.UserName, "hoody", int32(2)).WillReturnRows(rsOrder).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Quantity: 2}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("buys the merch at the price of the drop and returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the drop hasn't started yet", func() {
+			JustBeforeEach(func() {
+				rsDrop := pgxmock.NewRows(dropColumns).AddRow(int32(3), "hoody", time.Now().Add(time.Hour), time.Now().Add(2*time.Hour), int64(200), pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_drops .+").WithArgs("hoody").WillReturnRows(rsDrop).Times(1)
+
+				err = repo.BuyItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Quantity: 1}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns not on sale error", func() {
+				Expect(err).Should(MatchError(repository.ErrNotOnSale))
+			})
+		})
+
+		When("the stock of the drop is sold out", func() {
+			JustBeforeEach(func() {
+				rsDrop := pgxmock.NewRows(dropColumns).AddRow(int32(3), "hoody", time.Now().Add(-time.Hour), time.Now().Add(time.Hour), int64(200), pgtype.Int4{Int32: 1, Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_drops .+").WithArgs("hoody").WillReturnRows(rsDrop).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("hoody").WillReturnRows(rsVariants).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(600))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-400)).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(400)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 2, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				mockPool.ExpectExec("UPDATE merch_drops .+").WithArgs(int32(3), pgtype.Int4{Int32: 2, Valid: true}).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "merch_drops_stock_check"})
+
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Quantity: 2}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns out of stock error", func() {
+				Expect(err).Should(MatchError(repository.ErrOutOfStock))
+			})
+		})
+	})
+
+	Context("Calling CreateMerchDrop method", func() {
+		var drop model.MerchDrop
+
+		BeforeEach(func() {
+			drop = model.MerchDrop{
+				Type:     "hoody",
+				StartsAt: time.Now().Add(time.Hour),
+				EndsAt:   time.Now().Add(2 * time.Hour),
+			}
+		})
+
+		When("the drop overlaps another drop of the merch", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsMerch := pgxmock.NewRows(merchColumns).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+ FOR UPDATE").WithArgs("hoody").WillReturnRows(rsMerch).Times(1)
+
+				rsOverlapping := pgxmock.NewRows([]string{"overlapping"}).AddRow(true)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_drops .+").WithArgs("hoody", drop.EndsAt, drop.StartsAt).WillReturnRows(rsOverlapping).Times(1)
+
+				mockPool.ExpectRollback()
+
+				_, err = repo.CreateMerchDrop(ctx, bo, drop, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns conflict error", func() {
+				Expect(err).Should(MatchError(repository.ErrConflict))
+			})
+		})
+
+		When("the merch doesn't exist", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+ FOR UPDATE").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+				mockPool.ExpectRollback()
+
+				_, err = repo.CreateMerchDrop(ctx, bo, drop, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+			})
+		})
+	})
+})
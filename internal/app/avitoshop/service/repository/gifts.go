@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// BuyGift registers purchase of inventory item (merch) by a buyer for a recipient.
+// The buyer pays the price, and the item is added to the recipient inventory.
+func (r *Repository) BuyGift(ctx context.Context, bo *backoff.ExponentialBackOff, buyer model.User, recipient model.User, item model.InventoryItem, message string) (model.Gift, error) {
+	// Get merch from DB
+	merch, err := backoff.RetryWithData(func() (queries.MerchCurrent, error) {
+		return noRetryOnNoRows(r.q.GetMerch(ctx, item.Type))
+	}, bo)
+	if err != nil {
+		return model.Gift{}, noDataOnNoRows(err)
+	}
+
+	// Get the variant of merch selected for the gift
+	variantID, err := r.selectVariant(ctx, bo, item)
+	if err != nil {
+		return model.Gift{}, err
+	}
+
+	// Check if the recipient exists
+	_, err = backoff.RetryWithData(func() (queries.User, error) {
+		return noRetryOnNoRows(r.q.GetUser(ctx, recipient.UserName))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Gift{}, ErrNoUser
+	}
+	if err != nil {
+		return model.Gift{}, err
+	}
+
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Gift{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Withdraw merch price from the balance of the buyer,
+	// DB returns the negative balance error if the balance is not enough
+	if err = r.debit(ctx, bo, qtx, buyer.UserName, merch.Price); err != nil {
+		return model.Gift{}, err
+	}
+
+	// Check the purchase limit of the buyer, gifts count as purchases of the buyer.
+	// The balance of the buyer is locked by the withdrawal, so purchases of the buyer are counted one at a time
+	if err = r.checkPurchaseLimit(ctx, bo, qtx, buyer.UserName, merch.Type, 1); err != nil {
+		return model.Gift{}, err
+	}
+
+	// Take the item out of stock
+	if err = r.reserveStock(ctx, bo, qtx, merch.Type, 1); err != nil {
+		return model.Gift{}, err
+	}
+	if err = r.reserveVariantStock(ctx, bo, qtx, variantID, 1); err != nil {
+		return model.Gift{}, err
+	}
+
+	// Add item to the recipient inventory
+	inventoryID, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:  recipient.UserName,
+			Type:      merch.Type,
+			Quantity:  1,
+			Kind:      kindGift,
+			PricePaid: merch.Price,
+			VariantID: variantID,
+		})
+	}, bo)
+	if err != nil {
+		return model.Gift{}, err
+	}
+
+	// Place the order to hand over the item to the recipient
+	if err = r.createOrder(ctx, bo, qtx, inventoryID, recipient.UserName, merch.Type, 1); err != nil {
+		return model.Gift{}, err
+	}
+
+	// Create gift record, that is seen by both users
+	created, err := backoff.RetryWithData(func() (queries.CreateGiftRow, error) {
+		return noRetryOnViolation(qtx.CreateGift(ctx, queries.CreateGiftParams{
+			FromUser:    buyer.UserName,
+			ToUser:      recipient.UserName,
+			Type:        merch.Type,
+			PricePaid:   merch.Price,
+			Message:     message,
+			InventoryID: inventoryID,
+			VariantID:   variantID,
+		}))
+	}, bo)
+	if err != nil {
+		return model.Gift{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Gift{}, err
+	}
+
+	return model.Gift{
+		ID:        int(created.ID),
+		FromUser:  buyer.UserName,
+		ToUser:    recipient.UserName,
+		Item:      merch.Type,
+		Message:   message,
+		PricePaid: merch.Price,
+		Status:    model.GiftStatusSent,
+		CreatedAt: created.CreatedAt,
+	}, nil
+}
+
+// DeclineGift declines a gift with a given ID by its recipient -
+// the item is taken out of the recipient inventory and the price paid is refunded to the buyer.
+func (r *Repository) DeclineGift(ctx context.Context, bo *backoff.ExponentialBackOff, id int, recipient model.User) (model.Gift, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Gift{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the gift
+	gift, err := backoff.RetryWithData(func() (queries.Gift, error) {
+		return noRetryOnNoRows(qtx.GetGiftForUpdate(ctx, int32(id)))
+	}, bo)
+	if err != nil {
+		return model.Gift{}, noDataOnNoRows(err)
+	}
+
+	// Only the recipient can decline the gift
+	if gift.ToUser != recipient.UserName {
+		return model.Gift{}, ErrNoData
+	}
+	if gift.Status != model.GiftStatusSent {
+		return model.Gift{}, ErrAlreadyReversed
+	}
+
+	declinedAt, err := r.declineGift(ctx, bo, qtx, gift, recipient.UserName)
+	if err != nil {
+		return model.Gift{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Gift{}, err
+	}
+
+	gift.Status = model.GiftStatusDeclined
+	gift.DeclinedAt = declinedAt
+
+	return toGift(gift), nil
+}
+
+// declineGift declines a given locked gift within a transaction on behalf of a given user,
+// taking the item from the recipient and refunding the buyer. Returns the time the gift is declined at.
+func (r *Repository) declineGift(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, gift queries.Gift, actor string) (pgtype.Timestamp, error) {
+	// The recipient may have already handed the item to someone else
+	err := r.lockItems(ctx, bo, qtx, gift.ToUser, gift.Type, gift.VariantID, 1)
+	if err != nil {
+		return pgtype.Timestamp{}, err
+	}
+
+	// Create compensating inventory record of the recipient
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:   gift.ToUser,
+			Type:       gift.Type,
+			Quantity:   -1,
+			Kind:       kindDecline,
+			PricePaid:  gift.PricePaid,
+			ReversalOf: pgtype.Int4{Int32: gift.InventoryID, Valid: true},
+			VariantID:  gift.VariantID,
+		})
+	}, bo)
+	if err != nil {
+		return pgtype.Timestamp{}, err
+	}
+
+	// Put the item back in stock
+	if err = r.releaseStock(ctx, bo, qtx, gift.Type, 1); err != nil {
+		return pgtype.Timestamp{}, err
+	}
+	if err = r.releaseVariantStock(ctx, bo, qtx, gift.VariantID, 1); err != nil {
+		return pgtype.Timestamp{}, err
+	}
+
+	// The declined item won't be handed over anymore
+	if err = r.cancelPurchaseOrder(ctx, bo, qtx, gift.InventoryID, actor); err != nil {
+		return pgtype.Timestamp{}, err
+	}
+
+	// Refund the price paid to the balance of the buyer
+	if gift.PricePaid > 0 {
+		if err = r.credit(ctx, bo, qtx, gift.FromUser, gift.PricePaid, kindRefund); err != nil {
+			return pgtype.Timestamp{}, err
+		}
+
+		_, err = backoff.RetryWithData(func() (int32, error) {
+			return noRetryOnViolation(qtx.CreateHistoryEntry(ctx, queries.CreateHistoryEntryParams{
+				Username: gift.FromUser,
+				FromUser: gift.ToUser,
+				Amount:   gift.PricePaid,
+				Kind:     kindRefund,
+			}))
+		}, bo)
+		if err != nil {
+			return pgtype.Timestamp{}, err
+		}
+	}
+
+	// Mark the gift as declined
+	return backoff.RetryWithData(func() (pgtype.Timestamp, error) {
+		return qtx.DeclineGift(ctx, gift.ID)
+	}, bo)
+}
+
+// GetGifts returns gifts sent and received by a user.
+func (r *Repository) GetGifts(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Gifts, error) {
+	// Get gifts from DB
+	giftsQuery, err := backoff.RetryWithData(func() ([]queries.Gift, error) {
+		return r.q.GetUserGifts(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.Gifts{}, err
+	}
+
+	var gifts model.Gifts
+	for _, rec := range giftsQuery {
+		if rec.FromUser == user.UserName {
+			gifts.Sent = append(gifts.Sent, toGift(rec))
+		}
+		if rec.ToUser == user.UserName {
+			gifts.Received = append(gifts.Received, toGift(rec))
+		}
+	}
+
+	return gifts, nil
+}
+
+// toGift converts gift record of DB to the model.
+func toGift(rec queries.Gift) model.Gift {
+	gift := model.Gift{
+		ID:        int(rec.ID),
+		FromUser:  rec.FromUser,
+		ToUser:    rec.ToUser,
+		Item:      rec.Type,
+		Message:   rec.Message,
+		PricePaid: rec.PricePaid,
+		Status:    rec.Status,
+		CreatedAt: rec.CreatedAt,
+	}
+	if rec.DeclinedAt.Valid {
+		declinedAt := rec.DeclinedAt.Time
+		gift.DeclinedAt = &declinedAt
+	}
+	return gift
+}
@@ -0,0 +1,277 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository gifts", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		gift model.Gift
+
+		giftID      int32 = 3
+		inventoryID int32 = 5
+		price       int64 = 20
+
+		buyer     = model.User{UserName: "user"}
+		recipient = model.User{UserName: "user1"}
+
+		giftColumns = []string{"id", "from_user", "to_user", "type", "price_paid", "message", "status", "inventory_id", "created_at", "declined_at", "variant_id"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool, repository.WithCoinTTL(24*time.Hour))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling BuyGift method", func() {
+		BeforeEach(func() {
+			rsMerch := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), "cup", price, "", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("cup").WillReturnRows(rsMerch).Times(1)
+
+			rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+			mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("cup").WillReturnRows(rsVariants).Times(1)
+		})
+
+		When("the balance of the buyer is enough", func() {
+			BeforeEach(func() {
+				rsUser := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(int32(2), recipient.UserName, "", time.Now(), "")
+				mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(recipient.UserName).WillReturnRows(rsUser).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(980))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(buyer.UserName, -price).WillReturnRows(rsUpdate).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(buyer.UserName, price).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("cup").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("cup", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(inventoryID)
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(recipient.UserName, "cup", int32(1), "gift", price, pgtype.Int4{}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(inventoryID, recipient.UserName, "cup", int32(1)).WillReturnRows(rsOrder).Times(1)
+
+				rsGift := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(giftID, time.Now())
+				mockPool.ExpectQuery("INSERT INTO gifts .+ VALUES .+").WithArgs(buyer.UserName, recipient.UserName, "cup", price, "Happy birthday!", inventoryID, pgtype.Int4{}).WillReturnRows(rsGift).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				gift, err = repo.BuyGift(ctx, bo, buyer, recipient, model.InventoryItem{Type: "cup", Quantity: 1}, "Happy birthday!")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the sent gift and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(gift.ID).To(Equal(int(giftID)))
+				Expect(gift.ToUser).To(Equal(recipient.UserName))
+				Expect(gift.Status).To(Equal(model.GiftStatusSent))
+			})
+		})
+
+		When("the recipient doesn't exist", func() {
+			BeforeEach(func() {
+				rsUser := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"})
+				mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(recipient.UserName).WillReturnRows(rsUser).Times(1)
+
+				gift, err = repo.BuyGift(ctx, bo, buyer, recipient, model.InventoryItem{Type: "cup", Quantity: 1}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no user error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoUser))
+			})
+		})
+	})
+
+	Context("Calling DeclineGift method", func() {
+		When("the gift has been sent to the user", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGift := pgxmock.NewRows(giftColumns).AddRow(giftID, buyer.UserName, recipient.UserName, "cup", price, "", "sent", inventoryID, time.Now(), pgtype.Timestamp{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM gifts .+").WithArgs(giftID).WillReturnRows(rsGift).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(recipient.UserName).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(recipient.UserName, "cup", pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(6))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(recipient.UserName, "cup", int32(-1), "decline", price, pgtype.Int4{Int32: inventoryID, Valid: true}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("cup", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectExec("UPDATE orders SET .+").WithArgs(inventoryID, recipient.UserName).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1000))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(buyer.UserName, price).WillReturnRows(rsUpdate).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(buyer.UserName, "refund", price, pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(buyer.UserName, recipient.UserName, "", price, "refund", pgtype.Int4{}).WillReturnRows(rsHistory).Times(1)
+
+				rsDecline := pgxmock.NewRows([]string{"declined_at"}).AddRow(pgtype.Timestamp{Time: time.Now(), Valid: true})
+				mockPool.ExpectQuery("UPDATE gifts SET .+").WithArgs(giftID).WillReturnRows(rsDecline).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				gift, err = repo.DeclineGift(ctx, bo, int(giftID), recipient)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the declined gift and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(gift.Status).To(Equal(model.GiftStatusDeclined))
+				Expect(gift.DeclinedAt).ShouldNot(BeNil())
+			})
+		})
+
+		When("the gift has been sent to another user", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGift := pgxmock.NewRows(giftColumns).AddRow(giftID, buyer.UserName, recipient.UserName, "cup", price, "", "sent", inventoryID, time.Now(), pgtype.Timestamp{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM gifts .+").WithArgs(giftID).WillReturnRows(rsGift).Times(1)
+
+				mockPool.ExpectRollback()
+
+				gift, err = repo.DeclineGift(ctx, bo, int(giftID), buyer)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+			})
+		})
+
+		When("the gift has already been declined", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				declinedAt := pgtype.Timestamp{Time: time.Now(), Valid: true}
+				rsGift := pgxmock.NewRows(giftColumns).AddRow(giftID, buyer.UserName, recipient.UserName, "cup", price, "", "declined", inventoryID, time.Now(), declinedAt, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM gifts .+").WithArgs(giftID).WillReturnRows(rsGift).Times(1)
+
+				mockPool.ExpectRollback()
+
+				gift, err = repo.DeclineGift(ctx, bo, int(giftID), recipient)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns already reversed error", func() {
+				Expect(err).Should(MatchError(repository.ErrAlreadyReversed))
+			})
+		})
+
+		When("the item of the gift has been transferred by the recipient", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGift := pgxmock.NewRows(giftColumns).AddRow(giftID, buyer.UserName, recipient.UserName, "cup", price, "", "sent", inventoryID, time.Now(), pgtype.Timestamp{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM gifts .+").WithArgs(giftID).WillReturnRows(rsGift).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(recipient.UserName).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(0))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(recipient.UserName, "cup", pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				mockPool.ExpectRollback()
+
+				gift, err = repo.DeclineGift(ctx, bo, int(giftID), recipient)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns not enough items error", func() {
+				Expect(err).Should(MatchError(repository.ErrNotEnoughItems))
+			})
+		})
+	})
+
+	Context("Calling GetGifts method", func() {
+		var gifts model.Gifts
+
+		When("the user has sent and received gifts", func() {
+			BeforeEach(func() {
+				rs := pgxmock.NewRows(giftColumns).
+					AddRow(int32(1), buyer.UserName, recipient.UserName, "cup", price, "", "sent", int32(1), time.Now(), pgtype.Timestamp{}, pgtype.Int4{}).
+					AddRow(int32(2), recipient.UserName, buyer.UserName, "pen", int64(10), "Thanks!", "sent", int32(2), time.Now(), pgtype.Timestamp{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM gifts .+").WithArgs(buyer.UserName).WillReturnRows(rs).Times(1)
+
+				gifts, err = repo.GetGifts(ctx, bo, buyer)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns sent and received gifts and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(gifts.Sent).Should(HaveLen(1))
+				Expect(gifts.Received).Should(HaveLen(1))
+				Expect(gifts.Received[0].Message).To(Equal("Thanks!"))
+			})
+		})
+	})
+})
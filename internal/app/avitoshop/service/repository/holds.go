@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// CreateHold holds coins on the balance of a user on behalf of the creator of the hold.
+// DB returns the negative balance error, if the available balance of the user is not enough.
+func (r *Repository) CreateHold(ctx context.Context, bo *backoff.ExponentialBackOff, hold model.Hold) (model.Hold, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Hold{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Hold the coins on the balance
+	_, err = backoff.RetryWithData(func() (int64, error) {
+		return noRetryOnNoRows(noRetryOnViolation(qtx.HoldBalance(ctx, queries.HoldBalanceParams{
+			Username: hold.UserName,
+			Held:     hold.Amount,
+		})))
+	}, bo)
+	if err != nil {
+		return model.Hold{}, noDataOnNoRows(err)
+	}
+
+	// Create the hold
+	created, err := backoff.RetryWithData(func() (queries.BalanceHold, error) {
+		return noRetryOnViolation(qtx.CreateBalanceHold(ctx, queries.CreateBalanceHoldParams{
+			Username:  hold.UserName,
+			Amount:    hold.Amount,
+			Reason:    hold.Reason,
+			CreatedBy: hold.CreatedBy,
+			ExpiresAt: hold.ExpiresAt,
+		}))
+	}, bo)
+	if err != nil {
+		return model.Hold{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Hold{}, err
+	}
+
+	return toHold(created), nil
+}
+
+// GetHold returns a hold with a given ID.
+func (r *Repository) GetHold(ctx context.Context, bo *backoff.ExponentialBackOff, id int) (model.Hold, error) {
+	hold, err := backoff.RetryWithData(func() (queries.BalanceHold, error) {
+		return noRetryOnNoRows(r.q.GetBalanceHold(ctx, int32(id)))
+	}, bo)
+	if err != nil {
+		return model.Hold{}, noDataOnNoRows(err)
+	}
+
+	return toHold(hold), nil
+}
+
+// CaptureHold settles a hold with a given ID by withdrawal of a given amount of coins from the balance of the user.
+// The whole hold is captured, if the amount is zero. The rest of the hold is released.
+func (r *Repository) CaptureHold(ctx context.Context, bo *backoff.ExponentialBackOff, id int, amount int64) (model.Hold, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Hold{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the hold
+	hold, err := r.lockHold(ctx, bo, qtx, id)
+	if err != nil {
+		return model.Hold{}, err
+	}
+	if hold.Status != model.HoldStatusHeld || !time.Now().Before(hold.ExpiresAt) {
+		return model.Hold{}, ErrHoldClosed
+	}
+
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		return model.Hold{}, ErrOutOfRange
+	}
+
+	// Release the held coins before the withdrawal, so they can be withdrawn
+	if err = r.unhold(ctx, bo, qtx, hold); err != nil {
+		return model.Hold{}, err
+	}
+
+	// Withdraw the captured coins from the balance of the user
+	if err = r.debit(ctx, bo, qtx, hold.Username, amount); err != nil {
+		return model.Hold{}, err
+	}
+
+	// Create history record of the capture, the coins are withdrawn with no one to receive them
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateHistoryEntry(ctx, queries.CreateHistoryEntryParams{
+			Username: hold.Username,
+			Amount:   amount,
+			Kind:     kindCapture,
+		})
+	}, bo)
+	if err != nil {
+		return model.Hold{}, err
+	}
+
+	hold, err = r.closeHold(ctx, bo, qtx, hold.ID, model.HoldStatusCaptured, amount)
+	if err != nil {
+		return model.Hold{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Hold{}, err
+	}
+
+	return toHold(hold), nil
+}
+
+// ReleaseHold releases a hold with a given ID without withdrawal of coins.
+func (r *Repository) ReleaseHold(ctx context.Context, bo *backoff.ExponentialBackOff, id int) (model.Hold, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Hold{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the hold
+	hold, err := r.lockHold(ctx, bo, qtx, id)
+	if err != nil {
+		return model.Hold{}, err
+	}
+	if hold.Status != model.HoldStatusHeld {
+		return model.Hold{}, ErrHoldClosed
+	}
+
+	if err = r.unhold(ctx, bo, qtx, hold); err != nil {
+		return model.Hold{}, err
+	}
+
+	hold, err = r.closeHold(ctx, bo, qtx, hold.ID, model.HoldStatusReleased, 0)
+	if err != nil {
+		return model.Hold{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Hold{}, err
+	}
+
+	return toHold(hold), nil
+}
+
+// ExpireHolds releases holds past their expiry time. Holds are looked up in batches of a given size,
+// every hold is released in its own transaction. Returns the number of expired holds.
+func (r *Repository) ExpireHolds(ctx context.Context, bo *backoff.ExponentialBackOff, batchSize int) (int, error) {
+	var total int
+	for {
+		// Get holds, that have expired
+		ids, err := backoff.RetryWithData(func() ([]int32, error) {
+			return r.q.GetExpiredBalanceHolds(ctx, int32(batchSize))
+		}, bo)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		for _, id := range ids {
+			expired, err := r.expireHold(ctx, bo, id)
+			if err != nil {
+				return total, err
+			}
+			if expired {
+				total++
+			}
+		}
+	}
+}
+
+// expireHold releases an expired hold with a given ID, reports whether the hold has been released.
+func (r *Repository) expireHold(ctx context.Context, bo *backoff.ExponentialBackOff, id int32) (bool, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the hold
+	hold, err := r.lockHold(ctx, bo, qtx, int(id))
+	if err != nil {
+		return false, err
+	}
+
+	// The hold has been closed concurrently
+	if hold.Status != model.HoldStatusHeld {
+		return false, tx.Commit(ctx)
+	}
+
+	if err = r.unhold(ctx, bo, qtx, hold); err != nil {
+		return false, err
+	}
+
+	if _, err = r.closeHold(ctx, bo, qtx, hold.ID, model.HoldStatusExpired, 0); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// lockHold gets and locks a hold with a given ID in the transaction.
+func (r *Repository) lockHold(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, id int) (queries.BalanceHold, error) {
+	hold, err := backoff.RetryWithData(func() (queries.BalanceHold, error) {
+		return noRetryOnNoRows(qtx.GetBalanceHoldForUpdate(ctx, int32(id)))
+	}, bo)
+	if err != nil {
+		return queries.BalanceHold{}, noDataOnNoRows(err)
+	}
+
+	return hold, nil
+}
+
+// unhold releases the coins of a hold on the balance of the user.
+func (r *Repository) unhold(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, hold queries.BalanceHold) error {
+	_, err := backoff.RetryWithData(func() (struct{}, error) {
+		return noRetryOnViolation(struct{}{}, qtx.UnholdBalance(ctx, queries.UnholdBalanceParams{
+			Username: hold.Username,
+			Held:     hold.Amount,
+		}))
+	}, bo)
+
+	return err
+}
+
+// closeHold moves a hold with a given ID to a given final status with a given captured amount.
+func (r *Repository) closeHold(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, id int32, status string, captured int64) (queries.BalanceHold, error) {
+	return backoff.RetryWithData(func() (queries.BalanceHold, error) {
+		return qtx.CloseBalanceHold(ctx, queries.CloseBalanceHoldParams{
+			ID:       id,
+			Status:   status,
+			Captured: captured,
+		})
+	}, bo)
+}
+
+// toHold converts balance hold record of DB to the model.
+func toHold(rec queries.BalanceHold) model.Hold {
+	hold := model.Hold{
+		ID:        int(rec.ID),
+		UserName:  rec.Username,
+		Amount:    rec.Amount,
+		Captured:  rec.Captured,
+		Status:    rec.Status,
+		Reason:    rec.Reason,
+		CreatedBy: rec.CreatedBy,
+		ExpiresAt: rec.ExpiresAt,
+		CreatedAt: rec.CreatedAt,
+	}
+	if rec.ClosedAt.Valid {
+		closedAt := rec.ClosedAt.Time
+		hold.ClosedAt = &closedAt
+	}
+	return hold
+}
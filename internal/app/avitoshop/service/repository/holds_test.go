@@ -0,0 +1,233 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository holds", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		hold model.Hold
+
+		user        = model.User{UserName: "user"}
+		integration = model.User{UserName: "taxi"}
+
+		holdColumns = []string{"id", "username", "amount", "captured", "status", "reason", "created_by", "expires_at", "created_at", "closed_at"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling CreateHold method", func() {
+		var expiresAt = time.Now().Add(time.Hour)
+
+		When("available balance is enough", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsHold := pgxmock.NewRows([]string{"held"}).AddRow(int64(300))
+				mockPool.ExpectQuery("UPDATE balance SET held .+").WithArgs(user.UserName, int64(300)).WillReturnRows(rsHold).Times(1)
+
+				rsCreate := pgxmock.NewRows(holdColumns).
+					AddRow(int32(1), user.UserName, int64(300), int64(0), model.HoldStatusHeld, "ride", integration.UserName, expiresAt, time.Now(), pgtype.Timestamp{})
+				mockPool.ExpectQuery("INSERT INTO balance_holds .+ VALUES .+").
+					WithArgs(user.UserName, int64(300), "ride", integration.UserName, expiresAt).WillReturnRows(rsCreate).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				hold, err = repo.CreateHold(ctx, bo, model.Hold{UserName: user.UserName, Amount: 300, Reason: "ride", CreatedBy: integration.UserName, ExpiresAt: expiresAt})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("holds the coins and returns the hold", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(hold.ID).To(Equal(1))
+				Expect(hold.Status).To(Equal(model.HoldStatusHeld))
+				Expect(hold.ClosedAt).To(BeNil())
+			})
+		})
+
+		When("available balance is not enough", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery("UPDATE balance SET held .+").WithArgs(user.UserName, int64(300)).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "balance_held_check"})
+				mockPool.ExpectRollback()
+
+				hold, err = repo.CreateHold(ctx, bo, model.Hold{UserName: user.UserName, Amount: 300, Reason: "ride", CreatedBy: integration.UserName, ExpiresAt: expiresAt})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns negative balance error", func() {
+				Expect(err).Should(MatchError(repository.ErrNegativeBalance))
+			})
+		})
+	})
+
+	Context("Calling CaptureHold method", func() {
+		When("a part of the hold is captured", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsLock := pgxmock.NewRows(holdColumns).
+					AddRow(int32(1), user.UserName, int64(300), int64(0), model.HoldStatusHeld, "ride", integration.UserName, time.Now().Add(time.Hour), time.Now(), pgtype.Timestamp{})
+				mockPool.ExpectQuery("SELECT .+ FROM balance_holds .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsLock).Times(1)
+
+				mockPool.ExpectExec("UPDATE balance SET held .+").WithArgs(user.UserName, int64(300)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsWithdraw := pgxmock.NewRows([]string{"coins"}).AddRow(int64(800))
+				mockPool.ExpectQuery("UPDATE balance SET coins .+").WithArgs(user.UserName, int64(-200)).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(200)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(user.UserName, "", "", int64(200), "capture", pgtype.Int4{}).WillReturnRows(rsHistory).Times(1)
+
+				rsClose := pgxmock.NewRows(holdColumns).
+					AddRow(int32(1), user.UserName, int64(300), int64(200), model.HoldStatusCaptured, "ride", integration.UserName, time.Now().Add(time.Hour), time.Now(), pgtype.Timestamp{Time: time.Now(), Valid: true})
+				mockPool.ExpectQuery("UPDATE balance_holds .+ RETURNING .+").WithArgs(int32(1), model.HoldStatusCaptured, int64(200)).WillReturnRows(rsClose).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				hold, err = repo.CaptureHold(ctx, bo, 1, 200)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("withdraws the captured coins and releases the rest", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(hold.Status).To(Equal(model.HoldStatusCaptured))
+				Expect(hold.Captured).To(Equal(int64(200)))
+				Expect(hold.ClosedAt).NotTo(BeNil())
+			})
+		})
+
+		When("the hold has already been released", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsLock := pgxmock.NewRows(holdColumns).
+					AddRow(int32(1), user.UserName, int64(300), int64(0), model.HoldStatusReleased, "ride", integration.UserName, time.Now().Add(time.Hour), time.Now(), pgtype.Timestamp{Time: time.Now(), Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM balance_holds .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsLock).Times(1)
+
+				mockPool.ExpectRollback()
+
+				hold, err = repo.CaptureHold(ctx, bo, 1, 0)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns hold closed error", func() {
+				Expect(err).Should(MatchError(repository.ErrHoldClosed))
+			})
+		})
+
+		When("the amount is greater than the hold", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsLock := pgxmock.NewRows(holdColumns).
+					AddRow(int32(1), user.UserName, int64(300), int64(0), model.HoldStatusHeld, "ride", integration.UserName, time.Now().Add(time.Hour), time.Now(), pgtype.Timestamp{})
+				mockPool.ExpectQuery("SELECT .+ FROM balance_holds .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsLock).Times(1)
+
+				mockPool.ExpectRollback()
+
+				hold, err = repo.CaptureHold(ctx, bo, 1, 500)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns out of range error", func() {
+				Expect(err).Should(MatchError(repository.ErrOutOfRange))
+			})
+		})
+	})
+
+	Context("Calling ExpireHolds method", func() {
+		var expired int
+
+		JustBeforeEach(func() {
+			rsExpired := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+			mockPool.ExpectQuery("SELECT id FROM balance_holds .+").WithArgs(int32(100)).WillReturnRows(rsExpired).Times(1)
+
+			mockPool.ExpectBegin()
+
+			rsLock := pgxmock.NewRows(holdColumns).
+				AddRow(int32(1), user.UserName, int64(300), int64(0), model.HoldStatusHeld, "ride", integration.UserName, time.Now().Add(-time.Minute), time.Now(), pgtype.Timestamp{})
+			mockPool.ExpectQuery("SELECT .+ FROM balance_holds .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsLock).Times(1)
+
+			mockPool.ExpectExec("UPDATE balance SET held .+").WithArgs(user.UserName, int64(300)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+			rsClose := pgxmock.NewRows(holdColumns).
+				AddRow(int32(1), user.UserName, int64(300), int64(0), model.HoldStatusExpired, "ride", integration.UserName, time.Now().Add(-time.Minute), time.Now(), pgtype.Timestamp{Time: time.Now(), Valid: true})
+			mockPool.ExpectQuery("UPDATE balance_holds .+ RETURNING .+").WithArgs(int32(1), model.HoldStatusExpired, int64(0)).WillReturnRows(rsClose).Times(1)
+
+			mockPool.ExpectCommit()
+			mockPool.ExpectRollback()
+
+			mockPool.ExpectQuery("SELECT id FROM balance_holds .+").WithArgs(int32(100)).WillReturnRows(pgxmock.NewRows([]string{"id"})).Times(1)
+
+			expired, err = repo.ExpireHolds(ctx, bo, 100)
+		})
+		AfterEach(func() {
+			err = mockPool.ExpectationsWereMet()
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("releases the expired holds and returns their number", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(expired).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// TransferItems moves a given quantity of owned items of a type from one user to another.
+func (r *Repository) TransferItems(ctx context.Context, bo *backoff.ExponentialBackOff, fromUser model.User, toUser model.User, item model.InventoryItem) (model.ItemTransfer, error) {
+	// Check if the receiver exists
+	_, err := backoff.RetryWithData(func() (queries.User, error) {
+		return noRetryOnNoRows(r.q.GetUser(ctx, toUser.UserName))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ItemTransfer{}, ErrNoUser
+	}
+	if err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	// Get the variant of the items, items of merch without a variant are given without size and color
+	variantID, err := r.findVariant(ctx, bo, item)
+	if err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.ItemTransfer{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Check if the sender owns enough items of the variant
+	if err = r.lockItems(ctx, bo, qtx, fromUser.UserName, item.Type, variantID, item.Quantity); err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	// Take items out of the sender inventory
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:  fromUser.UserName,
+			Type:      item.Type,
+			Quantity:  -int32(item.Quantity),
+			Kind:      kindTransfer,
+			VariantID: variantID,
+		})
+	}, bo)
+	if err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	// Add items to the receiver inventory
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:  toUser.UserName,
+			Type:      item.Type,
+			Quantity:  int32(item.Quantity),
+			Kind:      kindTransfer,
+			VariantID: variantID,
+		})
+	}, bo)
+	if err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	// Create item transfer record, that is seen by both users
+	created, err := backoff.RetryWithData(func() (queries.CreateItemTransferRow, error) {
+		return noRetryOnViolation(qtx.CreateItemTransfer(ctx, queries.CreateItemTransferParams{
+			FromUser: fromUser.UserName,
+			ToUser:   toUser.UserName,
+			Type:     item.Type,
+			Quantity: int32(item.Quantity),
+		}))
+	}, bo)
+	if err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	return model.ItemTransfer{
+		ID:        int(created.ID),
+		FromUser:  fromUser.UserName,
+		ToUser:    toUser.UserName,
+		Item:      item.Type,
+		Quantity:  item.Quantity,
+		CreatedAt: created.CreatedAt,
+	}, nil
+}
+
+// ReturnItem returns the latest item of a type purchased by a user after a given time and refunds the price paid.
+// Only items, that haven't been transferred, listed or otherwise taken out of the inventory since the purchase,
+// can be returned.
+func (r *Repository) ReturnItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, itemType string, boughtAfter time.Time) (model.ItemReturn, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.ItemReturn{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the purchase to return
+	purchase, err := backoff.RetryWithData(func() (queries.Inventory, error) {
+		return noRetryOnNoRows(qtx.GetReturnablePurchase(ctx, queries.GetReturnablePurchaseParams{
+			Username: user.UserName,
+			Type:     itemType,
+			BoughtAt: boughtAfter,
+		}))
+	}, bo)
+	if err != nil {
+		return model.ItemReturn{}, noDataOnNoRows(err)
+	}
+
+	// Lock the inventory and check the user still owns the items of the variant purchased
+	err = r.lockItems(ctx, bo, qtx, user.UserName, itemType, purchase.VariantID, int(purchase.Quantity))
+	if errors.Is(err, ErrNotEnoughItems) {
+		return model.ItemReturn{}, ErrNoData
+	}
+	if err != nil {
+		return model.ItemReturn{}, err
+	}
+
+	refund, err := amountPaid(purchase)
+	if err != nil {
+		return model.ItemReturn{}, err
+	}
+
+	// Create compensating inventory record
+	returnID, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:   purchase.Username,
+			Type:       purchase.Type,
+			Quantity:   -purchase.Quantity,
+			Kind:       kindReturn,
+			PricePaid:  purchase.PricePaid,
+			ReversalOf: pgtype.Int4{Int32: purchase.ID, Valid: true},
+			VariantID:  purchase.VariantID,
+		})
+	}, bo)
+	if err != nil {
+		return model.ItemReturn{}, err
+	}
+
+	// Put the item back in stock
+	if err = r.releaseStock(ctx, bo, qtx, purchase.Type, purchase.Quantity); err != nil {
+		return model.ItemReturn{}, err
+	}
+	if err = r.releaseVariantStock(ctx, bo, qtx, purchase.VariantID, purchase.Quantity); err != nil {
+		return model.ItemReturn{}, err
+	}
+
+	// The returned items won't be handed over anymore
+	if err = r.cancelPurchaseOrder(ctx, bo, qtx, purchase.ID, user.UserName); err != nil {
+		return model.ItemReturn{}, err
+	}
+
+	// Refund the price paid to the balance of the user, if there is anything to refund
+	if refund > 0 {
+		if err = r.credit(ctx, bo, qtx, purchase.Username, refund, kindRefund); err != nil {
+			return model.ItemReturn{}, err
+		}
+
+		err = r.createHistoryEntry(ctx, bo, qtx, queries.CreateHistoryEntryParams{
+			Username: purchase.Username,
+			Amount:   refund,
+			Kind:     kindRefund,
+		})
+		if err != nil {
+			return model.ItemReturn{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.ItemReturn{}, err
+	}
+
+	return model.ItemReturn{
+		ID:         int(returnID),
+		PurchaseID: int(purchase.ID),
+		Item:       purchase.Type,
+		Refund:     refund,
+		ReturnedAt: time.Now(),
+	}, nil
+}
+
+// GetItemsHistory returns item transfers sent and received by a user.
+func (r *Repository) GetItemsHistory(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.ItemsHistory, error) {
+	// Get item transfers from DB
+	transfersQuery, err := backoff.RetryWithData(func() ([]queries.ItemTransfer, error) {
+		return r.q.GetItemTransfers(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.ItemsHistory{}, err
+	}
+
+	var history model.ItemsHistory
+	for _, rec := range transfersQuery {
+		transfer := model.ItemTransfer{
+			ID:        int(rec.ID),
+			FromUser:  rec.FromUser,
+			ToUser:    rec.ToUser,
+			Item:      rec.Type,
+			Quantity:  int(rec.Quantity),
+			CreatedAt: rec.CreatedAt,
+		}
+		if rec.FromUser == user.UserName {
+			history.Sent = append(history.Sent, transfer)
+		}
+		if rec.ToUser == user.UserName {
+			history.Received = append(history.Received, transfer)
+		}
+	}
+
+	return history, nil
+}
+
+// lockItems locks inventory of a user until the end of transaction and checks the user owns
+// at least a given quantity of items of a type and a variant. Inventory is locked by the balance row of the user,
+// so it is serialized with purchases, which update the balance.
+func (r *Repository) lockItems(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, username string, itemType string, variantID pgtype.Int4, quantity int) error {
+	// Lock the balance of the user
+	_, err := backoff.RetryWithData(func() (queries.LockBalanceRow, error) {
+		return noRetryOnNoRows(qtx.LockBalance(ctx, username))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Get quantity of items owned by the user
+	owned, err := backoff.RetryWithData(func() (int64, error) {
+		return qtx.GetItemQuantity(ctx, queries.GetItemQuantityParams{
+			Username:  username,
+			Type:      itemType,
+			VariantID: variantID,
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+	if owned < int64(quantity) {
+		return ErrNotEnoughItems
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository items", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		fromUser = model.User{UserName: "user"}
+		toUser   = model.User{UserName: "user1"}
+		item     = model.InventoryItem{Type: "book", Quantity: 2}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling TransferItems method", func() {
+		var transfer model.ItemTransfer
+
+		BeforeEach(func() {
+			rsUser := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(int32(2), toUser.UserName, "", time.Now(), "")
+			mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(toUser.UserName).WillReturnRows(rsUser).Times(1)
+
+			mockPool.ExpectBegin()
+
+			rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+			mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(fromUser.UserName).WillReturnRows(rsLock).Times(1)
+		})
+
+		When("the sender owns enough items", func() {
+			BeforeEach(func() {
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(3))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(fromUser.UserName, item.Type, pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				rsSender := pgxmock.NewRows([]string{"id"}).AddRow(int32(10))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(fromUser.UserName, item.Type, int32(-2), "transfer", int64(0), pgtype.Int4{}, pgtype.Int4{}).WillReturnRows(rsSender).Times(1)
+
+				rsReceiver := pgxmock.NewRows([]string{"id"}).AddRow(int32(11))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(toUser.UserName, item.Type, int32(2), "transfer", int64(0), pgtype.Int4{}, pgtype.Int4{}).WillReturnRows(rsReceiver).Times(1)
+
+				rsTransfer := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int32(1), time.Now())
+				mockPool.ExpectQuery("INSERT INTO item_transfers .+ VALUES .+").WithArgs(fromUser.UserName, toUser.UserName, item.Type, int32(2)).WillReturnRows(rsTransfer).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				transfer, err = repo.TransferItems(ctx, bo, fromUser, toUser, item)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the transfer and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(transfer.ID).To(Equal(1))
+				Expect(transfer.Quantity).To(Equal(item.Quantity))
+			})
+		})
+
+		When("the sender doesn't own enough items", func() {
+			BeforeEach(func() {
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(fromUser.UserName, item.Type, pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				mockPool.ExpectRollback()
+
+				transfer, err = repo.TransferItems(ctx, bo, fromUser, toUser, item)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns not enough items error", func() {
+				Expect(err).Should(MatchError(repository.ErrNotEnoughItems))
+			})
+		})
+	})
+
+	Context("Calling GetItemsHistory method", func() {
+		var history model.ItemsHistory
+
+		When("the user has sent and received items", func() {
+			BeforeEach(func() {
+				rs := pgxmock.NewRows([]string{"id", "from_user", "to_user", "type", "quantity", "created_at"}).
+					AddRow(int32(1), fromUser.UserName, toUser.UserName, "book", int32(2), time.Now()).
+					AddRow(int32(2), toUser.UserName, fromUser.UserName, "pen", int32(1), time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM item_transfers .+").WithArgs(fromUser.UserName).WillReturnRows(rs).Times(1)
+
+				history, err = repo.GetItemsHistory(ctx, bo, fromUser)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns sent and received items and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(history.Sent).Should(HaveLen(1))
+				Expect(history.Received).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Context("Calling ReturnItem method", func() {
+		var (
+			itemReturn  model.ItemReturn
+			boughtAfter = time.Now().Add(-14 * 24 * time.Hour)
+		)
+
+		BeforeEach(func() {
+			mockPool.ExpectBegin()
+		})
+
+		When("the item has been purchased within the return window", func() {
+			BeforeEach(func() {
+				var price int64 = 20
+
+				rsPurchase := pgxmock.NewRows([]string{"id", "username", "type", "quantity", "bought_at", "kind", "price_paid", "reversal_of", "discount", "variant_id"}).
+					AddRow(int32(5), fromUser.UserName, "cup", int32(1), time.Now(), "purchase", price, pgtype.Int4{}, int64(0), pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM inventory p .+").WithArgs(fromUser.UserName, "cup", boughtAfter).WillReturnRows(rsPurchase).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(fromUser.UserName).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(fromUser.UserName, "cup", pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(fromUser.UserName, "cup", int32(-1), "return", price, pgtype.Int4{Int32: 5, Valid: true}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("cup", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectExec("UPDATE orders SET .+").WithArgs(int32(5), fromUser.UserName).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(520))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(fromUser.UserName, price).WillReturnRows(rsUpdate).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(fromUser.UserName, "refund", price, pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(8))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(fromUser.UserName, "", "", price, "refund", pgtype.Int4{}).WillReturnRows(rsHistory).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				itemReturn, err = repo.ReturnItem(ctx, bo, fromUser, "cup", boughtAfter)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the return with the price paid refunded and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(itemReturn.ID).To(Equal(7))
+				Expect(itemReturn.PurchaseID).To(Equal(5))
+				Expect(itemReturn.Refund).To(Equal(int64(20)))
+			})
+		})
+
+		When("the items of the purchased variant have been transferred", func() {
+			BeforeEach(func() {
+				rsPurchase := pgxmock.NewRows([]string{"id", "username", "type", "quantity", "bought_at", "kind", "price_paid", "reversal_of", "discount", "variant_id"}).
+					AddRow(int32(5), fromUser.UserName, "hoody", int32(2), time.Now(), "purchase", int64(600), pgtype.Int4{}, int64(0), pgtype.Int4{Int32: 3, Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM inventory p .+").WithArgs(fromUser.UserName, "hoody", boughtAfter).WillReturnRows(rsPurchase).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(fromUser.UserName).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(fromUser.UserName, "hoody", pgtype.Int4{Int32: 3, Valid: true}).WillReturnRows(rsQuantity).Times(1)
+
+				mockPool.ExpectRollback()
+
+				itemReturn, err = repo.ReturnItem(ctx, bo, fromUser, "hoody", boughtAfter)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+			})
+		})
+
+		When("the item has been transferred since the purchase", func() {
+			BeforeEach(func() {
+				rsPurchase := pgxmock.NewRows([]string{"id", "username", "type", "quantity", "bought_at", "kind", "price_paid", "reversal_of", "discount", "variant_id"})
+				mockPool.ExpectQuery("SELECT .+ FROM inventory p .+").WithArgs(fromUser.UserName, "cup", boughtAfter).WillReturnRows(rsPurchase).Times(1)
+
+				mockPool.ExpectRollback()
+
+				itemReturn, err = repo.ReturnItem(ctx, bo, fromUser, "cup", boughtAfter)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+			})
+		})
+	})
+})
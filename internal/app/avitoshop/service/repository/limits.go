@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// SetPurchaseLimit sets the purchase limit of merch on behalf of a given user, replacing the previous limit.
+func (r *Repository) SetPurchaseLimit(ctx context.Context, bo *backoff.ExponentialBackOff, limit model.PurchaseLimit, actor string) (model.PurchaseLimit, error) {
+	// Check that the merch exists
+	if _, err := r.GetCatalogItem(ctx, bo, limit.Type); err != nil {
+		return model.PurchaseLimit{}, err
+	}
+
+	// Create or replace the limit
+	upserted, err := backoff.RetryWithData(func() (queries.PurchaseLimit, error) {
+		return r.q.UpsertPurchaseLimit(ctx, queries.UpsertPurchaseLimitParams{
+			Type:        limit.Type,
+			MaxQuantity: int32(limit.MaxQuantity),
+			PeriodDays:  fromCap(limit.PeriodDays),
+			Actor:       actor,
+		})
+	}, bo)
+	if err != nil {
+		return model.PurchaseLimit{}, err
+	}
+
+	return toPurchaseLimit(upserted), nil
+}
+
+// DeletePurchaseLimit removes the purchase limit of merch of a given type.
+func (r *Repository) DeletePurchaseLimit(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) error {
+	deleted, err := backoff.RetryWithData(func() (int64, error) {
+		return r.q.DeletePurchaseLimit(ctx, itemType)
+	}, bo)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// GetPurchaseLimits returns purchase limits of all merch.
+func (r *Repository) GetPurchaseLimits(ctx context.Context, bo *backoff.ExponentialBackOff) ([]model.PurchaseLimit, error) {
+	// Get limits from DB
+	limitsQuery, err := backoff.RetryWithData(func() ([]queries.PurchaseLimit, error) {
+		return r.q.GetPurchaseLimits(ctx)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make([]model.PurchaseLimit, 0, len(limitsQuery))
+	for _, rec := range limitsQuery {
+		limits = append(limits, toPurchaseLimit(rec))
+	}
+
+	return limits, nil
+}
+
+// checkPurchaseLimit checks, that a user can buy a quantity of merch without exceeding its purchase limit.
+// Returned and reversed purchases don't count towards the limit.
+func (r *Repository) checkPurchaseLimit(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, username string, itemType string, quantity int) error {
+	// Get the limit, merch without a limit can be bought in any quantity
+	limit, err := backoff.RetryWithData(func() (queries.PurchaseLimit, error) {
+		return noRetryOnNoRows(qtx.GetPurchaseLimit(ctx, itemType))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Count the items the user has bought within the period of the limit
+	bought, err := backoff.RetryWithData(func() (int64, error) {
+		return qtx.CountPurchasedItems(ctx, queries.CountPurchasedItemsParams{
+			Username:   username,
+			Type:       itemType,
+			PeriodDays: limit.PeriodDays,
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	if bought+int64(quantity) > int64(limit.MaxQuantity) {
+		return ErrLimitReached
+	}
+
+	return nil
+}
+
+// toPurchaseLimit converts purchase limit record of DB to the model.
+func toPurchaseLimit(rec queries.PurchaseLimit) model.PurchaseLimit {
+	return model.PurchaseLimit{
+		Type:        rec.Type,
+		MaxQuantity: int(rec.MaxQuantity),
+		PeriodDays:  toCap(rec.PeriodDays),
+		Actor:       rec.Actor,
+		UpdatedAt:   rec.UpdatedAt,
+	}
+}
@@ -0,0 +1,271 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository purchase limits", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		user = model.User{UserName: "user"}
+		item = model.InventoryItem{Type: "pink-hoody", Quantity: 2}
+
+		limitColumns = []string{"type", "max_quantity", "period_days", "actor", "updated_at"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling BuyItem method for merch with a purchase limit", func() {
+		var bought int64
+
+		JustBeforeEach(func() {
+			rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), item.Type, int64(500), "", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(item.Type).WillReturnRows(rsGet).Times(1)
+
+			rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+			mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs(item.Type).WillReturnRows(rsVariants).Times(1)
+
+			mockPool.ExpectBegin()
+
+			rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(0))
+			mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-1000)).WillReturnRows(rsWithdraw).Times(1)
+			mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(1000)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+			rsLimit := pgxmock.NewRows(limitColumns).AddRow(item.Type, int32(3), pgtype.Int4{Int32: 30, Valid: true}, "admin", time.Now())
+			mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs(item.Type).WillReturnRows(rsLimit).Times(1)
+
+			rsBought := pgxmock.NewRows([]string{"quantity"}).AddRow(bought)
+			mockPool.ExpectQuery("SELECT .+ FROM inventory p .+").WithArgs(item.Type, user.UserName, pgtype.Int4{Int32: 30, Valid: true}).WillReturnRows(rsBought).Times(1)
+		})
+
+		When("the purchase fits into the limit", func() {
+			BeforeEach(func() {
+				bought = 1
+			})
+
+			JustBeforeEach(func() {
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs(item.Type, pgtype.Int4{Int32: 2, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, item.Type, int32(2), int64(500), int64(0), pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(7), user.UserName, item.Type, int32(2)).WillReturnRows(rsOrder).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, item, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the purchase exceeds the limit", func() {
+			BeforeEach(func() {
+				bought = 2
+			})
+
+			JustBeforeEach(func() {
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, item, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns limit reached error", func() {
+				Expect(err).Should(MatchError(repository.ErrLimitReached))
+			})
+		})
+	})
+
+	Context("Calling BuyGift method for merch with a purchase limit", func() {
+		var recipient = model.User{UserName: "user1"}
+
+		JustBeforeEach(func() {
+			rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), item.Type, int64(500), "", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(item.Type).WillReturnRows(rsGet).Times(1)
+
+			rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+			mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs(item.Type).WillReturnRows(rsVariants).Times(1)
+
+			rsUser := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(int32(2), recipient.UserName, "", time.Now(), "")
+			mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(recipient.UserName).WillReturnRows(rsUser).Times(1)
+
+			mockPool.ExpectBegin()
+
+			rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(500))
+			mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-500)).WillReturnRows(rsWithdraw).Times(1)
+			mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(500)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+			rsLimit := pgxmock.NewRows(limitColumns).AddRow(item.Type, int32(3), pgtype.Int4{}, "admin", time.Now())
+			mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs(item.Type).WillReturnRows(rsLimit).Times(1)
+
+			rsBought := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(3))
+			mockPool.ExpectQuery("SELECT .+ FROM inventory p .+").WithArgs(item.Type, user.UserName, pgtype.Int4{}).WillReturnRows(rsBought).Times(1)
+
+			mockPool.ExpectRollback()
+
+			_, err = repo.BuyGift(ctx, bo, user, recipient, model.InventoryItem{Type: item.Type, Quantity: 1}, "")
+		})
+		AfterEach(func() {
+			err = mockPool.ExpectationsWereMet()
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("counts the gift against the limit of the buyer and returns limit reached error", func() {
+			Expect(err).Should(MatchError(repository.ErrLimitReached))
+		})
+	})
+
+	Context("Calling WalletBuyItem method for merch with a purchase limit", func() {
+		var walletID int32 = 2
+
+		JustBeforeEach(func() {
+			rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), item.Type, int64(500), "", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(item.Type).WillReturnRows(rsGet).Times(1)
+
+			rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+			mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs(item.Type).WillReturnRows(rsVariants).Times(1)
+
+			mockPool.ExpectBegin()
+
+			rsWallet := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1000))
+			mockPool.ExpectQuery("SELECT coins FROM wallets .+").WithArgs(walletID).WillReturnRows(rsWallet).Times(1)
+
+			rsRole := pgxmock.NewRows([]string{"role"}).AddRow(model.WalletRoleSpend)
+			mockPool.ExpectQuery("SELECT role FROM wallet_members .+").WithArgs(walletID, user.UserName).WillReturnRows(rsRole).Times(1)
+
+			rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(500))
+			mockPool.ExpectQuery("UPDATE wallets SET .+").WithArgs(walletID, int64(-500)).WillReturnRows(rsUpdate).Times(1)
+
+			rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(0), int64(0))
+			mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsLock).Times(1)
+
+			rsLimit := pgxmock.NewRows(limitColumns).AddRow(item.Type, int32(3), pgtype.Int4{}, "admin", time.Now())
+			mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs(item.Type).WillReturnRows(rsLimit).Times(1)
+
+			rsBought := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(3))
+			mockPool.ExpectQuery("SELECT .+ FROM inventory p .+").WithArgs(item.Type, user.UserName, pgtype.Int4{}).WillReturnRows(rsBought).Times(1)
+
+			mockPool.ExpectRollback()
+
+			err = repo.WalletBuyItem(ctx, bo, int(walletID), user, model.InventoryItem{Type: item.Type, Quantity: 1})
+		})
+		AfterEach(func() {
+			err = mockPool.ExpectationsWereMet()
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns limit reached error", func() {
+			Expect(err).Should(MatchError(repository.ErrLimitReached))
+		})
+	})
+
+	Context("Calling SetPurchaseLimit method", func() {
+		var limit model.PurchaseLimit
+
+		When("the merch exists", func() {
+			BeforeEach(func() {
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), item.Type, int64(500), "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(item.Type).WillReturnRows(rsGet).Times(1)
+
+				rsLimit := pgxmock.NewRows(limitColumns).AddRow(item.Type, int32(1), pgtype.Int4{}, "admin", time.Now())
+				mockPool.ExpectQuery("INSERT INTO purchase_limits .+").WithArgs(item.Type, int32(1), pgtype.Int4{}, "admin").WillReturnRows(rsLimit).Times(1)
+
+				limit, err = repo.SetPurchaseLimit(ctx, bo, model.PurchaseLimit{Type: item.Type, MaxQuantity: 1}, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the limit and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(limit.MaxQuantity).To(Equal(1))
+				Expect(limit.PeriodDays).To(BeNil())
+			})
+		})
+
+		When("the merch doesn't exist", func() {
+			BeforeEach(func() {
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("unknown").WillReturnError(sql.ErrNoRows)
+
+				limit, err = repo.SetPurchaseLimit(ctx, bo, model.PurchaseLimit{Type: "unknown", MaxQuantity: 1}, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+			})
+		})
+	})
+
+	Context("Calling DeletePurchaseLimit method", func() {
+		When("there is no limit", func() {
+			BeforeEach(func() {
+				mockPool.ExpectExec("DELETE FROM purchase_limits .+").WithArgs(item.Type).WillReturnResult(pgxmock.NewResult("DELETE", 0)).Times(1)
+
+				err = repo.DeletePurchaseLimit(ctx, bo, item.Type)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoData))
+			})
+		})
+	})
+})
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// DefaultCoinTTL is the default time after which received coins expire.
+const DefaultCoinTTL = 365 * 24 * time.Hour
+
+// Sources of coin lots, that are not kinds of history records.
+const (
+	sourceWelcome = "welcome"
+	sourceMint    = "mint"
+)
+
+// Option is the repository option.
+type Option func(r *Repository)
+
+// WithCoinTTL sets the time after which received coins expire.
+func WithCoinTTL(ttl time.Duration) Option {
+	return func(r *Repository) {
+		r.coinTTL = ttl
+	}
+}
+
+// expiresAt returns expiry time of coins received now.
+func (r *Repository) expiresAt() time.Time {
+	return time.Now().Add(r.coinTTL)
+}
+
+// credit adds coins to the balance of a user and opens a new lot of them.
+func (r *Repository) credit(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, username string, amount int64, source string) error {
+	// Update the balance of the user
+	_, err := backoff.RetryWithData(func() (int64, error) {
+		return noRetryOnNoRows(noRetryOnViolation(qtx.UpdateBalance(ctx, queries.UpdateBalanceParams{
+			Username: username,
+			Coins:    amount,
+		})))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// There is nothing to expire
+	if amount == 0 {
+		return nil
+	}
+
+	// Open new lot of the coins
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateCoinLot(ctx, queries.CreateCoinLotParams{
+			Username:  username,
+			Source:    source,
+			Amount:    amount,
+			ExpiresAt: r.expiresAt(),
+		})
+	}, bo)
+
+	return err
+}
+
+// debit withdraws coins from the balance of a user, consuming the oldest lots first.
+// DB returns the negative balance error if the balance is not enough.
+func (r *Repository) debit(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, username string, amount int64) error {
+	// Update the balance of the user, it also locks the balance until the end of transaction
+	_, err := backoff.RetryWithData(func() (int64, error) {
+		return noRetryOnNoRows(noRetryOnViolation(qtx.UpdateBalance(ctx, queries.UpdateBalanceParams{
+			Username: username,
+			Coins:    -amount,
+		})))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Consume the lots
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, qtx.ConsumeCoinLots(ctx, queries.ConsumeCoinLotsParams{
+			Username: username,
+			Amount:   amount,
+		})
+	}, bo)
+
+	return err
+}
+
+// ExpireCoins expires coin lots past their time to live and withdraws the rest of their coins from users balances.
+// Expired coins, that are held, are withdrawn after the hold is released. Users are processed in batches of a given size,
+// every user in its own transaction. Returns total amount of expired coins.
+func (r *Repository) ExpireCoins(ctx context.Context, bo *backoff.ExponentialBackOff, batchSize int) (int64, error) {
+	var total int64
+	for {
+		// Get users, that have expired coins
+		users, err := backoff.RetryWithData(func() ([]string, error) {
+			return r.q.GetUsersWithExpiredCoinLots(ctx, int32(batchSize))
+		}, bo)
+		if err != nil {
+			return total, err
+		}
+		if len(users) == 0 {
+			return total, nil
+		}
+
+		for _, username := range users {
+			expired, err := r.expireUserCoins(ctx, bo, username)
+			if err != nil {
+				return total, err
+			}
+			total += expired
+		}
+	}
+}
+
+// expireUserCoins expires coin lots of a given user.
+func (r *Repository) expireUserCoins(ctx context.Context, bo *backoff.ExponentialBackOff, username string) (int64, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Lock the balance before the lots, as every balance change does
+	balance, err := backoff.RetryWithData(func() (queries.GetBalanceForUpdateRow, error) {
+		return noRetryOnNoRows(qtx.GetBalanceForUpdate(ctx, username))
+	}, bo)
+	if err != nil {
+		return 0, noDataOnNoRows(err)
+	}
+
+	// Held coins don't expire until the hold is closed, the lots covering them stay expired but not withdrawn
+	available := balance.Coins - balance.Held
+	if available <= 0 {
+		return 0, tx.Commit(ctx)
+	}
+
+	// Expire the lots
+	expired, err := backoff.RetryWithData(func() (int64, error) {
+		return qtx.ExpireCoinLots(ctx, queries.ExpireCoinLotsParams{
+			Username:  username,
+			Available: available,
+		})
+	}, bo)
+	if err != nil {
+		return 0, err
+	}
+
+	// The lots have been consumed concurrently
+	if expired == 0 {
+		return 0, tx.Commit(ctx)
+	}
+
+	// Withdraw expired coins from the balance
+	_, err = backoff.RetryWithData(func() (int64, error) {
+		return noRetryOnViolation(qtx.UpdateBalance(ctx, queries.UpdateBalanceParams{
+			Username: username,
+			Coins:    -expired,
+		}))
+	}, bo)
+	if err != nil {
+		return 0, err
+	}
+
+	// Create history record of the expiry
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateHistoryEntry(ctx, queries.CreateHistoryEntryParams{
+			Username: username,
+			Amount:   expired,
+			Kind:     kindExpiry,
+		})
+	}, bo)
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, tx.Commit(ctx)
+}
+
+// GetExpiringCoins returns users coins, that expire before a given time.
+func (r *Repository) GetExpiringCoins(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, before time.Time) ([]model.ExpiringCoins, error) {
+	// Get expiring lots from DB
+	lotsQuery, err := backoff.RetryWithData(func() ([]queries.GetExpiringCoinLotsRow, error) {
+		return r.q.GetExpiringCoinLots(ctx, queries.GetExpiringCoinLotsParams{
+			Username:  user.UserName,
+			ExpiresAt: before,
+		})
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	expiring := make([]model.ExpiringCoins, 0, len(lotsQuery))
+	for _, lot := range lotsQuery {
+		expiring = append(expiring, model.ExpiringCoins{
+			Amount:    lot.Remaining,
+			ExpiresAt: lot.ExpiresAt,
+		})
+	}
+
+	return expiring, nil
+}
@@ -0,0 +1,150 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository coin lots", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool, repository.WithCoinTTL(24*time.Hour))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling ExpireCoins method", func() {
+		var expired int64
+
+		When("there are users with expired coins", func() {
+			BeforeEach(func() {
+				var batchSize int32 = 2
+
+				rsUsers := pgxmock.NewRows([]string{"username"}).AddRow("user1").AddRow("user2")
+				mockPool.ExpectQuery("SELECT DISTINCT l.username FROM coin_lots .+").WithArgs(batchSize).WillReturnRows(rsUsers).Times(1)
+
+				// The first user has expired coins
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs("user1").WillReturnRows(pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(300), int64(0))).Times(1)
+				mockPool.ExpectQuery("WITH locked AS .+").WithArgs("user1", int64(300)).WillReturnRows(pgxmock.NewRows([]string{"expired"}).AddRow(int64(200))).Times(1)
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs("user1", int64(-200)).WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(int64(100))).Times(1)
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs("user1", "", "", int64(200), "expiry", pgtype.Int4{}).WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int32(1))).Times(1)
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				// The coins of the second user have been spent concurrently
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs("user2").WillReturnRows(pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(0), int64(0))).Times(1)
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				// Nobody left
+				mockPool.ExpectQuery("SELECT DISTINCT l.username FROM coin_lots .+").WithArgs(batchSize).WillReturnRows(pgxmock.NewRows([]string{"username"})).Times(1)
+
+				expired, err = repo.ExpireCoins(ctx, bo, int(batchSize))
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns total amount of expired coins and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(expired).To(Equal(int64(200)))
+			})
+		})
+
+		When("some coins of the user are held", func() {
+			BeforeEach(func() {
+				var batchSize int32 = 1
+
+				rsUsers := pgxmock.NewRows([]string{"username"}).AddRow("user")
+				mockPool.ExpectQuery("SELECT DISTINCT l.username FROM coin_lots .+").WithArgs(batchSize).WillReturnRows(rsUsers).Times(1)
+
+				// Only the available coins expire
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs("user").WillReturnRows(pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(300), int64(250))).Times(1)
+				mockPool.ExpectQuery("WITH locked AS .+").WithArgs("user", int64(50)).WillReturnRows(pgxmock.NewRows([]string{"expired"}).AddRow(int64(50))).Times(1)
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs("user", int64(-50)).WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(int64(250))).Times(1)
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs("user", "", "", int64(50), "expiry", pgtype.Int4{}).WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int32(1))).Times(1)
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				// The rest of the expired coins is held
+				mockPool.ExpectQuery("SELECT DISTINCT l.username FROM coin_lots .+").WithArgs(batchSize).WillReturnRows(pgxmock.NewRows([]string{"username"})).Times(1)
+
+				expired, err = repo.ExpireCoins(ctx, bo, int(batchSize))
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns amount of expired available coins and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(expired).To(Equal(int64(50)))
+			})
+		})
+	})
+
+	Context("Calling GetExpiringCoins method", func() {
+		When("the user has expiring coins", func() {
+			var (
+				user     = model.User{UserName: "user"}
+				before   = time.Now().Add(30 * 24 * time.Hour)
+				expires  = time.Now().Add(24 * time.Hour)
+				expiring []model.ExpiringCoins
+			)
+
+			BeforeEach(func() {
+				rs := pgxmock.NewRows([]string{"remaining", "expires_at"}).AddRow(int64(150), expires)
+				mockPool.ExpectQuery("SELECT remaining, expires_at FROM coin_lots .+").WithArgs(user.UserName, before).WillReturnRows(rs).Times(1)
+
+				expiring, err = repo.GetExpiringCoins(ctx, bo, user, before)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns expiring coins and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(expiring).To(Equal([]model.ExpiringCoins{{Amount: 150, ExpiresAt: expires}}))
+			})
+		})
+	})
+})
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// Kinds of inventory records, that move items through the marketplace.
+const (
+	kindListing   = "listing"
+	kindUnlisting = "unlisting"
+	kindMarket    = "market"
+)
+
+// CreateListing puts an owned item of a seller up for sale at a given price.
+// The item is taken out of the seller inventory until the listing is closed,
+// so it can't be transferred or listed twice.
+func (r *Repository) CreateListing(ctx context.Context, bo *backoff.ExponentialBackOff, seller model.User, item model.InventoryItem, price int64) (model.Listing, error) {
+	// Get the variant of the item, items of merch without a variant are given without size and color
+	variantID, err := r.findVariant(ctx, bo, item)
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Listing{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Check if the seller owns the item of the variant
+	if err = r.lockItems(ctx, bo, qtx, seller.UserName, item.Type, variantID, 1); err != nil {
+		return model.Listing{}, err
+	}
+
+	// Reserve the item by taking it out of the seller inventory
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:  seller.UserName,
+			Type:      item.Type,
+			Quantity:  -1,
+			Kind:      kindListing,
+			VariantID: variantID,
+		})
+	}, bo)
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	// Create the listing
+	created, err := backoff.RetryWithData(func() (queries.CreateListingRow, error) {
+		return noRetryOnViolation(qtx.CreateListing(ctx, queries.CreateListingParams{
+			Seller:    seller.UserName,
+			Type:      item.Type,
+			Price:     price,
+			VariantID: variantID,
+		}))
+	}, bo)
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Listing{}, err
+	}
+
+	return model.Listing{
+		ID:        int(created.ID),
+		Seller:    seller.UserName,
+		Item:      item.Type,
+		Price:     price,
+		Status:    model.ListingStatusActive,
+		CreatedAt: created.CreatedAt,
+	}, nil
+}
+
+// CancelListing cancels an active listing of a seller and returns the item to the seller inventory.
+func (r *Repository) CancelListing(ctx context.Context, bo *backoff.ExponentialBackOff, id int, seller model.User) (model.Listing, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Listing{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the listing
+	listing, err := r.lockListing(ctx, bo, qtx, id)
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	// Only the seller can cancel the listing
+	if listing.Seller != seller.UserName {
+		return model.Listing{}, ErrNoData
+	}
+
+	// Return the item to the seller inventory
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:  listing.Seller,
+			Type:      listing.Type,
+			Quantity:  1,
+			Kind:      kindUnlisting,
+			VariantID: listing.VariantID,
+		})
+	}, bo)
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	// Close the listing
+	listing, err = r.closeListing(ctx, bo, qtx, listing, model.ListingStatusCancelled, "")
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Listing{}, err
+	}
+
+	return toListing(listing), nil
+}
+
+// BuyListing buys an active listing - the price is moved from the buyer to the seller,
+// and the item is added to the buyer inventory.
+func (r *Repository) BuyListing(ctx context.Context, bo *backoff.ExponentialBackOff, id int, buyer model.User) (model.Listing, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Listing{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the listing, so it can't be bought twice
+	listing, err := r.lockListing(ctx, bo, qtx, id)
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	// The seller can't buy own listing
+	if listing.Seller == buyer.UserName {
+		return model.Listing{}, ErrPermissionDenied
+	}
+
+	// Withdraw the price from the balance of the buyer,
+	// DB returns the negative balance error if the balance is not enough
+	if err = r.debit(ctx, bo, qtx, buyer.UserName, listing.Price); err != nil {
+		return model.Listing{}, err
+	}
+
+	// Create history record for the buyer
+	err = r.createHistoryEntry(ctx, bo, qtx, queries.CreateHistoryEntryParams{
+		Username: buyer.UserName,
+		ToUser:   listing.Seller,
+		Amount:   listing.Price,
+		Kind:     kindMarket,
+	})
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	// Pay the price to the seller
+	if err = r.credit(ctx, bo, qtx, listing.Seller, listing.Price, kindMarket); err != nil {
+		return model.Listing{}, err
+	}
+
+	// Create history record for the seller
+	err = r.createHistoryEntry(ctx, bo, qtx, queries.CreateHistoryEntryParams{
+		Username: listing.Seller,
+		FromUser: buyer.UserName,
+		Amount:   listing.Price,
+		Kind:     kindMarket,
+	})
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	// Add the item to the buyer inventory
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:  buyer.UserName,
+			Type:      listing.Type,
+			Quantity:  1,
+			Kind:      kindMarket,
+			PricePaid: listing.Price,
+			VariantID: listing.VariantID,
+		})
+	}, bo)
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	// Close the listing
+	listing, err = r.closeListing(ctx, bo, qtx, listing, model.ListingStatusSold, buyer.UserName)
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Listing{}, err
+	}
+
+	return toListing(listing), nil
+}
+
+// GetListings returns active listings, optionally of a given item type only.
+func (r *Repository) GetListings(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) ([]model.Listing, error) {
+	// Get active listings from DB
+	listingsQuery, err := backoff.RetryWithData(func() ([]queries.Listing, error) {
+		return r.q.GetActiveListings(ctx, itemType)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	return toListings(listingsQuery), nil
+}
+
+// GetUserListings returns all listings of a seller.
+func (r *Repository) GetUserListings(ctx context.Context, bo *backoff.ExponentialBackOff, seller model.User) ([]model.Listing, error) {
+	// Get listings of the seller from DB
+	listingsQuery, err := backoff.RetryWithData(func() ([]queries.Listing, error) {
+		return r.q.GetUserListings(ctx, seller.UserName)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	return toListings(listingsQuery), nil
+}
+
+// lockListing locks an active listing until the end of transaction.
+func (r *Repository) lockListing(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, id int) (queries.Listing, error) {
+	listing, err := backoff.RetryWithData(func() (queries.Listing, error) {
+		return noRetryOnNoRows(qtx.GetListingForUpdate(ctx, int32(id)))
+	}, bo)
+	if err != nil {
+		return queries.Listing{}, noDataOnNoRows(err)
+	}
+
+	if listing.Status != model.ListingStatusActive {
+		return queries.Listing{}, ErrConflict
+	}
+
+	return listing, nil
+}
+
+// closeListing sets final status of a listing.
+func (r *Repository) closeListing(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, listing queries.Listing, status string, buyer string) (queries.Listing, error) {
+	closedAt, err := backoff.RetryWithData(func() (pgtype.Timestamp, error) {
+		return qtx.CloseListing(ctx, queries.CloseListingParams{
+			ID:     listing.ID,
+			Status: status,
+			Buyer:  buyer,
+		})
+	}, bo)
+	if err != nil {
+		return queries.Listing{}, err
+	}
+
+	listing.Status = status
+	listing.Buyer = buyer
+	listing.ClosedAt = closedAt
+
+	return listing, nil
+}
+
+// createHistoryEntry creates history record of coins movement.
+func (r *Repository) createHistoryEntry(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, params queries.CreateHistoryEntryParams) error {
+	_, err := backoff.RetryWithData(func() (int32, error) {
+		return noRetryOnViolation(qtx.CreateHistoryEntry(ctx, params))
+	}, bo)
+	return err
+}
+
+// toListings converts listing records of DB to the model.
+func toListings(listingsQuery []queries.Listing) []model.Listing {
+	listings := make([]model.Listing, 0, len(listingsQuery))
+	for _, rec := range listingsQuery {
+		listings = append(listings, toListing(rec))
+	}
+	return listings
+}
+
+// toListing converts listing record of DB to the model.
+func toListing(rec queries.Listing) model.Listing {
+	listing := model.Listing{
+		ID:        int(rec.ID),
+		Seller:    rec.Seller,
+		Item:      rec.Type,
+		Price:     rec.Price,
+		Status:    rec.Status,
+		Buyer:     rec.Buyer,
+		CreatedAt: rec.CreatedAt,
+	}
+	if rec.ClosedAt.Valid {
+		closedAt := rec.ClosedAt.Time
+		listing.ClosedAt = &closedAt
+	}
+	return listing
+}
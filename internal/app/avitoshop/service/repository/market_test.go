@@ -0,0 +1,220 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository market", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		listing model.Listing
+
+		listingID int32 = 4
+		price     int64 = 15
+
+		seller = model.User{UserName: "user"}
+		buyer  = model.User{UserName: "user1"}
+
+		listingColumns = []string{"id", "seller", "type", "price", "status", "buyer", "created_at", "closed_at", "variant_id"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling CreateListing method", func() {
+		When("the seller owns the item", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(seller.UserName).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(2))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(seller.UserName, "socks", pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(10))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(seller.UserName, "socks", int32(-1), "listing", int64(0), pgtype.Int4{}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				rsListing := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(listingID, time.Now())
+				mockPool.ExpectQuery("INSERT INTO listings .+ VALUES .+").WithArgs(seller.UserName, "socks", price, pgtype.Int4{}).WillReturnRows(rsListing).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				listing, err = repo.CreateListing(ctx, bo, seller, model.InventoryItem{Type: "socks", Quantity: 1}, price)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the active listing and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(listing.ID).To(Equal(int(listingID)))
+				Expect(listing.Status).To(Equal(model.ListingStatusActive))
+			})
+		})
+
+		When("the only item of the seller has already been listed", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(seller.UserName).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(0))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(seller.UserName, "socks", pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				mockPool.ExpectRollback()
+
+				listing, err = repo.CreateListing(ctx, bo, seller, model.InventoryItem{Type: "socks", Quantity: 1}, price)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns not enough items error", func() {
+				Expect(err).Should(MatchError(repository.ErrNotEnoughItems))
+			})
+		})
+	})
+
+	Context("Calling BuyListing method", func() {
+		When("the listing is active", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsListing := pgxmock.NewRows(listingColumns).AddRow(listingID, seller.UserName, "socks", price, "active", "", time.Now(), pgtype.Timestamp{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM listings .+").WithArgs(listingID).WillReturnRows(rsListing).Times(1)
+
+				rsWithdraw := pgxmock.NewRows([]string{"coins"}).AddRow(int64(985))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(buyer.UserName, -price).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(buyer.UserName, price).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsBuyerHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(buyer.UserName, "", seller.UserName, price, "market", pgtype.Int4{}).WillReturnRows(rsBuyerHistory).Times(1)
+
+				rsDeposit := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1015))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(seller.UserName, price).WillReturnRows(rsDeposit).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(seller.UserName, "market", price, pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				rsSellerHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(2))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(seller.UserName, buyer.UserName, "", price, "market", pgtype.Int4{}).WillReturnRows(rsSellerHistory).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(11))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(buyer.UserName, "socks", int32(1), "market", price, pgtype.Int4{}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				rsClose := pgxmock.NewRows([]string{"closed_at"}).AddRow(pgtype.Timestamp{Time: time.Now(), Valid: true})
+				mockPool.ExpectQuery("UPDATE listings SET .+").WithArgs(listingID, "sold", buyer.UserName).WillReturnRows(rsClose).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				listing, err = repo.BuyListing(ctx, bo, int(listingID), buyer)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the sold listing and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(listing.Status).To(Equal(model.ListingStatusSold))
+				Expect(listing.Buyer).To(Equal(buyer.UserName))
+				Expect(listing.ClosedAt).ShouldNot(BeNil())
+			})
+		})
+
+		When("the listing has already been cancelled", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				closedAt := pgtype.Timestamp{Time: time.Now(), Valid: true}
+				rsListing := pgxmock.NewRows(listingColumns).AddRow(listingID, seller.UserName, "socks", price, "cancelled", "", time.Now(), closedAt, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM listings .+").WithArgs(listingID).WillReturnRows(rsListing).Times(1)
+
+				mockPool.ExpectRollback()
+
+				listing, err = repo.BuyListing(ctx, bo, int(listingID), buyer)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns conflict error", func() {
+				Expect(err).Should(MatchError(repository.ErrConflict))
+			})
+		})
+	})
+
+	Context("Calling CancelListing method", func() {
+		When("the listing is active", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsListing := pgxmock.NewRows(listingColumns).AddRow(listingID, seller.UserName, "socks", price, "active", "", time.Now(), pgtype.Timestamp{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM listings .+").WithArgs(listingID).WillReturnRows(rsListing).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(12))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(seller.UserName, "socks", int32(1), "unlisting", int64(0), pgtype.Int4{}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				rsClose := pgxmock.NewRows([]string{"closed_at"}).AddRow(pgtype.Timestamp{Time: time.Now(), Valid: true})
+				mockPool.ExpectQuery("UPDATE listings SET .+").WithArgs(listingID, "cancelled", "").WillReturnRows(rsClose).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				listing, err = repo.CancelListing(ctx, bo, int(listingID), seller)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the cancelled listing and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(listing.Status).To(Equal(model.ListingStatusCancelled))
+			})
+		})
+	})
+})
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// MintCoins mints coins to the balance of a given user.
+func (r *Repository) MintCoins(ctx context.Context, bo *backoff.ExponentialBackOff, op model.CoinOperation) (model.CoinOperation, error) {
+	return r.changeSupply(ctx, bo, op, func(qtx *queries.Queries) error {
+		return r.credit(ctx, bo, qtx, op.UserName, op.Amount, sourceMint)
+	})
+}
+
+// BurnCoins burns coins from the balance of a given user.
+func (r *Repository) BurnCoins(ctx context.Context, bo *backoff.ExponentialBackOff, op model.CoinOperation) (model.CoinOperation, error) {
+	return r.changeSupply(ctx, bo, op, func(qtx *queries.Queries) error {
+		return r.debit(ctx, bo, qtx, op.UserName, op.Amount)
+	})
+}
+
+// changeSupply changes the balance of a given user with a given function and records the coin operation.
+func (r *Repository) changeSupply(ctx context.Context, bo *backoff.ExponentialBackOff, op model.CoinOperation, change func(qtx *queries.Queries) error) (model.CoinOperation, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.CoinOperation{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Update the balance of the user,
+	// DB returns the negative balance error if there is not enough coins to burn
+	if err = change(qtx); err != nil {
+		return model.CoinOperation{}, err
+	}
+
+	// Record the coin operation
+	created, err := backoff.RetryWithData(func() (queries.CreateCoinOperationRow, error) {
+		return qtx.CreateCoinOperation(ctx, queries.CreateCoinOperationParams{
+			Kind:       op.Kind,
+			Username:   op.UserName,
+			Amount:     op.Amount,
+			Recipients: 1,
+			Actor:      op.Actor,
+			Reason:     op.Reason,
+		})
+	}, bo)
+	if err != nil {
+		return model.CoinOperation{}, err
+	}
+
+	// Create history record of the operation for the user
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateCoinOperationHistoryEntry(ctx, queries.CreateCoinOperationHistoryEntryParams{
+			Username:        op.UserName,
+			Amount:          op.Amount,
+			Kind:            op.Kind,
+			CoinOperationID: pgtype.Int4{Int32: created.ID, Valid: true},
+		})
+	}, bo)
+	if err != nil {
+		return model.CoinOperation{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.CoinOperation{}, err
+	}
+
+	op.ID = int(created.ID)
+	op.Recipients = 1
+	op.CreatedAt = created.CreatedAt
+
+	return op, nil
+}
+
+// GrantCoins mints coins to the balances of all users.
+// Users are granted in batches of a given size, every batch in its own transaction,
+// so the grant doesn't lock all balances at once. The coin operation keeps the progress of the grant.
+func (r *Repository) GrantCoins(ctx context.Context, bo *backoff.ExponentialBackOff, op model.CoinOperation, batchSize int) (model.CoinOperation, error) {
+	// Record the coin operation before granting, so every batch refers to it
+	created, err := backoff.RetryWithData(func() (queries.CreateCoinOperationRow, error) {
+		return r.q.CreateCoinOperation(ctx, queries.CreateCoinOperationParams{
+			Kind:   op.Kind,
+			Amount: op.Amount,
+			Actor:  op.Actor,
+			Reason: op.Reason,
+		})
+	}, bo)
+	if err != nil {
+		return model.CoinOperation{}, err
+	}
+
+	op.ID = int(created.ID)
+	op.CreatedAt = created.CreatedAt
+
+	// Grant batches of users in order of their balances until there is no one left
+	var afterID int32
+	for {
+		batch, err := r.grantBatch(ctx, bo, created.ID, op.Amount, afterID, int32(batchSize))
+		if err != nil {
+			return model.CoinOperation{}, err
+		}
+		if batch.Recipients == 0 {
+			break
+		}
+
+		op.Recipients += int(batch.Recipients)
+		afterID = batch.LastBalanceID
+	}
+
+	return op, nil
+}
+
+// grantBatch grants coins to a batch of users, whose balances follow the given one.
+func (r *Repository) grantBatch(ctx context.Context, bo *backoff.ExponentialBackOff, opID int32, amount int64, afterID int32, batchSize int32) (queries.GrantCoinsBatchRow, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return queries.GrantCoinsBatchRow{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Update balances of the batch and create history records
+	batch, err := backoff.RetryWithData(func() (queries.GrantCoinsBatchRow, error) {
+		return noRetryOnViolation(qtx.GrantCoinsBatch(ctx, queries.GrantCoinsBatchParams{
+			Amount:          amount,
+			AfterID:         afterID,
+			BatchSize:       batchSize,
+			CoinOperationID: opID,
+			ExpiresAt:       r.expiresAt(),
+		}))
+	}, bo)
+	if err != nil {
+		return queries.GrantCoinsBatchRow{}, err
+	}
+
+	// Nothing left to grant
+	if batch.Recipients == 0 {
+		return batch, nil
+	}
+
+	// Save the progress of the grant
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, qtx.UpdateCoinOperationProgress(ctx, queries.UpdateCoinOperationProgressParams{
+			Recipients:    batch.Recipients,
+			LastBalanceID: batch.LastBalanceID,
+			ID:            opID,
+		})
+	}, bo)
+	if err != nil {
+		return queries.GrantCoinsBatchRow{}, err
+	}
+
+	return batch, tx.Commit(ctx)
+}
+
+// GetMoneySupply returns total amount of coins and amounts of coins minted and burned.
+func (r *Repository) GetMoneySupply(ctx context.Context, bo *backoff.ExponentialBackOff) (model.MoneySupply, error) {
+	// Get total amount of coins on users balances from DB
+	supply, err := backoff.RetryWithData(func() (queries.GetMoneySupplyRow, error) {
+		return r.q.GetMoneySupply(ctx)
+	}, bo)
+	if err != nil {
+		return model.MoneySupply{}, err
+	}
+
+	// Get totals of coin operations from DB
+	totals, err := backoff.RetryWithData(func() (queries.GetCoinOperationsTotalsRow, error) {
+		return r.q.GetCoinOperationsTotals(ctx)
+	}, bo)
+	if err != nil {
+		return model.MoneySupply{}, err
+	}
+
+	return model.MoneySupply{
+		Coins:  supply.Coins,
+		Users:  int(supply.Users),
+		Minted: totals.Minted,
+		Burned: totals.Burned,
+	}, nil
+}
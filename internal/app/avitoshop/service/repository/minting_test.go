@@ -0,0 +1,231 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository minting", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		op     model.CoinOperation
+		result model.CoinOperation
+
+		opID int32 = 1
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling MintCoins method", func() {
+		BeforeEach(func() {
+			op = model.CoinOperation{
+				Kind:     model.CoinOperationMint,
+				UserName: "user",
+				Amount:   100,
+				Actor:    "admin",
+				Reason:   "hackathon winner",
+			}
+		})
+
+		When("the user exists", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1100))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(op.UserName, op.Amount).WillReturnRows(rsUpdate).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(op.UserName, "mint", op.Amount, pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				rsCreateOp := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(opID, time.Now())
+				mockPool.ExpectQuery("INSERT INTO coin_operations .+ VALUES .+").WithArgs(op.Kind, op.UserName, op.Amount, int32(1), op.Actor, op.Reason).WillReturnRows(rsCreateOp).Times(1)
+
+				rsCreateHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(10))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(op.UserName, op.Amount, op.Kind, pgtype.Int4{Int32: opID, Valid: true}).WillReturnRows(rsCreateHistory).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				result, err = repo.MintCoins(ctx, bo, op)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the coin operation and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(result.ID).To(Equal(int(opID)))
+				Expect(result.Recipients).To(Equal(1))
+			})
+		})
+
+		When("the user doesn't exist", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"})
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(op.UserName, op.Amount).WillReturnRows(rsUpdate).Times(1)
+
+				mockPool.ExpectRollback()
+
+				result, err = repo.MintCoins(ctx, bo, op)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).To(Equal(repository.ErrNoData))
+			})
+		})
+	})
+
+	Context("Calling BurnCoins method", func() {
+		BeforeEach(func() {
+			op = model.CoinOperation{
+				Kind:     model.CoinOperationBurn,
+				UserName: "user",
+				Amount:   2000,
+				Actor:    "admin",
+				Reason:   "duplicate account",
+			}
+		})
+
+		When("the balance is not enough", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(0)).RowError(0, &pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "balance_coins_check"})
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(op.UserName, -op.Amount).WillReturnRows(rsUpdate).Times(1)
+
+				mockPool.ExpectRollback()
+
+				result, err = repo.BurnCoins(ctx, bo, op)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns negative balance error", func() {
+				Expect(err).To(Equal(repository.ErrNegativeBalance))
+			})
+		})
+	})
+
+	Context("Calling GrantCoins method", func() {
+		BeforeEach(func() {
+			op = model.CoinOperation{
+				Kind:   model.CoinOperationMint,
+				Amount: 50,
+				Actor:  "admin",
+				Reason: "holiday bonus",
+			}
+		})
+
+		When("there are users to grant in two batches", func() {
+			BeforeEach(func() {
+				var batchSize int32 = 2
+
+				rsCreateOp := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(opID, time.Now())
+				mockPool.ExpectQuery("INSERT INTO coin_operations .+ VALUES .+").WithArgs(op.Kind, "", op.Amount, int32(0), op.Actor, op.Reason).WillReturnRows(rsCreateOp).Times(1)
+
+				// The first batch
+				mockPool.ExpectBegin()
+				rsFirst := pgxmock.NewRows([]string{"recipients", "last_balance_id"}).AddRow(int32(2), int32(5))
+				mockPool.ExpectQuery("WITH granted AS .+").WithArgs(op.Amount, int32(0), batchSize, opID, pgxmock.AnyArg()).WillReturnRows(rsFirst).Times(1)
+				mockPool.ExpectExec("UPDATE coin_operations SET .+").WithArgs(int32(2), int32(5), opID).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				// The second batch
+				mockPool.ExpectBegin()
+				rsSecond := pgxmock.NewRows([]string{"recipients", "last_balance_id"}).AddRow(int32(1), int32(7))
+				mockPool.ExpectQuery("WITH granted AS .+").WithArgs(op.Amount, int32(5), batchSize, opID, pgxmock.AnyArg()).WillReturnRows(rsSecond).Times(1)
+				mockPool.ExpectExec("UPDATE coin_operations SET .+").WithArgs(int32(1), int32(7), opID).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				// Nobody left
+				mockPool.ExpectBegin()
+				rsEmpty := pgxmock.NewRows([]string{"recipients", "last_balance_id"}).AddRow(int32(0), int32(0))
+				mockPool.ExpectQuery("WITH granted AS .+").WithArgs(op.Amount, int32(7), batchSize, opID, pgxmock.AnyArg()).WillReturnRows(rsEmpty).Times(1)
+				mockPool.ExpectRollback()
+
+				result, err = repo.GrantCoins(ctx, bo, op, int(batchSize))
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the grant with all recipients and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(result.ID).To(Equal(int(opID)))
+				Expect(result.Recipients).To(Equal(3))
+			})
+		})
+	})
+
+	Context("Calling GetMoneySupply method", func() {
+		When("there is no error", func() {
+			BeforeEach(func() {
+				rsSupply := pgxmock.NewRows([]string{"coins", "users"}).AddRow(int64(3000), int32(3))
+				mockPool.ExpectQuery("SELECT .+ FROM balance").WillReturnRows(rsSupply).Times(1)
+
+				rsTotals := pgxmock.NewRows([]string{"minted", "burned"}).AddRow(int64(150), int64(50))
+				mockPool.ExpectQuery("SELECT .+ FROM coin_operations").WillReturnRows(rsTotals).Times(1)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the money supply and nil error", func() {
+				supply, err := repo.GetMoneySupply(ctx, bo)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(supply).To(Equal(model.MoneySupply{Coins: 3000, Users: 3, Minted: 150, Burned: 50}))
+			})
+		})
+	})
+})
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// orderTransitions are the statuses, that an order can be advanced to from its current status.
+// Cancellation is not a plain transition, as it reverses the purchase of the order.
+var orderTransitions = map[string]string{
+	model.OrderStatusPlaced: model.OrderStatusReady,
+	model.OrderStatusReady:  model.OrderStatusDelivered,
+}
+
+// GetUserOrders returns orders of a user, the latest first.
+func (r *Repository) GetUserOrders(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.Order, error) {
+	// Get orders from DB
+	ordersQuery, err := backoff.RetryWithData(func() ([]queries.Order, error) {
+		return r.q.GetUserOrders(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	return toOrders(ordersQuery), nil
+}
+
+// GetOrders returns orders of all users, optionally in a given status only.
+func (r *Repository) GetOrders(ctx context.Context, bo *backoff.ExponentialBackOff, status string) ([]model.Order, error) {
+	// Get orders from DB
+	ordersQuery, err := backoff.RetryWithData(func() ([]queries.Order, error) {
+		return r.q.GetOrders(ctx, pgtype.Text{String: status, Valid: status != ""})
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	return toOrders(ordersQuery), nil
+}
+
+// AdvanceOrder moves an order with a given ID to a given status on behalf of a given user.
+func (r *Repository) AdvanceOrder(ctx context.Context, bo *backoff.ExponentialBackOff, id int, status string, actor string) (model.Order, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Order{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the order
+	order, err := backoff.RetryWithData(func() (queries.Order, error) {
+		return noRetryOnNoRows(qtx.GetOrderForUpdate(ctx, int32(id)))
+	}, bo)
+	if err != nil {
+		return model.Order{}, noDataOnNoRows(err)
+	}
+
+	if orderTransitions[order.Status] != status {
+		return model.Order{}, ErrInvalidTransition
+	}
+
+	// Update the status of the order
+	order, err = backoff.RetryWithData(func() (queries.Order, error) {
+		return qtx.UpdateOrderStatus(ctx, queries.UpdateOrderStatusParams{
+			Status:    status,
+			UpdatedBy: actor,
+			ID:        order.ID,
+		})
+	}, bo)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Order{}, err
+	}
+
+	return toOrder(order), nil
+}
+
+// CancelOrder cancels an order with a given ID on behalf of a given user,
+// reversing the purchase of the order with refund. Delivered orders can't be cancelled.
+func (r *Repository) CancelOrder(ctx context.Context, bo *backoff.ExponentialBackOff, id int, actor string) (model.Order, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Order{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the order
+	order, err := backoff.RetryWithData(func() (queries.Order, error) {
+		return noRetryOnNoRows(qtx.GetOrderForUpdate(ctx, int32(id)))
+	}, bo)
+	if err != nil {
+		return model.Order{}, noDataOnNoRows(err)
+	}
+
+	if order.Status != model.OrderStatusPlaced && order.Status != model.OrderStatusReady {
+		return model.Order{}, ErrInvalidTransition
+	}
+
+	// Get and lock the inventory record the order hands over
+	purchase, err := backoff.RetryWithData(func() (queries.Inventory, error) {
+		return noRetryOnNoRows(qtx.GetInventoryRecord(ctx, order.InventoryID))
+	}, bo)
+	if err != nil {
+		return model.Order{}, noDataOnNoRows(err)
+	}
+
+	// Reverse the purchase with refund to whoever paid for it, that cancels the order as well
+	switch purchase.Kind {
+	case kindPurchase:
+		_, err = r.reversePurchase(ctx, bo, qtx, purchase, actor)
+	case kindGift:
+		err = r.cancelGiftOrder(ctx, bo, qtx, purchase.ID, actor)
+	case kindWallet:
+		err = r.reverseWalletPurchase(ctx, bo, qtx, purchase, actor)
+	default:
+		err = ErrNoData
+	}
+	if errors.Is(err, ErrNoData) {
+		return model.Order{}, ErrInvalidTransition
+	}
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	// Get the cancelled order
+	order, err = backoff.RetryWithData(func() (queries.Order, error) {
+		return qtx.GetOrderForUpdate(ctx, order.ID)
+	}, bo)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Order{}, err
+	}
+
+	return toOrder(order), nil
+}
+
+// cancelGiftOrder declines the gift with a given inventory record within a transaction on behalf of a given user.
+func (r *Repository) cancelGiftOrder(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, inventoryID int32, actor string) error {
+	// Get and lock the gift
+	gift, err := backoff.RetryWithData(func() (queries.Gift, error) {
+		return noRetryOnNoRows(qtx.GetInventoryGiftForUpdate(ctx, inventoryID))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	if gift.Status != model.GiftStatusSent {
+		return ErrAlreadyReversed
+	}
+
+	_, err = r.declineGift(ctx, bo, qtx, gift, actor)
+
+	return err
+}
+
+// createOrder places an order to hand over the items of a purchase to a user.
+func (r *Repository) createOrder(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, inventoryID int32, username string, itemType string, quantity int32) error {
+	_, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateOrder(ctx, queries.CreateOrderParams{
+			InventoryID: inventoryID,
+			Username:    username,
+			Type:        itemType,
+			Quantity:    quantity,
+		})
+	}, bo)
+	return err
+}
+
+// cancelPurchaseOrder cancels the order of a purchase on behalf of a given user, if the order is still open.
+func (r *Repository) cancelPurchaseOrder(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, inventoryID int32, actor string) error {
+	_, err := backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, qtx.CancelPurchaseOrder(ctx, queries.CancelPurchaseOrderParams{
+			InventoryID: inventoryID,
+			UpdatedBy:   actor,
+		})
+	}, bo)
+	return err
+}
+
+// toOrders converts order records of DB to the model.
+func toOrders(ordersQuery []queries.Order) []model.Order {
+	orders := make([]model.Order, 0, len(ordersQuery))
+	for _, rec := range ordersQuery {
+		orders = append(orders, toOrder(rec))
+	}
+	return orders
+}
+
+// toOrder converts order record of DB to the model.
+func toOrder(rec queries.Order) model.Order {
+	order := model.Order{
+		ID:         int(rec.ID),
+		PurchaseID: int(rec.InventoryID),
+		User:       rec.Username,
+		Item:       rec.Type,
+		Quantity:   int(rec.Quantity),
+		Status:     rec.Status,
+		UpdatedBy:  rec.UpdatedBy,
+		PlacedAt:   rec.PlacedAt,
+	}
+	if rec.ReadyAt.Valid {
+		readyAt := rec.ReadyAt.Time
+		order.ReadyAt = &readyAt
+	}
+	if rec.DeliveredAt.Valid {
+		deliveredAt := rec.DeliveredAt.Time
+		order.DeliveredAt = &deliveredAt
+	}
+	if rec.CancelledAt.Valid {
+		cancelledAt := rec.CancelledAt.Time
+		order.CancelledAt = &cancelledAt
+	}
+	return order
+}
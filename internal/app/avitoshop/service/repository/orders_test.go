@@ -0,0 +1,221 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository orders", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		keeper = "keeper"
+
+		inventoryColumns = []string{"id", "username", "type", "quantity", "bought_at", "kind", "price_paid", "reversal_of", "discount", "variant_id"}
+		giftColumns      = []string{"id", "from_user", "to_user", "type", "price_paid", "message", "status", "inventory_id", "created_at", "declined_at", "variant_id"}
+		orderColumns     = []string{"id", "inventory_id", "username", "type", "quantity", "status", "updated_by", "placed_at", "ready_at", "delivered_at", "cancelled_at"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling AdvanceOrder method", func() {
+		var status string
+
+		JustBeforeEach(func() {
+			mockPool.ExpectBegin()
+
+			rsOrder := pgxmock.NewRows(orderColumns).AddRow(int32(1), int32(5), "user", "pink-hoody", int32(1), status, "", time.Now(), pgtype.Timestamp{}, pgtype.Timestamp{}, pgtype.Timestamp{})
+			mockPool.ExpectQuery("SELECT .+ FROM orders .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsOrder).Times(1)
+		})
+
+		When("the order is placed", func() {
+			BeforeEach(func() {
+				status = model.OrderStatusPlaced
+			})
+
+			It("marks the order as ready for pickup", func() {
+				rsUpdate := pgxmock.NewRows(orderColumns).AddRow(int32(1), int32(5), "user", "pink-hoody", int32(1), model.OrderStatusReady, keeper, time.Now(), pgtype.Timestamp{Time: time.Now(), Valid: true}, pgtype.Timestamp{}, pgtype.Timestamp{})
+				mockPool.ExpectQuery("UPDATE orders SET .+").WithArgs(model.OrderStatusReady, keeper, int32(1)).WillReturnRows(rsUpdate).Times(1)
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				order, err := repo.AdvanceOrder(ctx, bo, 1, model.OrderStatusReady, keeper)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(order.Status).To(Equal(model.OrderStatusReady))
+				Expect(order.UpdatedBy).To(Equal(keeper))
+				Expect(order.ReadyAt).ShouldNot(BeNil())
+				Expect(order.DeliveredAt).Should(BeNil())
+				Expect(mockPool.ExpectationsWereMet()).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the order is placed but not ready yet", func() {
+			BeforeEach(func() {
+				status = model.OrderStatusPlaced
+			})
+
+			It("returns an invalid transition error on delivery", func() {
+				mockPool.ExpectRollback()
+
+				_, err := repo.AdvanceOrder(ctx, bo, 1, model.OrderStatusDelivered, keeper)
+				Expect(err).Should(MatchError(repository.ErrInvalidTransition))
+				Expect(mockPool.ExpectationsWereMet()).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Calling CancelOrder method", func() {
+		When("the order has been delivered", func() {
+			It("returns an invalid transition error", func() {
+				mockPool.ExpectBegin()
+
+				rsOrder := pgxmock.NewRows(orderColumns).AddRow(int32(1), int32(5), "user", "pink-hoody", int32(1), model.OrderStatusDelivered, keeper, time.Now(), pgtype.Timestamp{Time: time.Now(), Valid: true}, pgtype.Timestamp{Time: time.Now(), Valid: true}, pgtype.Timestamp{})
+				mockPool.ExpectQuery("SELECT .+ FROM orders .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsOrder).Times(1)
+				mockPool.ExpectRollback()
+
+				_, err := repo.CancelOrder(ctx, bo, 1, "admin")
+				Expect(err).Should(MatchError(repository.ErrInvalidTransition))
+				Expect(mockPool.ExpectationsWereMet()).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the order is of a gift", func() {
+			It("declines the gift with refund to the buyer", func() {
+				mockPool.ExpectBegin()
+
+				rsOrder := pgxmock.NewRows(orderColumns).AddRow(int32(1), int32(5), "user", "pink-hoody", int32(1), model.OrderStatusPlaced, "", time.Now(), pgtype.Timestamp{}, pgtype.Timestamp{}, pgtype.Timestamp{})
+				mockPool.ExpectQuery("SELECT .+ FROM orders .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsOrder).Times(1)
+
+				rsGet := pgxmock.NewRows(inventoryColumns).
+					AddRow(int32(5), "user", "pink-hoody", int32(1), time.Now(), "gift", int64(500), pgtype.Int4{}, int64(0), pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM inventory .+").WithArgs(int32(5)).WillReturnRows(rsGet).Times(1)
+
+				rsGift := pgxmock.NewRows(giftColumns).AddRow(int32(2), "buyer", "user", "pink-hoody", int64(500), "", model.GiftStatusSent, int32(5), time.Now(), pgtype.Timestamp{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM gifts .+").WithArgs(int32(5)).WillReturnRows(rsGift).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs("user").WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs("user", "pink-hoody", pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(6))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs("user", "pink-hoody", int32(-1), "decline", int64(500), pgtype.Int4{Int32: 5, Valid: true}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("pink-hoody", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectExec("UPDATE orders SET .+").WithArgs(int32(5), "admin").WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1000))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs("buyer", int64(500)).WillReturnRows(rsUpdate).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs("buyer", "refund", int64(500), pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs("buyer", "user", "", int64(500), "refund", pgtype.Int4{}).WillReturnRows(rsHistory).Times(1)
+
+				rsDecline := pgxmock.NewRows([]string{"declined_at"}).AddRow(pgtype.Timestamp{Time: time.Now(), Valid: true})
+				mockPool.ExpectQuery("UPDATE gifts SET .+").WithArgs(int32(2)).WillReturnRows(rsDecline).Times(1)
+
+				rsCancelled := pgxmock.NewRows(orderColumns).AddRow(int32(1), int32(5), "user", "pink-hoody", int32(1), model.OrderStatusCancelled, "admin", time.Now(), pgtype.Timestamp{}, pgtype.Timestamp{}, pgtype.Timestamp{Time: time.Now(), Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM orders .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsCancelled).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				order, err := repo.CancelOrder(ctx, bo, 1, "admin")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(order.Status).To(Equal(model.OrderStatusCancelled))
+				Expect(mockPool.ExpectationsWereMet()).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the order is of a wallet purchase", func() {
+			It("reverses the purchase with refund to the wallet", func() {
+				mockPool.ExpectBegin()
+
+				rsOrder := pgxmock.NewRows(orderColumns).AddRow(int32(1), int32(5), "user", "cup", int32(1), model.OrderStatusReady, keeper, time.Now(), pgtype.Timestamp{Time: time.Now(), Valid: true}, pgtype.Timestamp{}, pgtype.Timestamp{})
+				mockPool.ExpectQuery("SELECT .+ FROM orders .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsOrder).Times(1)
+
+				rsGet := pgxmock.NewRows(inventoryColumns).
+					AddRow(int32(5), "user", "cup", int32(1), time.Now(), "wallet", int64(20), pgtype.Int4{}, int64(0), pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM inventory .+").WithArgs(int32(5)).WillReturnRows(rsGet).Times(1)
+
+				rsReversed := pgxmock.NewRows([]string{"exists"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS.+ FROM inventory .+").WithArgs(pgtype.Int4{Int32: 5, Valid: true}).WillReturnRows(rsReversed).Times(1)
+
+				rsWalletID := pgxmock.NewRows([]string{"wallet_id"}).AddRow(int32(3))
+				mockPool.ExpectQuery("SELECT wallet_id FROM wallet_history .+").WithArgs(pgtype.Int4{Int32: 5, Valid: true}).WillReturnRows(rsWalletID).Times(1)
+
+				rsWallet := pgxmock.NewRows([]string{"coins"}).AddRow(int64(480))
+				mockPool.ExpectQuery("SELECT coins FROM wallets .+").WithArgs(int32(3)).WillReturnRows(rsWallet).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs("user").WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs("user", "cup", pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(6))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs("user", "cup", int32(-1), "reversal", int64(20), pgtype.Int4{Int32: 5, Valid: true}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("cup", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectExec("UPDATE orders SET .+").WithArgs(int32(5), "admin").WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsRefund := pgxmock.NewRows([]string{"coins"}).AddRow(int64(500))
+				mockPool.ExpectQuery("UPDATE wallets SET .+").WithArgs(int32(3), int64(20)).WillReturnRows(rsRefund).Times(1)
+
+				rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(4))
+				mockPool.ExpectQuery("INSERT INTO wallet_history .+ VALUES .+").WithArgs(int32(3), "user", "refund", int64(20), "", "cup", pgtype.Int4{Int32: 5, Valid: true}).WillReturnRows(rsHistory).Times(1)
+
+				rsCancelled := pgxmock.NewRows(orderColumns).AddRow(int32(1), int32(5), "user", "cup", int32(1), model.OrderStatusCancelled, "admin", time.Now(), pgtype.Timestamp{Time: time.Now(), Valid: true}, pgtype.Timestamp{}, pgtype.Timestamp{Time: time.Now(), Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM orders .+ FOR UPDATE").WithArgs(int32(1)).WillReturnRows(rsCancelled).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				order, err := repo.CancelOrder(ctx, bo, 1, "admin")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(order.Status).To(Equal(model.OrderStatusCancelled))
+				Expect(mockPool.ExpectationsWereMet()).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// CreatePromotion creates a promotion with its codes on behalf of a given user.
+func (r *Repository) CreatePromotion(ctx context.Context, bo *backoff.ExponentialBackOff, promotion model.Promotion, actor string) (model.Promotion, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	params := queries.CreatePromotionParams{
+		Name:           promotion.Name,
+		Kind:           promotion.Kind,
+		Value:          promotion.Value,
+		ValidFrom:      promotion.ValidFrom,
+		MaxUses:        fromCap(promotion.MaxUses),
+		MaxUsesPerUser: fromCap(promotion.MaxUsesPerUser),
+		Actor:          actor,
+	}
+	if promotion.Item != "" {
+		params.Type = pgtype.Text{String: promotion.Item, Valid: true}
+	}
+	if promotion.ValidTo != nil {
+		params.ValidTo = pgtype.Timestamp{Time: *promotion.ValidTo, Valid: true}
+	}
+
+	created, err := backoff.RetryWithData(func() (queries.Promotion, error) {
+		return noRetryOnViolation(qtx.CreatePromotion(ctx, params))
+	}, bo)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	// Create the codes, DB creates no code if the code already exists
+	for _, code := range promotion.Codes {
+		createdCodes, err := backoff.RetryWithData(func() (int64, error) {
+			return qtx.CreatePromoCode(ctx, queries.CreatePromoCodeParams{
+				Code:        code.Code,
+				PromotionID: created.ID,
+				SingleUse:   code.SingleUse,
+			})
+		}, bo)
+		if err != nil {
+			return model.Promotion{}, err
+		}
+		if createdCodes == 0 {
+			return model.Promotion{}, ErrConflict
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Promotion{}, err
+	}
+
+	result := toPromotion(created)
+	result.Codes = promotion.Codes
+
+	return result, nil
+}
+
+// GetPromotions returns all promotions with their codes and the number of uses.
+func (r *Repository) GetPromotions(ctx context.Context, bo *backoff.ExponentialBackOff) ([]model.Promotion, error) {
+	// Get promotions from DB
+	promotionsQuery, err := backoff.RetryWithData(func() ([]queries.Promotion, error) {
+		return r.q.GetPromotions(ctx)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get codes of the promotions from DB
+	codesQuery, err := backoff.RetryWithData(func() ([]queries.PromoCode, error) {
+		return r.q.GetPromoCodes(ctx)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get number of uses of the promotions from DB
+	usesQuery, err := backoff.RetryWithData(func() ([]queries.GetPromoRedemptionCountsRow, error) {
+		return r.q.GetPromoRedemptionCounts(ctx)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make(map[int32][]model.PromoCode)
+	for _, rec := range codesQuery {
+		codes[rec.PromotionID] = append(codes[rec.PromotionID], model.PromoCode{
+			Code:      rec.Code,
+			SingleUse: rec.SingleUse,
+		})
+	}
+
+	uses := make(map[int32]int64, len(usesQuery))
+	for _, rec := range usesQuery {
+		uses[rec.PromotionID] = rec.Uses
+	}
+
+	promotions := make([]model.Promotion, 0, len(promotionsQuery))
+	for _, rec := range promotionsQuery {
+		promotion := toPromotion(rec)
+		promotion.Codes = codes[rec.ID]
+		promotion.Uses = int(uses[rec.ID])
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, nil
+}
+
+// redeemablePromotion locks a promo code with its promotion and checks, that the code can be applied
+// to a purchase of an item by a user at the moment.
+func (r *Repository) redeemablePromotion(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, username string, itemType string, code string) (model.Promotion, error) {
+	// Get and lock the code
+	promoCode, err := backoff.RetryWithData(func() (queries.PromoCode, error) {
+		return noRetryOnNoRows(qtx.GetPromoCodeForUpdate(ctx, code))
+	}, bo)
+	if err != nil {
+		return model.Promotion{}, notApplicableOnNoRows(err)
+	}
+
+	// Get and lock the promotion, so the usage caps hold until the commit
+	rec, err := backoff.RetryWithData(func() (queries.Promotion, error) {
+		return noRetryOnNoRows(qtx.GetPromotionForUpdate(ctx, promoCode.PromotionID))
+	}, bo)
+	if err != nil {
+		return model.Promotion{}, notApplicableOnNoRows(err)
+	}
+	promotion := toPromotion(rec)
+	promotion.Codes = []model.PromoCode{{Code: promoCode.Code, SingleUse: promoCode.SingleUse}}
+
+	now := time.Now()
+	if now.Before(promotion.ValidFrom) || (promotion.ValidTo != nil && !now.Before(*promotion.ValidTo)) {
+		return model.Promotion{}, ErrPromoNotApplicable
+	}
+	if promotion.Item != "" && promotion.Item != itemType {
+		return model.Promotion{}, ErrPromoNotApplicable
+	}
+
+	// Check the usage caps
+	counts, err := backoff.RetryWithData(func() (queries.CountPromoRedemptionsRow, error) {
+		return qtx.CountPromoRedemptions(ctx, queries.CountPromoRedemptionsParams{
+			PromotionID: rec.ID,
+			Username:    username,
+			Code:        promoCode.Code,
+		})
+	}, bo)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	switch {
+	case promotion.MaxUses != nil && counts.Total >= int64(*promotion.MaxUses):
+		return model.Promotion{}, ErrPromoUsedUp
+	case promotion.MaxUsesPerUser != nil && counts.ByUser >= int64(*promotion.MaxUsesPerUser):
+		return model.Promotion{}, ErrPromoUsedUp
+	case promoCode.SingleUse && counts.ByCode > 0:
+		return model.Promotion{}, ErrPromoUsedUp
+	}
+
+	return promotion, nil
+}
+
+// redeemPromotion records the use of a promo code for a purchase.
+func (r *Repository) redeemPromotion(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, username string, promotion model.Promotion, inventoryID int32, discount int64) error {
+	_, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreatePromoRedemption(ctx, queries.CreatePromoRedemptionParams{
+			PromotionID: int32(promotion.ID),
+			Code:        promotion.Codes[0].Code,
+			Username:    username,
+			InventoryID: inventoryID,
+			Discount:    discount,
+		})
+	}, bo)
+	return err
+}
+
+// notApplicableOnNoRows replaces the no rows error with the promo code not applicable error.
+func notApplicableOnNoRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPromoNotApplicable
+	}
+	return err
+}
+
+// toPromotion converts promotion record of DB to the model.
+func toPromotion(rec queries.Promotion) model.Promotion {
+	promotion := model.Promotion{
+		ID:             int(rec.ID),
+		Name:           rec.Name,
+		Kind:           rec.Kind,
+		Value:          rec.Value,
+		Item:           rec.Type.String,
+		ValidFrom:      rec.ValidFrom,
+		MaxUses:        toCap(rec.MaxUses),
+		MaxUsesPerUser: toCap(rec.MaxUsesPerUser),
+		Actor:          rec.Actor,
+		CreatedAt:      rec.CreatedAt,
+	}
+	if rec.ValidTo.Valid {
+		promotion.ValidTo = &rec.ValidTo.Time
+	}
+	return promotion
+}
+
+// toCap converts usage cap of promotion in DB to the model.
+func toCap(limit pgtype.Int4) *int {
+	if !limit.Valid {
+		return nil
+	}
+	value := int(limit.Int32)
+	return &value
+}
+
+// fromCap converts usage cap of promotion in the model to DB.
+func fromCap(limit *int) pgtype.Int4 {
+	if limit == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(*limit), Valid: true}
+}
@@ -0,0 +1,204 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository promotions", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		user = model.User{UserName: "user"}
+		item = model.InventoryItem{Type: "hoody", Quantity: 3}
+
+		promotionColumns = []string{"id", "name", "kind", "value", "type", "valid_from", "valid_to", "max_uses", "max_uses_per_user", "actor", "created_at"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling BuyItem method with a promo code", func() {
+		var singleUse bool
+
+		BeforeEach(func() {
+			singleUse = false
+		})
+
+		JustBeforeEach(func() {
+			rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), item.Type, int64(50), "", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(item.Type).WillReturnRows(rsGet).Times(1)
+
+			rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+			mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs(item.Type).WillReturnRows(rsVariants).Times(1)
+
+			mockPool.ExpectBegin()
+
+			rsCode := pgxmock.NewRows([]string{"code", "promotion_id", "single_use"}).AddRow("HOODY20", int32(1), singleUse)
+			mockPool.ExpectQuery("SELECT .+ FROM promo_codes .+").WithArgs("HOODY20").WillReturnRows(rsCode).Times(1)
+		})
+
+		When("the promotion applies to the item", func() {
+			JustBeforeEach(func() {
+				rsPromotion := pgxmock.NewRows(promotionColumns).
+					AddRow(int32(1), "Hoodies week", model.PromotionPercent, int64(20), pgtype.Text{String: item.Type, Valid: true},
+						time.Now().Add(-time.Hour), pgtype.Timestamp{}, pgtype.Int4{}, pgtype.Int4{Int32: 1, Valid: true}, "admin", time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM promotions .+").WithArgs(int32(1)).WillReturnRows(rsPromotion).Times(1)
+
+				rsCounts := pgxmock.NewRows([]string{"total", "by_user", "by_code"}).AddRow(int64(5), int64(0), int64(5))
+				mockPool.ExpectQuery("SELECT .+ FROM promo_redemptions .+").WithArgs(int32(1), user.UserName, "HOODY20").WillReturnRows(rsCounts).Times(1)
+
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(880))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-120)).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(120)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs(item.Type).WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs(item.Type, pgtype.Int4{Int32: 3, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, item.Type, int32(3), int64(50), int64(30), pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(7), user.UserName, item.Type, int32(3)).WillReturnRows(rsOrder).Times(1)
+
+				rsRedemption := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO promo_redemptions .+ VALUES .+").WithArgs(int32(1), "HOODY20", user.UserName, int32(7), int64(30)).WillReturnRows(rsRedemption).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, item, "HOODY20")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("withdraws the discounted cost, records the discount and returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the promotion is for another item", func() {
+			JustBeforeEach(func() {
+				rsPromotion := pgxmock.NewRows(promotionColumns).
+					AddRow(int32(1), "Socks", model.PromotionFixed, int64(10), pgtype.Text{String: "socks", Valid: true},
+						time.Now().Add(-time.Hour), pgtype.Timestamp{}, pgtype.Int4{}, pgtype.Int4{}, "admin", time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM promotions .+").WithArgs(int32(1)).WillReturnRows(rsPromotion).Times(1)
+
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, item, "HOODY20")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns promo not applicable error", func() {
+				Expect(err).Should(MatchError(repository.ErrPromoNotApplicable))
+			})
+		})
+
+		When("the single-use code has been used", func() {
+			BeforeEach(func() {
+				singleUse = true
+			})
+
+			JustBeforeEach(func() {
+				rsPromotion := pgxmock.NewRows(promotionColumns).
+					AddRow(int32(1), "Hoodies week", model.PromotionPercent, int64(20), pgtype.Text{},
+						time.Now().Add(-time.Hour), pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true}, pgtype.Int4{}, pgtype.Int4{}, "admin", time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM promotions .+").WithArgs(int32(1)).WillReturnRows(rsPromotion).Times(1)
+
+				rsCounts := pgxmock.NewRows([]string{"total", "by_user", "by_code"}).AddRow(int64(1), int64(0), int64(1))
+				mockPool.ExpectQuery("SELECT .+ FROM promo_redemptions .+").WithArgs(int32(1), user.UserName, "HOODY20").WillReturnRows(rsCounts).Times(1)
+
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, item, "HOODY20")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns promo used up error", func() {
+				Expect(err).Should(MatchError(repository.ErrPromoUsedUp))
+			})
+		})
+	})
+
+	Context("Calling CreatePromotion method", func() {
+		var promotion = model.Promotion{
+			Name:      "Socks",
+			Kind:      model.PromotionFixed,
+			Value:     10,
+			ValidFrom: time.Now(),
+			Codes:     []model.PromoCode{{Code: "SOCKS"}},
+		}
+
+		When("the code already exists", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsPromotion := pgxmock.NewRows(promotionColumns).
+					AddRow(int32(2), promotion.Name, promotion.Kind, promotion.Value, pgtype.Text{},
+						promotion.ValidFrom, pgtype.Timestamp{}, pgtype.Int4{}, pgtype.Int4{}, "admin", time.Now())
+				mockPool.ExpectQuery("INSERT INTO promotions .+ VALUES .+").
+					WithArgs(promotion.Name, promotion.Kind, promotion.Value, pgtype.Text{}, promotion.ValidFrom, pgtype.Timestamp{}, pgtype.Int4{}, pgtype.Int4{}, "admin").
+					WillReturnRows(rsPromotion).Times(1)
+
+				mockPool.ExpectExec("INSERT INTO promo_codes .+ VALUES .+").WithArgs("SOCKS", int32(2), false).WillReturnResult(pgxmock.NewResult("INSERT", 0)).Times(1)
+
+				mockPool.ExpectRollback()
+
+				_, err = repo.CreatePromotion(ctx, bo, promotion, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns conflict error", func() {
+				Expect(err).Should(MatchError(repository.ErrConflict))
+			})
+		})
+	})
+})
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// quoteTokenSize is the number of random bytes in the token of a quote.
+const quoteTokenSize = 16
+
+// QuoteItem quotes a purchase of a given quantity of inventory item with an optional promo code for a given user.
+// The purchase is made in a transaction, that is rolled back, so the quote passes the same checks as the purchase,
+// except the balance - the quote reports the shortfall, if the balance is not enough.
+// The quote is saved with its token and the price of merch, which the purchase with the token honours until a given time to live passes.
+func (r *Repository) QuoteItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string, ttl time.Duration) (model.Quote, error) {
+	quote, err := r.buy(ctx, bo, user, item, promoCode, "", true)
+	if err != nil {
+		return model.Quote{}, err
+	}
+
+	quote.Token, err = newQuoteToken()
+	if err != nil {
+		return model.Quote{}, err
+	}
+	quote.ExpiresAt = time.Now().Add(ttl)
+
+	// Remove expired quotes of the user
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, r.q.DeleteExpiredPurchaseQuotes(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.Quote{}, err
+	}
+
+	// Save the quote
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, r.q.CreatePurchaseQuote(ctx, queries.CreatePurchaseQuoteParams{
+			Token:     quote.Token,
+			Username:  user.UserName,
+			Type:      item.Type,
+			Size:      item.Size,
+			Color:     item.Color,
+			Quantity:  int32(item.Quantity),
+			PromoCode: promoCode,
+			Price:     quote.Price,
+			ExpiresAt: quote.ExpiresAt,
+		})
+	}, bo)
+	if err != nil {
+		return model.Quote{}, err
+	}
+
+	return quote, nil
+}
+
+// BuyQuotedItem buys a given quantity of inventory item for a given user at the price of the quote with a given token.
+// The quote is taken in the transaction of the purchase, so it is honoured once only.
+func (r *Repository) BuyQuotedItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string, quoteToken string) error {
+	_, err := r.buy(ctx, bo, user, item, promoCode, quoteToken, false)
+	return err
+}
+
+// QuoteSendCoins quotes sending of coins from one user to another.
+// Shortage of the balance doesn't fail the quote, the quote reports the shortfall instead.
+func (r *Repository) QuoteSendCoins(ctx context.Context, bo *backoff.ExponentialBackOff, fromUser model.User, toUser model.User, amount int64) (model.TransferQuote, error) {
+	// Check that the user that receives exists
+	_, err := backoff.RetryWithData(func() (queries.User, error) {
+		return noRetryOnNoRows(r.q.GetUser(ctx, toUser.UserName))
+	}, bo)
+	if err != nil {
+		return model.TransferQuote{}, noDataOnNoRows(err)
+	}
+
+	// Get the balance of the user that sends
+	balance, err := backoff.RetryWithData(func() (queries.Balance, error) {
+		return r.q.GetBalance(ctx, fromUser.UserName)
+	}, bo)
+	if err != nil {
+		return model.TransferQuote{}, err
+	}
+
+	quote := model.TransferQuote{
+		ToUser:       toUser.UserName,
+		Amount:       amount,
+		Balance:      balance.Coins,
+		BalanceAfter: balance.Coins - amount,
+	}
+
+	// Held coins can't be sent, so the sending is short of the amount less the coins available
+	if available := balance.Coins - balance.Held; amount > available {
+		quote.Shortfall = amount - available
+	}
+
+	return quote, nil
+}
+
+// takeQuote takes the quote with a given token in the transaction of the purchase and returns the price of the quote.
+// The quote must belong to the user, be not expired and match the purchase.
+func (r *Repository) takeQuote(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, username string, item model.InventoryItem, promoCode string, quoteToken string) (int64, error) {
+	quote, err := backoff.RetryWithData(func() (queries.PurchaseQuote, error) {
+		return noRetryOnNoRows(qtx.TakePurchaseQuote(ctx, queries.TakePurchaseQuoteParams{
+			Token:    quoteToken,
+			Username: username,
+		}))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidQuote
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if quote.Type != item.Type || quote.Size != item.Size || quote.Color != item.Color ||
+		int(quote.Quantity) != item.Quantity || quote.PromoCode != promoCode {
+		return 0, ErrInvalidQuote
+	}
+
+	return quote.Price, nil
+}
+
+// toQuote returns the quote of the purchase made in the transaction, that is going to be rolled back,
+// with the balance of the user before the purchase.
+func toQuote(item model.InventoryItem, price int64, cost int64, discount int64, balance queries.GetBalanceForUpdateRow) model.Quote {
+	quote := model.Quote{
+		Item:         item.Type,
+		Size:         item.Size,
+		Color:        item.Color,
+		Quantity:     item.Quantity,
+		Price:        price,
+		Cost:         cost,
+		Discount:     discount,
+		Total:        cost - discount,
+		Balance:      balance.Coins,
+		BalanceAfter: balance.Coins - cost + discount,
+	}
+
+	// Held coins can't be spent, so the purchase is short of the total less the coins available
+	if available := balance.Coins - balance.Held; quote.Total > available {
+		quote.Shortfall = quote.Total - available
+	}
+
+	return quote
+}
+
+// newQuoteToken generates a random token of a quote.
+func newQuoteToken() (string, error) {
+	token := make([]byte, quoteTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
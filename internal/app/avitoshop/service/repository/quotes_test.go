@@ -0,0 +1,298 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository quotes", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		user = model.User{UserName: "user"}
+		item = model.InventoryItem{Type: "hoody", Quantity: 2}
+
+		merchColumns = []string{"id", "type", "price", "description", "available", "stock"}
+		quoteColumns = []string{"token", "username", "type", "size", "color", "quantity", "promo_code", "price", "expires_at", "created_at"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling QuoteItem method", func() {
+		var quote model.Quote
+
+		When("the purchase passes all checks", func() {
+			JustBeforeEach(func() {
+				rsGet := pgxmock.NewRows(merchColumns).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("hoody").WillReturnRows(rsVariants).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsBalance := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(1000), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+ FOR UPDATE").WithArgs(user.UserName).WillReturnRows(rsBalance).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 2, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, "hoody", int32(2), int64(300), int64(0), pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(7), user.UserName, "hoody", int32(2)).WillReturnRows(rsOrder).Times(1)
+
+				mockPool.ExpectRollback()
+
+				mockPool.ExpectExec("DELETE FROM purchase_quotes .+").WithArgs(user.UserName).WillReturnResult(pgxmock.NewResult("DELETE", 0)).Times(1)
+				mockPool.ExpectExec("INSERT INTO purchase_quotes .+ VALUES .+").
+					WithArgs(pgxmock.AnyArg(), user.UserName, "hoody", "", "", int32(2), "", int64(300), pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+
+				quote, err = repo.QuoteItem(ctx, bo, user, item, "", time.Minute)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("rolls the purchase back and returns the quote", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(quote.Price).To(Equal(int64(300)))
+				Expect(quote.Total).To(Equal(int64(600)))
+				Expect(quote.Balance).To(Equal(int64(1000)))
+				Expect(quote.BalanceAfter).To(Equal(int64(400)))
+				Expect(quote.Shortfall).To(BeZero())
+				Expect(quote.Token).NotTo(BeEmpty())
+				Expect(quote.ExpiresAt).To(BeTemporally(">", time.Now()))
+			})
+		})
+
+		When("balance is not enough to buy", func() {
+			JustBeforeEach(func() {
+				rsGet := pgxmock.NewRows(merchColumns).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("hoody").WillReturnRows(rsVariants).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsBalance := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(700), int64(200))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+ FOR UPDATE").WithArgs(user.UserName).WillReturnRows(rsBalance).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 2, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, "hoody", int32(2), int64(300), int64(0), pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(7), user.UserName, "hoody", int32(2)).WillReturnRows(rsOrder).Times(1)
+
+				mockPool.ExpectRollback()
+
+				mockPool.ExpectExec("DELETE FROM purchase_quotes .+").WithArgs(user.UserName).WillReturnResult(pgxmock.NewResult("DELETE", 0)).Times(1)
+				mockPool.ExpectExec("INSERT INTO purchase_quotes .+ VALUES .+").
+					WithArgs(pgxmock.AnyArg(), user.UserName, "hoody", "", "", int32(2), "", int64(300), pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+
+				quote, err = repo.QuoteItem(ctx, bo, user, item, "", time.Minute)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the quote with the shortfall of the available balance", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(quote.Total).To(Equal(int64(600)))
+				Expect(quote.Balance).To(Equal(int64(700)))
+				Expect(quote.Shortfall).To(Equal(int64(100)))
+			})
+		})
+
+		When("the merch is out of stock", func() {
+			JustBeforeEach(func() {
+				rsGet := pgxmock.NewRows(merchColumns).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{Int32: 1, Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("hoody").WillReturnRows(rsVariants).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsBalance := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(0), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+ FOR UPDATE").WithArgs(user.UserName).WillReturnRows(rsBalance).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 2, Valid: true}).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "merch_stock_check"})
+
+				mockPool.ExpectRollback()
+
+				quote, err = repo.QuoteItem(ctx, bo, user, item, "", time.Minute)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns out of stock error and saves no quote", func() {
+				Expect(err).Should(MatchError(repository.ErrOutOfStock))
+			})
+		})
+	})
+
+	Context("Calling BuyQuotedItem method", func() {
+		JustBeforeEach(func() {
+			rsGet := pgxmock.NewRows(merchColumns).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnRows(rsGet).Times(1)
+
+			rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+			mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("hoody").WillReturnRows(rsVariants).Times(1)
+
+			mockPool.ExpectBegin()
+		})
+
+		When("the price has risen since the quote", func() {
+			JustBeforeEach(func() {
+				rsQuote := pgxmock.NewRows(quoteColumns).AddRow("token", user.UserName, "hoody", "", "", int32(2), "", int64(250), time.Now().Add(time.Minute), time.Now())
+				mockPool.ExpectQuery("DELETE FROM purchase_quotes .+ RETURNING .+").WithArgs("token", user.UserName).WillReturnRows(rsQuote).Times(1)
+
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(500))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-500)).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(500)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 2, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, "hoody", int32(2), int64(250), int64(0), pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(7), user.UserName, "hoody", int32(2)).WillReturnRows(rsOrder).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.BuyQuotedItem(ctx, bo, user, item, "", "token")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("buys the merch at the price of the quote and returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the quote doesn't match the purchase", func() {
+			JustBeforeEach(func() {
+				rsQuote := pgxmock.NewRows(quoteColumns).AddRow("token", user.UserName, "hoody", "", "", int32(1), "", int64(250), time.Now().Add(time.Minute), time.Now())
+				mockPool.ExpectQuery("DELETE FROM purchase_quotes .+ RETURNING .+").WithArgs("token", user.UserName).WillReturnRows(rsQuote).Times(1)
+
+				mockPool.ExpectRollback()
+
+				err = repo.BuyQuotedItem(ctx, bo, user, item, "", "token")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns invalid quote error", func() {
+				Expect(err).Should(MatchError(repository.ErrInvalidQuote))
+			})
+		})
+
+		When("the quote is expired or used", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectQuery("DELETE FROM purchase_quotes .+ RETURNING .+").WithArgs("token", user.UserName).WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectRollback()
+
+				err = repo.BuyQuotedItem(ctx, bo, user, item, "", "token")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns invalid quote error", func() {
+				Expect(err).Should(MatchError(repository.ErrInvalidQuote))
+			})
+		})
+	})
+
+	Context("Calling QuoteSendCoins method", func() {
+		var transferQuote model.TransferQuote
+
+		When("balance is not enough to send", func() {
+			JustBeforeEach(func() {
+				rsUser := pgxmock.NewRows([]string{"id", "username", "password", "created_at", "referrer"}).AddRow(int32(2), "receiver", "", time.Now(), "")
+				mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs("receiver").WillReturnRows(rsUser).Times(1)
+
+				rsBalance := pgxmock.NewRows([]string{"id", "username", "coins", "held"}).AddRow(int32(1), user.UserName, int64(250), int64(100))
+				mockPool.ExpectQuery("SELECT .+ FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsBalance).Times(1)
+
+				transferQuote, err = repo.QuoteSendCoins(ctx, bo, user, model.User{UserName: "receiver"}, 200)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the quote with the shortfall of the available balance and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(transferQuote.Balance).To(Equal(int64(250)))
+				Expect(transferQuote.BalanceAfter).To(Equal(int64(50)))
+				Expect(transferQuote.Shortfall).To(Equal(int64(50)))
+			})
+		})
+	})
+})
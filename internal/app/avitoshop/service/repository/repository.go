@@ -16,6 +16,7 @@ import (
 
 	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
 	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/coins"
 )
 
 var (
@@ -28,10 +29,83 @@ var (
 	// ErrNegativeBalance error means that user balance would become neagive if transaction being commited.
 	ErrNegativeBalance = fmt.Errorf("negative balance")
 
+	// ErrAlreadyReversed error means that the operation has already been reversed.
+	ErrAlreadyReversed = fmt.Errorf("already reversed")
+
+	// ErrOutOfRange error means that an amount of coins is out of range allowed in DB.
+	ErrOutOfRange = fmt.Errorf("amount out of range")
+
+	// ErrNoWallet error means that the wallet doesn't exist or the user is not a member of it.
+	ErrNoWallet = fmt.Errorf("no wallet")
+
+	// ErrPermissionDenied error means that the role of the user in the wallet doesn't allow the operation.
+	ErrPermissionDenied = fmt.Errorf("permission denied")
+
+	// ErrNoUser error means that the user, that the operation is addressed to, doesn't exist.
+	ErrNoUser = fmt.Errorf("no user")
+
+	// ErrNotEnoughItems error means that the user doesn't own enough items of a type.
+	ErrNotEnoughItems = fmt.Errorf("not enough items")
+
+	// ErrOutOfStock error means that there is not enough merch in stock.
+	ErrOutOfStock = fmt.Errorf("out of stock")
+
+	// ErrCheckoutFailed error means that some lines of the cart can't be bought.
+	ErrCheckoutFailed = fmt.Errorf("checkout failed")
+
+	// ErrPromoNotApplicable error means that the promo code doesn't exist or can't be applied to the purchase.
+	ErrPromoNotApplicable = fmt.Errorf("promo code not applicable")
+
+	// ErrPromoUsedUp error means that the usage cap of the promotion or the promo code has been reached.
+	ErrPromoUsedUp = fmt.Errorf("promo code used up")
+
+	// ErrNoVariant error means that the variant of merch is not selected, while merch has variants, or doesn't exist.
+	ErrNoVariant = fmt.Errorf("no variant")
+
+	// ErrLimitReached error means that the purchase would exceed the purchase limit of merch for the user.
+	ErrLimitReached = fmt.Errorf("purchase limit reached")
+
+	// ErrInvalidTransition error means that the order can't be moved from its current status to the requested one.
+	ErrInvalidTransition = fmt.Errorf("invalid order status transition")
+
+	// ErrNotOnSale error means that merch sold in drops only has no drop going on at the moment.
+	ErrNotOnSale = fmt.Errorf("not on sale")
+
+	// ErrInvalidQuote error means that the quote of a purchase is unknown, expired, used or doesn't match the purchase.
+	ErrInvalidQuote = fmt.Errorf("invalid quote")
+
+	// ErrHoldClosed error means that the hold of coins has been captured, released or has expired.
+	ErrHoldClosed = fmt.Errorf("hold is closed")
+
 	// DefaultBackOff - default backoff parameters.
 	DefaultBackOff = NewDefaultBackOff()
 )
 
+// Kinds of history and inventory records.
+const (
+	kindTransfer      = "transfer"
+	kindPurchase      = "purchase"
+	kindReversal      = "reversal"
+	kindRefund        = "refund"
+	kindReferral      = "referral"
+	kindExpiry        = "expiry"
+	kindGift          = "gift"
+	kindDecline       = "decline"
+	kindReturn        = "return"
+	kindCapture       = "capture"
+	kindWalletFunding = "wallet_funding"
+)
+
+// Names of the constraints, that keep user balances, held coins, wallets and merch stock within bounds.
+const (
+	balanceCoinsCheck      = "balance_coins_check"
+	balanceHeldCheck       = "balance_held_check"
+	walletCoinsCheck       = "wallets_coins_check"
+	merchStockCheck        = "merch_stock_check"
+	merchVariantStockCheck = "merch_variants_stock_check"
+	merchDropStockCheck    = "merch_drops_stock_check"
+)
+
 // conflictUser contains confict user and an error.
 type conflictUser struct {
 	user model.User
@@ -58,18 +132,27 @@ type PgxPool interface {
 }
 
 // New creates new repository.
-func New(dbpool PgxPool) (*Repository, error) {
-	// Return Repository struct with new queries
-	return &Repository{
-		db: dbpool,
-		q:  queries.New(dbpool),
-	}, nil
+func New(dbpool PgxPool, opts ...Option) (*Repository, error) {
+	// Create Repository struct with new queries
+	r := &Repository{
+		db:      dbpool,
+		q:       queries.New(dbpool),
+		coinTTL: DefaultCoinTTL,
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
 }
 
 // Repository is the repository structure.
 type Repository struct {
-	db PgxPool
-	q  *queries.Queries
+	db      PgxPool
+	q       *queries.Queries
+	coinTTL time.Duration
 }
 
 // CreateUser creates new user in the repository.
@@ -84,6 +167,7 @@ func (r *Repository) CreateUser(ctx context.Context, bo *backoff.ExponentialBack
 		_, errCreate := r.q.CreateUser(ctx, queries.CreateUserParams{
 			Username: user.UserName,
 			Password: user.Password,
+			Referrer: user.Referrer,
 		})
 
 		// Check if there is a conflict
@@ -124,22 +208,54 @@ func (r *Repository) CreateUser(ctx context.Context, bo *backoff.ExponentialBack
 	return user, nil
 }
 
-// CreateBalance creates new user balance in the repository.
-func (r *Repository) CreateBalance(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) error {
-	// Create new user balance in DB
-	_, err := backoff.RetryWithData(func() (int32, error) {
-		return r.q.CreateBalance(ctx, user.UserName)
+// GetUser returns an existing user with the hash of the password.
+func (r *Repository) GetUser(ctx context.Context, bo *backoff.ExponentialBackOff, userName string) (model.User, error) {
+	user, err := backoff.RetryWithData(func() (queries.User, error) {
+		return noRetryOnNoRows(r.q.GetUser(ctx, userName))
 	}, bo)
+	if err != nil {
+		return model.User{}, noDataOnNoRows(err)
+	}
+
+	return model.User{
+		UserName: user.Username,
+		Password: user.Password,
+	}, nil
+}
 
+// CreateBalance creates new user balance with a given amount of coins in the repository.
+func (r *Repository) CreateBalance(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, coins int64) error {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Create new empty user balance in DB
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return noRetryOnViolation(qtx.CreateBalance(ctx, queries.CreateBalanceParams{
+			Username: user.UserName,
+		}))
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	// Credit the welcome coins
+	if err = r.credit(ctx, bo, qtx, user.UserName, coins, sourceWelcome); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit(ctx)
 }
 
 // SendCoins transfer given amount of coins from one user to another.
-func (r *Repository) SendCoins(ctx context.Context, bo *backoff.ExponentialBackOff, fromUser model.User, toUser model.User, amount int) error {
+func (r *Repository) SendCoins(ctx context.Context, bo *backoff.ExponentialBackOff, fromUser model.User, toUser model.User, amount int64) error {
 	// Get user from DB
 	_, err := backoff.RetryWithData(func() (queries.User, error) {
 		return r.q.GetUser(ctx, toUser.UserName)
@@ -163,32 +279,21 @@ func (r *Repository) SendCoins(ctx context.Context, bo *backoff.ExponentialBackO
 	// Create query with transaction
 	qtx := r.q.WithTx(tx)
 
-	// Withdraw from the balance of user that sends
-	fromUserBalance, err := backoff.RetryWithData(func() (int32, error) {
-		return qtx.UpdateBalance(ctx, queries.UpdateBalanceParams{
-			Username: fromUser.UserName,
-			Coins:    int32(-amount),
-		})
-	}, bo)
+	// Withdraw from the balance of user that sends,
+	// DB returns the negative balance error if the balance is not enough
+	err = r.debit(ctx, bo, qtx, fromUser.UserName, amount)
 	if err != nil {
 		_ = tx.Rollback(ctx)
 		return err
 	}
 
-	// If the balance has become negative after withdrawal,
-	// rollback the transaction and return the negative balance error
-	if fromUserBalance < 0 {
-		_ = tx.Rollback(ctx)
-		return ErrNegativeBalance
-	}
-
 	// Balance enough to withdraw - create new history record for user that sends
 	_, err = backoff.RetryWithData(func() (int32, error) {
-		return qtx.CreateHistoryRecord(ctx, queries.CreateHistoryRecordParams{
+		return noRetryOnViolation(qtx.CreateHistoryRecord(ctx, queries.CreateHistoryRecordParams{
 			Username: fromUser.UserName,
 			ToUser:   toUser.UserName,
-			Amount:   int32(amount),
-		})
+			Amount:   amount,
+		}))
 	}, bo)
 	if err != nil {
 		_ = tx.Rollback(ctx)
@@ -196,12 +301,7 @@ func (r *Repository) SendCoins(ctx context.Context, bo *backoff.ExponentialBackO
 	}
 
 	// Update the balance of user that receives
-	_, err = backoff.RetryWithData(func() (int32, error) {
-		return qtx.UpdateBalance(ctx, queries.UpdateBalanceParams{
-			Username: toUser.UserName,
-			Coins:    int32(amount),
-		})
-	}, bo)
+	err = r.credit(ctx, bo, qtx, toUser.UserName, amount, kindTransfer)
 	if err != nil {
 		_ = tx.Rollback(ctx)
 		return err
@@ -209,11 +309,11 @@ func (r *Repository) SendCoins(ctx context.Context, bo *backoff.ExponentialBackO
 
 	// Create new history record for user that receives
 	_, err = backoff.RetryWithData(func() (int32, error) {
-		return qtx.CreateHistoryRecord(ctx, queries.CreateHistoryRecordParams{
+		return noRetryOnViolation(qtx.CreateHistoryRecord(ctx, queries.CreateHistoryRecordParams{
 			Username: toUser.UserName,
 			FromUser: fromUser.UserName,
-			Amount:   int32(amount),
-		})
+			Amount:   amount,
+		}))
 	}, bo)
 	if err != nil {
 		_ = tx.Rollback(ctx)
@@ -224,23 +324,52 @@ func (r *Repository) SendCoins(ctx context.Context, bo *backoff.ExponentialBackO
 }
 
 // BuyItem register purhcase of inventory item (merch) for a given user.
-func (r *Repository) BuyItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem) error {
+// The quantity of the item is bought at once, at the price valid at the moment.
+// The discount of the promo code is applied to the cost and recorded on the purchase, if the promo code is given.
+func (r *Repository) BuyItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string) error {
+	_, err := r.buy(ctx, bo, user, item, promoCode, "", false)
+	return err
+}
+
+// buy buys a given quantity of inventory item for a given user.
+// The price of merch is taken from the quote with a given token, if the token is given.
+// The transaction of the purchase is rolled back in the dry run, and the quote of the purchase is returned instead.
+func (r *Repository) buy(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string, quoteToken string, dryRun bool) (model.Quote, error) {
 	// Get merch from DB
-	merch, err := backoff.RetryWithData(func() (queries.Merch, error) {
-		return r.q.GetMerch(ctx, item.Type)
+	merch, err := backoff.RetryWithData(func() (queries.MerchCurrent, error) {
+		return noRetryOnNoRows(r.q.GetMerch(ctx, item.Type))
 	}, bo)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return err
+		return model.Quote{}, err
 	}
+
+	// Merch sold in drops only is bought at the price of the current drop
+	var drop queries.GetCurrentMerchDropRow
 	if errors.Is(err, sql.ErrNoRows) {
-		return ErrNoData
+		drop, err = r.currentDrop(ctx, bo, item.Type)
+		if err != nil {
+			return model.Quote{}, err
+		}
+		merch = queries.MerchCurrent{Type: drop.Type, Price: drop.Price}
+	}
+
+	// Get the selected variant of the merch
+	variantID, err := r.selectVariant(ctx, bo, item)
+	if err != nil {
+		return model.Quote{}, err
+	}
+
+	// Calculate the cost of the whole quantity
+	cost, err := coins.Mul(merch.Price, int64(item.Quantity))
+	if err != nil {
+		return model.Quote{}, ErrOutOfRange
 	}
 
 	// Begin transaction
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return err
+		return model.Quote{}, err
 	}
 	// Defer transaction rollback
 	defer func() { _ = tx.Rollback(ctx) }()
@@ -248,52 +377,122 @@ func (r *Repository) BuyItem(ctx context.Context, bo *backoff.ExponentialBackOff
 	// Create query with transaction
 	qtx := r.q.WithTx(tx)
 
-	// Withdraw merch price from the balance of the user
-	userBalance, err := backoff.RetryWithData(func() (int32, error) {
-		return qtx.UpdateBalance(ctx, queries.UpdateBalanceParams{
-			Username: user.UserName,
-			Coins:    -merch.Price,
-		})
-	}, bo)
+	// Honour the price of the quote
+	if quoteToken != "" {
+		merch.Price, err = r.takeQuote(ctx, bo, qtx, user.UserName, item, promoCode, quoteToken)
+		if err != nil {
+			return model.Quote{}, err
+		}
+		if cost, err = coins.Mul(merch.Price, int64(item.Quantity)); err != nil {
+			return model.Quote{}, ErrOutOfRange
+		}
+	}
+
+	// Check the promo code and calculate the discount
+	var promotion model.Promotion
+	var discount int64
+	if promoCode != "" {
+		promotion, err = r.redeemablePromotion(ctx, bo, qtx, user.UserName, item.Type, promoCode)
+		if err != nil {
+			return model.Quote{}, err
+		}
+		discount = promotion.Discount(cost)
+	}
+
+	// Withdraw the cost less the discount from the balance of the user,
+	// DB returns the negative balance error if the balance is not enough.
+	// The quote only locks the balance instead, as the balance, that is not enough, is reported by the quote
+	var balance queries.GetBalanceForUpdateRow
+	if dryRun {
+		balance, err = backoff.RetryWithData(func() (queries.GetBalanceForUpdateRow, error) {
+			return noRetryOnNoRows(qtx.GetBalanceForUpdate(ctx, user.UserName))
+		}, bo)
+		err = noDataOnNoRows(err)
+	} else {
+		err = r.debit(ctx, bo, qtx, user.UserName, cost-discount)
+	}
 	if err != nil {
 		_ = tx.Rollback(ctx)
-		return err
+		return model.Quote{}, err
 	}
 
-	// If the balance has become negative after withdrawal,
-	// rollback the transaction and return the negative balance error
-	if userBalance < 0 {
+	// Check the purchase limit of the merch, the balance of the user is locked by now,
+	// so concurrent purchases of the user can't exceed the limit together
+	err = r.checkPurchaseLimit(ctx, bo, qtx, user.UserName, item.Type, item.Quantity)
+	if err != nil {
 		_ = tx.Rollback(ctx)
-		return ErrNegativeBalance
+		return model.Quote{}, err
 	}
 
-	// Balance enough to withdraw - add item to the user inventory
-	_, err = backoff.RetryWithData(func() (int32, error) {
+	// Take the items out of stock,
+	// DB returns the out of stock error if there are not enough items left
+	err = r.reserveStock(ctx, bo, qtx, merch.Type, int32(item.Quantity))
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return model.Quote{}, err
+	}
+	err = r.reserveVariantStock(ctx, bo, qtx, variantID, int32(item.Quantity))
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return model.Quote{}, err
+	}
+	if drop.ID != 0 {
+		if err = r.reserveDropStock(ctx, bo, qtx, drop.ID, int32(item.Quantity)); err != nil {
+			_ = tx.Rollback(ctx)
+			return model.Quote{}, err
+		}
+	}
+
+	// Balance enough to withdraw - add items to the user inventory
+	inventoryID, err := backoff.RetryWithData(func() (int32, error) {
 		return qtx.CreateInventory(ctx, queries.CreateInventoryParams{
-			Username: user.UserName,
-			Type:     item.Type,
+			Username:  user.UserName,
+			Type:      item.Type,
+			Quantity:  int32(item.Quantity),
+			PricePaid: merch.Price,
+			Discount:  discount,
+			VariantID: variantID,
 		})
 	}, bo)
 	if err != nil {
 		_ = tx.Rollback(ctx)
-		return err
+		return model.Quote{}, err
 	}
 
-	return tx.Commit(ctx)
+	// Place the order to hand over the items
+	if err = r.createOrder(ctx, bo, qtx, inventoryID, user.UserName, item.Type, int32(item.Quantity)); err != nil {
+		return model.Quote{}, err
+	}
+
+	// Record the use of the promo code
+	if promoCode != "" {
+		if err = r.redeemPromotion(ctx, bo, qtx, user.UserName, promotion, inventoryID, discount); err != nil {
+			return model.Quote{}, err
+		}
+	}
+
+	if dryRun {
+		return toQuote(item, merch.Price, cost, discount, balance), nil
+	}
+
+	return model.Quote{}, tx.Commit(ctx)
 }
 
-// GetBalance returns users coins balance.
-func (r *Repository) GetBalance(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (int, error) {
+// GetBalance returns users coins balance with the held amount of coins.
+func (r *Repository) GetBalance(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Balance, error) {
 	// Get user balance from DB
 	userBalance, err := backoff.RetryWithData(func() (queries.Balance, error) {
 		return r.q.GetBalance(ctx, user.UserName)
 	}, bo)
 
 	if err != nil {
-		return 0, err
+		return model.Balance{}, err
 	}
 
-	return int(userBalance.Coins), nil
+	return model.Balance{
+		Coins: userBalance.Coins,
+		Held:  userBalance.Held,
+	}, nil
 }
 
 // GetInventory returns users inventory.
@@ -333,31 +532,88 @@ func (r *Repository) GetHistory(ctx context.Context, bo *backoff.ExponentialBack
 	// There is a compromise between the number of database accesses and the memory allocations for slices capacity.
 	received := make([]model.CoinsReceiving, 0, len(historyQuery))
 	sent := make([]model.CoinsSending, 0, len(historyQuery))
+	var adjustments []model.CoinsAdjustment
 
 	for _, rec := range historyQuery {
+		if rec.FromUser == "" && rec.ToUser == "" {
+			adjustments = append(adjustments, model.CoinsAdjustment{
+				Kind:   rec.Kind,
+				Amount: adjustmentAmount(rec.Kind, rec.Amount),
+			})
+			continue
+		}
 		if rec.FromUser != "" {
 			received = append(received, model.CoinsReceiving{
 				FromUser: rec.FromUser,
-				Amount:   int(rec.Amount),
+				Amount:   rec.Amount,
 			})
 			continue
 		}
 		if rec.ToUser != "" {
 			sent = append(sent, model.CoinsSending{
 				ToUser: rec.ToUser,
-				Amount: int(rec.Amount),
+				Amount: rec.Amount,
 			})
 		}
 	}
 
 	coinHistory := model.CoinsHistory{
-		Received: received,
-		Sent:     sent,
+		Received:    received,
+		Sent:        sent,
+		Adjustments: adjustments,
 	}
 
 	return coinHistory, nil
 }
 
+// adjustmentAmount returns the amount of history records without a counterparty with the sign of the balance change.
+// Coins are withdrawn by burns, expiry, captures of holds and wallet funding, all other such records credit coins.
+func adjustmentAmount(kind string, amount int64) int64 {
+	switch kind {
+	case model.CoinOperationBurn, kindExpiry, kindCapture, kindWalletFunding:
+		return -amount
+	}
+	return amount
+}
+
+// noRetryOnNoRows makes the backoff stop retrying if the query has returned no rows.
+func noRetryOnNoRows[T any](value T, err error) (T, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return value, backoff.Permanent(err)
+	}
+	return value, err
+}
+
+// noRetryOnViolation makes the backoff stop retrying if the query has violated DB constraints
+// and replaces the violation with the corresponding repository error.
+func noRetryOnViolation[T any](value T, err error) (T, error) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return value, err
+	}
+
+	switch {
+	case pgErr.Code == pgerrcode.CheckViolation && (pgErr.ConstraintName == balanceCoinsCheck || pgErr.ConstraintName == balanceHeldCheck || pgErr.ConstraintName == walletCoinsCheck):
+		return value, backoff.Permanent(ErrNegativeBalance)
+	case pgErr.Code == pgerrcode.CheckViolation && (pgErr.ConstraintName == merchStockCheck || pgErr.ConstraintName == merchVariantStockCheck || pgErr.ConstraintName == merchDropStockCheck):
+		return value, backoff.Permanent(ErrOutOfStock)
+	case pgErr.Code == pgerrcode.NumericValueOutOfRange:
+		return value, backoff.Permanent(ErrOutOfRange)
+	case pgerrcode.IsIntegrityConstraintViolation(pgErr.Code):
+		return value, backoff.Permanent(err)
+	}
+
+	return value, err
+}
+
+// noDataOnNoRows replaces the no rows error with the no data error.
+func noDataOnNoRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoData
+	}
+	return err
+}
+
 // NewDefaultBackOff returns default backoff parameters.
 func NewDefaultBackOff() *backoff.ExponentialBackOff {
 	bo := backoff.NewExponentialBackOff()
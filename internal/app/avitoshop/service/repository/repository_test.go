@@ -2,12 +2,15 @@ package repository_test
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"math"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/pashagolub/pgxmock/v4"
@@ -76,7 +79,7 @@ var _ = Describe("Repository", func() {
 				rowID = 1
 
 				rs := pgxmock.NewRows([]string{"id"}).AddRow(rowID)
-				mockPool.ExpectQuery("INSERT INTO users .+ VALUES .+").WithArgs(username, password).WillReturnRows(rs).Times(1)
+				mockPool.ExpectQuery("INSERT INTO users .+ VALUES .+").WithArgs(username, password, "").WillReturnRows(rs).Times(1)
 			})
 			AfterEach(func() {
 				err = mockPool.ExpectationsWereMet()
@@ -98,9 +101,9 @@ var _ = Describe("Repository", func() {
 				createdAt := time.Now()
 
 				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(rowID).RowError(int(rowID), &pgconn.PgError{Code: pgerrcode.IntegrityConstraintViolation})
-				mockPool.ExpectQuery("INSERT INTO users .+ VALUES .+").WithArgs(username, password).WillReturnRows(rsCreate).Times(1)
+				mockPool.ExpectQuery("INSERT INTO users .+ VALUES .+").WithArgs(username, password, "").WillReturnRows(rsCreate).Times(1)
 
-				rsGet := pgxmock.NewRows([]string{"id", "username", "password", "createdat"}).AddRow(rowID, username, password, createdAt)
+				rsGet := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(rowID, username, password, createdAt, "")
 				mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(username).WillReturnRows(rsGet).Times(1)
 			})
 			AfterEach(func() {
@@ -119,6 +122,8 @@ var _ = Describe("Repository", func() {
 	})
 
 	Context("Calling CreateBalance method", func() {
+		var welcomeBonus int64 = 1000
+
 		BeforeEach(func() {
 			username = "user"
 			password = "password"
@@ -133,8 +138,19 @@ var _ = Describe("Repository", func() {
 			BeforeEach(func() {
 				rowID = 1
 
+				mockPool.ExpectBegin()
+
 				rs := pgxmock.NewRows([]string{"id"}).AddRow(rowID)
-				mockPool.ExpectQuery("INSERT INTO balance .+ VALUES .+").WithArgs(username).WillReturnRows(rs).Times(1)
+				mockPool.ExpectQuery("INSERT INTO balance .+ VALUES .+").WithArgs(username, int64(0)).WillReturnRows(rs).Times(1)
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(welcomeBonus)
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(username, welcomeBonus).WillReturnRows(rsUpdate).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(rowID)
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(username, "welcome", welcomeBonus, pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
 			})
 			AfterEach(func() {
 				err = mockPool.ExpectationsWereMet()
@@ -142,7 +158,7 @@ var _ = Describe("Repository", func() {
 			})
 
 			It("returns nil error", func() {
-				err = repo.CreateBalance(ctx, bo, user)
+				err = repo.CreateBalance(ctx, bo, user, welcomeBonus)
 				Expect(err).ShouldNot(HaveOccurred())
 			})
 		})
@@ -151,8 +167,12 @@ var _ = Describe("Repository", func() {
 			BeforeEach(func() {
 				rowID = 0
 
+				mockPool.ExpectBegin()
+
 				rs := pgxmock.NewRows([]string{"id"}).AddRow(rowID).RowError(int(rowID), &pgconn.PgError{Code: pgerrcode.IntegrityConstraintViolation})
-				mockPool.ExpectQuery("INSERT INTO balance .+ VALUES .+").WithArgs(username).WillReturnRows(rs).Times(1)
+				mockPool.ExpectQuery("INSERT INTO balance .+ VALUES .+").WithArgs(username, int64(0)).WillReturnRows(rs).Times(1)
+
+				mockPool.ExpectRollback()
 			})
 			AfterEach(func() {
 				err = mockPool.ExpectationsWereMet()
@@ -160,7 +180,7 @@ var _ = Describe("Repository", func() {
 			})
 
 			It("returns an error", func() {
-				err = repo.CreateBalance(ctx, bo, user)
+				err = repo.CreateBalance(ctx, bo, user, welcomeBonus)
 				Expect(err).Should(HaveOccurred())
 			})
 		})
@@ -181,22 +201,23 @@ var _ = Describe("Repository", func() {
 			BeforeEach(func() {
 				rowID = 1
 
-				var balanceSender int32 = 900
-				var balanceReceiver int32 = 1100
-				var amount int32 = 100
+				var balanceSender int64 = 900
+				var balanceReceiver int64 = 1100
+				var amount int64 = 100
 				var toUser model.User = model.User{
 					UserName: "user1",
 					Password: "password1",
 				}
 				var toUserCreatedAt time.Time = time.Now()
 
-				rsGet := pgxmock.NewRows([]string{"id", "username", "password", "createdat"}).AddRow(rowID, toUser.UserName, toUser.Password, toUserCreatedAt)
+				rsGet := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(rowID, toUser.UserName, toUser.Password, toUserCreatedAt, "")
 				mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(toUser.UserName).WillReturnRows(rsGet).Times(1)
 
 				mockPool.ExpectBegin()
 
 				rsUpdateSender := pgxmock.NewRows([]string{"balance"}).AddRow(balanceSender)
 				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(username, -amount).WillReturnRows(rsUpdateSender).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(username, amount).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
 
 				rsCreateSender := pgxmock.NewRows([]string{"id"}).AddRow(rowID)
 				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(username, "", toUser.UserName, amount).WillReturnRows(rsCreateSender).Times(1)
@@ -204,13 +225,16 @@ var _ = Describe("Repository", func() {
 				rsUpdateReceiver := pgxmock.NewRows([]string{"balance"}).AddRow(balanceReceiver)
 				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(toUser.UserName, amount).WillReturnRows(rsUpdateReceiver).Times(1)
 
+				rsLotReceiver := pgxmock.NewRows([]string{"id"}).AddRow(rowID)
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(toUser.UserName, "transfer", amount, pgxmock.AnyArg()).WillReturnRows(rsLotReceiver).Times(1)
+
 				rsCreateReceiver := pgxmock.NewRows([]string{"id"}).AddRow(rowID)
 				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(toUser.UserName, username, "", amount).WillReturnRows(rsCreateReceiver).Times(1)
 
 				mockPool.ExpectCommit()
 				mockPool.ExpectRollback()
 
-				err = repo.SendCoins(ctx, bo, user, toUser, int(amount))
+				err = repo.SendCoins(ctx, bo, user, toUser, amount)
 			})
 			AfterEach(func() {
 				err = mockPool.ExpectationsWereMet()
@@ -226,25 +250,24 @@ var _ = Describe("Repository", func() {
 			BeforeEach(func() {
 				rowID = 1
 
-				var balanceSender int32 = 90
-				var amount int32 = 100
+				var amount int64 = 100
 				var toUser model.User = model.User{
 					UserName: "user1",
 					Password: "password1",
 				}
 				var toUserCreatedAt time.Time = time.Now()
 
-				rsGet := pgxmock.NewRows([]string{"id", "username", "password", "createdat"}).AddRow(rowID, toUser.UserName, toUser.Password, toUserCreatedAt)
+				rsGet := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(rowID, toUser.UserName, toUser.Password, toUserCreatedAt, "")
 				mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(toUser.UserName).WillReturnRows(rsGet).Times(1)
 
 				mockPool.ExpectBegin()
 
-				rsUpdateSender := pgxmock.NewRows([]string{"balance"}).AddRow(-balanceSender)
+				rsUpdateSender := pgxmock.NewRows([]string{"balance"}).AddRow(int64(0)).RowError(0, &pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "balance_coins_check"})
 				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(username, -amount).WillReturnRows(rsUpdateSender).Times(1)
 
 				mockPool.ExpectRollback()
 
-				err = repo.SendCoins(ctx, bo, user, toUser, int(amount))
+				err = repo.SendCoins(ctx, bo, user, toUser, amount)
 			})
 			AfterEach(func() {
 				err = mockPool.ExpectationsWereMet()
@@ -272,30 +295,41 @@ var _ = Describe("Repository", func() {
 			BeforeEach(func() {
 				rowID = 1
 
-				var balance int32 = 900
+				var balance int64 = 900
 				var itemType string = "book"
-				var itemPrice int32 = 50
+				var itemPrice int64 = 50
 
 				var item model.InventoryItem = model.InventoryItem{
 					Type:     itemType,
 					Quantity: 1,
 				}
 
-				rsGet := pgxmock.NewRows([]string{"id", "type", "price"}).AddRow(rowID, itemType, itemPrice)
-				mockPool.ExpectQuery("SELECT .+ FROM merch .+").WithArgs(itemType).WillReturnRows(rsGet).Times(1)
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(rowID, itemType, itemPrice, "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(itemType).WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs(itemType).WillReturnRows(rsVariants).Times(1)
 
 				mockPool.ExpectBegin()
 
 				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(balance)
 				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(username, -itemPrice).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(username, itemPrice).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs(itemType).WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs(itemType, pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
 
 				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(rowID)
-				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(username, itemType).WillReturnRows(rsCreate).Times(1)
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(username, itemType, int32(1), itemPrice, int64(0), pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(rowID, username, itemType, int32(1)).WillReturnRows(rsOrder).Times(1)
 
 				mockPool.ExpectCommit()
 				mockPool.ExpectRollback()
 
-				err = repo.BuyItem(ctx, bo, user, item)
+				err = repo.BuyItem(ctx, bo, user, item, "")
 			})
 			AfterEach(func() {
 				err = mockPool.ExpectationsWereMet()
@@ -307,30 +341,103 @@ var _ = Describe("Repository", func() {
 			})
 		})
 
+		When("several items are bought", func() {
+			BeforeEach(func() {
+				rowID = 1
+
+				var itemType string = "book"
+				var itemPrice int64 = 50
+
+				var item model.InventoryItem = model.InventoryItem{
+					Type:     itemType,
+					Quantity: 3,
+				}
+
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(rowID, itemType, itemPrice, "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(itemType).WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs(itemType).WillReturnRows(rsVariants).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(850))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(username, -3*itemPrice).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(username, 3*itemPrice).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs(itemType).WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs(itemType, pgtype.Int4{Int32: 3, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(rowID)
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(username, itemType, int32(3), itemPrice, int64(0), pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(rowID, username, itemType, int32(3)).WillReturnRows(rsOrder).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, item, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("charges the price of every item and returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the cost of the items is out of range", func() {
+			BeforeEach(func() {
+				rowID = 1
+
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(rowID, "pink-hoody", int64(math.MaxInt64/2), "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("pink-hoody").WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("pink-hoody").WillReturnRows(rsVariants).Times(1)
+
+				err = repo.BuyItem(ctx, bo, user, model.InventoryItem{Type: "pink-hoody", Quantity: 3}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns out of range error", func() {
+				Expect(err).To(Equal(repository.ErrOutOfRange))
+			})
+		})
+
 		When("balance is not enough to buy", func() {
 			BeforeEach(func() {
 				rowID = 1
 
-				var balance int32 = 90
 				var itemType string = "book"
-				var itemPrice int32 = 100
+				var itemPrice int64 = 100
 
 				var item model.InventoryItem = model.InventoryItem{
 					Type:     itemType,
 					Quantity: 1,
 				}
 
-				rsGet := pgxmock.NewRows([]string{"id", "type", "price"}).AddRow(rowID, itemType, itemPrice)
-				mockPool.ExpectQuery("SELECT .+ FROM merch .+").WithArgs(itemType).WillReturnRows(rsGet).Times(1)
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(rowID, itemType, itemPrice, "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(itemType).WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs(itemType).WillReturnRows(rsVariants).Times(1)
 
 				mockPool.ExpectBegin()
 
-				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(-balance)
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(0)).RowError(0, &pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "balance_coins_check"})
 				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(username, -itemPrice).WillReturnRows(rsWithdraw).Times(1)
 
 				mockPool.ExpectRollback()
 
-				err = repo.BuyItem(ctx, bo, user, item)
+				err = repo.BuyItem(ctx, bo, user, item, "")
 			})
 			AfterEach(func() {
 				err = mockPool.ExpectationsWereMet()
@@ -341,6 +448,48 @@ var _ = Describe("Repository", func() {
 				Expect(err).To(Equal(repository.ErrNegativeBalance))
 			})
 		})
+
+		When("the item is out of stock", func() {
+			BeforeEach(func() {
+				rowID = 1
+
+				var itemType string = "pink-hoody"
+				var itemPrice int64 = 500
+
+				var item model.InventoryItem = model.InventoryItem{
+					Type:     itemType,
+					Quantity: 1,
+				}
+
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(rowID, itemType, itemPrice, "", true, pgtype.Int4{Int32: 0, Valid: true})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs(itemType).WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs(itemType).WillReturnRows(rsVariants).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(500))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(username, -itemPrice).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(username, itemPrice).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs(itemType).WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs(itemType, pgtype.Int4{Int32: 1, Valid: true}).WillReturnError(&pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "merch_stock_check"})
+
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, item, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns out of stock error", func() {
+				Expect(err).To(Equal(repository.ErrOutOfStock))
+			})
+		})
 	})
 
 	Context("Calling GetBalance method", func() {
@@ -358,9 +507,9 @@ var _ = Describe("Repository", func() {
 			BeforeEach(func() {
 				rowID = 1
 
-				var balance int32 = 1000
+				var balance int64 = 1000
 
-				rs := pgxmock.NewRows([]string{"id", "username", "coins"}).AddRow(rowID, username, balance)
+				rs := pgxmock.NewRows([]string{"id", "username", "coins", "held"}).AddRow(rowID, username, balance, int64(300))
 				mockPool.ExpectQuery("SELECT .+ FROM balance .+").WithArgs(username).WillReturnRows(rs).Times(1)
 			})
 			AfterEach(func() {
@@ -371,7 +520,7 @@ var _ = Describe("Repository", func() {
 			It("returns a balance and nil error", func() {
 				result, err := repo.GetBalance(ctx, bo, user)
 				Expect(err).ShouldNot(HaveOccurred())
-				Expect(result).To(Equal(int(1000)))
+				Expect(result).To(Equal(model.Balance{Coins: 1000, Held: 300}))
 			})
 		})
 
@@ -390,7 +539,7 @@ var _ = Describe("Repository", func() {
 			It("returns zero balance and an error", func() {
 				result, err := repo.GetBalance(ctx, bo, user)
 				Expect(err).Should(HaveOccurred())
-				Expect(result).To(Equal(int(0)))
+				Expect(result).To(Equal(model.Balance{}))
 			})
 		})
 	})
@@ -470,7 +619,11 @@ var _ = Describe("Repository", func() {
 				var toUser string = ""
 				var amount int64 = 100
 
-				rs := pgxmock.NewRows([]string{"fromuser", "touser", "amount"}).AddRow(fromUser, toUser, amount)
+				rs := pgxmock.NewRows([]string{"fromuser", "touser", "kind", "amount"}).
+					AddRow(fromUser, toUser, "", amount).
+					AddRow("", "", "refund", int64(50)).
+					AddRow("", "", "expiry", int64(30)).
+					AddRow("", "", "capture", int64(20))
 				mockPool.ExpectQuery("SELECT .+ FROM history .+").WithArgs(username).WillReturnRows(rs).Times(1)
 			})
 			AfterEach(func() {
@@ -483,8 +636,13 @@ var _ = Describe("Repository", func() {
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(result.Received).Should(HaveLen(1))
 				Expect(result.Received[0].FromUser).Should(Equal("user1"))
-				Expect(result.Received[0].Amount).Should(Equal(100))
+				Expect(result.Received[0].Amount).Should(Equal(int64(100)))
 				Expect(result.Sent).Should(HaveLen(0))
+				Expect(result.Adjustments).Should(Equal([]model.CoinsAdjustment{
+					{Kind: "refund", Amount: 50},
+					{Kind: "expiry", Amount: -30},
+					{Kind: "capture", Amount: -20},
+				}))
 			})
 		})
 
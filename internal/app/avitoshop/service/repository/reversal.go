@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/coins"
+)
+
+// ReverseTransfer reverses coins transfer with a given ID by compensating history records.
+// If the balance of the user that has received the coins is not enough, the reversal is refused,
+// unless it is forced - then only the coins left on the balance are returned.
+func (r *Repository) ReverseTransfer(ctx context.Context, bo *backoff.ExponentialBackOff, id int, force bool) (model.Reversal, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the history record of the user that has sent coins
+	original, err := backoff.RetryWithData(func() (queries.History, error) {
+		return noRetryOnNoRows(qtx.GetHistoryRecord(ctx, int32(id)))
+	}, bo)
+	if err != nil {
+		return model.Reversal{}, noDataOnNoRows(err)
+	}
+
+	// Only the sender side of a transfer identifies the transfer
+	if original.Kind != kindTransfer || original.ToUser == "" {
+		return model.Reversal{}, ErrNoData
+	}
+
+	reversalOf := pgtype.Int4{Int32: original.ID, Valid: true}
+
+	// Check if the transfer has already been reversed
+	reversed, err := backoff.RetryWithData(func() (bool, error) {
+		return qtx.IsHistoryRecordReversed(ctx, reversalOf)
+	}, bo)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+	if reversed {
+		return model.Reversal{}, ErrAlreadyReversed
+	}
+
+	amount := original.Amount
+
+	// Balance can't become negative and held coins can't be withdrawn,
+	// so the forced reversal returns no more coins than the receiver has available
+	if force {
+		receiverBalance, err := backoff.RetryWithData(func() (queries.LockBalanceRow, error) {
+			return qtx.LockBalance(ctx, original.ToUser)
+		}, bo)
+		if err != nil {
+			return model.Reversal{}, err
+		}
+		amount = min(amount, max(receiverBalance.Coins-receiverBalance.Held, 0))
+	}
+	if amount == 0 {
+		return model.Reversal{}, ErrNegativeBalance
+	}
+
+	// Withdraw coins from the balance of user that has received them,
+	// DB returns the negative balance error if the balance is not enough
+	err = r.debit(ctx, bo, qtx, original.ToUser, amount)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	// Return coins to the balance of user that has sent them
+	err = r.credit(ctx, bo, qtx, original.Username, amount, kindReversal)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	// Create compensating history record for user that has received coins
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateHistoryEntry(ctx, queries.CreateHistoryEntryParams{
+			Username:   original.ToUser,
+			ToUser:     original.Username,
+			Amount:     amount,
+			Kind:       kindReversal,
+			ReversalOf: reversalOf,
+		})
+	}, bo)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	// Create compensating history record for user that has sent coins
+	reversalID, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateHistoryEntry(ctx, queries.CreateHistoryEntryParams{
+			Username:   original.Username,
+			FromUser:   original.ToUser,
+			Amount:     amount,
+			Kind:       kindReversal,
+			ReversalOf: reversalOf,
+		})
+	}, bo)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Reversal{}, err
+	}
+
+	return model.Reversal{
+		ID:         int(reversalID),
+		ReversalOf: int(original.ID),
+		Kind:       model.ReversalKindTransfer,
+		FromUser:   original.ToUser,
+		ToUser:     original.Username,
+		Amount:     amount,
+		ReversedAt: time.Now(),
+	}, nil
+}
+
+// ReversePurchase reverses item purchase with a given ID by compensating inventory and history records
+// and refunds the price paid to the user. The open order of the purchase is cancelled.
+func (r *Repository) ReversePurchase(ctx context.Context, bo *backoff.ExponentialBackOff, id int) (model.Reversal, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Get and lock the inventory record of the purchase
+	original, err := backoff.RetryWithData(func() (queries.Inventory, error) {
+		return noRetryOnNoRows(qtx.GetInventoryRecord(ctx, int32(id)))
+	}, bo)
+	if err != nil {
+		return model.Reversal{}, noDataOnNoRows(err)
+	}
+
+	if original.Kind != kindPurchase {
+		return model.Reversal{}, ErrNoData
+	}
+
+	reversal, err := r.reversePurchase(ctx, bo, qtx, original, "")
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Reversal{}, err
+	}
+
+	return reversal, nil
+}
+
+// reversePurchase reverses a given locked item purchase within a transaction on behalf of a given user.
+func (r *Repository) reversePurchase(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, original queries.Inventory, actor string) (model.Reversal, error) {
+	reversalOf := pgtype.Int4{Int32: original.ID, Valid: true}
+
+	// Check if the purchase has already been reversed
+	reversed, err := backoff.RetryWithData(func() (bool, error) {
+		return qtx.IsInventoryRecordReversed(ctx, reversalOf)
+	}, bo)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+	if reversed {
+		return model.Reversal{}, ErrAlreadyReversed
+	}
+
+	// Check that the user still has the items, they could have been transferred, listed or returned
+	if err = r.lockItems(ctx, bo, qtx, original.Username, original.Type, original.VariantID, int(original.Quantity)); err != nil {
+		return model.Reversal{}, err
+	}
+
+	refund, err := amountPaid(original)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	// Refund the price paid to the balance of the user
+	err = r.credit(ctx, bo, qtx, original.Username, refund, kindRefund)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	// Create compensating inventory record
+	reversalID, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:   original.Username,
+			Type:       original.Type,
+			Quantity:   -original.Quantity,
+			Kind:       kindReversal,
+			PricePaid:  original.PricePaid,
+			ReversalOf: reversalOf,
+			VariantID:  original.VariantID,
+		})
+	}, bo)
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	// Put the items back in stock
+	if err = r.releaseStock(ctx, bo, qtx, original.Type, original.Quantity); err != nil {
+		return model.Reversal{}, err
+	}
+	if err = r.releaseVariantStock(ctx, bo, qtx, original.VariantID, original.Quantity); err != nil {
+		return model.Reversal{}, err
+	}
+
+	// The items won't be handed over anymore
+	if err = r.cancelPurchaseOrder(ctx, bo, qtx, original.ID, actor); err != nil {
+		return model.Reversal{}, err
+	}
+
+	// Create history record of the refund, if there is anything refunded
+	if refund > 0 {
+		_, err = backoff.RetryWithData(func() (int32, error) {
+			return noRetryOnViolation(qtx.CreateHistoryEntry(ctx, queries.CreateHistoryEntryParams{
+				Username: original.Username,
+				Amount:   refund,
+				Kind:     kindRefund,
+			}))
+		}, bo)
+		if err != nil {
+			return model.Reversal{}, err
+		}
+	}
+
+	return model.Reversal{
+		ID:         int(reversalID),
+		ReversalOf: int(original.ID),
+		Kind:       model.ReversalKindPurchase,
+		ToUser:     original.Username,
+		Item:       original.Type,
+		Quantity:   int(original.Quantity),
+		Amount:     refund,
+		ReversedAt: time.Now(),
+	}, nil
+}
+
+// GetReversals returns reversals of users transfers and purchases.
+func (r *Repository) GetReversals(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.Reversal, error) {
+	// Get reversals of user transfers from DB
+	historyQuery, err := backoff.RetryWithData(func() ([]queries.GetHistoryReversalsRow, error) {
+		return r.q.GetHistoryReversals(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get reversals of user purchases from DB
+	inventoryQuery, err := backoff.RetryWithData(func() ([]queries.GetInventoryReversalsRow, error) {
+		return r.q.GetInventoryReversals(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	reversals := make([]model.Reversal, 0, len(historyQuery)+len(inventoryQuery))
+	for _, rec := range historyQuery {
+		reversals = append(reversals, model.Reversal{
+			ID:         int(rec.ID),
+			ReversalOf: int(rec.ReversalOf.Int32),
+			Kind:       model.ReversalKindTransfer,
+			FromUser:   rec.FromUser,
+			ToUser:     rec.ToUser,
+			Amount:     rec.Amount,
+			ReversedAt: rec.SentAt,
+		})
+	}
+	for _, rec := range inventoryQuery {
+		// The refund is the cost of the purchase less the discount of the purchase, as it has been paid
+		cost, err := coins.Mul(int64(-rec.Quantity), rec.PricePaid)
+		if err != nil {
+			return nil, err
+		}
+		amount := cost - rec.Discount
+		reversals = append(reversals, model.Reversal{
+			ID:         int(rec.ID),
+			ReversalOf: int(rec.ReversalOf.Int32),
+			Kind:       model.ReversalKindPurchase,
+			ToUser:     user.UserName,
+			Item:       rec.Type,
+			Quantity:   int(-rec.Quantity),
+			Amount:     amount,
+			ReversedAt: rec.BoughtAt,
+		})
+	}
+
+	return reversals, nil
+}
+
+// GetOperations returns users transfers and purchases.
+func (r *Repository) GetOperations(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Operations, error) {
+	// Get transfers sent or received by user from DB
+	transfersQuery, err := backoff.RetryWithData(func() ([]queries.GetTransfersRow, error) {
+		return r.q.GetTransfers(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.Operations{}, err
+	}
+
+	// Get user purchases from DB
+	purchases, err := r.GetPurchases(ctx, bo, user)
+	if err != nil {
+		return model.Operations{}, err
+	}
+
+	transfers := make([]model.Transfer, 0, len(transfersQuery))
+	for _, rec := range transfersQuery {
+		transfers = append(transfers, model.Transfer{
+			ID:       int(rec.ID),
+			FromUser: rec.Username,
+			ToUser:   rec.ToUser,
+			Amount:   rec.Amount,
+			SentAt:   rec.SentAt,
+			Reversed: rec.Reversed,
+		})
+	}
+
+	return model.Operations{
+		Transfers: transfers,
+		Purchases: purchases,
+	}, nil
+}
+
+// GetPurchases returns users purchases with the prices paid, earliest first.
+func (r *Repository) GetPurchases(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.Purchase, error) {
+	// Get user purchases from DB
+	purchasesQuery, err := backoff.RetryWithData(func() ([]queries.GetPurchasesRow, error) {
+		return r.q.GetPurchases(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	purchases := make([]model.Purchase, 0, len(purchasesQuery))
+	for _, rec := range purchasesQuery {
+		purchases = append(purchases, model.Purchase{
+			ID:        int(rec.ID),
+			Type:      rec.Type,
+			Quantity:  int(rec.Quantity),
+			PricePaid: rec.PricePaid,
+			Discount:  rec.Discount,
+			BoughtAt:  rec.BoughtAt,
+			Reversed:  rec.Reversed,
+		})
+	}
+
+	return purchases, nil
+}
+
+// amountPaid returns the amount of coins paid for a purchase, that is the cost of the purchase less the discount.
+func amountPaid(purchase queries.Inventory) (int64, error) {
+	cost, err := coins.Mul(purchase.PricePaid, int64(purchase.Quantity))
+	if err != nil {
+		return 0, err
+	}
+	return cost - purchase.Discount, nil
+}
@@ -0,0 +1,399 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository reversals", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		reversal model.Reversal
+
+		historyColumns   []string
+		inventoryColumns []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		historyColumns = []string{"id", "username", "from_user", "to_user", "amount", "sent_at", "kind", "reversal_of", "coin_operation_id", "wallet_id"}
+		inventoryColumns = []string{"id", "username", "type", "quantity", "bought_at", "kind", "price_paid", "reversal_of", "discount", "variant_id"}
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling ReverseTransfer method", func() {
+		var (
+			transferID int32 = 1
+			amount     int64 = 100
+			sender           = "user"
+			receiver         = "user1"
+			reversalOf       = pgtype.Int4{Int32: transferID, Valid: true}
+
+			receiverBalance int64 = 30
+		)
+
+		When("the transfer can be reversed", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(historyColumns).AddRow(transferID, sender, "", receiver, amount, time.Now(), "transfer", pgtype.Int4{}, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM history .+").WithArgs(transferID).WillReturnRows(rsGet).Times(1)
+
+				rsReversed := pgxmock.NewRows([]string{"reversed"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+").WithArgs(reversalOf).WillReturnRows(rsReversed).Times(1)
+
+				rsUpdateReceiver := pgxmock.NewRows([]string{"coins"}).AddRow(int64(900))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(receiver, -amount).WillReturnRows(rsUpdateReceiver).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(receiver, amount).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsUpdateSender := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1000))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(sender, amount).WillReturnRows(rsUpdateSender).Times(1)
+
+				rsLotSender := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(sender, "reversal", amount, pgxmock.AnyArg()).WillReturnRows(rsLotSender).Times(1)
+
+				rsCreateReceiver := pgxmock.NewRows([]string{"id"}).AddRow(int32(2))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(receiver, "", sender, amount, "reversal", reversalOf).WillReturnRows(rsCreateReceiver).Times(1)
+
+				rsCreateSender := pgxmock.NewRows([]string{"id"}).AddRow(int32(3))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(sender, receiver, "", amount, "reversal", reversalOf).WillReturnRows(rsCreateSender).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReverseTransfer(ctx, bo, int(transferID), false)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the reversal and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reversal.ID).To(Equal(3))
+				Expect(reversal.ReversalOf).To(Equal(int(transferID)))
+				Expect(reversal.FromUser).To(Equal(receiver))
+				Expect(reversal.ToUser).To(Equal(sender))
+				Expect(reversal.Amount).To(Equal(amount))
+			})
+		})
+
+		When("the receiver balance is not enough and the reversal is not forced", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(historyColumns).AddRow(transferID, sender, "", receiver, amount, time.Now(), "transfer", pgtype.Int4{}, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM history .+").WithArgs(transferID).WillReturnRows(rsGet).Times(1)
+
+				rsReversed := pgxmock.NewRows([]string{"reversed"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+").WithArgs(reversalOf).WillReturnRows(rsReversed).Times(1)
+
+				rsUpdateReceiver := pgxmock.NewRows([]string{"coins"}).AddRow(int64(0)).RowError(0, &pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "balance_coins_check"})
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(receiver, -amount).WillReturnRows(rsUpdateReceiver).Times(1)
+
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReverseTransfer(ctx, bo, int(transferID), false)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns negative balance error", func() {
+				Expect(err).To(Equal(repository.ErrNegativeBalance))
+			})
+		})
+
+		When("the receiver coins are held and the reversal is forced", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(historyColumns).AddRow(transferID, sender, "", receiver, amount, time.Now(), "transfer", pgtype.Int4{}, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM history .+").WithArgs(transferID).WillReturnRows(rsGet).Times(1)
+
+				rsReversed := pgxmock.NewRows([]string{"reversed"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+").WithArgs(reversalOf).WillReturnRows(rsReversed).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(receiverBalance, receiverBalance)
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(receiver).WillReturnRows(rsLock).Times(1)
+
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReverseTransfer(ctx, bo, int(transferID), true)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns negative balance error", func() {
+				Expect(err).To(Equal(repository.ErrNegativeBalance))
+			})
+		})
+
+		When("the receiver balance is not enough and the reversal is forced", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(historyColumns).AddRow(transferID, sender, "", receiver, amount, time.Now(), "transfer", pgtype.Int4{}, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM history .+").WithArgs(transferID).WillReturnRows(rsGet).Times(1)
+
+				rsReversed := pgxmock.NewRows([]string{"reversed"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+").WithArgs(reversalOf).WillReturnRows(rsReversed).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(receiverBalance, int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(receiver).WillReturnRows(rsLock).Times(1)
+
+				rsUpdateReceiver := pgxmock.NewRows([]string{"coins"}).AddRow(int64(0))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(receiver, -receiverBalance).WillReturnRows(rsUpdateReceiver).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(receiver, receiverBalance).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsUpdateSender := pgxmock.NewRows([]string{"coins"}).AddRow(int64(930))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(sender, receiverBalance).WillReturnRows(rsUpdateSender).Times(1)
+
+				rsLotSender := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(sender, "reversal", receiverBalance, pgxmock.AnyArg()).WillReturnRows(rsLotSender).Times(1)
+
+				rsCreateReceiver := pgxmock.NewRows([]string{"id"}).AddRow(int32(2))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(receiver, "", sender, receiverBalance, "reversal", reversalOf).WillReturnRows(rsCreateReceiver).Times(1)
+
+				rsCreateSender := pgxmock.NewRows([]string{"id"}).AddRow(int32(3))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(sender, receiver, "", receiverBalance, "reversal", reversalOf).WillReturnRows(rsCreateSender).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReverseTransfer(ctx, bo, int(transferID), true)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the reversal of the coins left on the receiver balance", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reversal.Amount).To(Equal(receiverBalance))
+			})
+		})
+
+		When("the transfer has already been reversed", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(historyColumns).AddRow(transferID, sender, "", receiver, amount, time.Now(), "transfer", pgtype.Int4{}, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM history .+").WithArgs(transferID).WillReturnRows(rsGet).Times(1)
+
+				rsReversed := pgxmock.NewRows([]string{"reversed"}).AddRow(true)
+				mockPool.ExpectQuery("SELECT EXISTS .+").WithArgs(reversalOf).WillReturnRows(rsReversed).Times(1)
+
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReverseTransfer(ctx, bo, int(transferID), false)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns already reversed error", func() {
+				Expect(err).To(Equal(repository.ErrAlreadyReversed))
+			})
+		})
+
+		When("the history record is not a transfer", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(historyColumns).AddRow(transferID, sender, "", receiver, amount, time.Now(), "reversal", reversalOf, pgtype.Int4{}, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM history .+").WithArgs(transferID).WillReturnRows(rsGet).Times(1)
+
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReverseTransfer(ctx, bo, int(transferID), false)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).To(Equal(repository.ErrNoData))
+			})
+		})
+	})
+
+	Context("Calling ReversePurchase method", func() {
+		var (
+			purchaseID int32 = 5
+			price      int64 = 50
+			username         = "user"
+			itemType         = "book"
+			reversalOf       = pgtype.Int4{Int32: purchaseID, Valid: true}
+		)
+
+		When("the purchase can be reversed", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(inventoryColumns).AddRow(purchaseID, username, itemType, int32(1), time.Now(), "purchase", price, pgtype.Int4{}, int64(0), pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM inventory .+").WithArgs(purchaseID).WillReturnRows(rsGet).Times(1)
+
+				rsReversed := pgxmock.NewRows([]string{"reversed"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+").WithArgs(reversalOf).WillReturnRows(rsReversed).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(950), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(username).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(username, itemType, pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1000))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(username, price).WillReturnRows(rsUpdate).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(username, "refund", price, pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				rsCreateInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(6))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(username, itemType, int32(-1), "reversal", price, reversalOf, pgtype.Int4{}).WillReturnRows(rsCreateInventory).Times(1)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs(itemType, pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectExec("UPDATE orders SET .+").WithArgs(purchaseID, "").WillReturnResult(pgxmock.NewResult("UPDATE", 0)).Times(1)
+
+				rsCreateHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(username, "", "", price, "refund", pgtype.Int4{}).WillReturnRows(rsCreateHistory).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReversePurchase(ctx, bo, int(purchaseID))
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the reversal and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reversal.ID).To(Equal(6))
+				Expect(reversal.ReversalOf).To(Equal(int(purchaseID)))
+				Expect(reversal.Item).To(Equal(itemType))
+				Expect(reversal.Amount).To(Equal(price))
+			})
+		})
+
+		When("the user no longer has the purchased items", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(inventoryColumns).AddRow(purchaseID, username, itemType, int32(1), time.Now(), "purchase", price, pgtype.Int4{}, int64(0), pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM inventory .+").WithArgs(purchaseID).WillReturnRows(rsGet).Times(1)
+
+				rsReversed := pgxmock.NewRows([]string{"reversed"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+").WithArgs(reversalOf).WillReturnRows(rsReversed).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(950), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(username).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(0))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(username, itemType, pgtype.Int4{}).WillReturnRows(rsQuantity).Times(1)
+
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReversePurchase(ctx, bo, int(purchaseID))
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns not enough items error", func() {
+				Expect(err).Should(MatchError(repository.ErrNotEnoughItems))
+			})
+		})
+
+		When("there is no such purchase", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsGet := pgxmock.NewRows(inventoryColumns)
+				mockPool.ExpectQuery("SELECT .+ FROM inventory .+").WithArgs(purchaseID).WillReturnRows(rsGet).Times(1)
+
+				mockPool.ExpectRollback()
+
+				reversal, err = repo.ReversePurchase(ctx, bo, int(purchaseID))
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).To(Equal(repository.ErrNoData))
+			})
+		})
+	})
+
+	Context("Calling GetReversals method", func() {
+		var reversals []model.Reversal
+
+		JustBeforeEach(func() {
+			rsHistory := pgxmock.NewRows([]string{"id", "from_user", "to_user", "amount", "reversal_of", "sent_at"})
+			mockPool.ExpectQuery("SELECT .+ FROM history .+").WithArgs("user").WillReturnRows(rsHistory).Times(1)
+
+			rsInventory := pgxmock.NewRows([]string{"id", "type", "quantity", "price_paid", "discount", "reversal_of", "bought_at"}).
+				AddRow(int32(2), "hoody", int32(-2), int64(300), int64(60), pgtype.Int4{Int32: 1, Valid: true}, time.Now())
+			mockPool.ExpectQuery("SELECT .+ FROM inventory r .+").WithArgs("user").WillReturnRows(rsInventory).Times(1)
+
+			reversals, err = repo.GetReversals(ctx, bo, model.User{UserName: "user"})
+		})
+		AfterEach(func() {
+			err = mockPool.ExpectationsWereMet()
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("reports the refund of a purchase less its discount", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(reversals).Should(HaveLen(1))
+			Expect(reversals[0].Quantity).To(Equal(2))
+			Expect(reversals[0].Amount).To(Equal(int64(540)))
+		})
+	})
+})
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// Names of economy settings in DB.
+const (
+	settingWelcomeBonus      = "welcome_bonus"
+	settingReferralBonus     = "referral_bonus"
+	settingMinTransferAmount = "min_transfer_amount"
+)
+
+// GetSettings returns economy settings, the settings absent in DB are taken from the given defaults.
+func (r *Repository) GetSettings(ctx context.Context, bo *backoff.ExponentialBackOff, defaults model.Settings) (model.Settings, error) {
+	// Get settings from DB
+	settingsQuery, err := backoff.RetryWithData(func() ([]queries.GetSettingsRow, error) {
+		return r.q.GetSettings(ctx)
+	}, bo)
+	if err != nil {
+		return model.Settings{}, err
+	}
+
+	settings := defaults
+	for _, setting := range settingsQuery {
+		switch setting.Name {
+		case settingWelcomeBonus:
+			settings.WelcomeBonus = setting.Value
+		case settingReferralBonus:
+			settings.ReferralBonus = setting.Value
+		case settingMinTransferAmount:
+			settings.MinTransferAmount = setting.Value
+		}
+	}
+
+	return settings, nil
+}
+
+// UpdateSettings saves given economy settings on behalf of a given user.
+func (r *Repository) UpdateSettings(ctx context.Context, bo *backoff.ExponentialBackOff, update model.SettingsUpdate, actor string) error {
+	// Collect given settings in a stable order
+	values := make([]queries.UpsertSettingParams, 0, 3)
+	if update.WelcomeBonus != nil {
+		values = append(values, queries.UpsertSettingParams{Name: settingWelcomeBonus, Value: *update.WelcomeBonus, UpdatedBy: actor})
+	}
+	if update.ReferralBonus != nil {
+		values = append(values, queries.UpsertSettingParams{Name: settingReferralBonus, Value: *update.ReferralBonus, UpdatedBy: actor})
+	}
+	if update.MinTransferAmount != nil {
+		values = append(values, queries.UpsertSettingParams{Name: settingMinTransferAmount, Value: *update.MinTransferAmount, UpdatedBy: actor})
+	}
+
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Save the settings
+	for _, value := range values {
+		_, err = backoff.RetryWithData(func() (struct{}, error) {
+			return noRetryOnViolation(struct{}{}, qtx.UpsertSetting(ctx, value))
+		}, bo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GrantReferralBonus grants a given amount of coins to the user, that has invited new user.
+func (r *Repository) GrantReferralBonus(ctx context.Context, bo *backoff.ExponentialBackOff, referrer model.User, amount int64) error {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Update the balance of the referrer
+	err = r.credit(ctx, bo, qtx, referrer.UserName, amount, kindReferral)
+	if err != nil {
+		return err
+	}
+
+	// Create history record of the bonus
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateHistoryEntry(ctx, queries.CreateHistoryEntryParams{
+			Username: referrer.UserName,
+			Amount:   amount,
+			Kind:     kindReferral,
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
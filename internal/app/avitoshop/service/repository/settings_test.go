@@ -0,0 +1,164 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository settings", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		defaults model.Settings
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		defaults = model.Settings{
+			WelcomeBonus:      1000,
+			ReferralBonus:     0,
+			MinTransferAmount: 1,
+		}
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling GetSettings method", func() {
+		When("some settings have been changed", func() {
+			BeforeEach(func() {
+				rs := pgxmock.NewRows([]string{"name", "value"}).AddRow("welcome_bonus", int64(500)).AddRow("referral_bonus", int64(100))
+				mockPool.ExpectQuery("SELECT .+ FROM settings").WillReturnRows(rs).Times(1)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns changed settings and defaults for the rest", func() {
+				settings, err := repo.GetSettings(ctx, bo, defaults)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(settings).To(Equal(model.Settings{
+					WelcomeBonus:      500,
+					ReferralBonus:     100,
+					MinTransferAmount: 1,
+				}))
+			})
+		})
+	})
+
+	Context("Calling UpdateSettings method", func() {
+		When("two settings are given", func() {
+			BeforeEach(func() {
+				var welcomeBonus int64 = 500
+				var minTransferAmount int64 = 10
+
+				mockPool.ExpectBegin()
+				mockPool.ExpectExec("INSERT INTO settings .+").WithArgs("welcome_bonus", welcomeBonus, "admin").WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+				mockPool.ExpectExec("INSERT INTO settings .+").WithArgs("min_transfer_amount", minTransferAmount, "admin").WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.UpdateSettings(ctx, bo, model.SettingsUpdate{
+					WelcomeBonus:      &welcomeBonus,
+					MinTransferAmount: &minTransferAmount,
+				}, "admin")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Calling GrantReferralBonus method", func() {
+		var (
+			referrer       = model.User{UserName: "user1"}
+			amount   int64 = 100
+		)
+
+		When("the referrer exists", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1100))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(referrer.UserName, amount).WillReturnRows(rsUpdate).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(referrer.UserName, "referral", amount, pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(referrer.UserName, "", "", amount, "referral", pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.GrantReferralBonus(ctx, bo, referrer, amount)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the referrer doesn't exist", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"})
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(referrer.UserName, amount).WillReturnRows(rsUpdate).Times(1)
+
+				mockPool.ExpectRollback()
+
+				err = repo.GrantReferralBonus(ctx, bo, referrer, amount)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no data error", func() {
+				Expect(err).To(Equal(repository.ErrNoData))
+			})
+		})
+	})
+})
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// CreateMerchVariant adds a variant to merch.
+func (r *Repository) CreateMerchVariant(ctx context.Context, bo *backoff.ExponentialBackOff, variant model.MerchVariant) (model.MerchVariant, error) {
+	// Check that the merch exists
+	if _, err := r.GetCatalogItem(ctx, bo, variant.Type); err != nil {
+		return model.MerchVariant{}, err
+	}
+
+	// Create variant, DB returns no rows if the variant with the same attributes already exists
+	created, err := backoff.RetryWithData(func() (queries.MerchVariant, error) {
+		return noRetryOnNoRows(r.q.CreateMerchVariant(ctx, queries.CreateMerchVariantParams{
+			Type:  variant.Type,
+			Size:  variant.Size,
+			Color: variant.Color,
+			Stock: fromStock(variant.Stock),
+		}))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.MerchVariant{}, ErrConflict
+	}
+	if err != nil {
+		return model.MerchVariant{}, err
+	}
+
+	return toMerchVariant(created), nil
+}
+
+// GetMerchVariants returns variants of merch of a given type.
+func (r *Repository) GetMerchVariants(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) ([]model.MerchVariant, error) {
+	// Get variants from DB
+	variantsQuery, err := backoff.RetryWithData(func() ([]queries.MerchVariant, error) {
+		return r.q.GetMerchVariants(ctx, itemType)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]model.MerchVariant, 0, len(variantsQuery))
+	for _, rec := range variantsQuery {
+		variants = append(variants, toMerchVariant(rec))
+	}
+
+	return variants, nil
+}
+
+// RestockMerchVariant adds a quantity of a variant of merch to its stock.
+// Stock of the variant, that is not tracked, starts being tracked from the given quantity.
+func (r *Repository) RestockMerchVariant(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, id int, quantity int) (model.MerchVariant, error) {
+	// Update the stock, DB returns the out of range error if the stock overflows
+	variant, err := backoff.RetryWithData(func() (queries.MerchVariant, error) {
+		return noRetryOnNoRows(noRetryOnViolation(r.q.RestockMerchVariant(ctx, queries.RestockMerchVariantParams{
+			ID:    int32(id),
+			Type:  itemType,
+			Stock: pgtype.Int4{Int32: int32(quantity), Valid: true},
+		})))
+	}, bo)
+	if err != nil {
+		return model.MerchVariant{}, noDataOnNoRows(err)
+	}
+
+	return toMerchVariant(variant), nil
+}
+
+// GetInventoryVariants returns the inventory of a user by type and variant of merch.
+func (r *Repository) GetInventoryVariants(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.InventoryItem, error) {
+	// Get inventory from DB
+	inventoryQuery, err := backoff.RetryWithData(func() ([]queries.GetInventoryVariantsRow, error) {
+		return r.q.GetInventoryVariants(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := make([]model.InventoryItem, 0, len(inventoryQuery))
+	for _, rec := range inventoryQuery {
+		inventory = append(inventory, model.InventoryItem{
+			Type:     rec.Type,
+			Size:     rec.Size,
+			Color:    rec.Color,
+			Quantity: int(rec.Quantity),
+		})
+	}
+
+	return inventory, nil
+}
+
+// selectVariant returns ID of the variant of merch selected by size and color of an item.
+// The ID is not valid, if no variant is selected and merch has no variants.
+func (r *Repository) selectVariant(ctx context.Context, bo *backoff.ExponentialBackOff, item model.InventoryItem) (pgtype.Int4, error) {
+	if item.Size == "" && item.Color == "" {
+		// Check that merch can be bought without a variant
+		hasVariants, err := backoff.RetryWithData(func() (bool, error) {
+			return r.q.HasMerchVariants(ctx, item.Type)
+		}, bo)
+		if err != nil {
+			return pgtype.Int4{}, err
+		}
+		if hasVariants {
+			return pgtype.Int4{}, ErrNoVariant
+		}
+		return pgtype.Int4{}, nil
+	}
+
+	return r.findVariant(ctx, bo, item)
+}
+
+// findVariant returns ID of the variant of merch with size and color of an item.
+// The ID is not valid, if neither size nor color is given - the item is of merch without a variant.
+func (r *Repository) findVariant(ctx context.Context, bo *backoff.ExponentialBackOff, item model.InventoryItem) (pgtype.Int4, error) {
+	if item.Size == "" && item.Color == "" {
+		return pgtype.Int4{}, nil
+	}
+
+	// Get the variant from DB
+	variant, err := backoff.RetryWithData(func() (queries.MerchVariant, error) {
+		return noRetryOnNoRows(r.q.GetMerchVariant(ctx, queries.GetMerchVariantParams{
+			Type:  item.Type,
+			Size:  item.Size,
+			Color: item.Color,
+		}))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgtype.Int4{}, ErrNoVariant
+	}
+	if err != nil {
+		return pgtype.Int4{}, err
+	}
+
+	return pgtype.Int4{Int32: variant.ID, Valid: true}, nil
+}
+
+// reserveVariantStock takes a quantity of a variant of merch out of stock, if the stock of the variant is tracked.
+func (r *Repository) reserveVariantStock(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, variantID pgtype.Int4, quantity int32) error {
+	if !variantID.Valid {
+		return nil
+	}
+
+	_, err := backoff.RetryWithData(func() (struct{}, error) {
+		return noRetryOnViolation(struct{}{}, qtx.ReserveVariantStock(ctx, queries.ReserveVariantStockParams{
+			ID:    variantID.Int32,
+			Stock: pgtype.Int4{Int32: quantity, Valid: true},
+		}))
+	}, bo)
+	return err
+}
+
+// releaseVariantStock puts a quantity of a variant of merch back in stock, if the stock of the variant is tracked.
+func (r *Repository) releaseVariantStock(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, variantID pgtype.Int4, quantity int32) error {
+	if !variantID.Valid {
+		return nil
+	}
+
+	_, err := backoff.RetryWithData(func() (struct{}, error) {
+		return noRetryOnViolation(struct{}{}, qtx.ReleaseVariantStock(ctx, queries.ReleaseVariantStockParams{
+			ID:    variantID.Int32,
+			Stock: pgtype.Int4{Int32: quantity, Valid: true},
+		}))
+	}, bo)
+	return err
+}
+
+// toMerchVariant converts merch variant record of DB to the model.
+func toMerchVariant(rec queries.MerchVariant) model.MerchVariant {
+	return model.MerchVariant{
+		ID:    int(rec.ID),
+		Type:  rec.Type,
+		Size:  rec.Size,
+		Color: rec.Color,
+		Stock: toStock(rec.Stock),
+	}
+}
@@ -0,0 +1,350 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository variants", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		user = model.User{UserName: "user"}
+
+		merchColumns   = []string{"id", "type", "price", "description", "available", "stock"}
+		variantColumns = []string{"id", "type", "size", "color", "stock", "created_at"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling BuyItem method for merch with variants", func() {
+		JustBeforeEach(func() {
+			rsGet := pgxmock.NewRows(merchColumns).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnRows(rsGet).Times(1)
+		})
+
+		When("the variant is selected", func() {
+			JustBeforeEach(func() {
+				rsVariant := pgxmock.NewRows(variantColumns).AddRow(int32(4), "hoody", "M", "black", pgtype.Int4{Int32: 2, Valid: true}, time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM merch_variants .+").WithArgs("hoody", "M", "black").WillReturnRows(rsVariant).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(700))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-300)).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(300)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				mockPool.ExpectExec("UPDATE merch_variants SET stock .+").WithArgs(int32(4), pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, "hoody", int32(1), int64(300), int64(0), pgtype.Int4{Int32: 4, Valid: true}).WillReturnRows(rsCreate).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(7), user.UserName, "hoody", int32(1)).WillReturnRows(rsOrder).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Size: "M", Color: "black", Quantity: 1}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("records the variant in the inventory and returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("no variant is selected", func() {
+			JustBeforeEach(func() {
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(true)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("hoody").WillReturnRows(rsVariants).Times(1)
+
+				err = repo.BuyItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Quantity: 1}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no variant error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoVariant))
+			})
+		})
+
+		When("the selected variant doesn't exist", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectQuery("SELECT .+ FROM merch_variants .+").WithArgs("hoody", "XXL", "").WillReturnError(sql.ErrNoRows)
+
+				err = repo.BuyItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Size: "XXL", Quantity: 1}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no variant error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoVariant))
+			})
+		})
+
+		When("the variant is out of stock", func() {
+			JustBeforeEach(func() {
+				rsVariant := pgxmock.NewRows(variantColumns).AddRow(int32(4), "hoody", "M", "black", pgtype.Int4{Int32: 0, Valid: true}, time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM merch_variants .+").WithArgs("hoody", "M", "black").WillReturnRows(rsVariant).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsWithdraw := pgxmock.NewRows([]string{"balance"}).AddRow(int64(700))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(user.UserName, int64(-300)).WillReturnRows(rsWithdraw).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(user.UserName, int64(300)).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("hoody").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+				mockPool.ExpectExec("UPDATE merch_variants SET stock .+").WithArgs(int32(4), pgtype.Int4{Int32: 1, Valid: true}).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "merch_variants_stock_check"})
+
+				mockPool.ExpectRollback()
+
+				err = repo.BuyItem(ctx, bo, user, model.InventoryItem{Type: "hoody", Size: "M", Color: "black", Quantity: 1}, "")
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns out of stock error", func() {
+				Expect(err).Should(MatchError(repository.ErrOutOfStock))
+			})
+		})
+	})
+
+	Context("Calling TransferItems method for merch with variants", func() {
+		var toUser = model.User{UserName: "user1"}
+
+		When("the sender owns enough items of the variant", func() {
+			JustBeforeEach(func() {
+				rsUser := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(int32(2), toUser.UserName, "", time.Now(), "")
+				mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(toUser.UserName).WillReturnRows(rsUser).Times(1)
+
+				rsVariant := pgxmock.NewRows(variantColumns).AddRow(int32(4), "hoody", "M", "black", pgtype.Int4{}, time.Now())
+				mockPool.ExpectQuery("SELECT .+ FROM merch_variants .+").WithArgs("hoody", "M", "black").WillReturnRows(rsVariant).Times(1)
+
+				mockPool.ExpectBegin()
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsLock).Times(1)
+
+				rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+				mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(user.UserName, "hoody", pgtype.Int4{Int32: 4, Valid: true}).WillReturnRows(rsQuantity).Times(1)
+
+				rsSender := pgxmock.NewRows([]string{"id"}).AddRow(int32(10))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(user.UserName, "hoody", int32(-1), "transfer", int64(0), pgtype.Int4{}, pgtype.Int4{Int32: 4, Valid: true}).WillReturnRows(rsSender).Times(1)
+
+				rsReceiver := pgxmock.NewRows([]string{"id"}).AddRow(int32(11))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(toUser.UserName, "hoody", int32(1), "transfer", int64(0), pgtype.Int4{}, pgtype.Int4{Int32: 4, Valid: true}).WillReturnRows(rsReceiver).Times(1)
+
+				rsTransfer := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int32(1), time.Now())
+				mockPool.ExpectQuery("INSERT INTO item_transfers .+ VALUES .+").WithArgs(user.UserName, toUser.UserName, "hoody", int32(1)).WillReturnRows(rsTransfer).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				_, err = repo.TransferItems(ctx, bo, user, toUser, model.InventoryItem{Type: "hoody", Size: "M", Color: "black", Quantity: 1})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("moves the items of the variant and returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the variant doesn't exist", func() {
+			JustBeforeEach(func() {
+				rsUser := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(int32(2), toUser.UserName, "", time.Now(), "")
+				mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(toUser.UserName).WillReturnRows(rsUser).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM merch_variants .+").WithArgs("hoody", "XXL", "").WillReturnError(sql.ErrNoRows)
+
+				_, err = repo.TransferItems(ctx, bo, user, toUser, model.InventoryItem{Type: "hoody", Size: "XXL", Quantity: 1})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no variant error", func() {
+				Expect(err).Should(MatchError(repository.ErrNoVariant))
+			})
+		})
+	})
+
+	Context("Calling DeclineGift method for a gift of a variant", func() {
+		var (
+			buyer       = model.User{UserName: "user1"}
+			giftColumns = []string{"id", "from_user", "to_user", "type", "price_paid", "message", "status", "inventory_id", "created_at", "declined_at", "variant_id"}
+		)
+
+		JustBeforeEach(func() {
+			mockPool.ExpectBegin()
+
+			rsGift := pgxmock.NewRows(giftColumns).AddRow(int32(3), buyer.UserName, user.UserName, "hoody", int64(300), "", "sent", int32(5), time.Now(), pgtype.Timestamp{}, pgtype.Int4{Int32: 4, Valid: true})
+			mockPool.ExpectQuery("SELECT .+ FROM gifts .+").WithArgs(int32(3)).WillReturnRows(rsGift).Times(1)
+
+			rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+			mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsLock).Times(1)
+
+			rsQuantity := pgxmock.NewRows([]string{"quantity"}).AddRow(int64(1))
+			mockPool.ExpectQuery("SELECT COALESCE.+ FROM inventory .+").WithArgs(user.UserName, "hoody", pgtype.Int4{Int32: 4, Valid: true}).WillReturnRows(rsQuantity).Times(1)
+
+			rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(6))
+			mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").
+				WithArgs(user.UserName, "hoody", int32(-1), "decline", int64(300), pgtype.Int4{Int32: 5, Valid: true}, pgtype.Int4{Int32: 4, Valid: true}).WillReturnRows(rsInventory).Times(1)
+
+			mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("hoody", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+			mockPool.ExpectExec("UPDATE merch_variants SET stock .+").WithArgs(int32(4), pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+			mockPool.ExpectExec("UPDATE orders SET .+").WithArgs(int32(5), user.UserName).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+			rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1000))
+			mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(buyer.UserName, int64(300)).WillReturnRows(rsUpdate).Times(1)
+
+			rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+			mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(buyer.UserName, "refund", int64(300), pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+			rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(7))
+			mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(buyer.UserName, user.UserName, "", int64(300), "refund", pgtype.Int4{}).WillReturnRows(rsHistory).Times(1)
+
+			rsDecline := pgxmock.NewRows([]string{"declined_at"}).AddRow(pgtype.Timestamp{Time: time.Now(), Valid: true})
+			mockPool.ExpectQuery("UPDATE gifts SET .+").WithArgs(int32(3)).WillReturnRows(rsDecline).Times(1)
+
+			mockPool.ExpectCommit()
+			mockPool.ExpectRollback()
+
+			_, err = repo.DeclineGift(ctx, bo, 3, user)
+		})
+		AfterEach(func() {
+			err = mockPool.ExpectationsWereMet()
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("takes the item of the variant back in stock and returns nil error", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("Calling CreateMerchVariant method", func() {
+		var variant model.MerchVariant
+
+		JustBeforeEach(func() {
+			rsGet := pgxmock.NewRows(merchColumns).AddRow(int32(1), "hoody", int64(300), "", true, pgtype.Int4{})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("hoody").WillReturnRows(rsGet).Times(1)
+		})
+
+		When("the variant is new", func() {
+			JustBeforeEach(func() {
+				rsCreate := pgxmock.NewRows(variantColumns).AddRow(int32(4), "hoody", "M", "black", pgtype.Int4{}, time.Now())
+				mockPool.ExpectQuery("INSERT INTO merch_variants .+").WithArgs("hoody", "M", "black", pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				variant, err = repo.CreateMerchVariant(ctx, bo, model.MerchVariant{Type: "hoody", Size: "M", Color: "black"})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns the variant and nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(variant).To(Equal(model.MerchVariant{ID: 4, Type: "hoody", Size: "M", Color: "black"}))
+			})
+		})
+
+		When("the variant already exists", func() {
+			JustBeforeEach(func() {
+				mockPool.ExpectQuery("INSERT INTO merch_variants .+").WithArgs("hoody", "M", "black", pgtype.Int4{}).WillReturnError(sql.ErrNoRows)
+
+				variant, err = repo.CreateMerchVariant(ctx, bo, model.MerchVariant{Type: "hoody", Size: "M", Color: "black"})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns conflict error", func() {
+				Expect(err).Should(MatchError(repository.ErrConflict))
+			})
+		})
+	})
+
+	Context("Calling GetInventoryVariants method", func() {
+		var inventory []model.InventoryItem
+
+		BeforeEach(func() {
+			rsInventory := pgxmock.NewRows([]string{"type", "size", "color", "quantity"}).
+				AddRow("hoody", "", "", int64(1)).
+				AddRow("hoody", "M", "black", int64(2))
+			mockPool.ExpectQuery("SELECT .+ FROM inventory .+").WithArgs(user.UserName).WillReturnRows(rsInventory).Times(1)
+
+			inventory, err = repo.GetInventoryVariants(ctx, bo, user)
+		})
+		AfterEach(func() {
+			err = mockPool.ExpectationsWereMet()
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns the inventory by variant and nil error", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(inventory).To(Equal([]model.InventoryItem{
+				{Type: "hoody", Quantity: 1},
+				{Type: "hoody", Size: "M", Color: "black", Quantity: 2},
+			}))
+		})
+	})
+})
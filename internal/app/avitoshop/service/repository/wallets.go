@@ -0,0 +1,637 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// Kinds of wallet records, that are not kinds of history records.
+const (
+	kindWallet = "wallet"
+	kindFund   = "fund"
+)
+
+// CreateWallet creates new wallet, the creator becomes its administrator.
+func (r *Repository) CreateWallet(ctx context.Context, bo *backoff.ExponentialBackOff, wallet model.Wallet) (model.Wallet, error) {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Wallet{}, err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Create the wallet
+	created, err := backoff.RetryWithData(func() (queries.CreateWalletRow, error) {
+		return qtx.CreateWallet(ctx, queries.CreateWalletParams{
+			Name:      wallet.Name,
+			CreatedBy: wallet.CreatedBy,
+		})
+	}, bo)
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	// Add the creator to the wallet members
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return noRetryOnViolation(struct{}{}, qtx.UpsertWalletMember(ctx, queries.UpsertWalletMemberParams{
+			WalletID: created.ID,
+			Username: wallet.CreatedBy,
+			Role:     model.WalletRoleAdmin,
+			AddedBy:  wallet.CreatedBy,
+		}))
+	}, bo)
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return model.Wallet{}, err
+	}
+
+	wallet.ID = int(created.ID)
+	wallet.Role = model.WalletRoleAdmin
+	wallet.CreatedAt = created.CreatedAt
+
+	return wallet, nil
+}
+
+// GetWallets returns wallets, that a given user is a member of.
+func (r *Repository) GetWallets(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.Wallet, error) {
+	// Get wallets of the user from DB
+	walletsQuery, err := backoff.RetryWithData(func() ([]queries.GetUserWalletsRow, error) {
+		return r.q.GetUserWallets(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets := make([]model.Wallet, 0, len(walletsQuery))
+	for _, wallet := range walletsQuery {
+		wallets = append(wallets, model.Wallet{
+			ID:        int(wallet.ID),
+			Name:      wallet.Name,
+			Coins:     wallet.Coins,
+			Role:      wallet.Role,
+			CreatedBy: wallet.CreatedBy,
+			CreatedAt: wallet.CreatedAt,
+		})
+	}
+
+	return wallets, nil
+}
+
+// GetWallet returns a wallet with its members and history, if a given user is a member of it.
+func (r *Repository) GetWallet(ctx context.Context, bo *backoff.ExponentialBackOff, id int, user model.User) (model.Wallet, error) {
+	// Get role of the user in the wallet
+	role, err := backoff.RetryWithData(func() (string, error) {
+		return noRetryOnNoRows(r.q.GetWalletRole(ctx, queries.GetWalletRoleParams{
+			WalletID: int32(id),
+			Username: user.UserName,
+		}))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Wallet{}, ErrNoWallet
+	}
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	// Get the wallet from DB
+	wallet, err := backoff.RetryWithData(func() (queries.Wallet, error) {
+		return noRetryOnNoRows(r.q.GetWallet(ctx, int32(id)))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Wallet{}, ErrNoWallet
+	}
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	// Get members of the wallet from DB
+	membersQuery, err := backoff.RetryWithData(func() ([]queries.GetWalletMembersRow, error) {
+		return r.q.GetWalletMembers(ctx, int32(id))
+	}, bo)
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	members := make([]model.WalletMember, 0, len(membersQuery))
+	for _, member := range membersQuery {
+		members = append(members, model.WalletMember{
+			UserName: member.Username,
+			Role:     member.Role,
+			AddedBy:  member.AddedBy,
+			AddedAt:  member.AddedAt,
+		})
+	}
+
+	// Get history of the wallet from DB
+	historyQuery, err := backoff.RetryWithData(func() ([]queries.WalletHistory, error) {
+		return r.q.GetWalletHistory(ctx, int32(id))
+	}, bo)
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	history := make([]model.WalletHistoryEntry, 0, len(historyQuery))
+	for _, rec := range historyQuery {
+		history = append(history, model.WalletHistoryEntry{
+			ID:        int(rec.ID),
+			Kind:      rec.Kind,
+			UserName:  rec.Username,
+			Amount:    rec.Amount,
+			ToUser:    rec.ToUser,
+			Item:      rec.Item,
+			CreatedAt: rec.CreatedAt,
+		})
+	}
+
+	return model.Wallet{
+		ID:        int(wallet.ID),
+		Name:      wallet.Name,
+		Coins:     wallet.Coins,
+		Role:      role,
+		CreatedBy: wallet.CreatedBy,
+		CreatedAt: wallet.CreatedAt,
+		Members:   members,
+		History:   history,
+	}, nil
+}
+
+// SetWalletMember adds a member to a wallet or changes the role of the member on behalf of a given user.
+func (r *Repository) SetWalletMember(ctx context.Context, bo *backoff.ExponentialBackOff, id int, member model.WalletMember, actor string) error {
+	// Check if the member is an existing user
+	_, err := backoff.RetryWithData(func() (queries.User, error) {
+		return noRetryOnNoRows(r.q.GetUser(ctx, member.UserName))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Lock the wallet and check the user is allowed to manage members
+	if err = r.lockWallet(ctx, bo, qtx, id, actor, model.WalletRoleAdmin); err != nil {
+		return err
+	}
+
+	// Save the member
+	_, err = backoff.RetryWithData(func() (struct{}, error) {
+		return noRetryOnViolation(struct{}{}, qtx.UpsertWalletMember(ctx, queries.UpsertWalletMemberParams{
+			WalletID: int32(id),
+			Username: member.UserName,
+			Role:     member.Role,
+			AddedBy:  actor,
+		}))
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	// The wallet must keep at least one administrator
+	if err = r.checkWalletAdmins(ctx, bo, qtx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RemoveWalletMember removes a member from a wallet on behalf of a given user.
+// Any member can leave a wallet, only administrators can remove other members.
+func (r *Repository) RemoveWalletMember(ctx context.Context, bo *backoff.ExponentialBackOff, id int, username string, actor string) error {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Lock the wallet and check the user is allowed to remove the member
+	required := model.WalletRoleAdmin
+	if username == actor {
+		required = model.WalletRoleView
+	}
+	if err = r.lockWallet(ctx, bo, qtx, id, actor, required); err != nil {
+		return err
+	}
+
+	// Remove the member
+	_, err = backoff.RetryWithData(func() (string, error) {
+		return noRetryOnNoRows(qtx.DeleteWalletMember(ctx, queries.DeleteWalletMemberParams{
+			WalletID: int32(id),
+			Username: username,
+		}))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// The wallet must keep at least one administrator
+	if err = r.checkWalletAdmins(ctx, bo, qtx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FundWallet moves a given amount of coins from the balance of a member to a wallet.
+func (r *Repository) FundWallet(ctx context.Context, bo *backoff.ExponentialBackOff, id int, user model.User, amount int64) error {
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Lock the wallet and check the user is a member of it
+	if err = r.lockWallet(ctx, bo, qtx, id, user.UserName, model.WalletRoleView); err != nil {
+		return err
+	}
+
+	// Withdraw coins from the balance of the member,
+	// DB returns the negative balance error if the balance is not enough
+	if err = r.debit(ctx, bo, qtx, user.UserName, amount); err != nil {
+		return err
+	}
+
+	// Update the wallet
+	if err = r.updateWalletCoins(ctx, bo, qtx, id, amount); err != nil {
+		return err
+	}
+
+	// Create wallet history record of the funding
+	err = r.createWalletHistoryEntry(ctx, bo, qtx, queries.CreateWalletHistoryEntryParams{
+		WalletID: int32(id),
+		Username: user.UserName,
+		Kind:     kindFund,
+		Amount:   amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Create history record of the funding for the member
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateWalletFundingHistoryEntry(ctx, queries.CreateWalletFundingHistoryEntryParams{
+			Username: user.UserName,
+			Amount:   amount,
+			Kind:     kindWalletFunding,
+			WalletID: pgtype.Int4{Int32: int32(id), Valid: true},
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// WalletSendCoins sends a given amount of coins from a wallet to a user on behalf of a member.
+func (r *Repository) WalletSendCoins(ctx context.Context, bo *backoff.ExponentialBackOff, id int, member model.User, toUser model.User, amount int64) error {
+	// Check if the receiver exists
+	_, err := backoff.RetryWithData(func() (queries.User, error) {
+		return noRetryOnNoRows(r.q.GetUser(ctx, toUser.UserName))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Lock the wallet and check the member is allowed to spend
+	if err = r.lockWallet(ctx, bo, qtx, id, member.UserName, model.WalletRoleSpend); err != nil {
+		return err
+	}
+
+	// Withdraw coins from the wallet,
+	// DB returns the negative balance error if the wallet is not enough
+	if err = r.updateWalletCoins(ctx, bo, qtx, id, -amount); err != nil {
+		return err
+	}
+
+	// Update the balance of user that receives
+	if err = r.credit(ctx, bo, qtx, toUser.UserName, amount, kindWallet); err != nil {
+		return err
+	}
+
+	// Create history record for user that receives
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateHistoryEntry(ctx, queries.CreateHistoryEntryParams{
+			Username: toUser.UserName,
+			FromUser: member.UserName,
+			Amount:   amount,
+			Kind:     kindWallet,
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	// Create wallet history record of the transfer
+	err = r.createWalletHistoryEntry(ctx, bo, qtx, queries.CreateWalletHistoryEntryParams{
+		WalletID: int32(id),
+		Username: member.UserName,
+		Kind:     kindTransfer,
+		Amount:   amount,
+		ToUser:   toUser.UserName,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// WalletBuyItem buys an item with coins of a wallet on behalf of a member, the item goes to the member inventory.
+func (r *Repository) WalletBuyItem(ctx context.Context, bo *backoff.ExponentialBackOff, id int, member model.User, item model.InventoryItem) error {
+	// Get merch from DB
+	merch, err := backoff.RetryWithData(func() (queries.MerchCurrent, error) {
+		return noRetryOnNoRows(r.q.GetMerch(ctx, item.Type))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Get the variant of merch selected for purchase
+	variantID, err := r.selectVariant(ctx, bo, item)
+	if err != nil {
+		return err
+	}
+
+	// Begin transaction
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Defer transaction rollback
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Create query with transaction
+	qtx := r.q.WithTx(tx)
+
+	// Lock the wallet and check the member is allowed to spend
+	if err = r.lockWallet(ctx, bo, qtx, id, member.UserName, model.WalletRoleSpend); err != nil {
+		return err
+	}
+
+	// Withdraw merch price from the wallet,
+	// DB returns the negative balance error if the wallet is not enough
+	if err = r.updateWalletCoins(ctx, bo, qtx, id, -merch.Price); err != nil {
+		return err
+	}
+
+	// Lock the balance of the member, so purchases of the member are counted against the limit one at a time
+	_, err = backoff.RetryWithData(func() (queries.LockBalanceRow, error) {
+		return noRetryOnNoRows(qtx.LockBalance(ctx, member.UserName))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Check the purchase limit of the member
+	if err = r.checkPurchaseLimit(ctx, bo, qtx, member.UserName, merch.Type, 1); err != nil {
+		return err
+	}
+
+	// Take the item out of stock
+	if err = r.reserveStock(ctx, bo, qtx, merch.Type, 1); err != nil {
+		return err
+	}
+	if err = r.reserveVariantStock(ctx, bo, qtx, variantID, 1); err != nil {
+		return err
+	}
+
+	// Add item to the member inventory
+	inventoryID, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:  member.UserName,
+			Type:      merch.Type,
+			Quantity:  1,
+			Kind:      kindWallet,
+			PricePaid: merch.Price,
+			VariantID: variantID,
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	// Place the order to hand over the item to the member
+	if err = r.createOrder(ctx, bo, qtx, inventoryID, member.UserName, merch.Type, 1); err != nil {
+		return err
+	}
+
+	// Create wallet history record of the purchase
+	err = r.createWalletHistoryEntry(ctx, bo, qtx, queries.CreateWalletHistoryEntryParams{
+		WalletID:    int32(id),
+		Username:    member.UserName,
+		Kind:        kindPurchase,
+		Amount:      merch.Price,
+		Item:        merch.Type,
+		InventoryID: pgtype.Int4{Int32: inventoryID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// reverseWalletPurchase reverses a given locked wallet purchase within a transaction on behalf of a given user,
+// refunding the price paid to the wallet the item was bought from.
+func (r *Repository) reverseWalletPurchase(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, original queries.Inventory, actor string) error {
+	reversalOf := pgtype.Int4{Int32: original.ID, Valid: true}
+
+	// Check if the purchase has already been reversed
+	reversed, err := backoff.RetryWithData(func() (bool, error) {
+		return qtx.IsInventoryRecordReversed(ctx, reversalOf)
+	}, bo)
+	if err != nil {
+		return err
+	}
+	if reversed {
+		return ErrAlreadyReversed
+	}
+
+	// Get the wallet the item was bought from
+	walletID, err := backoff.RetryWithData(func() (int32, error) {
+		return noRetryOnNoRows(qtx.GetWalletPurchaseWallet(ctx, reversalOf))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Lock the wallet before the balance of the member, as wallet purchases do
+	_, err = backoff.RetryWithData(func() (int64, error) {
+		return noRetryOnNoRows(qtx.LockWallet(ctx, walletID))
+	}, bo)
+	if err != nil {
+		return noDataOnNoRows(err)
+	}
+
+	// Check that the member still has the item, it could have been transferred, listed or returned
+	if err = r.lockItems(ctx, bo, qtx, original.Username, original.Type, original.VariantID, int(original.Quantity)); err != nil {
+		return err
+	}
+
+	refund, err := amountPaid(original)
+	if err != nil {
+		return err
+	}
+
+	// Create compensating inventory record
+	_, err = backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateInventoryEntry(ctx, queries.CreateInventoryEntryParams{
+			Username:   original.Username,
+			Type:       original.Type,
+			Quantity:   -original.Quantity,
+			Kind:       kindReversal,
+			PricePaid:  original.PricePaid,
+			ReversalOf: reversalOf,
+			VariantID:  original.VariantID,
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	// Put the items back in stock
+	if err = r.releaseStock(ctx, bo, qtx, original.Type, original.Quantity); err != nil {
+		return err
+	}
+	if err = r.releaseVariantStock(ctx, bo, qtx, original.VariantID, original.Quantity); err != nil {
+		return err
+	}
+
+	// The items won't be handed over anymore
+	if err = r.cancelPurchaseOrder(ctx, bo, qtx, original.ID, actor); err != nil {
+		return err
+	}
+
+	// Refund the price paid to the wallet, if there is anything refunded
+	if refund == 0 {
+		return nil
+	}
+
+	if err = r.updateWalletCoins(ctx, bo, qtx, int(walletID), refund); err != nil {
+		return err
+	}
+
+	return r.createWalletHistoryEntry(ctx, bo, qtx, queries.CreateWalletHistoryEntryParams{
+		WalletID:    walletID,
+		Username:    original.Username,
+		Kind:        kindRefund,
+		Amount:      refund,
+		Item:        original.Type,
+		InventoryID: reversalOf,
+	})
+}
+
+// lockWallet locks a wallet until the end of transaction and checks the role of a given user in it.
+// The wallet is always locked before balances, so concurrent funding and spending don't deadlock.
+func (r *Repository) lockWallet(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, id int, username string, required string) error {
+	// Lock the wallet
+	_, err := backoff.RetryWithData(func() (int64, error) {
+		return noRetryOnNoRows(qtx.LockWallet(ctx, int32(id)))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoWallet
+	}
+	if err != nil {
+		return err
+	}
+
+	// Get role of the user in the wallet
+	role, err := backoff.RetryWithData(func() (string, error) {
+		return noRetryOnNoRows(qtx.GetWalletRole(ctx, queries.GetWalletRoleParams{
+			WalletID: int32(id),
+			Username: username,
+		}))
+	}, bo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoWallet
+	}
+	if err != nil {
+		return err
+	}
+
+	if !model.WalletRoleAllows(role, required) {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
+// updateWalletCoins changes coins of a wallet by a given delta.
+func (r *Repository) updateWalletCoins(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, id int, delta int64) error {
+	_, err := backoff.RetryWithData(func() (int64, error) {
+		return noRetryOnViolation(qtx.UpdateWalletCoins(ctx, queries.UpdateWalletCoinsParams{
+			ID:    int32(id),
+			Coins: delta,
+		}))
+	}, bo)
+
+	return err
+}
+
+// createWalletHistoryEntry creates a wallet history record.
+func (r *Repository) createWalletHistoryEntry(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, entry queries.CreateWalletHistoryEntryParams) error {
+	_, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CreateWalletHistoryEntry(ctx, entry)
+	}, bo)
+
+	return err
+}
+
+// checkWalletAdmins returns the conflict error if a wallet has no administrators left.
+func (r *Repository) checkWalletAdmins(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, id int) error {
+	admins, err := backoff.RetryWithData(func() (int32, error) {
+		return qtx.CountWalletAdmins(ctx, int32(id))
+	}, bo)
+	if err != nil {
+		return err
+	}
+
+	if admins == 0 {
+		return ErrConflict
+	}
+
+	return nil
+}
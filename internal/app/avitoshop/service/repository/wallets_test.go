@@ -0,0 +1,318 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository wallets", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		walletID int32 = 1
+		member         = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	// expectLockWallet expects the wallet to be locked and the role of the member to be read.
+	expectLockWallet := func(role string) {
+		rsLock := pgxmock.NewRows([]string{"coins"}).AddRow(int64(500))
+		mockPool.ExpectQuery("SELECT coins FROM wallets .+").WithArgs(walletID).WillReturnRows(rsLock).Times(1)
+
+		rsRole := pgxmock.NewRows([]string{"role"}).AddRow(role)
+		mockPool.ExpectQuery("SELECT role FROM wallet_members .+").WithArgs(walletID, member.UserName).WillReturnRows(rsRole).Times(1)
+	}
+
+	Context("Calling CreateWallet method", func() {
+		var wallet model.Wallet
+
+		BeforeEach(func() {
+			mockPool.ExpectBegin()
+
+			rsCreate := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(walletID, time.Now())
+			mockPool.ExpectQuery("INSERT INTO wallets .+ VALUES .+").WithArgs("offsite", member.UserName).WillReturnRows(rsCreate).Times(1)
+			mockPool.ExpectExec("INSERT INTO wallet_members .+").WithArgs(walletID, member.UserName, model.WalletRoleAdmin, member.UserName).WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+
+			mockPool.ExpectCommit()
+			mockPool.ExpectRollback()
+
+			wallet, err = repo.CreateWallet(ctx, bo, model.Wallet{Name: "offsite", CreatedBy: member.UserName})
+		})
+		AfterEach(func() {
+			err = mockPool.ExpectationsWereMet()
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns the wallet with the creator as administrator", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(wallet.ID).To(Equal(int(walletID)))
+			Expect(wallet.Role).To(Equal(model.WalletRoleAdmin))
+		})
+	})
+
+	Context("Calling FundWallet method", func() {
+		var amount int64 = 100
+
+		When("the balance of the member is enough", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				expectLockWallet(model.WalletRoleView)
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(900))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(member.UserName, -amount).WillReturnRows(rsUpdate).Times(1)
+				mockPool.ExpectExec("UPDATE coin_lots .+").WithArgs(member.UserName, amount).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsWallet := pgxmock.NewRows([]string{"coins"}).AddRow(int64(600))
+				mockPool.ExpectQuery("UPDATE wallets SET .+").WithArgs(walletID, amount).WillReturnRows(rsWallet).Times(1)
+
+				rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO wallet_history .+ VALUES .+").WithArgs(walletID, member.UserName, "fund", amount, "", "", pgtype.Int4{}).WillReturnRows(rsHistory).Times(1)
+
+				rsFunding := pgxmock.NewRows([]string{"id"}).AddRow(int32(2))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(member.UserName, amount, "wallet_funding", pgtype.Int4{Int32: walletID, Valid: true}).WillReturnRows(rsFunding).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.FundWallet(ctx, bo, int(walletID), member, amount)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the user is not a member of the wallet", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				rsLock := pgxmock.NewRows([]string{"coins"}).AddRow(int64(500))
+				mockPool.ExpectQuery("SELECT coins FROM wallets .+").WithArgs(walletID).WillReturnRows(rsLock).Times(1)
+
+				rsRole := pgxmock.NewRows([]string{"role"})
+				mockPool.ExpectQuery("SELECT role FROM wallet_members .+").WithArgs(walletID, member.UserName).WillReturnRows(rsRole).Times(1)
+
+				mockPool.ExpectRollback()
+
+				err = repo.FundWallet(ctx, bo, int(walletID), member, amount)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns no wallet error", func() {
+				Expect(err).To(Equal(repository.ErrNoWallet))
+			})
+		})
+	})
+
+	Context("Calling WalletSendCoins method", func() {
+		var (
+			amount int64 = 50
+			toUser       = model.User{UserName: "user1"}
+		)
+
+		BeforeEach(func() {
+			rsGet := pgxmock.NewRows([]string{"id", "username", "password", "createdat", "referrer"}).AddRow(int32(2), toUser.UserName, "", time.Now(), "")
+			mockPool.ExpectQuery("SELECT .+ FROM users .+").WithArgs(toUser.UserName).WillReturnRows(rsGet).Times(1)
+
+			mockPool.ExpectBegin()
+		})
+
+		When("the member is allowed to spend", func() {
+			BeforeEach(func() {
+				expectLockWallet(model.WalletRoleSpend)
+
+				rsWallet := pgxmock.NewRows([]string{"coins"}).AddRow(int64(450))
+				mockPool.ExpectQuery("UPDATE wallets SET .+").WithArgs(walletID, -amount).WillReturnRows(rsWallet).Times(1)
+
+				rsUpdate := pgxmock.NewRows([]string{"coins"}).AddRow(int64(1050))
+				mockPool.ExpectQuery("UPDATE balance SET .+").WithArgs(toUser.UserName, amount).WillReturnRows(rsUpdate).Times(1)
+
+				rsLot := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO coin_lots .+ VALUES .+").WithArgs(toUser.UserName, "wallet", amount, pgxmock.AnyArg()).WillReturnRows(rsLot).Times(1)
+
+				rsCreate := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO history .+ VALUES .+").WithArgs(toUser.UserName, member.UserName, "", amount, "wallet", pgtype.Int4{}).WillReturnRows(rsCreate).Times(1)
+
+				rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(2))
+				mockPool.ExpectQuery("INSERT INTO wallet_history .+ VALUES .+").WithArgs(walletID, member.UserName, "transfer", amount, toUser.UserName, "", pgtype.Int4{}).WillReturnRows(rsHistory).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.WalletSendCoins(ctx, bo, int(walletID), member, toUser, amount)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("the member is only allowed to view", func() {
+			BeforeEach(func() {
+				expectLockWallet(model.WalletRoleView)
+
+				mockPool.ExpectRollback()
+
+				err = repo.WalletSendCoins(ctx, bo, int(walletID), member, toUser, amount)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns permission denied error", func() {
+				Expect(err).To(Equal(repository.ErrPermissionDenied))
+			})
+		})
+
+		When("the wallet is not enough", func() {
+			BeforeEach(func() {
+				expectLockWallet(model.WalletRoleAdmin)
+
+				rsWallet := pgxmock.NewRows([]string{"coins"}).AddRow(int64(0)).RowError(0, &pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "wallets_coins_check"})
+				mockPool.ExpectQuery("UPDATE wallets SET .+").WithArgs(walletID, -amount).WillReturnRows(rsWallet).Times(1)
+
+				mockPool.ExpectRollback()
+
+				err = repo.WalletSendCoins(ctx, bo, int(walletID), member, toUser, amount)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns negative balance error", func() {
+				Expect(err).To(Equal(repository.ErrNegativeBalance))
+			})
+		})
+	})
+
+	Context("Calling WalletBuyItem method", func() {
+		When("the member is allowed to spend", func() {
+			BeforeEach(func() {
+				var price int64 = 20
+
+				rsGet := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(1), "cup", price, "", true, pgtype.Int4{})
+				mockPool.ExpectQuery("SELECT .+ FROM merch_current .+").WithArgs("cup").WillReturnRows(rsGet).Times(1)
+
+				rsVariants := pgxmock.NewRows([]string{"has_variants"}).AddRow(false)
+				mockPool.ExpectQuery("SELECT EXISTS .+ FROM merch_variants .+").WithArgs("cup").WillReturnRows(rsVariants).Times(1)
+
+				mockPool.ExpectBegin()
+
+				expectLockWallet(model.WalletRoleSpend)
+
+				rsWallet := pgxmock.NewRows([]string{"coins"}).AddRow(int64(480))
+				mockPool.ExpectQuery("UPDATE wallets SET .+").WithArgs(walletID, -price).WillReturnRows(rsWallet).Times(1)
+
+				rsLock := pgxmock.NewRows([]string{"coins", "held"}).AddRow(int64(500), int64(0))
+				mockPool.ExpectQuery("SELECT coins, held FROM balance .+").WithArgs(member.UserName).WillReturnRows(rsLock).Times(1)
+
+				mockPool.ExpectQuery("SELECT .+ FROM purchase_limits .+").WithArgs("cup").WillReturnError(sql.ErrNoRows)
+
+				mockPool.ExpectExec("UPDATE merch SET stock .+").WithArgs("cup", pgtype.Int4{Int32: 1, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+				rsInventory := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO inventory .+ VALUES .+").WithArgs(member.UserName, "cup", int32(1), "wallet", price, pgtype.Int4{}, pgtype.Int4{}).WillReturnRows(rsInventory).Times(1)
+
+				rsOrder := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+				mockPool.ExpectQuery("INSERT INTO orders .+ VALUES .+").WithArgs(int32(1), member.UserName, "cup", int32(1)).WillReturnRows(rsOrder).Times(1)
+
+				rsHistory := pgxmock.NewRows([]string{"id"}).AddRow(int32(3))
+				mockPool.ExpectQuery("INSERT INTO wallet_history .+ VALUES .+").WithArgs(walletID, member.UserName, "purchase", price, "", "cup", pgtype.Int4{Int32: 1, Valid: true}).WillReturnRows(rsHistory).Times(1)
+
+				mockPool.ExpectCommit()
+				mockPool.ExpectRollback()
+
+				err = repo.WalletBuyItem(ctx, bo, int(walletID), member, model.InventoryItem{Type: "cup", Quantity: 1})
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns nil error", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Calling RemoveWalletMember method", func() {
+		When("the last administrator leaves the wallet", func() {
+			BeforeEach(func() {
+				mockPool.ExpectBegin()
+
+				expectLockWallet(model.WalletRoleAdmin)
+
+				rsDelete := pgxmock.NewRows([]string{"username"}).AddRow(member.UserName)
+				mockPool.ExpectQuery("DELETE FROM wallet_members .+").WithArgs(walletID, member.UserName).WillReturnRows(rsDelete).Times(1)
+
+				rsAdmins := pgxmock.NewRows([]string{"admins"}).AddRow(int32(0))
+				mockPool.ExpectQuery("SELECT COUNT.+ FROM wallet_members .+").WithArgs(walletID).WillReturnRows(rsAdmins).Times(1)
+
+				mockPool.ExpectRollback()
+
+				err = repo.RemoveWalletMember(ctx, bo, int(walletID), member.UserName, member.UserName)
+			})
+			AfterEach(func() {
+				err = mockPool.ExpectationsWereMet()
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns conflict error", func() {
+				Expect(err).To(Equal(repository.ErrConflict))
+			})
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/database/queries"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// AddWishlistItem adds merch of a given type to the wishlist of a given user.
+// Archived merch can be wished as well, so the user learns when it is back.
+func (r *Repository) AddWishlistItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, itemType string) error {
+	// Check that the merch exists
+	if _, err := r.GetCatalogItem(ctx, bo, itemType); err != nil {
+		return err
+	}
+
+	// Add the item to the wishlist, the item already in the wishlist stays as is
+	_, err := backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, r.q.AddWishlistItem(ctx, queries.AddWishlistItemParams{
+			Username: user.UserName,
+			Type:     itemType,
+		})
+	}, bo)
+	return err
+}
+
+// RemoveWishlistItem removes merch of a given type from the wishlist of a given user.
+func (r *Repository) RemoveWishlistItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, itemType string) error {
+	removed, err := backoff.RetryWithData(func() (int64, error) {
+		return r.q.RemoveWishlistItem(ctx, queries.RemoveWishlistItemParams{
+			Username: user.UserName,
+			Type:     itemType,
+		})
+	}, bo)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// GetWishlist returns the wishlist of a given user with the coins missing to buy each item
+// and the events of the items since they were added to the wishlist.
+func (r *Repository) GetWishlist(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Wishlist, error) {
+	// Get wishlist from DB
+	wishlistQuery, err := backoff.RetryWithData(func() ([]queries.GetWishlistRow, error) {
+		return r.q.GetWishlist(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.Wishlist{}, err
+	}
+
+	// Get events of the wished items, the latest first
+	eventsQuery, err := backoff.RetryWithData(func() ([]queries.GetWishlistEventsRow, error) {
+		return r.q.GetWishlistEvents(ctx, user.UserName)
+	}, bo)
+	if err != nil {
+		return model.Wishlist{}, err
+	}
+
+	balance, err := r.GetBalance(ctx, bo, user)
+	if err != nil {
+		return model.Wishlist{}, err
+	}
+
+	return toWishlist(wishlistQuery, eventsQuery, balance.Coins), nil
+}
+
+// markBackInStock records, that merch can be bought again, for the wishlists of users.
+func (r *Repository) markBackInStock(ctx context.Context, bo *backoff.ExponentialBackOff, qtx *queries.Queries, merch queries.MerchCurrent) error {
+	_, err := backoff.RetryWithData(func() (struct{}, error) {
+		return struct{}{}, qtx.CreateMerchBackInStock(ctx, queries.CreateMerchBackInStockParams{
+			Type:  merch.Type,
+			Price: merch.Price,
+		})
+	}, bo)
+	return err
+}
+
+// purchasable reports whether merch is available and in stock.
+func purchasable(merch queries.MerchCurrent) bool {
+	return merch.Available && (!merch.Stock.Valid || merch.Stock.Int32 > 0)
+}
+
+// toWishlist converts wishlist records of DB to the model.
+func toWishlist(wishlistQuery []queries.GetWishlistRow, eventsQuery []queries.GetWishlistEventsRow, balance int64) model.Wishlist {
+	events := make(map[string][]model.WishlistEvent, len(wishlistQuery))
+	for _, rec := range eventsQuery {
+		events[rec.Type] = append(events[rec.Type], model.WishlistEvent{
+			Kind:       rec.Kind,
+			Price:      rec.Price,
+			OccurredAt: rec.OccurredAt,
+		})
+	}
+
+	wishlist := model.Wishlist{
+		Items:   make([]model.WishlistItem, 0, len(wishlistQuery)),
+		Balance: balance,
+	}
+
+	for _, rec := range wishlistQuery {
+		item := model.WishlistItem{
+			Item:      rec.Type,
+			Price:     rec.Price,
+			Available: rec.Available,
+			Stock:     toStock(rec.Stock),
+			Missing:   max(rec.Price-balance, 0),
+			AddedAt:   rec.AddedAt,
+			Events:    events[rec.Type],
+		}
+		if item.Events == nil {
+			item.Events = []model.WishlistEvent{}
+		}
+		wishlist.Items = append(wishlist.Items, item)
+	}
+
+	return wishlist
+}
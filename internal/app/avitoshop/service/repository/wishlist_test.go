@@ -0,0 +1,124 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5/pgtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+var _ = Describe("Repository wishlist", func() {
+	var (
+		err error
+
+		ctx context.Context
+		bo  *backoff.ExponentialBackOff
+
+		mockPool pgxmock.PgxPoolIface
+		repo     *repository.Repository
+
+		user = model.User{UserName: "user"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		bo = backoff.NewExponentialBackOff()
+		bo.InitialInterval = 50 * time.Millisecond
+		bo.RandomizationFactor = 0.1
+		bo.Multiplier = 2.0
+		bo.MaxInterval = 1 * time.Second
+		bo.MaxElapsedTime = 2 * time.Second
+		bo.Reset()
+
+		mockPool, err = pgxmock.NewPool()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo, err = repository.New(mockPool)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockPool.Close()
+	})
+
+	Context("Calling GetWishlist method", func() {
+		It("returns the wished items with missing coins and events", func() {
+			addedAt := time.Now().Add(-48 * time.Hour)
+
+			rsWishlist := pgxmock.NewRows([]string{"type", "price", "available", "stock", "added_at"}).
+				AddRow("pink-hoody", int64(450), true, pgtype.Int4{Int32: 3, Valid: true}, addedAt).
+				AddRow("cup", int64(20), true, pgtype.Int4{}, addedAt)
+			mockPool.ExpectQuery("SELECT .+ FROM wishlist_items .+").WithArgs(user.UserName).WillReturnRows(rsWishlist).Times(1)
+
+			rsEvents := pgxmock.NewRows([]string{"type", "kind", "price", "occurred_at"}).
+				AddRow("pink-hoody", model.WishlistEventBackInStock, int64(450), time.Now().Add(-time.Hour)).
+				AddRow("pink-hoody", model.WishlistEventPriceDrop, int64(450), time.Now().Add(-24*time.Hour))
+			mockPool.ExpectQuery("SELECT .+ FROM wishlist_items .+ UNION ALL .+").WithArgs(user.UserName).WillReturnRows(rsEvents).Times(1)
+
+			rsBalance := pgxmock.NewRows([]string{"id", "username", "coins", "held"}).AddRow(int32(1), user.UserName, int64(300), int64(0))
+			mockPool.ExpectQuery("SELECT .+ FROM balance .+").WithArgs(user.UserName).WillReturnRows(rsBalance).Times(1)
+
+			wishlist, err := repo.GetWishlist(ctx, bo, user)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(wishlist.Balance).To(Equal(int64(300)))
+			Expect(wishlist.Items).To(HaveLen(2))
+
+			Expect(wishlist.Items[0].Item).To(Equal("pink-hoody"))
+			Expect(wishlist.Items[0].Missing).To(Equal(int64(150)))
+			Expect(*wishlist.Items[0].Stock).To(Equal(3))
+			Expect(wishlist.Items[0].Events).To(HaveLen(2))
+			Expect(wishlist.Items[0].Events[0].Kind).To(Equal(model.WishlistEventBackInStock))
+
+			Expect(wishlist.Items[1].Item).To(Equal("cup"))
+			Expect(wishlist.Items[1].Missing).To(BeZero())
+			Expect(wishlist.Items[1].Stock).To(BeNil())
+			Expect(wishlist.Items[1].Events).To(BeEmpty())
+
+			Expect(mockPool.ExpectationsWereMet()).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("Calling RemoveWishlistItem method", func() {
+		When("the item is not in the wishlist", func() {
+			It("returns no data error", func() {
+				mockPool.ExpectExec("DELETE FROM wishlist_items .+").WithArgs(user.UserName, "cup").WillReturnResult(pgxmock.NewResult("DELETE", 0)).Times(1)
+
+				err := repo.RemoveWishlistItem(ctx, bo, user, "cup")
+				Expect(err).Should(MatchError(repository.ErrNoData))
+				Expect(mockPool.ExpectationsWereMet()).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Calling RestockMerch method for merch out of stock", func() {
+		It("records that the merch is back in stock", func() {
+			mockPool.ExpectBegin()
+
+			rsMerch := pgxmock.NewRows([]string{"id", "type", "price", "description", "available", "stock"}).AddRow(int32(2), "cup", int64(20), "Ceramic mug", true, pgtype.Int4{Int32: 0, Valid: true})
+			mockPool.ExpectQuery("SELECT .+ FROM merch_current .+ FOR UPDATE").WithArgs("cup").WillReturnRows(rsMerch)
+
+			mockPool.ExpectExec("UPDATE merch .+").WithArgs("cup", int64(20), "Ceramic mug", true, pgtype.Int4{Int32: 10, Valid: true}).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+			rsAudit := pgxmock.NewRows([]string{"id"}).AddRow(int32(1))
+			mockPool.ExpectQuery("INSERT INTO merch_audit .+").WithArgs("cup", model.MerchActionRestock, int64(20), "Ceramic mug", true, "admin", pgtype.Int4{Int32: 10, Valid: true}).WillReturnRows(rsAudit)
+
+			mockPool.ExpectExec("INSERT INTO merch_back_in_stock .+").WithArgs("cup", int64(20)).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+			mockPool.ExpectCommit()
+			mockPool.ExpectRollback()
+
+			item, err := repo.RestockMerch(ctx, bo, "cup", 10, "admin")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(*item.Stock).To(Equal(10))
+			Expect(mockPool.ExpectationsWereMet()).ShouldNot(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// Cart returns the cart of a user.
+func (s *service) Cart(ctx context.Context, user model.User) (model.Cart, error) {
+	return s.repository.GetCart(ctx, repository.DefaultBackOff, user)
+}
+
+// AddToCart adds a quantity of an item to the cart of a user and returns the cart.
+func (s *service) AddToCart(ctx context.Context, user model.User, item model.InventoryItem) (model.Cart, error) {
+	err := s.repository.AddCartItem(ctx, repository.DefaultBackOff, user, item)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Cart{}, ErrNoSuchItem
+	}
+	if errors.Is(err, repository.ErrNoVariant) {
+		return model.Cart{}, ErrNoSuchVariant
+	}
+	if errors.Is(err, repository.ErrOutOfRange) {
+		return model.Cart{}, ErrAmountOutOfRange
+	}
+
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	return s.Cart(ctx, user)
+}
+
+// RemoveFromCart removes an item from the cart of a user and returns the cart.
+func (s *service) RemoveFromCart(ctx context.Context, user model.User, itemType string) (model.Cart, error) {
+	err := s.repository.RemoveCartItem(ctx, repository.DefaultBackOff, user, itemType)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Cart{}, ErrNotInCart
+	}
+
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	return s.Cart(ctx, user)
+}
+
+// Checkout buys all items of the cart of a user at once.
+// If the checkout fails, the cart is returned with the reasons of the failed lines.
+func (s *service) Checkout(ctx context.Context, user model.User) (model.Cart, error) {
+	cart, err := s.repository.Checkout(ctx, repository.DefaultBackOff, user)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Cart{}, ErrEmptyCart
+	}
+	if errors.Is(err, repository.ErrCheckoutFailed) {
+		return cart, ErrCheckoutFailed
+	}
+
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	return cart, nil
+}
@@ -0,0 +1,173 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/catalog"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/coins"
+)
+
+// Catalog returns merch catalog items filtered by price range in a given order.
+func (s *service) Catalog(ctx context.Context, filter model.CatalogFilter) ([]model.MerchItem, error) {
+	return s.repository.GetCatalog(ctx, repository.DefaultBackOff, filter)
+}
+
+// CatalogItem returns merch catalog item of a given type.
+func (s *service) CatalogItem(ctx context.Context, itemType string) (model.MerchItem, error) {
+	item, err := s.repository.GetCatalogItem(ctx, repository.DefaultBackOff, itemType)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.MerchItem{}, ErrNoSuchItem
+	}
+
+	if err != nil {
+		return model.MerchItem{}, err
+	}
+
+	return item, nil
+}
+
+// CreateMerch adds new merch to the catalog on behalf of a given administrator.
+func (s *service) CreateMerch(ctx context.Context, item model.MerchItem, admin model.User) (model.MerchItem, error) {
+	item, err := s.repository.CreateMerch(ctx, repository.DefaultBackOff, item, admin.UserName)
+	if errors.Is(err, repository.ErrConflict) {
+		return model.MerchItem{}, ErrMerchAlreadyExists
+	}
+
+	if err != nil {
+		return model.MerchItem{}, err
+	}
+
+	return item, nil
+}
+
+// UpdateMerch changes price or description of merch on behalf of a given administrator.
+func (s *service) UpdateMerch(ctx context.Context, itemType string, update model.MerchUpdate, admin model.User) (model.MerchItem, error) {
+	item, err := s.repository.UpdateMerch(ctx, repository.DefaultBackOff, itemType, update, admin.UserName)
+	return item, merchError(err)
+}
+
+// ArchiveMerch makes merch unavailable for purchase on behalf of a given administrator.
+func (s *service) ArchiveMerch(ctx context.Context, itemType string, admin model.User) (model.MerchItem, error) {
+	item, err := s.repository.ArchiveMerch(ctx, repository.DefaultBackOff, itemType, admin.UserName)
+	if errors.Is(err, repository.ErrConflict) {
+		return model.MerchItem{}, ErrMerchArchived
+	}
+
+	return item, merchError(err)
+}
+
+// RestoreMerch makes archived merch available for purchase again on behalf of a given administrator.
+func (s *service) RestoreMerch(ctx context.Context, itemType string, admin model.User) (model.MerchItem, error) {
+	item, err := s.repository.RestoreMerch(ctx, repository.DefaultBackOff, itemType, admin.UserName)
+	if errors.Is(err, repository.ErrConflict) {
+		return model.MerchItem{}, ErrMerchNotArchived
+	}
+
+	return item, merchError(err)
+}
+
+// MerchAudit returns merch catalog changes, optionally of a given merch type only.
+func (s *service) MerchAudit(ctx context.Context, itemType string) ([]model.MerchAuditEntry, error) {
+	return s.repository.GetMerchAudit(ctx, repository.DefaultBackOff, itemType)
+}
+
+// RestockMerch adds a quantity of merch to its stock on behalf of a given administrator.
+func (s *service) RestockMerch(ctx context.Context, itemType string, quantity int, admin model.User) (model.MerchItem, error) {
+	item, err := s.repository.RestockMerch(ctx, repository.DefaultBackOff, itemType, quantity, admin.UserName)
+	if errors.Is(err, repository.ErrOutOfRange) {
+		return model.MerchItem{}, ErrAmountOutOfRange
+	}
+
+	return item, merchError(err)
+}
+
+// LowStockMerch returns available merch with no more than a given quantity in stock.
+// If the quantity is negative, the threshold from the configuration is used.
+func (s *service) LowStockMerch(ctx context.Context, threshold int) ([]model.MerchItem, error) {
+	if threshold < 0 {
+		threshold = s.cfg.LowStockThreshold
+	}
+
+	return s.repository.GetLowStockMerch(ctx, repository.DefaultBackOff, threshold)
+}
+
+// SetMerchPrice changes price of merch from a given time on behalf of a given administrator.
+// The price is changed immediately, if the time is not given.
+func (s *service) SetMerchPrice(ctx context.Context, itemType string, request model.MerchPriceRequest, admin model.User) (model.MerchPrice, error) {
+	validFrom := time.Now()
+	if request.ValidFrom != nil {
+		validFrom = *request.ValidFrom
+	}
+
+	merchPrice, err := s.repository.SetMerchPrice(ctx, repository.DefaultBackOff, itemType, request.Price, validFrom, admin.UserName)
+	if errors.Is(err, repository.ErrConflict) {
+		return model.MerchPrice{}, ErrPriceAlreadyScheduled
+	}
+
+	return merchPrice, merchError(err)
+}
+
+// MerchPrices returns the price history of merch of a given type, including scheduled prices.
+func (s *service) MerchPrices(ctx context.Context, itemType string) ([]model.MerchPrice, error) {
+	return s.repository.GetMerchPrices(ctx, repository.DefaultBackOff, itemType)
+}
+
+// ReconcileCatalog brings merch catalog to the declared state and returns the changes made.
+// In dry run the changes are only returned. Every change is made in its own transaction,
+// so a failed reconciliation can be repeated to make the rest of the changes.
+func (s *service) ReconcileCatalog(ctx context.Context, declared []model.MerchRequest, dryRun bool) ([]model.CatalogChange, error) {
+	current, err := s.repository.GetCatalog(ctx, repository.DefaultBackOff, model.CatalogFilter{MaxPrice: coins.MaxAmount})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := catalog.Diff(current, declared)
+	if dryRun {
+		return changes, nil
+	}
+
+	stock := make(map[string]*int, len(declared))
+	for _, item := range declared {
+		stock[item.Type] = item.Stock
+	}
+
+	for i, change := range changes {
+		switch change.Action {
+		case model.MerchActionCreate:
+			_, err = s.repository.CreateMerch(ctx, repository.DefaultBackOff, model.MerchItem{
+				Type:        change.Type,
+				Price:       change.Price,
+				Description: change.Description,
+				Stock:       stock[change.Type],
+			}, catalog.Actor)
+		case model.MerchActionUpdate:
+			_, err = s.repository.UpdateMerch(ctx, repository.DefaultBackOff, change.Type, model.MerchUpdate{
+				Price:       &change.Price,
+				Description: &change.Description,
+			}, catalog.Actor)
+		case model.MerchActionRestore:
+			_, err = s.repository.RestoreMerch(ctx, repository.DefaultBackOff, change.Type, catalog.Actor)
+		case model.MerchActionArchive:
+			_, err = s.repository.ArchiveMerch(ctx, repository.DefaultBackOff, change.Type, catalog.Actor)
+		}
+		if err != nil {
+			return changes[:i], fmt.Errorf("%s merch %s: %w", change.Action, change.Type, err)
+		}
+	}
+
+	return changes, nil
+}
+
+// merchError replaces repository errors common for merch operations with service errors.
+func merchError(err error) error {
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrNoSuchItem
+	}
+
+	return err
+}
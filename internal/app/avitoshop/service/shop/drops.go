@@ -0,0 +1,30 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// CreateMerchDrop schedules a drop of merch of a given type on behalf of a given administrator.
+func (s *service) CreateMerchDrop(ctx context.Context, itemType string, request model.MerchDropRequest, admin model.User) (model.MerchDrop, error) {
+	drop, err := s.repository.CreateMerchDrop(ctx, repository.DefaultBackOff, model.MerchDrop{
+		Type:     itemType,
+		StartsAt: request.StartsAt,
+		EndsAt:   request.EndsAt,
+		Price:    request.Price,
+		Stock:    request.Stock,
+	}, admin.UserName)
+	if errors.Is(err, repository.ErrConflict) {
+		return model.MerchDrop{}, ErrDropOverlaps
+	}
+
+	return drop, merchError(err)
+}
+
+// MerchDrops returns current and upcoming drops of merch.
+func (s *service) MerchDrops(ctx context.Context) ([]model.MerchDrop, error) {
+	return s.repository.GetMerchDrops(ctx, repository.DefaultBackOff)
+}
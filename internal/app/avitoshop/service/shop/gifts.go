@@ -0,0 +1,61 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// BuyGift buys an item by a buyer for a recipient with an optional message.
+func (s *service) BuyGift(ctx context.Context, buyer model.User, recipient model.User, item model.InventoryItem, message string) (model.Gift, error) {
+	gift, err := s.repository.BuyGift(ctx, repository.DefaultBackOff, buyer, recipient, item, message)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Gift{}, ErrNoSuchItem
+	}
+	if errors.Is(err, repository.ErrNoUser) {
+		return model.Gift{}, ErrNoSuchUser
+	}
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		return model.Gift{}, ErrNotEnoughBalance
+	}
+	if errors.Is(err, repository.ErrOutOfStock) {
+		return model.Gift{}, ErrOutOfStock
+	}
+	if errors.Is(err, repository.ErrNoVariant) {
+		return model.Gift{}, ErrNoSuchVariant
+	}
+	if errors.Is(err, repository.ErrLimitReached) {
+		return model.Gift{}, ErrPurchaseLimitReached
+	}
+
+	if err != nil {
+		return model.Gift{}, err
+	}
+
+	return gift, nil
+}
+
+// DeclineGift declines a gift received by a recipient, the price paid is refunded to the buyer.
+func (s *service) DeclineGift(ctx context.Context, recipient model.User, id int) (model.Gift, error) {
+	gift, err := s.repository.DeclineGift(ctx, repository.DefaultBackOff, id, recipient)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Gift{}, ErrNoSuchGift
+	}
+	if errors.Is(err, repository.ErrAlreadyReversed) {
+		return model.Gift{}, ErrGiftAlreadyDeclined
+	}
+	if errors.Is(err, repository.ErrNotEnoughItems) {
+		return model.Gift{}, ErrNotEnoughItems
+	}
+	if errors.Is(err, repository.ErrOutOfRange) {
+		return model.Gift{}, ErrAmountOutOfRange
+	}
+
+	if err != nil {
+		return model.Gift{}, err
+	}
+
+	return gift, nil
+}
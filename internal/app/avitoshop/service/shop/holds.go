@@ -0,0 +1,108 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// holdExpiryBatchSize is the number of expired holds, that are looked up at once.
+const holdExpiryBatchSize = 100
+
+// CreateHold holds coins on the balance of a user on behalf of a given integration.
+func (s *service) CreateHold(ctx context.Context, request model.HoldRequest, integration model.User) (model.Hold, error) {
+	expiresAt := request.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(s.cfg.HoldTTL)
+	}
+
+	hold, err := s.repository.CreateHold(ctx, repository.DefaultBackOff, model.Hold{
+		UserName:  request.UserName,
+		Amount:    request.Amount,
+		Reason:    request.Reason,
+		CreatedBy: integration.UserName,
+		ExpiresAt: expiresAt,
+	})
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Hold{}, ErrNoSuchUser
+	}
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		return model.Hold{}, ErrNotEnoughBalance
+	}
+
+	if err != nil {
+		return model.Hold{}, err
+	}
+
+	return hold, nil
+}
+
+// Hold returns a hold with a given ID, created by a given integration.
+// Administrators can see holds of all integrations.
+func (s *service) Hold(ctx context.Context, id int, integration model.User) (model.Hold, error) {
+	hold, err := s.repository.GetHold(ctx, repository.DefaultBackOff, id)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Hold{}, ErrUnknownHold
+	}
+	if err != nil {
+		return model.Hold{}, err
+	}
+
+	// Holds of other integrations are not shown
+	if hold.CreatedBy != integration.UserName && !s.cfg.IsAdmin(integration.UserName) {
+		return model.Hold{}, ErrUnknownHold
+	}
+
+	return hold, nil
+}
+
+// CaptureHold captures a given amount of coins of a hold with a given ID, the whole hold is captured if the amount is zero.
+func (s *service) CaptureHold(ctx context.Context, id int, amount int64, integration model.User) (model.Hold, error) {
+	if _, err := s.Hold(ctx, id, integration); err != nil {
+		return model.Hold{}, err
+	}
+
+	hold, err := s.repository.CaptureHold(ctx, repository.DefaultBackOff, id, amount)
+	if err != nil {
+		return model.Hold{}, holdError(err)
+	}
+
+	return hold, nil
+}
+
+// ReleaseHold releases a hold with a given ID without withdrawal of coins.
+func (s *service) ReleaseHold(ctx context.Context, id int, integration model.User) (model.Hold, error) {
+	if _, err := s.Hold(ctx, id, integration); err != nil {
+		return model.Hold{}, err
+	}
+
+	hold, err := s.repository.ReleaseHold(ctx, repository.DefaultBackOff, id)
+	if err != nil {
+		return model.Hold{}, holdError(err)
+	}
+
+	return hold, nil
+}
+
+// ExpireHolds releases holds, that have not been captured or released before their expiry.
+func (s *service) ExpireHolds(ctx context.Context) (int, error) {
+	return s.repository.ExpireHolds(ctx, repository.DefaultBackOff, holdExpiryBatchSize)
+}
+
+// holdError replaces repository errors of hold settlement with the service errors.
+func holdError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNoData):
+		return ErrUnknownHold
+	case errors.Is(err, repository.ErrHoldClosed):
+		return ErrHoldClosed
+	case errors.Is(err, repository.ErrOutOfRange):
+		return ErrAmountOutOfRange
+	case errors.Is(err, repository.ErrNegativeBalance):
+		return ErrNotEnoughBalance
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// TransferItems transfers owned items of a type from one user to another.
+func (s *service) TransferItems(ctx context.Context, fromUser model.User, toUser model.User, item model.InventoryItem) (model.ItemTransfer, error) {
+	transfer, err := s.repository.TransferItems(ctx, repository.DefaultBackOff, fromUser, toUser, item)
+	if errors.Is(err, repository.ErrNoUser) {
+		return model.ItemTransfer{}, ErrNoSuchUser
+	}
+	if errors.Is(err, repository.ErrNotEnoughItems) {
+		return model.ItemTransfer{}, ErrNotEnoughItems
+	}
+	if errors.Is(err, repository.ErrNoVariant) {
+		return model.ItemTransfer{}, ErrNoSuchVariant
+	}
+
+	if err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// ReturnItem returns an item of a type purchased by a user within the return window and refunds the price paid.
+func (s *service) ReturnItem(ctx context.Context, user model.User, itemType string) (model.ItemReturn, error) {
+	itemReturn, err := s.repository.ReturnItem(ctx, repository.DefaultBackOff, user, itemType, time.Now().Add(-s.cfg.ReturnWindow))
+	if errors.Is(err, repository.ErrNoData) {
+		return model.ItemReturn{}, ErrNoReturnablePurchase
+	}
+	if errors.Is(err, repository.ErrOutOfRange) {
+		return model.ItemReturn{}, ErrAmountOutOfRange
+	}
+
+	if err != nil {
+		return model.ItemReturn{}, err
+	}
+
+	return itemReturn, nil
+}
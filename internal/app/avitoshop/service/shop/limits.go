@@ -0,0 +1,35 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// SetPurchaseLimit sets the purchase limit of merch of a given type on behalf of a given administrator.
+func (s *service) SetPurchaseLimit(ctx context.Context, itemType string, request model.PurchaseLimitRequest, admin model.User) (model.PurchaseLimit, error) {
+	limit, err := s.repository.SetPurchaseLimit(ctx, repository.DefaultBackOff, model.PurchaseLimit{
+		Type:        itemType,
+		MaxQuantity: request.MaxQuantity,
+		PeriodDays:  request.PeriodDays,
+	}, admin.UserName)
+
+	return limit, merchError(err)
+}
+
+// RemovePurchaseLimit removes the purchase limit of merch of a given type.
+func (s *service) RemovePurchaseLimit(ctx context.Context, itemType string) error {
+	err := s.repository.DeletePurchaseLimit(ctx, repository.DefaultBackOff, itemType)
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrNoSuchPurchaseLimit
+	}
+
+	return err
+}
+
+// PurchaseLimits returns purchase limits of all merch.
+func (s *service) PurchaseLimits(ctx context.Context) ([]model.PurchaseLimit, error) {
+	return s.repository.GetPurchaseLimits(ctx, repository.DefaultBackOff)
+}
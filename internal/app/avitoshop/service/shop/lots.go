@@ -0,0 +1,15 @@
+package shop
+
+import (
+	"context"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+)
+
+// expiryBatchSize is the number of users, whose expired coins are looked up at once.
+const expiryBatchSize = 100
+
+// ExpireCoins expires coins, that have not been spent during their time to live.
+func (s *service) ExpireCoins(ctx context.Context) (int64, error) {
+	return s.repository.ExpireCoins(ctx, repository.DefaultBackOff, expiryBatchSize)
+}
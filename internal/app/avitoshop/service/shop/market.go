@@ -0,0 +1,70 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// CreateListing puts an owned item of a seller up for sale on the marketplace.
+func (s *service) CreateListing(ctx context.Context, seller model.User, item model.InventoryItem, price int64) (model.Listing, error) {
+	listing, err := s.repository.CreateListing(ctx, repository.DefaultBackOff, seller, item, price)
+	if errors.Is(err, repository.ErrNotEnoughItems) {
+		return model.Listing{}, ErrNotEnoughItems
+	}
+	if errors.Is(err, repository.ErrNoVariant) {
+		return model.Listing{}, ErrNoSuchVariant
+	}
+
+	if err != nil {
+		return model.Listing{}, err
+	}
+
+	return listing, nil
+}
+
+// CancelListing cancels an active listing of a seller.
+func (s *service) CancelListing(ctx context.Context, seller model.User, id int) (model.Listing, error) {
+	listing, err := s.repository.CancelListing(ctx, repository.DefaultBackOff, id, seller)
+	return listing, listingError(err)
+}
+
+// BuyListing buys an active listing by a buyer.
+func (s *service) BuyListing(ctx context.Context, buyer model.User, id int) (model.Listing, error) {
+	listing, err := s.repository.BuyListing(ctx, repository.DefaultBackOff, id, buyer)
+	if errors.Is(err, repository.ErrPermissionDenied) {
+		return model.Listing{}, ErrOwnListing
+	}
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		return model.Listing{}, ErrNotEnoughBalance
+	}
+	if errors.Is(err, repository.ErrOutOfRange) {
+		return model.Listing{}, ErrAmountOutOfRange
+	}
+
+	return listing, listingError(err)
+}
+
+// Listings returns active listings of the marketplace, optionally of a given item type only.
+func (s *service) Listings(ctx context.Context, itemType string) ([]model.Listing, error) {
+	return s.repository.GetListings(ctx, repository.DefaultBackOff, itemType)
+}
+
+// UserListings returns all listings of a seller.
+func (s *service) UserListings(ctx context.Context, seller model.User) ([]model.Listing, error) {
+	return s.repository.GetUserListings(ctx, repository.DefaultBackOff, seller)
+}
+
+// listingError replaces repository errors common for listing operations with service errors.
+func listingError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNoData):
+		return ErrNoSuchListing
+	case errors.Is(err, repository.ErrConflict):
+		return ErrListingClosed
+	}
+
+	return err
+}
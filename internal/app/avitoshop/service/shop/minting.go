@@ -0,0 +1,57 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// MintCoins mints coins to a given user, or grants them to every user, if the user is not given.
+func (s *service) MintCoins(ctx context.Context, op model.CoinOperation) (model.CoinOperation, error) {
+	op.Kind = model.CoinOperationMint
+
+	var err error
+	if op.UserName == "" {
+		op, err = s.repository.GrantCoins(ctx, repository.DefaultBackOff, op, s.cfg.GrantBatchSize)
+	} else {
+		op, err = s.repository.MintCoins(ctx, repository.DefaultBackOff, op)
+	}
+	if errors.Is(err, repository.ErrNoData) {
+		return model.CoinOperation{}, ErrNoSuchAccount
+	}
+	if errors.Is(err, repository.ErrOutOfRange) {
+		return model.CoinOperation{}, ErrAmountOutOfRange
+	}
+
+	if err != nil {
+		return model.CoinOperation{}, err
+	}
+
+	return op, nil
+}
+
+// BurnCoins burns coins of a given user.
+func (s *service) BurnCoins(ctx context.Context, op model.CoinOperation) (model.CoinOperation, error) {
+	op.Kind = model.CoinOperationBurn
+
+	op, err := s.repository.BurnCoins(ctx, repository.DefaultBackOff, op)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.CoinOperation{}, ErrNoSuchAccount
+	}
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		return model.CoinOperation{}, ErrNotEnoughBalance
+	}
+
+	if err != nil {
+		return model.CoinOperation{}, err
+	}
+
+	return op, nil
+}
+
+// MoneySupply returns total amount of coins in the system.
+func (s *service) MoneySupply(ctx context.Context) (model.MoneySupply, error) {
+	return s.repository.GetMoneySupply(ctx, repository.DefaultBackOff)
+}
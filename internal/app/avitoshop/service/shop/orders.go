@@ -0,0 +1,50 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// UserOrders returns orders of a user.
+func (s *service) UserOrders(ctx context.Context, user model.User) ([]model.Order, error) {
+	return s.repository.GetUserOrders(ctx, repository.DefaultBackOff, user)
+}
+
+// Orders returns orders of all users, optionally in a given status only.
+func (s *service) Orders(ctx context.Context, status string) ([]model.Order, error) {
+	return s.repository.GetOrders(ctx, repository.DefaultBackOff, status)
+}
+
+// AdvanceOrder moves an order to a given status on behalf of a given member of the warehouse staff.
+func (s *service) AdvanceOrder(ctx context.Context, id int, status string, keeper model.User) (model.Order, error) {
+	order, err := s.repository.AdvanceOrder(ctx, repository.DefaultBackOff, id, status, keeper.UserName)
+	return order, orderError(err)
+}
+
+// CancelOrder cancels an order with refund on behalf of a given administrator.
+func (s *service) CancelOrder(ctx context.Context, id int, admin model.User) (model.Order, error) {
+	order, err := s.repository.CancelOrder(ctx, repository.DefaultBackOff, id, admin.UserName)
+	if errors.Is(err, repository.ErrAlreadyReversed) {
+		return model.Order{}, ErrAlreadyReversed
+	}
+	if errors.Is(err, repository.ErrNotEnoughItems) {
+		return model.Order{}, ErrNotEnoughItems
+	}
+
+	return order, orderError(err)
+}
+
+// orderError replaces repository errors common for order operations with service errors.
+func orderError(err error) error {
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrNoSuchOrder
+	}
+	if errors.Is(err, repository.ErrInvalidTransition) {
+		return ErrInvalidOrderTransition
+	}
+
+	return err
+}
@@ -0,0 +1,50 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// CreatePromotion creates a promotion with its codes on behalf of a given administrator.
+func (s *service) CreatePromotion(ctx context.Context, request model.PromotionRequest, admin model.User) (model.Promotion, error) {
+	promotion := model.Promotion{
+		Name:           request.Name,
+		Kind:           request.Kind,
+		Value:          request.Value,
+		Item:           request.Item,
+		ValidFrom:      time.Now(),
+		ValidTo:        request.ValidTo,
+		MaxUses:        request.MaxUses,
+		MaxUsesPerUser: request.MaxUsesPerUser,
+		Codes:          make([]model.PromoCode, 0, len(request.Codes)),
+	}
+	if request.ValidFrom != nil {
+		promotion.ValidFrom = *request.ValidFrom
+	}
+	for _, code := range request.Codes {
+		promotion.Codes = append(promotion.Codes, model.PromoCode{
+			Code:      code,
+			SingleUse: request.SingleUse,
+		})
+	}
+
+	promotion, err := s.repository.CreatePromotion(ctx, repository.DefaultBackOff, promotion, admin.UserName)
+	if errors.Is(err, repository.ErrConflict) {
+		return model.Promotion{}, ErrPromoCodeAlreadyExists
+	}
+
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+// Promotions returns all promotions.
+func (s *service) Promotions(ctx context.Context) ([]model.Promotion, error) {
+	return s.repository.GetPromotions(ctx, repository.DefaultBackOff)
+}
@@ -0,0 +1,48 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// QuoteItem quotes a purchase of a given quantity of inventory item with an optional promo code.
+// The quote passes the same checks as the purchase, but nothing is bought.
+func (s *service) QuoteItem(ctx context.Context, user model.User, item model.InventoryItem, promoCode string) (model.Quote, error) {
+	quote, err := s.repository.QuoteItem(ctx, repository.DefaultBackOff, user, item, promoCode, s.cfg.QuoteTTL)
+	if err != nil {
+		return model.Quote{}, buyError(err)
+	}
+
+	return quote, nil
+}
+
+// BuyQuotedItem buys a given quantity of inventory item at the price of the quote with a given token.
+func (s *service) BuyQuotedItem(ctx context.Context, user model.User, item model.InventoryItem, promoCode string, quoteToken string) error {
+	err := s.repository.BuyQuotedItem(ctx, repository.DefaultBackOff, user, item, promoCode, quoteToken)
+	return buyError(err)
+}
+
+// QuoteSendCoins quotes sending of coins from one user to another.
+// The quote passes the same checks as the sending, but no coins are sent and shortage of the balance is reported.
+func (s *service) QuoteSendCoins(ctx context.Context, fromUser model.User, toUser model.User, amount int64) (model.TransferQuote, error) {
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return model.TransferQuote{}, err
+	}
+	if amount < settings.MinTransferAmount {
+		return model.TransferQuote{}, ErrAmountBelowMinimum
+	}
+
+	quote, err := s.repository.QuoteSendCoins(ctx, repository.DefaultBackOff, fromUser, toUser, amount)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.TransferQuote{}, ErrNoSuchUser
+	}
+	if err != nil {
+		return model.TransferQuote{}, err
+	}
+
+	return quote, nil
+}
@@ -0,0 +1,59 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// ReverseTransfer reverses coins transfer with a given ID.
+func (s *service) ReverseTransfer(ctx context.Context, id int, force bool) (model.Reversal, error) {
+	reversal, err := s.repository.ReverseTransfer(ctx, repository.DefaultBackOff, id, force)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Reversal{}, ErrNoSuchTransfer
+	}
+	if errors.Is(err, repository.ErrAlreadyReversed) {
+		return model.Reversal{}, ErrAlreadyReversed
+	}
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		return model.Reversal{}, ErrNotEnoughBalance
+	}
+
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	return reversal, nil
+}
+
+// ReversePurchase reverses item purchase with a given ID.
+func (s *service) ReversePurchase(ctx context.Context, id int) (model.Reversal, error) {
+	reversal, err := s.repository.ReversePurchase(ctx, repository.DefaultBackOff, id)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Reversal{}, ErrNoSuchPurchase
+	}
+	if errors.Is(err, repository.ErrAlreadyReversed) {
+		return model.Reversal{}, ErrAlreadyReversed
+	}
+	if errors.Is(err, repository.ErrNotEnoughItems) {
+		return model.Reversal{}, ErrNotEnoughItems
+	}
+
+	if err != nil {
+		return model.Reversal{}, err
+	}
+
+	return reversal, nil
+}
+
+// UserOperations returns user transfers and purchases, that can be reversed.
+func (s *service) UserOperations(ctx context.Context, user model.User) (model.Operations, error) {
+	return s.repository.GetOperations(ctx, repository.DefaultBackOff, user)
+}
+
+// UserPurchases returns user purchases with the prices paid.
+func (s *service) UserPurchases(ctx context.Context, user model.User) ([]model.Purchase, error) {
+	return s.repository.GetPurchases(ctx, repository.DefaultBackOff, user)
+}
@@ -0,0 +1,61 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// Settings returns current economy settings,
+// the settings not changed by administrators are taken from the configuration.
+func (s *service) Settings(ctx context.Context) (model.Settings, error) {
+	defaults := model.Settings{
+		WelcomeBonus:      s.cfg.WelcomeBonus,
+		ReferralBonus:     s.cfg.ReferralBonus,
+		MinTransferAmount: s.cfg.MinTransferAmount,
+	}
+
+	return s.repository.GetSettings(ctx, repository.DefaultBackOff, defaults)
+}
+
+// UpdateSettings changes economy settings on behalf of a given administrator.
+func (s *service) UpdateSettings(ctx context.Context, update model.SettingsUpdate, admin model.User) (model.Settings, error) {
+	err := s.repository.UpdateSettings(ctx, repository.DefaultBackOff, update, admin.UserName)
+	if err != nil {
+		return model.Settings{}, err
+	}
+
+	return s.Settings(ctx)
+}
+
+// welcome creates the balance of new user with the welcome bonus
+// and grants the referral bonus to the user, that has invited new user.
+func (s *service) welcome(ctx context.Context, user model.User) error {
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.CreateBalance(ctx, repository.DefaultBackOff, user, settings.WelcomeBonus)
+	if err != nil {
+		return err
+	}
+
+	if user.Referrer == "" || settings.ReferralBonus == 0 {
+		return nil
+	}
+
+	referrer := model.User{
+		UserName: user.Referrer,
+	}
+
+	// Unknown referrer doesn't prevent new user from registration
+	err = s.repository.GrantReferralBonus(ctx, repository.DefaultBackOff, referrer, settings.ReferralBonus)
+	if err != nil && !errors.Is(err, repository.ErrNoData) {
+		return err
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/cenkalti/backoff/v4"
 
@@ -23,26 +24,211 @@ var (
 	ErrNotEnoughBalance       = fmt.Errorf("not enough coins to send")
 	ErrNoSuchItem             = fmt.Errorf("no such item")
 	ErrNoSuchUser             = fmt.Errorf("no such user to send coins")
+	ErrNoSuchTransfer         = fmt.Errorf("no such transfer")
+	ErrNoSuchPurchase         = fmt.Errorf("no such purchase")
+	ErrAlreadyReversed        = fmt.Errorf("operation has already been reversed")
+	ErrNoSuchAccount          = fmt.Errorf("no such user account")
+	ErrAmountOutOfRange       = fmt.Errorf("amount of coins is out of range")
+	ErrAmountBelowMinimum     = fmt.Errorf("amount of coins is less than the minimum transfer amount")
+	ErrNoSuchWallet           = fmt.Errorf("no such wallet")
+	ErrNoSuchMember           = fmt.Errorf("no such wallet member")
+	ErrWalletPermissionDenied = fmt.Errorf("wallet role doesn't allow the operation")
+	ErrWalletWithoutAdmin     = fmt.Errorf("wallet must have an administrator")
+	ErrNoSuchGift             = fmt.Errorf("no such gift")
+	ErrGiftAlreadyDeclined    = fmt.Errorf("gift has already been declined")
+	ErrNotEnoughItems         = fmt.Errorf("not enough items to transfer")
+	ErrNoSuchListing          = fmt.Errorf("no such listing")
+	ErrListingClosed          = fmt.Errorf("listing has already been sold or cancelled")
+	ErrOwnListing             = fmt.Errorf("seller can't buy own listing")
+	ErrNoReturnablePurchase   = fmt.Errorf("no purchase of the item to return")
+	ErrMerchAlreadyExists     = fmt.Errorf("merch of the type already exists")
+	ErrMerchArchived          = fmt.Errorf("merch has already been archived")
+	ErrMerchNotArchived       = fmt.Errorf("merch is not archived")
+	ErrOutOfStock             = fmt.Errorf("merch is out of stock")
+	ErrPriceAlreadyScheduled  = fmt.Errorf("price of the merch is already set from the time")
+	ErrEmptyCart              = fmt.Errorf("cart is empty")
+	ErrNotInCart              = fmt.Errorf("item is not in the cart")
+	ErrCheckoutFailed         = fmt.Errorf("some items of the cart can't be bought")
+	ErrInvalidPromoCode       = fmt.Errorf("promo code is not valid for the purchase")
+	ErrPromoCodeUsedUp        = fmt.Errorf("promo code has been used up")
+	ErrPromoCodeAlreadyExists = fmt.Errorf("promo code already exists")
+	ErrNoSuchVariant          = fmt.Errorf("no such variant of merch")
+	ErrVariantAlreadyExists   = fmt.Errorf("variant of merch already exists")
+	ErrPurchaseLimitReached   = fmt.Errorf("purchase limit of the merch has been reached")
+	ErrNoSuchPurchaseLimit    = fmt.Errorf("no purchase limit of the merch")
+	ErrNoSuchOrder            = fmt.Errorf("no such order")
+	ErrInvalidOrderTransition = fmt.Errorf("order can't be moved to the status")
+	ErrNotInWishlist          = fmt.Errorf("item is not in the wishlist")
+	ErrDropOverlaps           = fmt.Errorf("drop overlaps another drop of the merch")
+	ErrMerchNotOnSale         = fmt.Errorf("merch is sold in drops only and is not on sale now")
+	ErrInvalidQuote           = fmt.Errorf("quote is unknown, expired or doesn't match the purchase")
+	ErrUnknownHold            = fmt.Errorf("unknown hold")
+	ErrHoldClosed             = fmt.Errorf("hold has been captured, released or has expired")
 )
 
 // Service is the user service interface.
 type Service interface {
 	UserAuth(ctx context.Context, user model.User) error
+	ProvisionUser(ctx context.Context, user model.User) error
 	UserBalance(ctx context.Context, user model.User) error
 	UserInfo(ctx context.Context, user model.User) (model.Info, error)
-	SendCoins(ctx context.Context, fromUser model.User, toUser model.User, amount int) error
-	BuyItem(ctx context.Context, user model.User, item model.InventoryItem) error
+	SendCoins(ctx context.Context, fromUser model.User, toUser model.User, amount int64) error
+	BuyItem(ctx context.Context, user model.User, item model.InventoryItem, promoCode string) error
+	ReverseTransfer(ctx context.Context, id int, force bool) (model.Reversal, error)
+	ReversePurchase(ctx context.Context, id int) (model.Reversal, error)
+	UserOperations(ctx context.Context, user model.User) (model.Operations, error)
+	MintCoins(ctx context.Context, op model.CoinOperation) (model.CoinOperation, error)
+	BurnCoins(ctx context.Context, op model.CoinOperation) (model.CoinOperation, error)
+	MoneySupply(ctx context.Context) (model.MoneySupply, error)
+	Settings(ctx context.Context) (model.Settings, error)
+	UpdateSettings(ctx context.Context, update model.SettingsUpdate, admin model.User) (model.Settings, error)
+	ExpireCoins(ctx context.Context) (int64, error)
+	CreateWallet(ctx context.Context, user model.User, name string) (model.Wallet, error)
+	Wallets(ctx context.Context, user model.User) ([]model.Wallet, error)
+	Wallet(ctx context.Context, user model.User, id int) (model.Wallet, error)
+	SetWalletMember(ctx context.Context, user model.User, id int, member model.WalletMember) error
+	RemoveWalletMember(ctx context.Context, user model.User, id int, username string) error
+	FundWallet(ctx context.Context, user model.User, id int, amount int64) error
+	WalletSendCoins(ctx context.Context, member model.User, id int, toUser model.User, amount int64) error
+	WalletBuyItem(ctx context.Context, member model.User, id int, item model.InventoryItem) error
+	BuyGift(ctx context.Context, buyer model.User, recipient model.User, item model.InventoryItem, message string) (model.Gift, error)
+	DeclineGift(ctx context.Context, recipient model.User, id int) (model.Gift, error)
+	TransferItems(ctx context.Context, fromUser model.User, toUser model.User, item model.InventoryItem) (model.ItemTransfer, error)
+	CreateListing(ctx context.Context, seller model.User, item model.InventoryItem, price int64) (model.Listing, error)
+	CancelListing(ctx context.Context, seller model.User, id int) (model.Listing, error)
+	BuyListing(ctx context.Context, buyer model.User, id int) (model.Listing, error)
+	Listings(ctx context.Context, itemType string) ([]model.Listing, error)
+	UserListings(ctx context.Context, seller model.User) ([]model.Listing, error)
+	ReturnItem(ctx context.Context, user model.User, itemType string) (model.ItemReturn, error)
+	Catalog(ctx context.Context, filter model.CatalogFilter) ([]model.MerchItem, error)
+	CatalogItem(ctx context.Context, itemType string) (model.MerchItem, error)
+	CreateMerch(ctx context.Context, item model.MerchItem, admin model.User) (model.MerchItem, error)
+	UpdateMerch(ctx context.Context, itemType string, update model.MerchUpdate, admin model.User) (model.MerchItem, error)
+	ArchiveMerch(ctx context.Context, itemType string, admin model.User) (model.MerchItem, error)
+	RestoreMerch(ctx context.Context, itemType string, admin model.User) (model.MerchItem, error)
+	MerchAudit(ctx context.Context, itemType string) ([]model.MerchAuditEntry, error)
+	RestockMerch(ctx context.Context, itemType string, quantity int, admin model.User) (model.MerchItem, error)
+	LowStockMerch(ctx context.Context, threshold int) ([]model.MerchItem, error)
+	SetMerchPrice(ctx context.Context, itemType string, request model.MerchPriceRequest, admin model.User) (model.MerchPrice, error)
+	MerchPrices(ctx context.Context, itemType string) ([]model.MerchPrice, error)
+	ReconcileCatalog(ctx context.Context, declared []model.MerchRequest, dryRun bool) ([]model.CatalogChange, error)
+	CreateMerchDrop(ctx context.Context, itemType string, request model.MerchDropRequest, admin model.User) (model.MerchDrop, error)
+	MerchDrops(ctx context.Context) ([]model.MerchDrop, error)
+	QuoteItem(ctx context.Context, user model.User, item model.InventoryItem, promoCode string) (model.Quote, error)
+	BuyQuotedItem(ctx context.Context, user model.User, item model.InventoryItem, promoCode string, quoteToken string) error
+	QuoteSendCoins(ctx context.Context, fromUser model.User, toUser model.User, amount int64) (model.TransferQuote, error)
+	CreateHold(ctx context.Context, request model.HoldRequest, integration model.User) (model.Hold, error)
+	Hold(ctx context.Context, id int, integration model.User) (model.Hold, error)
+	CaptureHold(ctx context.Context, id int, amount int64, integration model.User) (model.Hold, error)
+	ReleaseHold(ctx context.Context, id int, integration model.User) (model.Hold, error)
+	ExpireHolds(ctx context.Context) (int, error)
+	UserPurchases(ctx context.Context, user model.User) ([]model.Purchase, error)
+	Cart(ctx context.Context, user model.User) (model.Cart, error)
+	AddToCart(ctx context.Context, user model.User, item model.InventoryItem) (model.Cart, error)
+	RemoveFromCart(ctx context.Context, user model.User, itemType string) (model.Cart, error)
+	Checkout(ctx context.Context, user model.User) (model.Cart, error)
+	CreatePromotion(ctx context.Context, request model.PromotionRequest, admin model.User) (model.Promotion, error)
+	Promotions(ctx context.Context) ([]model.Promotion, error)
+	MerchVariants(ctx context.Context, itemType string) ([]model.MerchVariant, error)
+	CreateMerchVariant(ctx context.Context, itemType string, request model.MerchVariantRequest) (model.MerchVariant, error)
+	RestockMerchVariant(ctx context.Context, itemType string, id int, quantity int) (model.MerchVariant, error)
+	UserInventory(ctx context.Context, user model.User) ([]model.InventoryItem, error)
+	SetPurchaseLimit(ctx context.Context, itemType string, request model.PurchaseLimitRequest, admin model.User) (model.PurchaseLimit, error)
+	RemovePurchaseLimit(ctx context.Context, itemType string) error
+	PurchaseLimits(ctx context.Context) ([]model.PurchaseLimit, error)
+	UserOrders(ctx context.Context, user model.User) ([]model.Order, error)
+	Orders(ctx context.Context, status string) ([]model.Order, error)
+	AdvanceOrder(ctx context.Context, id int, status string, keeper model.User) (model.Order, error)
+	CancelOrder(ctx context.Context, id int, admin model.User) (model.Order, error)
+	Wishlist(ctx context.Context, user model.User) (model.Wishlist, error)
+	AddToWishlist(ctx context.Context, user model.User, itemType string) (model.Wishlist, error)
+	RemoveFromWishlist(ctx context.Context, user model.User, itemType string) (model.Wishlist, error)
 }
 
 // Repository is the user service repository interface.
 type Repository interface {
 	CreateUser(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.User, error)
-	CreateBalance(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) error
-	SendCoins(ctx context.Context, bo *backoff.ExponentialBackOff, fromUser model.User, toUser model.User, amount int) error
-	BuyItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem) error
-	GetBalance(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (int, error)
+	GetUser(ctx context.Context, bo *backoff.ExponentialBackOff, userName string) (model.User, error)
+	CreateBalance(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, coins int64) error
+	SendCoins(ctx context.Context, bo *backoff.ExponentialBackOff, fromUser model.User, toUser model.User, amount int64) error
+	BuyItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string) error
+	GetBalance(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Balance, error)
 	GetInventory(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.InventoryItem, error)
 	GetHistory(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.CoinsHistory, error)
+	ReverseTransfer(ctx context.Context, bo *backoff.ExponentialBackOff, id int, force bool) (model.Reversal, error)
+	ReversePurchase(ctx context.Context, bo *backoff.ExponentialBackOff, id int) (model.Reversal, error)
+	GetReversals(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.Reversal, error)
+	GetOperations(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Operations, error)
+	MintCoins(ctx context.Context, bo *backoff.ExponentialBackOff, op model.CoinOperation) (model.CoinOperation, error)
+	BurnCoins(ctx context.Context, bo *backoff.ExponentialBackOff, op model.CoinOperation) (model.CoinOperation, error)
+	GrantCoins(ctx context.Context, bo *backoff.ExponentialBackOff, op model.CoinOperation, batchSize int) (model.CoinOperation, error)
+	GetMoneySupply(ctx context.Context, bo *backoff.ExponentialBackOff) (model.MoneySupply, error)
+	GetSettings(ctx context.Context, bo *backoff.ExponentialBackOff, defaults model.Settings) (model.Settings, error)
+	UpdateSettings(ctx context.Context, bo *backoff.ExponentialBackOff, update model.SettingsUpdate, actor string) error
+	GrantReferralBonus(ctx context.Context, bo *backoff.ExponentialBackOff, referrer model.User, amount int64) error
+	ExpireCoins(ctx context.Context, bo *backoff.ExponentialBackOff, batchSize int) (int64, error)
+	GetExpiringCoins(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, before time.Time) ([]model.ExpiringCoins, error)
+	CreateWallet(ctx context.Context, bo *backoff.ExponentialBackOff, wallet model.Wallet) (model.Wallet, error)
+	GetWallets(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.Wallet, error)
+	GetWallet(ctx context.Context, bo *backoff.ExponentialBackOff, id int, user model.User) (model.Wallet, error)
+	SetWalletMember(ctx context.Context, bo *backoff.ExponentialBackOff, id int, member model.WalletMember, actor string) error
+	RemoveWalletMember(ctx context.Context, bo *backoff.ExponentialBackOff, id int, username string, actor string) error
+	FundWallet(ctx context.Context, bo *backoff.ExponentialBackOff, id int, user model.User, amount int64) error
+	WalletSendCoins(ctx context.Context, bo *backoff.ExponentialBackOff, id int, member model.User, toUser model.User, amount int64) error
+	WalletBuyItem(ctx context.Context, bo *backoff.ExponentialBackOff, id int, member model.User, item model.InventoryItem) error
+	BuyGift(ctx context.Context, bo *backoff.ExponentialBackOff, buyer model.User, recipient model.User, item model.InventoryItem, message string) (model.Gift, error)
+	DeclineGift(ctx context.Context, bo *backoff.ExponentialBackOff, id int, recipient model.User) (model.Gift, error)
+	GetGifts(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Gifts, error)
+	TransferItems(ctx context.Context, bo *backoff.ExponentialBackOff, fromUser model.User, toUser model.User, item model.InventoryItem) (model.ItemTransfer, error)
+	GetItemsHistory(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.ItemsHistory, error)
+	CreateListing(ctx context.Context, bo *backoff.ExponentialBackOff, seller model.User, item model.InventoryItem, price int64) (model.Listing, error)
+	CancelListing(ctx context.Context, bo *backoff.ExponentialBackOff, id int, seller model.User) (model.Listing, error)
+	BuyListing(ctx context.Context, bo *backoff.ExponentialBackOff, id int, buyer model.User) (model.Listing, error)
+	GetListings(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) ([]model.Listing, error)
+	GetUserListings(ctx context.Context, bo *backoff.ExponentialBackOff, seller model.User) ([]model.Listing, error)
+	ReturnItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, itemType string, boughtAfter time.Time) (model.ItemReturn, error)
+	GetCatalog(ctx context.Context, bo *backoff.ExponentialBackOff, filter model.CatalogFilter) ([]model.MerchItem, error)
+	GetCatalogItem(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) (model.MerchItem, error)
+	CreateMerch(ctx context.Context, bo *backoff.ExponentialBackOff, item model.MerchItem, actor string) (model.MerchItem, error)
+	UpdateMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, update model.MerchUpdate, actor string) (model.MerchItem, error)
+	ArchiveMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, actor string) (model.MerchItem, error)
+	RestoreMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, actor string) (model.MerchItem, error)
+	GetMerchAudit(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) ([]model.MerchAuditEntry, error)
+	RestockMerch(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, quantity int, actor string) (model.MerchItem, error)
+	GetLowStockMerch(ctx context.Context, bo *backoff.ExponentialBackOff, threshold int) ([]model.MerchItem, error)
+	SetMerchPrice(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, price int64, validFrom time.Time, actor string) (model.MerchPrice, error)
+	GetMerchPrices(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) ([]model.MerchPrice, error)
+	GetPurchases(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.Purchase, error)
+	GetCart(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Cart, error)
+	AddCartItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem) error
+	RemoveCartItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, itemType string) error
+	Checkout(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Cart, error)
+	CreatePromotion(ctx context.Context, bo *backoff.ExponentialBackOff, promotion model.Promotion, actor string) (model.Promotion, error)
+	GetPromotions(ctx context.Context, bo *backoff.ExponentialBackOff) ([]model.Promotion, error)
+	CreateMerchVariant(ctx context.Context, bo *backoff.ExponentialBackOff, variant model.MerchVariant) (model.MerchVariant, error)
+	GetMerchVariants(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) ([]model.MerchVariant, error)
+	RestockMerchVariant(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string, id int, quantity int) (model.MerchVariant, error)
+	GetInventoryVariants(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.InventoryItem, error)
+	SetPurchaseLimit(ctx context.Context, bo *backoff.ExponentialBackOff, limit model.PurchaseLimit, actor string) (model.PurchaseLimit, error)
+	DeletePurchaseLimit(ctx context.Context, bo *backoff.ExponentialBackOff, itemType string) error
+	GetPurchaseLimits(ctx context.Context, bo *backoff.ExponentialBackOff) ([]model.PurchaseLimit, error)
+	GetUserOrders(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) ([]model.Order, error)
+	GetOrders(ctx context.Context, bo *backoff.ExponentialBackOff, status string) ([]model.Order, error)
+	AdvanceOrder(ctx context.Context, bo *backoff.ExponentialBackOff, id int, status string, actor string) (model.Order, error)
+	CancelOrder(ctx context.Context, bo *backoff.ExponentialBackOff, id int, actor string) (model.Order, error)
+	GetWishlist(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User) (model.Wishlist, error)
+	AddWishlistItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, itemType string) error
+	RemoveWishlistItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, itemType string) error
+	CreateMerchDrop(ctx context.Context, bo *backoff.ExponentialBackOff, drop model.MerchDrop, actor string) (model.MerchDrop, error)
+	GetMerchDrops(ctx context.Context, bo *backoff.ExponentialBackOff) ([]model.MerchDrop, error)
+	QuoteItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string, ttl time.Duration) (model.Quote, error)
+	BuyQuotedItem(ctx context.Context, bo *backoff.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string, quoteToken string) error
+	QuoteSendCoins(ctx context.Context, bo *backoff.ExponentialBackOff, fromUser model.User, toUser model.User, amount int64) (model.TransferQuote, error)
+	CreateHold(ctx context.Context, bo *backoff.ExponentialBackOff, hold model.Hold) (model.Hold, error)
+	GetHold(ctx context.Context, bo *backoff.ExponentialBackOff, id int) (model.Hold, error)
+	CaptureHold(ctx context.Context, bo *backoff.ExponentialBackOff, id int, amount int64) (model.Hold, error)
+	ReleaseHold(ctx context.Context, bo *backoff.ExponentialBackOff, id int) (model.Hold, error)
+	ExpireHolds(ctx context.Context, bo *backoff.ExponentialBackOff, batchSize int) (int, error)
 }
 
 // NewService creates new user service.
@@ -60,7 +246,12 @@ type service struct {
 }
 
 // UserAuth creates new user.
+// Privileged users are only authenticated, they are never created on the first authorization.
 func (s *service) UserAuth(ctx context.Context, user model.User) error {
+	if s.cfg.IsPrivileged(user.UserName) {
+		return s.authPrivileged(ctx, user)
+	}
+
 	// Replace password with hash
 	hash, err := auth.HashPassword(user.Password)
 	if err != nil {
@@ -82,20 +273,72 @@ func (s *service) UserAuth(ctx context.Context, user model.User) error {
 	}
 
 	if err == nil {
-		return s.repository.CreateBalance(ctx, repository.DefaultBackOff, user)
+		return s.welcome(ctx, user)
 	}
 
 	return nil
 }
 
-// UserBalance creates new user balance.
+// ProvisionUser creates an account of a user, privileged or not, with the welcome bonus.
+// It is used to provision accounts of privileged users before their first authorization.
+func (s *service) ProvisionUser(ctx context.Context, user model.User) error {
+	// Replace password with hash
+	hash, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+
+	// Create user in the repository
+	_, err = s.repository.CreateUser(ctx, repository.DefaultBackOff, user)
+	if errors.Is(err, repository.ErrConflict) {
+		return ErrUserNameIsAlreadyTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.welcome(ctx, user)
+}
+
+// authPrivileged authenticates a privileged user against the provisioned account.
+func (s *service) authPrivileged(ctx context.Context, user model.User) error {
+	userInRepo, err := s.repository.GetUser(ctx, repository.DefaultBackOff, user.UserName)
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrWrongUserNamePassword
+	}
+	if err != nil {
+		return err
+	}
+
+	if !auth.CheckPasswordHash(user.Password, userInRepo.Password) {
+		return ErrWrongUserNamePassword
+	}
+
+	return nil
+}
+
+// UserBalance creates new user balance with the welcome bonus.
 func (s *service) UserBalance(ctx context.Context, user model.User) error {
-	return s.repository.CreateBalance(ctx, repository.DefaultBackOff, user)
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.repository.CreateBalance(ctx, repository.DefaultBackOff, user, settings.WelcomeBonus)
 }
 
 // SendCoins sends given amount of coins from one user to another.
-func (s *service) SendCoins(ctx context.Context, fromUser model.User, toUser model.User, amount int) error {
-	err := s.repository.SendCoins(ctx, repository.DefaultBackOff, fromUser, toUser, amount)
+func (s *service) SendCoins(ctx context.Context, fromUser model.User, toUser model.User, amount int64) error {
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return err
+	}
+	if amount < settings.MinTransferAmount {
+		return ErrAmountBelowMinimum
+	}
+
+	err = s.repository.SendCoins(ctx, repository.DefaultBackOff, fromUser, toUser, amount)
 	if errors.Is(err, repository.ErrNoData) {
 		return ErrNoSuchUser
 	}
@@ -110,15 +353,44 @@ func (s *service) SendCoins(ctx context.Context, fromUser model.User, toUser mod
 	return nil
 }
 
-// BuyItem buys a given inventory item.
-func (s *service) BuyItem(ctx context.Context, user model.User, item model.InventoryItem) error {
-	err := s.repository.BuyItem(ctx, repository.DefaultBackOff, user, item)
+// BuyItem buys a given quantity of inventory item with an optional promo code.
+func (s *service) BuyItem(ctx context.Context, user model.User, item model.InventoryItem, promoCode string) error {
+	err := s.repository.BuyItem(ctx, repository.DefaultBackOff, user, item, promoCode)
+	return buyError(err)
+}
+
+// buyError replaces repository errors of a purchase with the service errors.
+func buyError(err error) error {
 	if errors.Is(err, repository.ErrNoData) {
 		return ErrNoSuchItem
 	}
 	if errors.Is(err, repository.ErrNegativeBalance) {
 		return ErrNotEnoughBalance
 	}
+	if errors.Is(err, repository.ErrOutOfRange) {
+		return ErrAmountOutOfRange
+	}
+	if errors.Is(err, repository.ErrOutOfStock) {
+		return ErrOutOfStock
+	}
+	if errors.Is(err, repository.ErrPromoNotApplicable) {
+		return ErrInvalidPromoCode
+	}
+	if errors.Is(err, repository.ErrPromoUsedUp) {
+		return ErrPromoCodeUsedUp
+	}
+	if errors.Is(err, repository.ErrNoVariant) {
+		return ErrNoSuchVariant
+	}
+	if errors.Is(err, repository.ErrLimitReached) {
+		return ErrPurchaseLimitReached
+	}
+	if errors.Is(err, repository.ErrNotOnSale) {
+		return ErrMerchNotOnSale
+	}
+	if errors.Is(err, repository.ErrInvalidQuote) {
+		return ErrInvalidQuote
+	}
 
 	if err != nil {
 		return err
@@ -129,7 +401,7 @@ func (s *service) BuyItem(ctx context.Context, user model.User, item model.Inven
 
 // UserInfo returns user info about coins, inventory and transaction history.
 func (s *service) UserInfo(ctx context.Context, user model.User) (model.Info, error) {
-	coins, err := s.repository.GetBalance(ctx, repository.DefaultBackOff, user)
+	balance, err := s.repository.GetBalance(ctx, repository.DefaultBackOff, user)
 	if err != nil {
 		return model.Info{}, err
 	}
@@ -144,9 +416,34 @@ func (s *service) UserInfo(ctx context.Context, user model.User) (model.Info, er
 		return model.Info{}, err
 	}
 
+	reversals, err := s.repository.GetReversals(ctx, repository.DefaultBackOff, user)
+	if err != nil {
+		return model.Info{}, err
+	}
+
+	expiring, err := s.repository.GetExpiringCoins(ctx, repository.DefaultBackOff, user, time.Now().Add(s.cfg.CoinExpiryNotice))
+	if err != nil {
+		return model.Info{}, err
+	}
+
+	gifts, err := s.repository.GetGifts(ctx, repository.DefaultBackOff, user)
+	if err != nil {
+		return model.Info{}, err
+	}
+
+	itemsHistory, err := s.repository.GetItemsHistory(ctx, repository.DefaultBackOff, user)
+	if err != nil {
+		return model.Info{}, err
+	}
+
 	return model.Info{
-		Coins:        coins,
-		Inventory:    inventory,
-		CoinsHistory: history,
+		Coins:          balance.Coins,
+		AvailableCoins: balance.Coins - balance.Held,
+		Inventory:      inventory,
+		CoinsHistory:   history,
+		Reversals:      reversals,
+		ExpiringCoins:  expiring,
+		Gifts:          gifts,
+		ItemsHistory:   itemsHistory,
 	}, nil
 }
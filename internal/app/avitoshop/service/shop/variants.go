@@ -0,0 +1,51 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// MerchVariants returns variants of merch of a given type.
+func (s *service) MerchVariants(ctx context.Context, itemType string) ([]model.MerchVariant, error) {
+	return s.repository.GetMerchVariants(ctx, repository.DefaultBackOff, itemType)
+}
+
+// CreateMerchVariant adds a variant to merch of a given type.
+func (s *service) CreateMerchVariant(ctx context.Context, itemType string, request model.MerchVariantRequest) (model.MerchVariant, error) {
+	variant, err := s.repository.CreateMerchVariant(ctx, repository.DefaultBackOff, model.MerchVariant{
+		Type:  itemType,
+		Size:  request.Size,
+		Color: request.Color,
+		Stock: request.Stock,
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return model.MerchVariant{}, ErrVariantAlreadyExists
+	}
+
+	return variant, merchError(err)
+}
+
+// RestockMerchVariant adds a quantity of a variant of merch to its stock.
+func (s *service) RestockMerchVariant(ctx context.Context, itemType string, id int, quantity int) (model.MerchVariant, error) {
+	variant, err := s.repository.RestockMerchVariant(ctx, repository.DefaultBackOff, itemType, id, quantity)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.MerchVariant{}, ErrNoSuchVariant
+	}
+	if errors.Is(err, repository.ErrOutOfRange) {
+		return model.MerchVariant{}, ErrAmountOutOfRange
+	}
+
+	if err != nil {
+		return model.MerchVariant{}, err
+	}
+
+	return variant, nil
+}
+
+// UserInventory returns inventory of a user by type and variant of merch.
+func (s *service) UserInventory(ctx context.Context, user model.User) ([]model.InventoryItem, error) {
+	return s.repository.GetInventoryVariants(ctx, repository.DefaultBackOff, user)
+}
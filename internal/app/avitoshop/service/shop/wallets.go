@@ -0,0 +1,119 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// CreateWallet creates new wallet with a given name, the user becomes its administrator.
+func (s *service) CreateWallet(ctx context.Context, user model.User, name string) (model.Wallet, error) {
+	return s.repository.CreateWallet(ctx, repository.DefaultBackOff, model.Wallet{
+		Name:      name,
+		CreatedBy: user.UserName,
+	})
+}
+
+// Wallets returns wallets, that a given user is a member of.
+func (s *service) Wallets(ctx context.Context, user model.User) ([]model.Wallet, error) {
+	return s.repository.GetWallets(ctx, repository.DefaultBackOff, user)
+}
+
+// Wallet returns a wallet with its members and history.
+func (s *service) Wallet(ctx context.Context, user model.User, id int) (model.Wallet, error) {
+	wallet, err := s.repository.GetWallet(ctx, repository.DefaultBackOff, id, user)
+	if errors.Is(err, repository.ErrNoWallet) {
+		return model.Wallet{}, ErrNoSuchWallet
+	}
+
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// SetWalletMember adds a member to a wallet or changes the role of the member on behalf of a given user.
+func (s *service) SetWalletMember(ctx context.Context, user model.User, id int, member model.WalletMember) error {
+	err := s.repository.SetWalletMember(ctx, repository.DefaultBackOff, id, member, user.UserName)
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrNoSuchAccount
+	}
+
+	return walletError(err)
+}
+
+// RemoveWalletMember removes a member from a wallet on behalf of a given user.
+func (s *service) RemoveWalletMember(ctx context.Context, user model.User, id int, username string) error {
+	err := s.repository.RemoveWalletMember(ctx, repository.DefaultBackOff, id, username, user.UserName)
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrNoSuchMember
+	}
+
+	return walletError(err)
+}
+
+// FundWallet moves a given amount of coins from the balance of a member to a wallet.
+func (s *service) FundWallet(ctx context.Context, user model.User, id int, amount int64) error {
+	err := s.repository.FundWallet(ctx, repository.DefaultBackOff, id, user, amount)
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrNoSuchAccount
+	}
+
+	return walletError(err)
+}
+
+// WalletSendCoins sends a given amount of coins from a wallet to a user on behalf of a member.
+func (s *service) WalletSendCoins(ctx context.Context, member model.User, id int, toUser model.User, amount int64) error {
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return err
+	}
+	if amount < settings.MinTransferAmount {
+		return ErrAmountBelowMinimum
+	}
+
+	err = s.repository.WalletSendCoins(ctx, repository.DefaultBackOff, id, member, toUser, amount)
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrNoSuchUser
+	}
+
+	return walletError(err)
+}
+
+// WalletBuyItem buys a given item with coins of a wallet on behalf of a member.
+func (s *service) WalletBuyItem(ctx context.Context, member model.User, id int, item model.InventoryItem) error {
+	err := s.repository.WalletBuyItem(ctx, repository.DefaultBackOff, id, member, item)
+	if errors.Is(err, repository.ErrNoData) {
+		return ErrNoSuchItem
+	}
+	if errors.Is(err, repository.ErrOutOfStock) {
+		return ErrOutOfStock
+	}
+	if errors.Is(err, repository.ErrNoVariant) {
+		return ErrNoSuchVariant
+	}
+	if errors.Is(err, repository.ErrLimitReached) {
+		return ErrPurchaseLimitReached
+	}
+
+	return walletError(err)
+}
+
+// walletError replaces repository errors common for wallet operations with service errors.
+func walletError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNoWallet):
+		return ErrNoSuchWallet
+	case errors.Is(err, repository.ErrPermissionDenied):
+		return ErrWalletPermissionDenied
+	case errors.Is(err, repository.ErrConflict):
+		return ErrWalletWithoutAdmin
+	case errors.Is(err, repository.ErrNegativeBalance):
+		return ErrNotEnoughBalance
+	}
+
+	return err
+}
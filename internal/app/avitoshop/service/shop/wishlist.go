@@ -0,0 +1,42 @@
+package shop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/app/avitoshop/service/repository"
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// Wishlist returns the wishlist of a user.
+func (s *service) Wishlist(ctx context.Context, user model.User) (model.Wishlist, error) {
+	return s.repository.GetWishlist(ctx, repository.DefaultBackOff, user)
+}
+
+// AddToWishlist adds an item to the wishlist of a user and returns the wishlist.
+func (s *service) AddToWishlist(ctx context.Context, user model.User, itemType string) (model.Wishlist, error) {
+	err := s.repository.AddWishlistItem(ctx, repository.DefaultBackOff, user, itemType)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Wishlist{}, ErrNoSuchItem
+	}
+
+	if err != nil {
+		return model.Wishlist{}, err
+	}
+
+	return s.Wishlist(ctx, user)
+}
+
+// RemoveFromWishlist removes an item from the wishlist of a user and returns the wishlist.
+func (s *service) RemoveFromWishlist(ctx context.Context, user model.User, itemType string) (model.Wishlist, error) {
+	err := s.repository.RemoveWishlistItem(ctx, repository.DefaultBackOff, user, itemType)
+	if errors.Is(err, repository.ErrNoData) {
+		return model.Wishlist{}, ErrNotInWishlist
+	}
+
+	if err != nil {
+		return model.Wishlist{}, err
+	}
+
+	return s.Wishlist(ctx, user)
+}
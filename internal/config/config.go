@@ -1,10 +1,14 @@
-// Package config provides application configuration building.  
+// Package config provides application configuration building.
 package config
 
 import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // ErrInitConfigFailed - config initialization error.
@@ -12,17 +16,85 @@ var ErrInitConfigFailed = fmt.Errorf("failed to init config")
 
 // Config - application configuration structure.
 type Config struct {
-	RunAddress  string // Address and port of HTTP server
-	DatabaseURI string // Address for database connection
-	SecretKey   string // Authentication secret key
+	RunAddress     string   // Address and port of HTTP server
+	DatabaseURI    string   // Address for database connection
+	SecretKey      string   // Authentication secret key
+	Admins         []string // Names of users with administrative rights
+	Warehouse      []string // Names of warehouse staff, that fulfil orders of merch
+	Integrations   []string // Names of integrations, that hold coins on balances of users
+	GrantBatchSize int      // Number of users granted coins in one transaction
 
+	WelcomeBonus      int64 // Default number of coins on the balance of new user
+	ReferralBonus     int64 // Default number of coins granted to the user, that has invited new user
+	MinTransferAmount int64 // Default minimum number of coins in one transfer
+
+	CoinTTL            time.Duration // Time after which received coins expire
+	CoinExpiryNotice   time.Duration // Time before expiry when coins are shown as expiring soon
+	CoinExpiryInterval time.Duration // Interval of the background expiry of coins
+
+	ReturnWindow time.Duration // Time after purchase during which an item can be returned
+	QuoteTTL     time.Duration // Time during which a purchase quote can be honoured
+
+	HoldTTL            time.Duration // Default time after which a balance hold expires
+	HoldExpiryInterval time.Duration // Interval of the background expiry of balance holds
+
+	LowStockThreshold int // Quantity of merch in stock, at which the stock is reported as low
+
+	LegacyBuyRoute bool // Whether items can be bought with GET /api/buy/{item}
+
+	CatalogFile string // Path of the merch catalog file reconciled at startup, the catalog is not reconciled if empty
+}
+
+// IsAdmin reports whether the user with the given name is an administrator.
+func (c *Config) IsAdmin(userName string) bool {
+	return slices.Contains(c.Admins, userName)
+}
+
+// IsIntegration reports whether the user with the given name can hold coins - is an integration or an administrator.
+func (c *Config) IsIntegration(userName string) bool {
+	return slices.Contains(c.Integrations, userName) || c.IsAdmin(userName)
+}
+
+// IsWarehouse reports whether the user with the given name can fulfil orders - is warehouse staff or an administrator.
+func (c *Config) IsWarehouse(userName string) bool {
+	return slices.Contains(c.Warehouse, userName) || c.IsAdmin(userName)
+}
+
+// IsPrivileged reports whether the user with the given name has any rights beyond an ordinary user.
+// Privileged users are not registered on the first authorization, their accounts are provisioned in advance.
+func (c *Config) IsPrivileged(userName string) bool {
+	return c.IsWarehouse(userName) || c.IsIntegration(userName)
 }
 
 // configBuilder - application configuration builder.
 type configBuilder struct {
-	runAddress  string `env:"RUN_ADDRESS"`
-	databaseURI string `env:"DATABASE_URI"`
-	secretKey   string `env:"SECRET_KEY"`
+	runAddress     string `env:"RUN_ADDRESS"`
+	databaseURI    string `env:"DATABASE_URI"`
+	secretKey      string `env:"SECRET_KEY"`
+	admins         string `env:"ADMINS"`
+	warehouse      string `env:"WAREHOUSE"`
+	integrations   string `env:"INTEGRATIONS"`
+	grantBatchSize int    `env:"GRANT_BATCH_SIZE"`
+
+	welcomeBonus      int64 `env:"WELCOME_BONUS"`
+	referralBonus     int64 `env:"REFERRAL_BONUS"`
+	minTransferAmount int64 `env:"MIN_TRANSFER_AMOUNT"`
+
+	coinTTL            time.Duration `env:"COIN_TTL"`
+	coinExpiryNotice   time.Duration `env:"COIN_EXPIRY_NOTICE"`
+	coinExpiryInterval time.Duration `env:"COIN_EXPIRY_INTERVAL"`
+
+	returnWindow time.Duration `env:"RETURN_WINDOW"`
+	quoteTTL     time.Duration `env:"QUOTE_TTL"`
+
+	holdTTL            time.Duration `env:"HOLD_TTL"`
+	holdExpiryInterval time.Duration `env:"HOLD_EXPIRY_INTERVAL"`
+
+	lowStockThreshold int `env:"LOW_STOCK_THRESHOLD"`
+
+	legacyBuyRoute bool `env:"LEGACY_BUY_ROUTE"`
+
+	catalogFile string `env:"CATALOG_FILE"`
 }
 
 // newConfigBuilder creates new application configuration builder.
@@ -35,6 +107,23 @@ func (cb *configBuilder) setDefaults() error {
 	cb.runAddress = ":8080"
 	cb.databaseURI = ""
 	cb.secretKey = "secret"
+	cb.admins = ""
+	cb.warehouse = ""
+	cb.integrations = ""
+	cb.grantBatchSize = 1000
+	cb.welcomeBonus = 1000
+	cb.referralBonus = 0
+	cb.minTransferAmount = 1
+	cb.coinTTL = 365 * 24 * time.Hour
+	cb.coinExpiryNotice = 30 * 24 * time.Hour
+	cb.coinExpiryInterval = time.Hour
+	cb.returnWindow = 14 * 24 * time.Hour
+	cb.quoteTTL = 5 * time.Minute
+	cb.holdTTL = 24 * time.Hour
+	cb.holdExpiryInterval = time.Minute
+	cb.lowStockThreshold = 5
+	cb.legacyBuyRoute = true
+	cb.catalogFile = ""
 
 	return nil
 }
@@ -69,16 +158,150 @@ func (cb *configBuilder) setEnvs() error {
 		cb.secretKey = sk
 	}
 
+	adm := os.Getenv("ADMINS")
+	if adm != "" {
+		cb.admins = adm
+	}
+
+	wh := os.Getenv("WAREHOUSE")
+	if wh != "" {
+		cb.warehouse = wh
+	}
+
+	itg := os.Getenv("INTEGRATIONS")
+	if itg != "" {
+		cb.integrations = itg
+	}
+
+	gbs := os.Getenv("GRANT_BATCH_SIZE")
+	if gbs != "" {
+		batchSize, err := strconv.Atoi(gbs)
+		if err != nil || batchSize <= 0 {
+			return fmt.Errorf("invalid grant batch size: %s", gbs)
+		}
+		cb.grantBatchSize = batchSize
+	}
+
+	wb := os.Getenv("WELCOME_BONUS")
+	if wb != "" {
+		welcomeBonus, err := strconv.ParseInt(wb, 10, 64)
+		if err != nil || welcomeBonus < 0 {
+			return fmt.Errorf("invalid welcome bonus: %s", wb)
+		}
+		cb.welcomeBonus = welcomeBonus
+	}
+
+	rb := os.Getenv("REFERRAL_BONUS")
+	if rb != "" {
+		referralBonus, err := strconv.ParseInt(rb, 10, 64)
+		if err != nil || referralBonus < 0 {
+			return fmt.Errorf("invalid referral bonus: %s", rb)
+		}
+		cb.referralBonus = referralBonus
+	}
+
+	mta := os.Getenv("MIN_TRANSFER_AMOUNT")
+	if mta != "" {
+		minTransferAmount, err := strconv.ParseInt(mta, 10, 64)
+		if err != nil || minTransferAmount < 1 {
+			return fmt.Errorf("invalid minimum transfer amount: %s", mta)
+		}
+		cb.minTransferAmount = minTransferAmount
+	}
+
+	lst := os.Getenv("LOW_STOCK_THRESHOLD")
+	if lst != "" {
+		threshold, err := strconv.Atoi(lst)
+		if err != nil || threshold < 0 {
+			return fmt.Errorf("invalid low stock threshold: %s", lst)
+		}
+		cb.lowStockThreshold = threshold
+	}
+
+	lbr := os.Getenv("LEGACY_BUY_ROUTE")
+	if lbr != "" {
+		legacyBuyRoute, err := strconv.ParseBool(lbr)
+		if err != nil {
+			return fmt.Errorf("invalid legacy buy route: %s", lbr)
+		}
+		cb.legacyBuyRoute = legacyBuyRoute
+	}
+
+	cf := os.Getenv("CATALOG_FILE")
+	if cf != "" {
+		cb.catalogFile = cf
+	}
+
+	durations := []struct {
+		env   string
+		value *time.Duration
+	}{
+		{"COIN_TTL", &cb.coinTTL},
+		{"COIN_EXPIRY_NOTICE", &cb.coinExpiryNotice},
+		{"COIN_EXPIRY_INTERVAL", &cb.coinExpiryInterval},
+		{"RETURN_WINDOW", &cb.returnWindow},
+		{"QUOTE_TTL", &cb.quoteTTL},
+		{"HOLD_TTL", &cb.holdTTL},
+		{"HOLD_EXPIRY_INTERVAL", &cb.holdExpiryInterval},
+	}
+	for _, d := range durations {
+		env := os.Getenv(d.env)
+		if env == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(env)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("invalid %s: %s", d.env, env)
+		}
+		*d.value = duration
+	}
+
 	return nil
 }
 
 // build builds application cofiguration.
 func (cb *configBuilder) build() *Config {
 	return &Config{
-		RunAddress:  cb.runAddress,
-		DatabaseURI: cb.databaseURI,
-		SecretKey:   cb.secretKey,
+		RunAddress:     cb.runAddress,
+		DatabaseURI:    cb.databaseURI,
+		SecretKey:      cb.secretKey,
+		Admins:         splitList(cb.admins),
+		Warehouse:      splitList(cb.warehouse),
+		Integrations:   splitList(cb.integrations),
+		GrantBatchSize: cb.grantBatchSize,
+
+		WelcomeBonus:      cb.welcomeBonus,
+		ReferralBonus:     cb.referralBonus,
+		MinTransferAmount: cb.minTransferAmount,
+
+		CoinTTL:            cb.coinTTL,
+		CoinExpiryNotice:   cb.coinExpiryNotice,
+		CoinExpiryInterval: cb.coinExpiryInterval,
+
+		ReturnWindow: cb.returnWindow,
+		QuoteTTL:     cb.quoteTTL,
+
+		HoldTTL:            cb.holdTTL,
+		HoldExpiryInterval: cb.holdExpiryInterval,
+
+		LowStockThreshold: cb.lowStockThreshold,
+
+		LegacyBuyRoute: cb.legacyBuyRoute,
+
+		CatalogFile: cb.catalogFile,
+	}
+}
+
+// splitList splits comma separated list of values, skipping empty ones.
+func splitList(list string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
 	}
+	return values
 }
 
 // Get returns application configuration.
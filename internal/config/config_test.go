@@ -3,6 +3,7 @@ package config_test
 import (
 	"flag"
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -121,6 +122,242 @@ var _ = Describe("Config", func() {
 		Entry(nil, sk.envNam, "", sk.defVal, sk.defVal),
 		Entry(nil, "", "", sk.defVal, sk.defVal),
 	)
+
+	// Admins
+	DescribeTable("Admins",
+		func(envVal string, expected []string) {
+			setEnv("ADMINS", envVal)
+
+			cfg, err = config.Get()
+
+			Expect(err).Should(BeNil())
+			Expect(cfg.Admins).To(Equal(expected))
+			for _, admin := range expected {
+				Expect(cfg.IsAdmin(admin)).To(BeTrue())
+			}
+			Expect(cfg.IsAdmin("user")).To(BeFalse())
+		},
+
+		EntryDescription("When env ADMINS=%s"),
+		Entry(nil, "admin", []string{"admin"}),
+		Entry(nil, "admin, root,", []string{"admin", "root"}),
+		Entry(nil, "", []string{}),
+	)
+
+	// Warehouse
+	DescribeTable("Warehouse",
+		func(envVal string, expected []string) {
+			setEnv("ADMINS", "admin")
+			setEnv("WAREHOUSE", envVal)
+
+			cfg, err = config.Get()
+
+			Expect(err).Should(BeNil())
+			Expect(cfg.Warehouse).To(Equal(expected))
+			for _, keeper := range expected {
+				Expect(cfg.IsWarehouse(keeper)).To(BeTrue())
+				Expect(cfg.IsPrivileged(keeper)).To(BeTrue())
+			}
+			Expect(cfg.IsWarehouse("admin")).To(BeTrue())
+			Expect(cfg.IsWarehouse("user")).To(BeFalse())
+			Expect(cfg.IsPrivileged("admin")).To(BeTrue())
+			Expect(cfg.IsPrivileged("user")).To(BeFalse())
+		},
+
+		EntryDescription("When env WAREHOUSE=%s"),
+		Entry(nil, "keeper", []string{"keeper"}),
+		Entry(nil, "keeper, loader,", []string{"keeper", "loader"}),
+		Entry(nil, "", []string{}),
+	)
+
+	// Integrations
+	DescribeTable("Integrations",
+		func(envVal string, expected []string) {
+			setEnv("ADMINS", "admin")
+			setEnv("INTEGRATIONS", envVal)
+
+			cfg, err = config.Get()
+
+			Expect(err).Should(BeNil())
+			Expect(cfg.Integrations).To(Equal(expected))
+			for _, integration := range expected {
+				Expect(cfg.IsIntegration(integration)).To(BeTrue())
+				Expect(cfg.IsPrivileged(integration)).To(BeTrue())
+			}
+			Expect(cfg.IsIntegration("admin")).To(BeTrue())
+			Expect(cfg.IsIntegration("user")).To(BeFalse())
+		},
+
+		EntryDescription("When env INTEGRATIONS=%s"),
+		Entry(nil, "canteen", []string{"canteen"}),
+		Entry(nil, "canteen, events,", []string{"canteen", "events"}),
+		Entry(nil, "", []string{}),
+	)
+
+	// Grant batch size
+	DescribeTable("Grant batch size",
+		func(envVal string, expected int, fails bool) {
+			setEnv("GRANT_BATCH_SIZE", envVal)
+
+			cfg, err = config.Get()
+
+			if fails {
+				Expect(err).Should(Equal(config.ErrInitConfigFailed))
+				return
+			}
+			Expect(err).Should(BeNil())
+			Expect(cfg.GrantBatchSize).To(Equal(expected))
+		},
+
+		EntryDescription("When env GRANT_BATCH_SIZE=%s"),
+		Entry(nil, "500", 500, false),
+		Entry(nil, "", 1000, false),
+		Entry(nil, "0", 0, true),
+		Entry(nil, "many", 0, true),
+	)
+
+	// Low stock threshold
+	DescribeTable("Low stock threshold",
+		func(envVal string, expected int, fails bool) {
+			setEnv("LOW_STOCK_THRESHOLD", envVal)
+
+			cfg, err = config.Get()
+
+			if fails {
+				Expect(err).Should(Equal(config.ErrInitConfigFailed))
+				return
+			}
+			Expect(err).Should(BeNil())
+			Expect(cfg.LowStockThreshold).To(Equal(expected))
+		},
+
+		EntryDescription("When env LOW_STOCK_THRESHOLD=%s"),
+		Entry(nil, "10", 10, false),
+		Entry(nil, "0", 0, false),
+		Entry(nil, "", 5, false),
+		Entry(nil, "-1", 0, true),
+	)
+
+	// Legacy buy route
+	DescribeTable("Legacy buy route",
+		func(envVal string, expected bool, fails bool) {
+			setEnv("LEGACY_BUY_ROUTE", envVal)
+
+			cfg, err = config.Get()
+
+			if fails {
+				Expect(err).Should(Equal(config.ErrInitConfigFailed))
+				return
+			}
+			Expect(err).Should(BeNil())
+			Expect(cfg.LegacyBuyRoute).To(Equal(expected))
+		},
+
+		EntryDescription("When env LEGACY_BUY_ROUTE=%s"),
+		Entry(nil, "false", false, false),
+		Entry(nil, "true", true, false),
+		Entry(nil, "", true, false),
+		Entry(nil, "sometimes", false, true),
+	)
+
+	// Catalog file
+	DescribeTable("Catalog file",
+		func(envVal string, expected string) {
+			setEnv("CATALOG_FILE", envVal)
+
+			cfg, err = config.Get()
+
+			Expect(err).Should(BeNil())
+			Expect(cfg.CatalogFile).To(Equal(expected))
+		},
+
+		EntryDescription("When env CATALOG_FILE=%s"),
+		Entry(nil, "configs/catalog.json", "configs/catalog.json"),
+		Entry(nil, "", ""),
+	)
+
+	// Economy settings
+	DescribeTable("Economy settings",
+		func(envName, envVal string, expected int64, fails bool) {
+			setEnv(envName, envVal)
+
+			cfg, err = config.Get()
+
+			if fails {
+				Expect(err).Should(Equal(config.ErrInitConfigFailed))
+				return
+			}
+			Expect(err).Should(BeNil())
+
+			switch envName {
+			case "WELCOME_BONUS":
+				Expect(cfg.WelcomeBonus).To(Equal(expected))
+			case "REFERRAL_BONUS":
+				Expect(cfg.ReferralBonus).To(Equal(expected))
+			case "MIN_TRANSFER_AMOUNT":
+				Expect(cfg.MinTransferAmount).To(Equal(expected))
+			}
+		},
+
+		EntryDescription("When env %s=%s"),
+		Entry(nil, "WELCOME_BONUS", "500", int64(500), false),
+		Entry(nil, "WELCOME_BONUS", "", int64(1000), false),
+		Entry(nil, "WELCOME_BONUS", "-1", int64(0), true),
+		Entry(nil, "REFERRAL_BONUS", "100", int64(100), false),
+		Entry(nil, "REFERRAL_BONUS", "", int64(0), false),
+		Entry(nil, "MIN_TRANSFER_AMOUNT", "10", int64(10), false),
+		Entry(nil, "MIN_TRANSFER_AMOUNT", "", int64(1), false),
+		Entry(nil, "MIN_TRANSFER_AMOUNT", "0", int64(0), true),
+	)
+
+	// Coin expiry
+	DescribeTable("Coin expiry",
+		func(envName, envVal string, expected time.Duration, fails bool) {
+			setEnv(envName, envVal)
+
+			cfg, err = config.Get()
+
+			if fails {
+				Expect(err).Should(Equal(config.ErrInitConfigFailed))
+				return
+			}
+			Expect(err).Should(BeNil())
+
+			switch envName {
+			case "COIN_TTL":
+				Expect(cfg.CoinTTL).To(Equal(expected))
+			case "COIN_EXPIRY_NOTICE":
+				Expect(cfg.CoinExpiryNotice).To(Equal(expected))
+			case "COIN_EXPIRY_INTERVAL":
+				Expect(cfg.CoinExpiryInterval).To(Equal(expected))
+			case "RETURN_WINDOW":
+				Expect(cfg.ReturnWindow).To(Equal(expected))
+			case "QUOTE_TTL":
+				Expect(cfg.QuoteTTL).To(Equal(expected))
+			case "HOLD_TTL":
+				Expect(cfg.HoldTTL).To(Equal(expected))
+			case "HOLD_EXPIRY_INTERVAL":
+				Expect(cfg.HoldExpiryInterval).To(Equal(expected))
+			}
+		},
+
+		EntryDescription("When env %s=%s"),
+		Entry(nil, "COIN_TTL", "720h", 720*time.Hour, false),
+		Entry(nil, "COIN_TTL", "", 365*24*time.Hour, false),
+		Entry(nil, "COIN_TTL", "month", time.Duration(0), true),
+		Entry(nil, "COIN_EXPIRY_NOTICE", "", 30*24*time.Hour, false),
+		Entry(nil, "COIN_EXPIRY_INTERVAL", "10m", 10*time.Minute, false),
+		Entry(nil, "COIN_EXPIRY_INTERVAL", "-1h", time.Duration(0), true),
+		Entry(nil, "RETURN_WINDOW", "", 14*24*time.Hour, false),
+		Entry(nil, "RETURN_WINDOW", "48h", 48*time.Hour, false),
+		Entry(nil, "QUOTE_TTL", "", 5*time.Minute, false),
+		Entry(nil, "QUOTE_TTL", "30s", 30*time.Second, false),
+		Entry(nil, "QUOTE_TTL", "0s", time.Duration(0), true),
+		Entry(nil, "HOLD_TTL", "", 24*time.Hour, false),
+		Entry(nil, "HOLD_TTL", "2h", 2*time.Hour, false),
+		Entry(nil, "HOLD_EXPIRY_INTERVAL", "", time.Minute, false),
+		Entry(nil, "HOLD_EXPIRY_INTERVAL", "soon", time.Duration(0), true),
+	)
 })
 
 func setEnv(name, value string) {
@@ -6,35 +6,259 @@ package queries
 
 import (
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Balance struct {
 	ID       int32
 	Username string
-	Coins    int32
+	Coins    int64
+	Held     int64
 }
 
-type History struct {
-	ID       int32
-	Username string
-	FromUser string
-	ToUser   string
-	Amount   int32
-	SentAt   time.Time
+type BalanceHold struct {
+	ID        int32
+	Username  string
+	Amount    int64
+	Captured  int64
+	Status    string
+	Reason    string
+	CreatedBy string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	ClosedAt  pgtype.Timestamp
 }
 
-type Inventory struct {
-	ID       int32
+type CartItem struct {
 	Username string
 	Type     string
 	Quantity int32
-	BoughtAt time.Time
+	AddedAt  time.Time
+	Size     string
+	Color    string
+}
+
+type CoinLot struct {
+	ID         int32
+	Username   string
+	Source     string
+	Amount     int64
+	Remaining  int64
+	ReceivedAt time.Time
+	ExpiresAt  time.Time
+}
+
+type CoinOperation struct {
+	ID            int32
+	Kind          string
+	Username      string
+	Amount        int64
+	Recipients    int32
+	LastBalanceID int32
+	Actor         string
+	Reason        string
+	CreatedAt     time.Time
+}
+
+type Gift struct {
+	ID          int32
+	FromUser    string
+	ToUser      string
+	Type        string
+	PricePaid   int64
+	Message     string
+	Status      string
+	InventoryID int32
+	CreatedAt   time.Time
+	DeclinedAt  pgtype.Timestamp
+	VariantID   pgtype.Int4
+}
+
+type History struct {
+	ID              int32
+	Username        string
+	FromUser        string
+	ToUser          string
+	Amount          int64
+	SentAt          time.Time
+	Kind            string
+	ReversalOf      pgtype.Int4
+	CoinOperationID pgtype.Int4
+	WalletID        pgtype.Int4
+}
+
+type Inventory struct {
+	ID         int32
+	Username   string
+	Type       string
+	Quantity   int32
+	BoughtAt   time.Time
+	Kind       string
+	PricePaid  int64
+	ReversalOf pgtype.Int4
+	Discount   int64
+	VariantID  pgtype.Int4
+}
+
+type ItemTransfer struct {
+	ID        int32
+	FromUser  string
+	ToUser    string
+	Type      string
+	Quantity  int32
+	CreatedAt time.Time
+}
+
+type Listing struct {
+	ID        int32
+	Seller    string
+	Type      string
+	Price     int64
+	Status    string
+	Buyer     string
+	CreatedAt time.Time
+	ClosedAt  pgtype.Timestamp
+	VariantID pgtype.Int4
 }
 
 type Merch struct {
-	ID    int32
-	Type  string
-	Price int32
+	ID          int32
+	Type        string
+	Price       int64
+	Description string
+	Available   bool
+	Stock       pgtype.Int4
+}
+
+type MerchAudit struct {
+	ID          int32
+	Type        string
+	Action      string
+	Price       int64
+	Description string
+	Available   bool
+	Actor       string
+	CreatedAt   time.Time
+	Stock       pgtype.Int4
+}
+
+type MerchBackInStock struct {
+	ID        int32
+	Type      string
+	Price     int64
+	CreatedAt time.Time
+}
+
+type MerchCurrent struct {
+	ID          int32
+	Type        string
+	Price       int64
+	Description string
+	Available   bool
+	Stock       pgtype.Int4
+}
+
+type MerchDrop struct {
+	ID        int32
+	Type      string
+	StartsAt  time.Time
+	EndsAt    time.Time
+	Price     pgtype.Int8
+	Stock     pgtype.Int4
+	Actor     string
+	CreatedAt time.Time
+}
+
+type MerchPrice struct {
+	ID        int32
+	Type      string
+	Price     int64
+	ValidFrom time.Time
+	ValidTo   pgtype.Timestamp
+	Actor     string
+	CreatedAt time.Time
+}
+
+type MerchVariant struct {
+	ID        int32
+	Type      string
+	Size      string
+	Color     string
+	Stock     pgtype.Int4
+	CreatedAt time.Time
+}
+
+type Order struct {
+	ID          int32
+	InventoryID int32
+	Username    string
+	Type        string
+	Quantity    int32
+	Status      string
+	UpdatedBy   string
+	PlacedAt    time.Time
+	ReadyAt     pgtype.Timestamp
+	DeliveredAt pgtype.Timestamp
+	CancelledAt pgtype.Timestamp
+}
+
+type PromoCode struct {
+	Code        string
+	PromotionID int32
+	SingleUse   bool
+}
+
+type PromoRedemption struct {
+	ID          int32
+	PromotionID int32
+	Code        string
+	Username    string
+	InventoryID int32
+	Discount    int64
+	CreatedAt   time.Time
+}
+
+type Promotion struct {
+	ID             int32
+	Name           string
+	Kind           string
+	Value          int64
+	Type           pgtype.Text
+	ValidFrom      time.Time
+	ValidTo        pgtype.Timestamp
+	MaxUses        pgtype.Int4
+	MaxUsesPerUser pgtype.Int4
+	Actor          string
+	CreatedAt      time.Time
+}
+
+type PurchaseLimit struct {
+	Type        string
+	MaxQuantity int32
+	PeriodDays  pgtype.Int4
+	Actor       string
+	UpdatedAt   time.Time
+}
+
+type PurchaseQuote struct {
+	Token     string
+	Username  string
+	Type      string
+	Size      string
+	Color     string
+	Quantity  int32
+	PromoCode string
+	Price     int64
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type Setting struct {
+	Name      string
+	Value     int64
+	UpdatedBy string
+	UpdatedAt time.Time
 }
 
 type User struct {
@@ -42,4 +266,39 @@ type User struct {
 	Username  string
 	Password  string
 	CreatedAt time.Time
+	Referrer  string
+}
+
+type Wallet struct {
+	ID        int32
+	Name      string
+	Coins     int64
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+type WalletHistory struct {
+	ID          int32
+	WalletID    int32
+	Username    string
+	Kind        string
+	Amount      int64
+	ToUser      string
+	Item        string
+	CreatedAt   time.Time
+	InventoryID pgtype.Int4
+}
+
+type WalletMember struct {
+	WalletID int32
+	Username string
+	Role     string
+	AddedBy  string
+	AddedAt  time.Time
+}
+
+type WishlistItem struct {
+	Username string
+	Type     string
+	AddedAt  time.Time
 }
@@ -1,18 +1,18 @@
 -- name: CreateUser :one
-INSERT INTO users (username, password)
-VALUES ($1, $2) RETURNING id;
+INSERT INTO users (username, password, referrer)
+VALUES ($1, $2, $3) RETURNING id;
 
 -- name: GetUser :one
-SELECT id, username, password, created_at
+SELECT id, username, password, created_at, referrer
 FROM users
 WHERE username = $1 LIMIT 1;
 
 -- name: CreateBalance :one
 INSERT INTO balance (username, coins)
-VALUES ($1, 1000) RETURNING id;
+VALUES ($1, $2) RETURNING id;
 
 -- name: GetBalance :one
-SELECT id, username, coins
+SELECT id, username, coins, held
 FROM balance
 WHERE username = $1 LIMIT 1;
 
@@ -22,26 +22,827 @@ SET coins = coins + $2
 WHERE username = $1 RETURNING coins;
 
 -- name: GetMerch :one
-SELECT id, type, price
-FROM merch
-WHERE type = $1 LIMIT 1;
+SELECT id, type, price, description, available, stock
+FROM merch_current m
+WHERE type = $1
+  AND available
+  AND NOT EXISTS (SELECT 1 FROM merch_drops d WHERE d.type = m.type) LIMIT 1;
 
 -- name: CreateHistoryRecord :one
 INSERT INTO history (username, from_user, to_user, amount)
 VALUES ($1, $2, $3, $4) RETURNING id;
 
 -- name: CreateInventory :one
-INSERT INTO inventory (username, type, quantity)
-VALUES ($1, $2, 1) RETURNING id;
+INSERT INTO inventory (username, type, quantity, price_paid, discount, variant_id)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
 
 -- name: GetInventory :many
 SELECT type, SUM(quantity) AS quantity
 FROM inventory
 WHERE username = $1
-GROUP BY type;
+GROUP BY type
+HAVING SUM(quantity) > 0;
 
 -- name: GetHistory :many
-SELECT from_user, to_user, SUM(amount) AS amount
+SELECT from_user,
+       to_user,
+       (CASE WHEN from_user = '' AND to_user = '' THEN kind ELSE '' END)::VARCHAR AS kind,
+       SUM(amount)                                                              AS amount
+FROM history
+WHERE username = $1
+GROUP BY from_user, to_user, 3;
+
+-- name: GetHistoryRecord :one
+SELECT id, username, from_user, to_user, amount, sent_at, kind, reversal_of, coin_operation_id, wallet_id
+FROM history
+WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: IsHistoryRecordReversed :one
+SELECT EXISTS (SELECT 1 FROM history WHERE reversal_of = $1) AS reversed;
+
+-- name: CreateHistoryEntry :one
+INSERT INTO history (username, from_user, to_user, amount, kind, reversal_of)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+
+-- name: CreateWalletFundingHistoryEntry :one
+INSERT INTO history (username, amount, kind, wallet_id)
+VALUES ($1, $2, $3, $4) RETURNING id;
+
+-- name: GetInventoryRecord :one
+SELECT id, username, type, quantity, bought_at, kind, price_paid, reversal_of, discount, variant_id
+FROM inventory
+WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: IsInventoryRecordReversed :one
+SELECT EXISTS (SELECT 1 FROM inventory WHERE reversal_of = $1) AS reversed;
+
+-- name: CreateInventoryEntry :one
+INSERT INTO inventory (username, type, quantity, kind, price_paid, reversal_of, variant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;
+
+-- name: GetHistoryReversals :many
+SELECT id, from_user, to_user, amount, reversal_of, sent_at
 FROM history
 WHERE username = $1
-GROUP BY from_user, to_user;
\ No newline at end of file
+  AND reversal_of IS NOT NULL
+ORDER BY id;
+
+-- name: GetInventoryReversals :many
+SELECT r.id, r.type, r.quantity, r.price_paid, p.discount, r.reversal_of, r.bought_at
+FROM inventory r
+         JOIN inventory p ON p.id = r.reversal_of
+WHERE r.username = $1
+  AND r.kind = 'reversal'
+ORDER BY r.id;
+
+-- name: GetTransfers :many
+SELECT h.id, h.username, h.to_user, h.amount, h.sent_at,
+       EXISTS (SELECT 1 FROM history r WHERE r.reversal_of = h.id) AS reversed
+FROM history h
+WHERE h.kind = 'transfer'
+  AND h.to_user <> ''
+  AND (h.username = $1 OR h.to_user = $1)
+ORDER BY h.id;
+
+-- name: GetPurchases :many
+SELECT i.id, i.type, i.quantity, i.price_paid, i.discount, i.bought_at,
+       EXISTS (SELECT 1 FROM inventory r WHERE r.reversal_of = i.id) AS reversed
+FROM inventory i
+WHERE i.username = $1
+  AND i.kind = 'purchase'
+ORDER BY i.id;
+
+-- name: LockBalance :one
+SELECT coins, held
+FROM balance
+WHERE username = $1 LIMIT 1 FOR UPDATE;
+
+-- name: GetBalanceForUpdate :one
+SELECT coins, held
+FROM balance
+WHERE username = $1 LIMIT 1 FOR UPDATE;
+
+-- name: CreateCoinOperation :one
+INSERT INTO coin_operations (kind, username, amount, recipients, actor, reason)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at;
+
+-- name: UpdateCoinOperationProgress :exec
+UPDATE coin_operations
+SET recipients      = recipients + @recipients,
+    last_balance_id = @last_balance_id
+WHERE id = @id;
+
+-- name: CreateCoinOperationHistoryEntry :one
+INSERT INTO history (username, amount, kind, coin_operation_id)
+VALUES ($1, $2, $3, $4) RETURNING id;
+
+-- name: GrantCoinsBatch :one
+WITH granted AS (
+    UPDATE balance
+    SET coins = coins + @amount
+    WHERE id IN (SELECT id
+                 FROM balance
+                 WHERE id > @after_id
+                 ORDER BY id LIMIT @batch_size)
+    RETURNING id, username
+), recorded AS (
+    INSERT INTO history (username, amount, kind, coin_operation_id)
+    SELECT username, @amount, 'mint', @coin_operation_id::INTEGER
+    FROM granted
+), lots AS (
+    INSERT INTO coin_lots (username, source, amount, remaining, expires_at)
+    SELECT username, 'mint', @amount, @amount, @expires_at
+    FROM granted
+)
+SELECT COUNT(*)::INTEGER AS recipients, COALESCE(MAX(id), 0)::INTEGER AS last_balance_id
+FROM granted;
+
+-- name: GetMoneySupply :one
+SELECT (COALESCE(SUM(coins), 0) + (SELECT COALESCE(SUM(coins), 0) FROM wallets))::BIGINT AS coins,
+       COUNT(*)::INTEGER                                                               AS users
+FROM balance;
+
+-- name: GetCoinOperationsTotals :one
+SELECT COALESCE(SUM(amount * recipients) FILTER (WHERE kind = 'mint'), 0)::BIGINT AS minted,
+       COALESCE(SUM(amount * recipients) FILTER (WHERE kind = 'burn'), 0)::BIGINT AS burned
+FROM coin_operations;
+
+-- name: GetSettings :many
+SELECT name, value
+FROM settings;
+
+-- name: UpsertSetting :exec
+INSERT INTO settings (name, value, updated_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+    SET value      = EXCLUDED.value,
+        updated_by = EXCLUDED.updated_by,
+        updated_at = NOW();
+
+-- name: CreateCoinLot :one
+INSERT INTO coin_lots (username, source, amount, remaining, expires_at)
+VALUES (@username, @source, @amount, @amount, @expires_at) RETURNING id;
+
+-- name: ConsumeCoinLots :exec
+WITH lots AS (
+    SELECT id, remaining,
+           SUM(remaining) OVER (ORDER BY received_at, id) - remaining AS consumed_before
+    FROM coin_lots
+    WHERE username = @username
+      AND remaining > 0
+)
+UPDATE coin_lots
+SET remaining = coin_lots.remaining - LEAST(lots.remaining, @amount::BIGINT - lots.consumed_before)
+FROM lots
+WHERE coin_lots.id = lots.id
+  AND lots.consumed_before < @amount::BIGINT;
+
+-- name: GetUsersWithExpiredCoinLots :many
+SELECT DISTINCT l.username
+FROM coin_lots l
+         JOIN balance b ON b.username = l.username
+WHERE l.remaining > 0
+  AND l.expires_at <= NOW()
+  AND b.coins > b.held
+LIMIT $1;
+
+-- name: ExpireCoinLots :one
+WITH locked AS (
+    SELECT id, remaining, received_at
+    FROM coin_lots
+    WHERE username = @username
+      AND remaining > 0
+      AND expires_at <= NOW()
+    FOR UPDATE
+), expired AS (
+    SELECT id,
+           LEAST(remaining, @available::BIGINT - (SUM(remaining) OVER (ORDER BY received_at, id) - remaining)) AS amount
+    FROM locked
+), updated AS (
+    UPDATE coin_lots
+    SET remaining = coin_lots.remaining - expired.amount
+    FROM expired
+    WHERE coin_lots.id = expired.id
+      AND expired.amount > 0
+)
+SELECT COALESCE(SUM(amount), 0)::BIGINT AS expired
+FROM expired
+WHERE amount > 0;
+
+-- name: GetExpiringCoinLots :many
+SELECT remaining, expires_at
+FROM coin_lots
+WHERE username = $1
+  AND remaining > 0
+  AND expires_at <= $2
+ORDER BY expires_at, id;
+
+-- name: CreateWallet :one
+INSERT INTO wallets (name, created_by)
+VALUES ($1, $2) RETURNING id, created_at;
+
+-- name: GetWallet :one
+SELECT id, name, coins, created_by, created_at
+FROM wallets
+WHERE id = $1 LIMIT 1;
+
+-- name: LockWallet :one
+SELECT coins
+FROM wallets
+WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: UpdateWalletCoins :one
+UPDATE wallets
+SET coins = coins + $2
+WHERE id = $1 RETURNING coins;
+
+-- name: GetUserWallets :many
+SELECT w.id, w.name, w.coins, w.created_by, w.created_at, m.role
+FROM wallets w
+         JOIN wallet_members m ON m.wallet_id = w.id
+WHERE m.username = $1
+ORDER BY w.id;
+
+-- name: GetWalletRole :one
+SELECT role
+FROM wallet_members
+WHERE wallet_id = $1
+  AND username = $2 LIMIT 1;
+
+-- name: UpsertWalletMember :exec
+INSERT INTO wallet_members (wallet_id, username, role, added_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (wallet_id, username) DO UPDATE
+    SET role = EXCLUDED.role;
+
+-- name: DeleteWalletMember :one
+DELETE
+FROM wallet_members
+WHERE wallet_id = $1
+  AND username = $2 RETURNING username;
+
+-- name: CountWalletAdmins :one
+SELECT COUNT(*)::INTEGER AS admins
+FROM wallet_members
+WHERE wallet_id = $1
+  AND role = 'admin';
+
+-- name: GetWalletMembers :many
+SELECT username, role, added_by, added_at
+FROM wallet_members
+WHERE wallet_id = $1
+ORDER BY added_at, username;
+
+-- name: CreateWalletHistoryEntry :one
+INSERT INTO wallet_history (wallet_id, username, kind, amount, to_user, item, inventory_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;
+
+-- name: GetWalletHistory :many
+SELECT id, wallet_id, username, kind, amount, to_user, item, created_at, inventory_id
+FROM wallet_history
+WHERE wallet_id = $1
+ORDER BY id;
+
+-- name: GetWalletPurchaseWallet :one
+SELECT wallet_id
+FROM wallet_history
+WHERE inventory_id = $1
+  AND kind = 'purchase' LIMIT 1;
+
+-- name: CreateGift :one
+INSERT INTO gifts (from_user, to_user, type, price_paid, message, inventory_id, variant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at;
+
+-- name: GetGiftForUpdate :one
+SELECT id, from_user, to_user, type, price_paid, message, status, inventory_id, created_at, declined_at, variant_id
+FROM gifts
+WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: GetInventoryGiftForUpdate :one
+SELECT id, from_user, to_user, type, price_paid, message, status, inventory_id, created_at, declined_at, variant_id
+FROM gifts
+WHERE inventory_id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: DeclineGift :one
+UPDATE gifts
+SET status      = 'declined',
+    declined_at = NOW()
+WHERE id = $1 RETURNING declined_at;
+
+-- name: GetUserGifts :many
+SELECT id, from_user, to_user, type, price_paid, message, status, inventory_id, created_at, declined_at, variant_id
+FROM gifts
+WHERE from_user = $1
+   OR to_user = $1
+ORDER BY id;
+
+-- name: GetItemQuantity :one
+SELECT COALESCE(SUM(quantity), 0)::BIGINT AS quantity
+FROM inventory
+WHERE username = $1
+  AND type = $2
+  AND variant_id IS NOT DISTINCT FROM $3;
+
+-- name: CreateItemTransfer :one
+INSERT INTO item_transfers (from_user, to_user, type, quantity)
+VALUES ($1, $2, $3, $4) RETURNING id, created_at;
+
+-- name: GetItemTransfers :many
+SELECT id, from_user, to_user, type, quantity, created_at
+FROM item_transfers
+WHERE from_user = $1
+   OR to_user = $1
+ORDER BY id;
+
+-- name: CreateListing :one
+INSERT INTO listings (seller, type, price, variant_id)
+VALUES ($1, $2, $3, $4) RETURNING id, created_at;
+
+-- name: GetListingForUpdate :one
+SELECT id, seller, type, price, status, buyer, created_at, closed_at, variant_id
+FROM listings
+WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: CloseListing :one
+UPDATE listings
+SET status    = $2,
+    buyer     = $3,
+    closed_at = NOW()
+WHERE id = $1 RETURNING closed_at;
+
+-- name: GetActiveListings :many
+SELECT id, seller, type, price, status, buyer, created_at, closed_at, variant_id
+FROM listings
+WHERE status = 'active'
+  AND ($1::VARCHAR = '' OR type = $1)
+ORDER BY id;
+
+-- name: GetUserListings :many
+SELECT id, seller, type, price, status, buyer, created_at, closed_at, variant_id
+FROM listings
+WHERE seller = $1
+ORDER BY id;
+
+-- name: GetReturnablePurchase :one
+SELECT p.id, p.username, p.type, p.quantity, p.bought_at, p.kind, p.price_paid, p.reversal_of, p.discount, p.variant_id
+FROM inventory p
+WHERE p.username = $1
+  AND p.type = $2
+  AND p.kind = 'purchase'
+  AND p.bought_at >= $3
+  AND NOT EXISTS (SELECT 1 FROM inventory r WHERE r.reversal_of = p.id)
+  AND NOT EXISTS (SELECT 1
+                  FROM inventory o
+                  WHERE o.username = p.username
+                    AND o.type = p.type
+                    AND o.quantity < 0
+                    AND o.reversal_of IS NULL
+                    AND o.id > p.id)
+ORDER BY p.id DESC
+LIMIT 1 FOR UPDATE OF p;
+
+-- name: GetCatalog :many
+SELECT id, type, price, description, available, stock
+FROM merch_current
+WHERE price >= @min_price::BIGINT
+  AND price <= @max_price::BIGINT
+ORDER BY CASE WHEN @sort::VARCHAR = 'price' THEN price END,
+         CASE WHEN @sort::VARCHAR = '-price' THEN price END DESC,
+         CASE WHEN @sort::VARCHAR = '-type' THEN type END DESC,
+         type;
+
+-- name: GetCatalogItem :one
+SELECT id, type, price, description, available, stock
+FROM merch_current
+WHERE type = $1 LIMIT 1;
+
+-- name: CreateMerch :one
+INSERT INTO merch (type, price, description, stock)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (type) DO NOTHING
+RETURNING id, type, price, description, available, stock;
+
+-- name: GetMerchForUpdate :one
+SELECT id, type, price, description, available, stock
+FROM merch_current
+WHERE type = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: UpdateMerch :exec
+UPDATE merch
+SET price       = $2,
+    description = $3,
+    available   = $4,
+    stock       = $5
+WHERE type = $1;
+
+-- name: CreateMerchAuditEntry :one
+INSERT INTO merch_audit (type, action, price, description, available, actor, stock)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;
+
+-- name: GetMerchAudit :many
+SELECT id, type, action, price, description, available, actor, created_at, stock
+FROM merch_audit
+WHERE @type::VARCHAR = ''
+   OR type = @type::VARCHAR
+ORDER BY id DESC;
+
+-- name: ReserveMerchStock :exec
+UPDATE merch
+SET stock = stock - $2
+WHERE type = $1
+  AND stock IS NOT NULL;
+
+-- name: ReleaseMerchStock :exec
+UPDATE merch
+SET stock = stock + $2
+WHERE type = $1
+  AND stock IS NOT NULL;
+
+-- name: GetLowStockMerch :many
+SELECT id, type, price, description, available, stock
+FROM merch_current
+WHERE stock <= $1
+  AND available
+ORDER BY stock, type;
+
+-- name: GetNextMerchPriceStart :one
+SELECT valid_from
+FROM merch_prices
+WHERE type = $1
+  AND valid_from > $2
+ORDER BY valid_from LIMIT 1;
+
+-- name: CreateMerchPrice :one
+INSERT INTO merch_prices (type, price, valid_from, valid_to, actor)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (type, valid_from) DO NOTHING
+RETURNING id, type, price, valid_from, valid_to, actor, created_at;
+
+-- name: CloseMerchPrice :exec
+UPDATE merch_prices
+SET valid_to = @valid_from
+WHERE type = @type
+  AND valid_from < @valid_from
+  AND (valid_to IS NULL OR valid_to > @valid_from);
+
+-- name: GetMerchPrices :many
+SELECT id, type, price, valid_from, valid_to, actor, created_at
+FROM merch_prices
+WHERE type = $1
+ORDER BY valid_from;
+
+-- name: AddCartItem :one
+INSERT INTO cart_items (username, type, size, color, quantity)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (username, type, size, color) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity
+RETURNING quantity;
+
+-- name: RemoveCartItem :execrows
+DELETE
+FROM cart_items
+WHERE username = $1
+  AND type = $2;
+
+-- name: GetCart :many
+SELECT c.type,
+       c.size,
+       c.color,
+       c.quantity,
+       m.price,
+       (m.available AND NOT EXISTS (SELECT 1 FROM merch_drops d WHERE d.type = c.type))::BOOLEAN AS available,
+       m.stock,
+       v.stock AS variant_stock
+FROM cart_items c
+         LEFT JOIN merch_current m ON m.type = c.type
+         LEFT JOIN merch_variants v ON v.type = c.type AND v.size = c.size AND v.color = c.color
+WHERE c.username = $1
+ORDER BY c.added_at, c.type, c.size, c.color;
+
+-- name: GetCartForUpdate :many
+SELECT c.type,
+       c.size,
+       c.color,
+       c.quantity,
+       m.price,
+       (m.available AND NOT EXISTS (SELECT 1 FROM merch_drops d WHERE d.type = c.type))::BOOLEAN AS available,
+       m.stock,
+       v.stock AS variant_stock
+FROM cart_items c
+         LEFT JOIN merch_current m ON m.type = c.type
+         LEFT JOIN merch_variants v ON v.type = c.type AND v.size = c.size AND v.color = c.color
+WHERE c.username = $1
+ORDER BY c.added_at, c.type, c.size, c.color
+FOR UPDATE OF c;
+
+-- name: ClearCart :exec
+DELETE
+FROM cart_items
+WHERE username = $1;
+
+-- name: CreatePromotion :one
+INSERT INTO promotions (name, kind, value, type, valid_from, valid_to, max_uses, max_uses_per_user, actor)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, name, kind, value, type, valid_from, valid_to, max_uses, max_uses_per_user, actor, created_at;
+
+-- name: CreatePromoCode :execrows
+INSERT INTO promo_codes (code, promotion_id, single_use)
+VALUES ($1, $2, $3)
+ON CONFLICT (code) DO NOTHING;
+
+-- name: GetPromotions :many
+SELECT id, name, kind, value, type, valid_from, valid_to, max_uses, max_uses_per_user, actor, created_at
+FROM promotions
+ORDER BY id;
+
+-- name: GetPromoCodes :many
+SELECT code, promotion_id, single_use
+FROM promo_codes
+ORDER BY promotion_id, code;
+
+-- name: GetPromoRedemptionCounts :many
+SELECT promotion_id, COUNT(*) AS uses
+FROM promo_redemptions
+GROUP BY promotion_id;
+
+-- name: GetPromoCodeForUpdate :one
+SELECT code, promotion_id, single_use
+FROM promo_codes
+WHERE code = $1 LIMIT 1 FOR UPDATE;
+
+-- name: GetPromotionForUpdate :one
+SELECT id, name, kind, value, type, valid_from, valid_to, max_uses, max_uses_per_user, actor, created_at
+FROM promotions
+WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: CountPromoRedemptions :one
+SELECT COUNT(*)                              AS total,
+       COUNT(*) FILTER (WHERE username = $2) AS by_user,
+       COUNT(*) FILTER (WHERE code = $3)     AS by_code
+FROM promo_redemptions
+WHERE promotion_id = $1;
+
+-- name: CreatePromoRedemption :one
+INSERT INTO promo_redemptions (promotion_id, code, username, inventory_id, discount)
+VALUES ($1, $2, $3, $4, $5) RETURNING id;
+
+-- name: CreateMerchVariant :one
+INSERT INTO merch_variants (type, size, color, stock)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (type, size, color) DO NOTHING
+RETURNING id, type, size, color, stock, created_at;
+
+-- name: GetMerchVariants :many
+SELECT id, type, size, color, stock, created_at
+FROM merch_variants
+WHERE type = $1
+ORDER BY id;
+
+-- name: GetMerchVariant :one
+SELECT id, type, size, color, stock, created_at
+FROM merch_variants
+WHERE type = $1
+  AND size = $2
+  AND color = $3 LIMIT 1;
+
+-- name: HasMerchVariants :one
+SELECT EXISTS (SELECT 1 FROM merch_variants WHERE type = $1) AS has_variants;
+
+-- name: RestockMerchVariant :one
+UPDATE merch_variants
+SET stock = COALESCE(stock, 0) + $3
+WHERE id = $1
+  AND type = $2
+RETURNING id, type, size, color, stock, created_at;
+
+-- name: ReserveVariantStock :exec
+UPDATE merch_variants
+SET stock = stock - $2
+WHERE id = $1
+  AND stock IS NOT NULL;
+
+-- name: ReleaseVariantStock :exec
+UPDATE merch_variants
+SET stock = stock + $2
+WHERE id = $1
+  AND stock IS NOT NULL;
+
+-- name: GetInventoryVariants :many
+SELECT i.type, COALESCE(v.size, '')::VARCHAR AS size, COALESCE(v.color, '')::VARCHAR AS color, SUM(i.quantity) AS quantity
+FROM inventory i
+         LEFT JOIN merch_variants v ON v.id = i.variant_id
+WHERE i.username = $1
+GROUP BY i.type, v.id, v.size, v.color
+HAVING SUM(i.quantity) > 0
+ORDER BY i.type, v.id NULLS FIRST;
+
+-- name: UpsertPurchaseLimit :one
+INSERT INTO purchase_limits (type, max_quantity, period_days, actor)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (type) DO UPDATE
+    SET max_quantity = EXCLUDED.max_quantity,
+        period_days  = EXCLUDED.period_days,
+        actor        = EXCLUDED.actor,
+        updated_at   = NOW()
+RETURNING type, max_quantity, period_days, actor, updated_at;
+
+-- name: DeletePurchaseLimit :execrows
+DELETE
+FROM purchase_limits
+WHERE type = $1;
+
+-- name: GetPurchaseLimits :many
+SELECT type, max_quantity, period_days, actor, updated_at
+FROM purchase_limits
+ORDER BY type;
+
+-- name: GetPurchaseLimit :one
+SELECT type, max_quantity, period_days, actor, updated_at
+FROM purchase_limits
+WHERE type = $1 LIMIT 1;
+
+-- name: CountPurchasedItems :one
+SELECT COALESCE(SUM(p.quantity + COALESCE(r.quantity, 0)), 0)::BIGINT AS quantity
+FROM inventory p
+         LEFT JOIN gifts g ON g.inventory_id = p.id
+         LEFT JOIN (SELECT reversal_of, SUM(quantity) AS quantity
+                    FROM inventory
+                    WHERE reversal_of IS NOT NULL
+                      AND kind IN ('return', 'reversal', 'decline')
+                    GROUP BY reversal_of) r ON r.reversal_of = p.id
+WHERE p.type = sqlc.arg(type)
+  AND ((p.username = sqlc.arg(username) AND p.kind IN ('purchase', 'wallet'))
+    OR (g.from_user = sqlc.arg(username) AND p.kind = 'gift'))
+  AND (sqlc.narg(period_days)::INTEGER IS NULL OR p.bought_at >= NOW() - make_interval(days => sqlc.narg(period_days)::INTEGER));
+
+-- name: CreateOrder :one
+INSERT INTO orders (inventory_id, username, type, quantity)
+VALUES ($1, $2, $3, $4) RETURNING id;
+
+-- name: GetOrderForUpdate :one
+SELECT id, inventory_id, username, type, quantity, status, updated_by, placed_at, ready_at, delivered_at, cancelled_at
+FROM orders
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: GetUserOrders :many
+SELECT id, inventory_id, username, type, quantity, status, updated_by, placed_at, ready_at, delivered_at, cancelled_at
+FROM orders
+WHERE username = $1
+ORDER BY id DESC;
+
+-- name: GetOrders :many
+SELECT id, inventory_id, username, type, quantity, status, updated_by, placed_at, ready_at, delivered_at, cancelled_at
+FROM orders
+WHERE sqlc.narg(status)::VARCHAR IS NULL
+   OR status = sqlc.narg(status)::VARCHAR
+ORDER BY id;
+
+-- name: UpdateOrderStatus :one
+UPDATE orders
+SET status       = sqlc.arg(status),
+    updated_by   = sqlc.arg(updated_by),
+    ready_at     = CASE WHEN sqlc.arg(status) = 'ready' THEN NOW() ELSE ready_at END,
+    delivered_at = CASE WHEN sqlc.arg(status) = 'delivered' THEN NOW() ELSE delivered_at END,
+    cancelled_at = CASE WHEN sqlc.arg(status) = 'cancelled' THEN NOW() ELSE cancelled_at END
+WHERE id = sqlc.arg(id)
+RETURNING id, inventory_id, username, type, quantity, status, updated_by, placed_at, ready_at, delivered_at, cancelled_at;
+
+-- name: CancelPurchaseOrder :exec
+UPDATE orders
+SET status       = 'cancelled',
+    updated_by   = $2,
+    cancelled_at = NOW()
+WHERE inventory_id = $1
+  AND status IN ('placed', 'ready');
+
+-- name: AddWishlistItem :exec
+INSERT INTO wishlist_items (username, type)
+VALUES ($1, $2)
+ON CONFLICT (username, type) DO NOTHING;
+
+-- name: RemoveWishlistItem :execrows
+DELETE
+FROM wishlist_items
+WHERE username = $1
+  AND type = $2;
+
+-- name: GetWishlist :many
+SELECT w.type, m.price, m.available, m.stock, w.added_at
+FROM wishlist_items w
+         JOIN merch_current m ON m.type = w.type
+WHERE w.username = $1
+ORDER BY w.added_at, w.type;
+
+-- name: GetWishlistEvents :many
+SELECT p.type, 'price_drop'::VARCHAR AS kind, p.price, p.valid_from AS occurred_at
+FROM wishlist_items w
+         JOIN (SELECT type, price, valid_from, LAG(price) OVER (PARTITION BY type ORDER BY valid_from) AS previous_price
+               FROM merch_prices) p ON p.type = w.type
+WHERE w.username = $1
+  AND p.valid_from > w.added_at
+  AND p.valid_from <= NOW()
+  AND p.price < p.previous_price
+UNION ALL
+SELECT b.type, 'back_in_stock'::VARCHAR AS kind, b.price, b.created_at AS occurred_at
+FROM wishlist_items w
+         JOIN merch_back_in_stock b ON b.type = w.type
+WHERE w.username = $1
+  AND b.created_at > w.added_at
+ORDER BY occurred_at DESC;
+
+-- name: CreateMerchBackInStock :exec
+INSERT INTO merch_back_in_stock (type, price)
+VALUES ($1, $2);
+
+-- name: CreateMerchDrop :one
+INSERT INTO merch_drops (type, starts_at, ends_at, price, stock, actor)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, type, starts_at, ends_at, price, stock, actor, created_at;
+
+-- name: HasOverlappingMerchDrop :one
+SELECT EXISTS (SELECT 1
+               FROM merch_drops
+               WHERE type = @type
+                 AND starts_at < @ends_at
+                 AND ends_at > @starts_at) AS overlapping;
+
+-- name: GetMerchDrops :many
+SELECT id, type, starts_at, ends_at, price, stock, actor, created_at
+FROM merch_drops
+WHERE ends_at > NOW()
+ORDER BY starts_at, type;
+
+-- name: GetCurrentMerchDrop :one
+SELECT d.id, d.type, d.starts_at, d.ends_at, COALESCE(d.price, m.price)::BIGINT AS price, d.stock
+FROM merch_drops d
+         JOIN merch_current m ON m.type = d.type
+WHERE d.type = $1
+  AND m.available
+ORDER BY d.ends_at <= NOW(), d.starts_at LIMIT 1;
+
+-- name: ReserveMerchDropStock :execrows
+UPDATE merch_drops
+SET stock = stock - $2
+WHERE id = $1
+  AND starts_at <= NOW()
+  AND ends_at > NOW();
+
+-- name: CreatePurchaseQuote :exec
+INSERT INTO purchase_quotes (token, username, type, size, color, quantity, promo_code, price, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: DeleteExpiredPurchaseQuotes :exec
+DELETE
+FROM purchase_quotes
+WHERE username = $1
+  AND expires_at <= NOW();
+
+-- name: TakePurchaseQuote :one
+DELETE
+FROM purchase_quotes
+WHERE token = $1
+  AND username = $2
+  AND expires_at > NOW()
+RETURNING token, username, type, size, color, quantity, promo_code, price, expires_at, created_at;
+
+-- name: HoldBalance :one
+UPDATE balance
+SET held = held + $2
+WHERE username = $1 RETURNING held;
+
+-- name: UnholdBalance :exec
+UPDATE balance
+SET held = held - $2
+WHERE username = $1;
+
+-- name: CreateBalanceHold :one
+INSERT INTO balance_holds (username, amount, reason, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, username, amount, captured, status, reason, created_by, expires_at, created_at, closed_at;
+
+-- name: GetBalanceHold :one
+SELECT id, username, amount, captured, status, reason, created_by, expires_at, created_at, closed_at
+FROM balance_holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetBalanceHoldForUpdate :one
+SELECT id, username, amount, captured, status, reason, created_by, expires_at, created_at, closed_at
+FROM balance_holds
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: CloseBalanceHold :one
+UPDATE balance_holds
+SET status    = $2,
+    captured  = $3,
+    closed_at = NOW()
+WHERE id = $1
+RETURNING id, username, amount, captured, status, reason, created_by, expires_at, created_at, closed_at;
+
+-- name: GetExpiredBalanceHolds :many
+SELECT id
+FROM balance_holds
+WHERE status = 'held'
+  AND expires_at <= NOW()
+ORDER BY id LIMIT $1;
@@ -7,187 +7,3403 @@ package queries
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addCartItem = `-- name: AddCartItem :one
+INSERT INTO cart_items (username, type, size, color, quantity)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (username, type, size, color) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity
+RETURNING quantity
+`
+
+type AddCartItemParams struct {
+	Username string
+	Type     string
+	Size     string
+	Color    string
+	Quantity int32
+}
+
+func (q *Queries) AddCartItem(ctx context.Context, arg AddCartItemParams) (int32, error) {
+	row := q.db.QueryRow(ctx, addCartItem,
+		arg.Username,
+		arg.Type,
+		arg.Size,
+		arg.Color,
+		arg.Quantity,
+	)
+	var quantity int32
+	err := row.Scan(&quantity)
+	return quantity, err
+}
+
+const addWishlistItem = `-- name: AddWishlistItem :exec
+INSERT INTO wishlist_items (username, type)
+VALUES ($1, $2)
+ON CONFLICT (username, type) DO NOTHING
+`
+
+type AddWishlistItemParams struct {
+	Username string
+	Type     string
+}
+
+func (q *Queries) AddWishlistItem(ctx context.Context, arg AddWishlistItemParams) error {
+	_, err := q.db.Exec(ctx, addWishlistItem, arg.Username, arg.Type)
+	return err
+}
+
+const cancelPurchaseOrder = `-- name: CancelPurchaseOrder :exec
+UPDATE orders
+SET status       = 'cancelled',
+    updated_by   = $2,
+    cancelled_at = NOW()
+WHERE inventory_id = $1
+  AND status IN ('placed', 'ready')
+`
+
+type CancelPurchaseOrderParams struct {
+	InventoryID int32
+	UpdatedBy   string
+}
+
+func (q *Queries) CancelPurchaseOrder(ctx context.Context, arg CancelPurchaseOrderParams) error {
+	_, err := q.db.Exec(ctx, cancelPurchaseOrder, arg.InventoryID, arg.UpdatedBy)
+	return err
+}
+
+const clearCart = `-- name: ClearCart :exec
+DELETE
+FROM cart_items
+WHERE username = $1
+`
+
+func (q *Queries) ClearCart(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, clearCart, username)
+	return err
+}
+
+const closeBalanceHold = `-- name: CloseBalanceHold :one
+UPDATE balance_holds
+SET status    = $2,
+    captured  = $3,
+    closed_at = NOW()
+WHERE id = $1
+RETURNING id, username, amount, captured, status, reason, created_by, expires_at, created_at, closed_at
+`
+
+type CloseBalanceHoldParams struct {
+	ID       int32
+	Status   string
+	Captured int64
+}
+
+func (q *Queries) CloseBalanceHold(ctx context.Context, arg CloseBalanceHoldParams) (BalanceHold, error) {
+	row := q.db.QueryRow(ctx, closeBalanceHold, arg.ID, arg.Status, arg.Captured)
+	var i BalanceHold
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Amount,
+		&i.Captured,
+		&i.Status,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const closeListing = `-- name: CloseListing :one
+UPDATE listings
+SET status    = $2,
+    buyer     = $3,
+    closed_at = NOW()
+WHERE id = $1 RETURNING closed_at
+`
+
+type CloseListingParams struct {
+	ID     int32
+	Status string
+	Buyer  string
+}
+
+func (q *Queries) CloseListing(ctx context.Context, arg CloseListingParams) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, closeListing, arg.ID, arg.Status, arg.Buyer)
+	var closedAt pgtype.Timestamp
+	err := row.Scan(&closedAt)
+	return closedAt, err
+}
+
+const closeMerchPrice = `-- name: CloseMerchPrice :exec
+UPDATE merch_prices
+SET valid_to = $1
+WHERE type = $2
+  AND valid_from < $1
+  AND (valid_to IS NULL OR valid_to > $1)
+`
+
+type CloseMerchPriceParams struct {
+	ValidFrom time.Time
+	Type      string
+}
+
+func (q *Queries) CloseMerchPrice(ctx context.Context, arg CloseMerchPriceParams) error {
+	_, err := q.db.Exec(ctx, closeMerchPrice, arg.ValidFrom, arg.Type)
+	return err
+}
+
+const consumeCoinLots = `-- name: ConsumeCoinLots :exec
+WITH lots AS (
+    SELECT id, remaining,
+           SUM(remaining) OVER (ORDER BY received_at, id) - remaining AS consumed_before
+    FROM coin_lots
+    WHERE username = $1
+      AND remaining > 0
 )
+UPDATE coin_lots
+SET remaining = coin_lots.remaining - LEAST(lots.remaining, $2::BIGINT - lots.consumed_before)
+FROM lots
+WHERE coin_lots.id = lots.id
+  AND lots.consumed_before < $2::BIGINT
+`
+
+type ConsumeCoinLotsParams struct {
+	Username string
+	Amount   int64
+}
+
+func (q *Queries) ConsumeCoinLots(ctx context.Context, arg ConsumeCoinLotsParams) error {
+	_, err := q.db.Exec(ctx, consumeCoinLots, arg.Username, arg.Amount)
+	return err
+}
+
+const countPromoRedemptions = `-- name: CountPromoRedemptions :one
+SELECT COUNT(*)                              AS total,
+       COUNT(*) FILTER (WHERE username = $2) AS by_user,
+       COUNT(*) FILTER (WHERE code = $3)     AS by_code
+FROM promo_redemptions
+WHERE promotion_id = $1
+`
+
+type CountPromoRedemptionsParams struct {
+	PromotionID int32
+	Username    string
+	Code        string
+}
+
+type CountPromoRedemptionsRow struct {
+	Total  int64
+	ByUser int64
+	ByCode int64
+}
+
+func (q *Queries) CountPromoRedemptions(ctx context.Context, arg CountPromoRedemptionsParams) (CountPromoRedemptionsRow, error) {
+	row := q.db.QueryRow(ctx, countPromoRedemptions, arg.PromotionID, arg.Username, arg.Code)
+	var i CountPromoRedemptionsRow
+	err := row.Scan(&i.Total, &i.ByUser, &i.ByCode)
+	return i, err
+}
+
+const countPurchasedItems = `-- name: CountPurchasedItems :one
+SELECT COALESCE(SUM(p.quantity + COALESCE(r.quantity, 0)), 0)::BIGINT AS quantity
+FROM inventory p
+         LEFT JOIN gifts g ON g.inventory_id = p.id
+         LEFT JOIN (SELECT reversal_of, SUM(quantity) AS quantity
+                    FROM inventory
+                    WHERE reversal_of IS NOT NULL
+                      AND kind IN ('return', 'reversal', 'decline')
+                    GROUP BY reversal_of) r ON r.reversal_of = p.id
+WHERE p.type = $1
+  AND ((p.username = $2 AND p.kind IN ('purchase', 'wallet'))
+    OR (g.from_user = $2 AND p.kind = 'gift'))
+  AND ($3::INTEGER IS NULL OR p.bought_at >= NOW() - make_interval(days => $3::INTEGER))
+`
+
+type CountPurchasedItemsParams struct {
+	Type       string
+	Username   string
+	PeriodDays pgtype.Int4
+}
+
+func (q *Queries) CountPurchasedItems(ctx context.Context, arg CountPurchasedItemsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countPurchasedItems, arg.Type, arg.Username, arg.PeriodDays)
+	var quantity int64
+	err := row.Scan(&quantity)
+	return quantity, err
+}
+
+const countWalletAdmins = `-- name: CountWalletAdmins :one
+SELECT COUNT(*)::INTEGER AS admins
+FROM wallet_members
+WHERE wallet_id = $1
+  AND role = 'admin'
+`
+
+func (q *Queries) CountWalletAdmins(ctx context.Context, walletID int32) (int32, error) {
+	row := q.db.QueryRow(ctx, countWalletAdmins, walletID)
+	var admins int32
+	err := row.Scan(&admins)
+	return admins, err
+}
+
+const createBalance = `-- name: CreateBalance :one
+INSERT INTO balance (username, coins)
+VALUES ($1, $2) RETURNING id
+`
+
+type CreateBalanceParams struct {
+	Username string
+	Coins    int64
+}
+
+func (q *Queries) CreateBalance(ctx context.Context, arg CreateBalanceParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createBalance, arg.Username, arg.Coins)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createBalanceHold = `-- name: CreateBalanceHold :one
+INSERT INTO balance_holds (username, amount, reason, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, username, amount, captured, status, reason, created_by, expires_at, created_at, closed_at
+`
+
+type CreateBalanceHoldParams struct {
+	Username  string
+	Amount    int64
+	Reason    string
+	CreatedBy string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateBalanceHold(ctx context.Context, arg CreateBalanceHoldParams) (BalanceHold, error) {
+	row := q.db.QueryRow(ctx, createBalanceHold,
+		arg.Username,
+		arg.Amount,
+		arg.Reason,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	var i BalanceHold
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Amount,
+		&i.Captured,
+		&i.Status,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const createCoinLot = `-- name: CreateCoinLot :one
+INSERT INTO coin_lots (username, source, amount, remaining, expires_at)
+VALUES ($1, $2, $3, $3, $4) RETURNING id
+`
+
+type CreateCoinLotParams struct {
+	Username  string
+	Source    string
+	Amount    int64
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateCoinLot(ctx context.Context, arg CreateCoinLotParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createCoinLot,
+		arg.Username,
+		arg.Source,
+		arg.Amount,
+		arg.ExpiresAt,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createCoinOperation = `-- name: CreateCoinOperation :one
+INSERT INTO coin_operations (kind, username, amount, recipients, actor, reason)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at
+`
+
+type CreateCoinOperationParams struct {
+	Kind       string
+	Username   string
+	Amount     int64
+	Recipients int32
+	Actor      string
+	Reason     string
+}
+
+type CreateCoinOperationRow struct {
+	ID        int32
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateCoinOperation(ctx context.Context, arg CreateCoinOperationParams) (CreateCoinOperationRow, error) {
+	row := q.db.QueryRow(ctx, createCoinOperation,
+		arg.Kind,
+		arg.Username,
+		arg.Amount,
+		arg.Recipients,
+		arg.Actor,
+		arg.Reason,
+	)
+	var i CreateCoinOperationRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const createCoinOperationHistoryEntry = `-- name: CreateCoinOperationHistoryEntry :one
+INSERT INTO history (username, amount, kind, coin_operation_id)
+VALUES ($1, $2, $3, $4) RETURNING id
+`
+
+type CreateCoinOperationHistoryEntryParams struct {
+	Username        string
+	Amount          int64
+	Kind            string
+	CoinOperationID pgtype.Int4
+}
+
+func (q *Queries) CreateCoinOperationHistoryEntry(ctx context.Context, arg CreateCoinOperationHistoryEntryParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createCoinOperationHistoryEntry,
+		arg.Username,
+		arg.Amount,
+		arg.Kind,
+		arg.CoinOperationID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createGift = `-- name: CreateGift :one
+INSERT INTO gifts (from_user, to_user, type, price_paid, message, inventory_id, variant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at
+`
+
+type CreateGiftParams struct {
+	FromUser    string
+	ToUser      string
+	Type        string
+	PricePaid   int64
+	Message     string
+	InventoryID int32
+	VariantID   pgtype.Int4
+}
+
+type CreateGiftRow struct {
+	ID        int32
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateGift(ctx context.Context, arg CreateGiftParams) (CreateGiftRow, error) {
+	row := q.db.QueryRow(ctx, createGift,
+		arg.FromUser,
+		arg.ToUser,
+		arg.Type,
+		arg.PricePaid,
+		arg.Message,
+		arg.InventoryID,
+		arg.VariantID,
+	)
+	var i CreateGiftRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const createHistoryEntry = `-- name: CreateHistoryEntry :one
+INSERT INTO history (username, from_user, to_user, amount, kind, reversal_of)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id
+`
+
+type CreateHistoryEntryParams struct {
+	Username   string
+	FromUser   string
+	ToUser     string
+	Amount     int64
+	Kind       string
+	ReversalOf pgtype.Int4
+}
+
+func (q *Queries) CreateHistoryEntry(ctx context.Context, arg CreateHistoryEntryParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createHistoryEntry,
+		arg.Username,
+		arg.FromUser,
+		arg.ToUser,
+		arg.Amount,
+		arg.Kind,
+		arg.ReversalOf,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createHistoryRecord = `-- name: CreateHistoryRecord :one
+INSERT INTO history (username, from_user, to_user, amount)
+VALUES ($1, $2, $3, $4) RETURNING id
+`
+
+type CreateHistoryRecordParams struct {
+	Username string
+	FromUser string
+	ToUser   string
+	Amount   int64
+}
+
+func (q *Queries) CreateHistoryRecord(ctx context.Context, arg CreateHistoryRecordParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createHistoryRecord,
+		arg.Username,
+		arg.FromUser,
+		arg.ToUser,
+		arg.Amount,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createInventory = `-- name: CreateInventory :one
+INSERT INTO inventory (username, type, quantity, price_paid, discount, variant_id)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id
+`
+
+type CreateInventoryParams struct {
+	Username  string
+	Type      string
+	Quantity  int32
+	PricePaid int64
+	Discount  int64
+	VariantID pgtype.Int4
+}
+
+func (q *Queries) CreateInventory(ctx context.Context, arg CreateInventoryParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createInventory,
+		arg.Username,
+		arg.Type,
+		arg.Quantity,
+		arg.PricePaid,
+		arg.Discount,
+		arg.VariantID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createInventoryEntry = `-- name: CreateInventoryEntry :one
+INSERT INTO inventory (username, type, quantity, kind, price_paid, reversal_of, variant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
+`
+
+type CreateInventoryEntryParams struct {
+	Username   string
+	Type       string
+	Quantity   int32
+	Kind       string
+	PricePaid  int64
+	ReversalOf pgtype.Int4
+	VariantID  pgtype.Int4
+}
+
+func (q *Queries) CreateInventoryEntry(ctx context.Context, arg CreateInventoryEntryParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createInventoryEntry,
+		arg.Username,
+		arg.Type,
+		arg.Quantity,
+		arg.Kind,
+		arg.PricePaid,
+		arg.ReversalOf,
+		arg.VariantID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createItemTransfer = `-- name: CreateItemTransfer :one
+INSERT INTO item_transfers (from_user, to_user, type, quantity)
+VALUES ($1, $2, $3, $4) RETURNING id, created_at
+`
+
+type CreateItemTransferParams struct {
+	FromUser string
+	ToUser   string
+	Type     string
+	Quantity int32
+}
+
+type CreateItemTransferRow struct {
+	ID        int32
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateItemTransfer(ctx context.Context, arg CreateItemTransferParams) (CreateItemTransferRow, error) {
+	row := q.db.QueryRow(ctx, createItemTransfer,
+		arg.FromUser,
+		arg.ToUser,
+		arg.Type,
+		arg.Quantity,
+	)
+	var i CreateItemTransferRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const createListing = `-- name: CreateListing :one
+INSERT INTO listings (seller, type, price, variant_id)
+VALUES ($1, $2, $3, $4) RETURNING id, created_at
+`
+
+type CreateListingParams struct {
+	Seller    string
+	Type      string
+	Price     int64
+	VariantID pgtype.Int4
+}
+
+type CreateListingRow struct {
+	ID        int32
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateListing(ctx context.Context, arg CreateListingParams) (CreateListingRow, error) {
+	row := q.db.QueryRow(ctx, createListing,
+		arg.Seller,
+		arg.Type,
+		arg.Price,
+		arg.VariantID,
+	)
+	var i CreateListingRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const createMerch = `-- name: CreateMerch :one
+INSERT INTO merch (type, price, description, stock)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (type) DO NOTHING
+RETURNING id, type, price, description, available, stock
+`
+
+type CreateMerchParams struct {
+	Type        string
+	Price       int64
+	Description string
+	Stock       pgtype.Int4
+}
+
+func (q *Queries) CreateMerch(ctx context.Context, arg CreateMerchParams) (Merch, error) {
+	row := q.db.QueryRow(ctx, createMerch,
+		arg.Type,
+		arg.Price,
+		arg.Description,
+		arg.Stock,
+	)
+	var i Merch
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Price,
+		&i.Description,
+		&i.Available,
+		&i.Stock,
+	)
+	return i, err
+}
+
+const createMerchAuditEntry = `-- name: CreateMerchAuditEntry :one
+INSERT INTO merch_audit (type, action, price, description, available, actor, stock)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
+`
+
+type CreateMerchAuditEntryParams struct {
+	Type        string
+	Action      string
+	Price       int64
+	Description string
+	Available   bool
+	Actor       string
+	Stock       pgtype.Int4
+}
+
+func (q *Queries) CreateMerchAuditEntry(ctx context.Context, arg CreateMerchAuditEntryParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createMerchAuditEntry,
+		arg.Type,
+		arg.Action,
+		arg.Price,
+		arg.Description,
+		arg.Available,
+		arg.Actor,
+		arg.Stock,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createMerchBackInStock = `-- name: CreateMerchBackInStock :exec
+INSERT INTO merch_back_in_stock (type, price)
+VALUES ($1, $2)
+`
+
+type CreateMerchBackInStockParams struct {
+	Type  string
+	Price int64
+}
+
+func (q *Queries) CreateMerchBackInStock(ctx context.Context, arg CreateMerchBackInStockParams) error {
+	_, err := q.db.Exec(ctx, createMerchBackInStock, arg.Type, arg.Price)
+	return err
+}
+
+const createMerchDrop = `-- name: CreateMerchDrop :one
+INSERT INTO merch_drops (type, starts_at, ends_at, price, stock, actor)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, type, starts_at, ends_at, price, stock, actor, created_at
+`
+
+type CreateMerchDropParams struct {
+	Type     string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Price    pgtype.Int8
+	Stock    pgtype.Int4
+	Actor    string
+}
+
+func (q *Queries) CreateMerchDrop(ctx context.Context, arg CreateMerchDropParams) (MerchDrop, error) {
+	row := q.db.QueryRow(ctx, createMerchDrop,
+		arg.Type,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Price,
+		arg.Stock,
+		arg.Actor,
+	)
+	var i MerchDrop
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Price,
+		&i.Stock,
+		&i.Actor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createMerchPrice = `-- name: CreateMerchPrice :one
+INSERT INTO merch_prices (type, price, valid_from, valid_to, actor)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (type, valid_from) DO NOTHING
+RETURNING id, type, price, valid_from, valid_to, actor, created_at
+`
+
+type CreateMerchPriceParams struct {
+	Type      string
+	Price     int64
+	ValidFrom time.Time
+	ValidTo   pgtype.Timestamp
+	Actor     string
+}
+
+func (q *Queries) CreateMerchPrice(ctx context.Context, arg CreateMerchPriceParams) (MerchPrice, error) {
+	row := q.db.QueryRow(ctx, createMerchPrice,
+		arg.Type,
+		arg.Price,
+		arg.ValidFrom,
+		arg.ValidTo,
+		arg.Actor,
+	)
+	var i MerchPrice
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Price,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.Actor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createMerchVariant = `-- name: CreateMerchVariant :one
+INSERT INTO merch_variants (type, size, color, stock)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (type, size, color) DO NOTHING
+RETURNING id, type, size, color, stock, created_at
+`
+
+type CreateMerchVariantParams struct {
+	Type  string
+	Size  string
+	Color string
+	Stock pgtype.Int4
+}
+
+func (q *Queries) CreateMerchVariant(ctx context.Context, arg CreateMerchVariantParams) (MerchVariant, error) {
+	row := q.db.QueryRow(ctx, createMerchVariant,
+		arg.Type,
+		arg.Size,
+		arg.Color,
+		arg.Stock,
+	)
+	var i MerchVariant
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Size,
+		&i.Color,
+		&i.Stock,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOrder = `-- name: CreateOrder :one
+INSERT INTO orders (inventory_id, username, type, quantity)
+VALUES ($1, $2, $3, $4) RETURNING id
+`
+
+type CreateOrderParams struct {
+	InventoryID int32
+	Username    string
+	Type        string
+	Quantity    int32
+}
+
+func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createOrder,
+		arg.InventoryID,
+		arg.Username,
+		arg.Type,
+		arg.Quantity,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createPromoCode = `-- name: CreatePromoCode :execrows
+INSERT INTO promo_codes (code, promotion_id, single_use)
+VALUES ($1, $2, $3)
+ON CONFLICT (code) DO NOTHING
+`
+
+type CreatePromoCodeParams struct {
+	Code        string
+	PromotionID int32
+	SingleUse   bool
+}
+
+func (q *Queries) CreatePromoCode(ctx context.Context, arg CreatePromoCodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createPromoCode, arg.Code, arg.PromotionID, arg.SingleUse)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createPromoRedemption = `-- name: CreatePromoRedemption :one
+INSERT INTO promo_redemptions (promotion_id, code, username, inventory_id, discount)
+VALUES ($1, $2, $3, $4, $5) RETURNING id
+`
+
+type CreatePromoRedemptionParams struct {
+	PromotionID int32
+	Code        string
+	Username    string
+	InventoryID int32
+	Discount    int64
+}
+
+func (q *Queries) CreatePromoRedemption(ctx context.Context, arg CreatePromoRedemptionParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createPromoRedemption,
+		arg.PromotionID,
+		arg.Code,
+		arg.Username,
+		arg.InventoryID,
+		arg.Discount,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createPromotion = `-- name: CreatePromotion :one
+INSERT INTO promotions (name, kind, value, type, valid_from, valid_to, max_uses, max_uses_per_user, actor)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, name, kind, value, type, valid_from, valid_to, max_uses, max_uses_per_user, actor, created_at
+`
+
+type CreatePromotionParams struct {
+	Name           string
+	Kind           string
+	Value          int64
+	Type           pgtype.Text
+	ValidFrom      time.Time
+	ValidTo        pgtype.Timestamp
+	MaxUses        pgtype.Int4
+	MaxUsesPerUser pgtype.Int4
+	Actor          string
+}
+
+func (q *Queries) CreatePromotion(ctx context.Context, arg CreatePromotionParams) (Promotion, error) {
+	row := q.db.QueryRow(ctx, createPromotion,
+		arg.Name,
+		arg.Kind,
+		arg.Value,
+		arg.Type,
+		arg.ValidFrom,
+		arg.ValidTo,
+		arg.MaxUses,
+		arg.MaxUsesPerUser,
+		arg.Actor,
+	)
+	var i Promotion
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Kind,
+		&i.Value,
+		&i.Type,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.MaxUses,
+		&i.MaxUsesPerUser,
+		&i.Actor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPurchaseQuote = `-- name: CreatePurchaseQuote :exec
+INSERT INTO purchase_quotes (token, username, type, size, color, quantity, promo_code, price, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type CreatePurchaseQuoteParams struct {
+	Token     string
+	Username  string
+	Type      string
+	Size      string
+	Color     string
+	Quantity  int32
+	PromoCode string
+	Price     int64
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreatePurchaseQuote(ctx context.Context, arg CreatePurchaseQuoteParams) error {
+	_, err := q.db.Exec(ctx, createPurchaseQuote,
+		arg.Token,
+		arg.Username,
+		arg.Type,
+		arg.Size,
+		arg.Color,
+		arg.Quantity,
+		arg.PromoCode,
+		arg.Price,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (username, password, referrer)
+VALUES ($1, $2, $3) RETURNING id
+`
+
+type CreateUserParams struct {
+	Username string
+	Password string
+	Referrer string
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.Password, arg.Referrer)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createWallet = `-- name: CreateWallet :one
+INSERT INTO wallets (name, created_by)
+VALUES ($1, $2) RETURNING id, created_at
+`
+
+type CreateWalletParams struct {
+	Name      string
+	CreatedBy string
+}
+
+type CreateWalletRow struct {
+	ID        int32
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (CreateWalletRow, error) {
+	row := q.db.QueryRow(ctx, createWallet, arg.Name, arg.CreatedBy)
+	var i CreateWalletRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const createWalletFundingHistoryEntry = `-- name: CreateWalletFundingHistoryEntry :one
+INSERT INTO history (username, amount, kind, wallet_id)
+VALUES ($1, $2, $3, $4) RETURNING id
+`
+
+type CreateWalletFundingHistoryEntryParams struct {
+	Username string
+	Amount   int64
+	Kind     string
+	WalletID pgtype.Int4
+}
+
+func (q *Queries) CreateWalletFundingHistoryEntry(ctx context.Context, arg CreateWalletFundingHistoryEntryParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createWalletFundingHistoryEntry,
+		arg.Username,
+		arg.Amount,
+		arg.Kind,
+		arg.WalletID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createWalletHistoryEntry = `-- name: CreateWalletHistoryEntry :one
+INSERT INTO wallet_history (wallet_id, username, kind, amount, to_user, item, inventory_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
+`
+
+type CreateWalletHistoryEntryParams struct {
+	WalletID    int32
+	Username    string
+	Kind        string
+	Amount      int64
+	ToUser      string
+	Item        string
+	InventoryID pgtype.Int4
+}
+
+func (q *Queries) CreateWalletHistoryEntry(ctx context.Context, arg CreateWalletHistoryEntryParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createWalletHistoryEntry,
+		arg.WalletID,
+		arg.Username,
+		arg.Kind,
+		arg.Amount,
+		arg.ToUser,
+		arg.Item,
+		arg.InventoryID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const declineGift = `-- name: DeclineGift :one
+UPDATE gifts
+SET status      = 'declined',
+    declined_at = NOW()
+WHERE id = $1 RETURNING declined_at
+`
+
+func (q *Queries) DeclineGift(ctx context.Context, id int32) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, declineGift, id)
+	var declinedAt pgtype.Timestamp
+	err := row.Scan(&declinedAt)
+	return declinedAt, err
+}
+
+const deleteExpiredPurchaseQuotes = `-- name: DeleteExpiredPurchaseQuotes :exec
+DELETE
+FROM purchase_quotes
+WHERE username = $1
+  AND expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredPurchaseQuotes(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, deleteExpiredPurchaseQuotes, username)
+	return err
+}
+
+const deletePurchaseLimit = `-- name: DeletePurchaseLimit :execrows
+DELETE
+FROM purchase_limits
+WHERE type = $1
+`
+
+func (q *Queries) DeletePurchaseLimit(ctx context.Context, type_ string) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePurchaseLimit, type_)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWalletMember = `-- name: DeleteWalletMember :one
+DELETE
+FROM wallet_members
+WHERE wallet_id = $1
+  AND username = $2 RETURNING username
+`
+
+type DeleteWalletMemberParams struct {
+	WalletID int32
+	Username string
+}
+
+func (q *Queries) DeleteWalletMember(ctx context.Context, arg DeleteWalletMemberParams) (string, error) {
+	row := q.db.QueryRow(ctx, deleteWalletMember, arg.WalletID, arg.Username)
+	var username string
+	err := row.Scan(&username)
+	return username, err
+}
+
+const expireCoinLots = `-- name: ExpireCoinLots :one
+WITH locked AS (
+    SELECT id, remaining, received_at
+    FROM coin_lots
+    WHERE username = $1
+      AND remaining > 0
+      AND expires_at <= NOW()
+    FOR UPDATE
+), expired AS (
+    SELECT id,
+           LEAST(remaining, $2::BIGINT - (SUM(remaining) OVER (ORDER BY received_at, id) - remaining)) AS amount
+    FROM locked
+), updated AS (
+    UPDATE coin_lots
+    SET remaining = coin_lots.remaining - expired.amount
+    FROM expired
+    WHERE coin_lots.id = expired.id
+      AND expired.amount > 0
+)
+SELECT COALESCE(SUM(amount), 0)::BIGINT AS expired
+FROM expired
+WHERE amount > 0
+`
+
+type ExpireCoinLotsParams struct {
+	Username  string
+	Available int64
+}
+
+func (q *Queries) ExpireCoinLots(ctx context.Context, arg ExpireCoinLotsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, expireCoinLots, arg.Username, arg.Available)
+	var expired int64
+	err := row.Scan(&expired)
+	return expired, err
+}
+
+const getActiveListings = `-- name: GetActiveListings :many
+SELECT id, seller, type, price, status, buyer, created_at, closed_at, variant_id
+FROM listings
+WHERE status = 'active'
+  AND ($1::VARCHAR = '' OR type = $1)
+ORDER BY id
+`
+
+func (q *Queries) GetActiveListings(ctx context.Context, column1 string) ([]Listing, error) {
+	rows, err := q.db.Query(ctx, getActiveListings, column1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Listing
+	for rows.Next() {
+		var i Listing
+		if err := rows.Scan(
+			&i.ID,
+			&i.Seller,
+			&i.Type,
+			&i.Price,
+			&i.Status,
+			&i.Buyer,
+			&i.CreatedAt,
+			&i.ClosedAt,
+			&i.VariantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBalance = `-- name: GetBalance :one
+SELECT id, username, coins, held
+FROM balance
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetBalance(ctx context.Context, username string) (Balance, error) {
+	row := q.db.QueryRow(ctx, getBalance, username)
+	var i Balance
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Coins,
+		&i.Held,
+	)
+	return i, err
+}
+
+const getBalanceForUpdate = `-- name: GetBalanceForUpdate :one
+SELECT coins, held
+FROM balance
+WHERE username = $1 LIMIT 1 FOR UPDATE
+`
+
+type GetBalanceForUpdateRow struct {
+	Coins int64
+	Held  int64
+}
+
+func (q *Queries) GetBalanceForUpdate(ctx context.Context, username string) (GetBalanceForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getBalanceForUpdate, username)
+	var i GetBalanceForUpdateRow
+	err := row.Scan(&i.Coins, &i.Held)
+	return i, err
+}
+
+const getBalanceHold = `-- name: GetBalanceHold :one
+SELECT id, username, amount, captured, status, reason, created_by, expires_at, created_at, closed_at
+FROM balance_holds
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetBalanceHold(ctx context.Context, id int32) (BalanceHold, error) {
+	row := q.db.QueryRow(ctx, getBalanceHold, id)
+	var i BalanceHold
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Amount,
+		&i.Captured,
+		&i.Status,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const getBalanceHoldForUpdate = `-- name: GetBalanceHoldForUpdate :one
+SELECT id, username, amount, captured, status, reason, created_by, expires_at, created_at, closed_at
+FROM balance_holds
+WHERE id = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetBalanceHoldForUpdate(ctx context.Context, id int32) (BalanceHold, error) {
+	row := q.db.QueryRow(ctx, getBalanceHoldForUpdate, id)
+	var i BalanceHold
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Amount,
+		&i.Captured,
+		&i.Status,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const getCart = `-- name: GetCart :many
+SELECT c.type,
+       c.size,
+       c.color,
+       c.quantity,
+       m.price,
+       (m.available AND NOT EXISTS (SELECT 1 FROM merch_drops d WHERE d.type = c.type))::BOOLEAN AS available,
+       m.stock,
+       v.stock AS variant_stock
+FROM cart_items c
+         LEFT JOIN merch_current m ON m.type = c.type
+         LEFT JOIN merch_variants v ON v.type = c.type AND v.size = c.size AND v.color = c.color
+WHERE c.username = $1
+ORDER BY c.added_at, c.type, c.size, c.color
+`
+
+type GetCartRow struct {
+	Type         string
+	Size         string
+	Color        string
+	Quantity     int32
+	Price        pgtype.Int8
+	Available    pgtype.Bool
+	Stock        pgtype.Int4
+	VariantStock pgtype.Int4
+}
+
+func (q *Queries) GetCart(ctx context.Context, username string) ([]GetCartRow, error) {
+	rows, err := q.db.Query(ctx, getCart, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCartRow
+	for rows.Next() {
+		var i GetCartRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.Size,
+			&i.Color,
+			&i.Quantity,
+			&i.Price,
+			&i.Available,
+			&i.Stock,
+			&i.VariantStock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCartForUpdate = `-- name: GetCartForUpdate :many
+SELECT c.type,
+       c.size,
+       c.color,
+       c.quantity,
+       m.price,
+       (m.available AND NOT EXISTS (SELECT 1 FROM merch_drops d WHERE d.type = c.type))::BOOLEAN AS available,
+       m.stock,
+       v.stock AS variant_stock
+FROM cart_items c
+         LEFT JOIN merch_current m ON m.type = c.type
+         LEFT JOIN merch_variants v ON v.type = c.type AND v.size = c.size AND v.color = c.color
+WHERE c.username = $1
+ORDER BY c.added_at, c.type, c.size, c.color
+FOR UPDATE OF c
+`
+
+type GetCartForUpdateRow struct {
+	Type         string
+	Size         string
+	Color        string
+	Quantity     int32
+	Price        pgtype.Int8
+	Available    pgtype.Bool
+	Stock        pgtype.Int4
+	VariantStock pgtype.Int4
+}
+
+func (q *Queries) GetCartForUpdate(ctx context.Context, username string) ([]GetCartForUpdateRow, error) {
+	rows, err := q.db.Query(ctx, getCartForUpdate, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCartForUpdateRow
+	for rows.Next() {
+		var i GetCartForUpdateRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.Size,
+			&i.Color,
+			&i.Quantity,
+			&i.Price,
+			&i.Available,
+			&i.Stock,
+			&i.VariantStock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCatalog = `-- name: GetCatalog :many
+SELECT id, type, price, description, available, stock
+FROM merch_current
+WHERE price >= $1::BIGINT
+  AND price <= $2::BIGINT
+ORDER BY CASE WHEN $3::VARCHAR = 'price' THEN price END,
+         CASE WHEN $3::VARCHAR = '-price' THEN price END DESC,
+         CASE WHEN $3::VARCHAR = '-type' THEN type END DESC,
+         type
+`
+
+type GetCatalogParams struct {
+	MinPrice int64
+	MaxPrice int64
+	Sort     string
+}
+
+func (q *Queries) GetCatalog(ctx context.Context, arg GetCatalogParams) ([]MerchCurrent, error) {
+	rows, err := q.db.Query(ctx, getCatalog, arg.MinPrice, arg.MaxPrice, arg.Sort)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchCurrent
+	for rows.Next() {
+		var i MerchCurrent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Price,
+			&i.Description,
+			&i.Available,
+			&i.Stock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCatalogItem = `-- name: GetCatalogItem :one
+SELECT id, type, price, description, available, stock
+FROM merch_current
+WHERE type = $1 LIMIT 1
+`
+
+func (q *Queries) GetCatalogItem(ctx context.Context, type_ string) (MerchCurrent, error) {
+	row := q.db.QueryRow(ctx, getCatalogItem, type_)
+	var i MerchCurrent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Price,
+		&i.Description,
+		&i.Available,
+		&i.Stock,
+	)
+	return i, err
+}
+
+const getCoinOperationsTotals = `-- name: GetCoinOperationsTotals :one
+SELECT COALESCE(SUM(amount * recipients) FILTER (WHERE kind = 'mint'), 0)::BIGINT AS minted,
+       COALESCE(SUM(amount * recipients) FILTER (WHERE kind = 'burn'), 0)::BIGINT AS burned
+FROM coin_operations
+`
+
+type GetCoinOperationsTotalsRow struct {
+	Minted int64
+	Burned int64
+}
+
+func (q *Queries) GetCoinOperationsTotals(ctx context.Context) (GetCoinOperationsTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getCoinOperationsTotals)
+	var i GetCoinOperationsTotalsRow
+	err := row.Scan(&i.Minted, &i.Burned)
+	return i, err
+}
+
+const getCurrentMerchDrop = `-- name: GetCurrentMerchDrop :one
+SELECT d.id, d.type, d.starts_at, d.ends_at, COALESCE(d.price, m.price)::BIGINT AS price, d.stock
+FROM merch_drops d
+         JOIN merch_current m ON m.type = d.type
+WHERE d.type = $1
+  AND m.available
+ORDER BY d.ends_at <= NOW(), d.starts_at LIMIT 1
+`
+
+type GetCurrentMerchDropRow struct {
+	ID       int32
+	Type     string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Price    int64
+	Stock    pgtype.Int4
+}
+
+func (q *Queries) GetCurrentMerchDrop(ctx context.Context, type_ string) (GetCurrentMerchDropRow, error) {
+	row := q.db.QueryRow(ctx, getCurrentMerchDrop, type_)
+	var i GetCurrentMerchDropRow
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Price,
+		&i.Stock,
+	)
+	return i, err
+}
+
+const getExpiredBalanceHolds = `-- name: GetExpiredBalanceHolds :many
+SELECT id
+FROM balance_holds
+WHERE status = 'held'
+  AND expires_at <= NOW()
+ORDER BY id LIMIT $1
+`
+
+func (q *Queries) GetExpiredBalanceHolds(ctx context.Context, limit int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getExpiredBalanceHolds, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiringCoinLots = `-- name: GetExpiringCoinLots :many
+SELECT remaining, expires_at
+FROM coin_lots
+WHERE username = $1
+  AND remaining > 0
+  AND expires_at <= $2
+ORDER BY expires_at, id
+`
+
+type GetExpiringCoinLotsParams struct {
+	Username  string
+	ExpiresAt time.Time
+}
+
+type GetExpiringCoinLotsRow struct {
+	Remaining int64
+	ExpiresAt time.Time
+}
+
+func (q *Queries) GetExpiringCoinLots(ctx context.Context, arg GetExpiringCoinLotsParams) ([]GetExpiringCoinLotsRow, error) {
+	rows, err := q.db.Query(ctx, getExpiringCoinLots, arg.Username, arg.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetExpiringCoinLotsRow
+	for rows.Next() {
+		var i GetExpiringCoinLotsRow
+		if err := rows.Scan(&i.Remaining, &i.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGiftForUpdate = `-- name: GetGiftForUpdate :one
+SELECT id, from_user, to_user, type, price_paid, message, status, inventory_id, created_at, declined_at, variant_id
+FROM gifts
+WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetGiftForUpdate(ctx context.Context, id int32) (Gift, error) {
+	row := q.db.QueryRow(ctx, getGiftForUpdate, id)
+	var i Gift
+	err := row.Scan(
+		&i.ID,
+		&i.FromUser,
+		&i.ToUser,
+		&i.Type,
+		&i.PricePaid,
+		&i.Message,
+		&i.Status,
+		&i.InventoryID,
+		&i.CreatedAt,
+		&i.DeclinedAt,
+		&i.VariantID,
+	)
+	return i, err
+}
+
+const getHistory = `-- name: GetHistory :many
+SELECT from_user,
+       to_user,
+       (CASE WHEN from_user = '' AND to_user = '' THEN kind ELSE '' END)::VARCHAR AS kind,
+       SUM(amount)                                                              AS amount
+FROM history
+WHERE username = $1
+GROUP BY from_user, to_user, 3
+`
+
+type GetHistoryRow struct {
+	FromUser string
+	ToUser   string
+	Kind     string
+	Amount   int64
+}
+
+func (q *Queries) GetHistory(ctx context.Context, username string) ([]GetHistoryRow, error) {
+	rows, err := q.db.Query(ctx, getHistory, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetHistoryRow
+	for rows.Next() {
+		var i GetHistoryRow
+		if err := rows.Scan(
+			&i.FromUser,
+			&i.ToUser,
+			&i.Kind,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHistoryRecord = `-- name: GetHistoryRecord :one
+SELECT id, username, from_user, to_user, amount, sent_at, kind, reversal_of, coin_operation_id, wallet_id
+FROM history
+WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetHistoryRecord(ctx context.Context, id int32) (History, error) {
+	row := q.db.QueryRow(ctx, getHistoryRecord, id)
+	var i History
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromUser,
+		&i.ToUser,
+		&i.Amount,
+		&i.SentAt,
+		&i.Kind,
+		&i.ReversalOf,
+		&i.CoinOperationID,
+		&i.WalletID,
+	)
+	return i, err
+}
+
+const getHistoryReversals = `-- name: GetHistoryReversals :many
+SELECT id, from_user, to_user, amount, reversal_of, sent_at
+FROM history
+WHERE username = $1
+  AND reversal_of IS NOT NULL
+ORDER BY id
+`
+
+type GetHistoryReversalsRow struct {
+	ID         int32
+	FromUser   string
+	ToUser     string
+	Amount     int64
+	ReversalOf pgtype.Int4
+	SentAt     time.Time
+}
+
+func (q *Queries) GetHistoryReversals(ctx context.Context, username string) ([]GetHistoryReversalsRow, error) {
+	rows, err := q.db.Query(ctx, getHistoryReversals, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetHistoryReversalsRow
+	for rows.Next() {
+		var i GetHistoryReversalsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromUser,
+			&i.ToUser,
+			&i.Amount,
+			&i.ReversalOf,
+			&i.SentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInventory = `-- name: GetInventory :many
+SELECT type, SUM(quantity) AS quantity
+FROM inventory
+WHERE username = $1
+GROUP BY type
+HAVING SUM(quantity) > 0
+`
+
+type GetInventoryRow struct {
+	Type     string
+	Quantity int64
+}
+
+func (q *Queries) GetInventory(ctx context.Context, username string) ([]GetInventoryRow, error) {
+	rows, err := q.db.Query(ctx, getInventory, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInventoryRow
+	for rows.Next() {
+		var i GetInventoryRow
+		if err := rows.Scan(&i.Type, &i.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInventoryGiftForUpdate = `-- name: GetInventoryGiftForUpdate :one
+SELECT id, from_user, to_user, type, price_paid, message, status, inventory_id, created_at, declined_at, variant_id
+FROM gifts
+WHERE inventory_id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetInventoryGiftForUpdate(ctx context.Context, inventoryID int32) (Gift, error) {
+	row := q.db.QueryRow(ctx, getInventoryGiftForUpdate, inventoryID)
+	var i Gift
+	err := row.Scan(
+		&i.ID,
+		&i.FromUser,
+		&i.ToUser,
+		&i.Type,
+		&i.PricePaid,
+		&i.Message,
+		&i.Status,
+		&i.InventoryID,
+		&i.CreatedAt,
+		&i.DeclinedAt,
+		&i.VariantID,
+	)
+	return i, err
+}
+
+const getInventoryRecord = `-- name: GetInventoryRecord :one
+SELECT id, username, type, quantity, bought_at, kind, price_paid, reversal_of, discount, variant_id
+FROM inventory
+WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetInventoryRecord(ctx context.Context, id int32) (Inventory, error) {
+	row := q.db.QueryRow(ctx, getInventoryRecord, id)
+	var i Inventory
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Type,
+		&i.Quantity,
+		&i.BoughtAt,
+		&i.Kind,
+		&i.PricePaid,
+		&i.ReversalOf,
+		&i.Discount,
+		&i.VariantID,
+	)
+	return i, err
+}
+
+const getInventoryReversals = `-- name: GetInventoryReversals :many
+SELECT r.id, r.type, r.quantity, r.price_paid, p.discount, r.reversal_of, r.bought_at
+FROM inventory r
+         JOIN inventory p ON p.id = r.reversal_of
+WHERE r.username = $1
+  AND r.kind = 'reversal'
+ORDER BY r.id
+`
+
+type GetInventoryReversalsRow struct {
+	ID         int32
+	Type       string
+	Quantity   int32
+	PricePaid  int64
+	Discount   int64
+	ReversalOf pgtype.Int4
+	BoughtAt   time.Time
+}
+
+func (q *Queries) GetInventoryReversals(ctx context.Context, username string) ([]GetInventoryReversalsRow, error) {
+	rows, err := q.db.Query(ctx, getInventoryReversals, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInventoryReversalsRow
+	for rows.Next() {
+		var i GetInventoryReversalsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Quantity,
+			&i.PricePaid,
+			&i.Discount,
+			&i.ReversalOf,
+			&i.BoughtAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInventoryVariants = `-- name: GetInventoryVariants :many
+SELECT i.type, COALESCE(v.size, '')::VARCHAR AS size, COALESCE(v.color, '')::VARCHAR AS color, SUM(i.quantity) AS quantity
+FROM inventory i
+         LEFT JOIN merch_variants v ON v.id = i.variant_id
+WHERE i.username = $1
+GROUP BY i.type, v.id, v.size, v.color
+HAVING SUM(i.quantity) > 0
+ORDER BY i.type, v.id NULLS FIRST
+`
+
+type GetInventoryVariantsRow struct {
+	Type     string
+	Size     string
+	Color    string
+	Quantity int64
+}
+
+func (q *Queries) GetInventoryVariants(ctx context.Context, username string) ([]GetInventoryVariantsRow, error) {
+	rows, err := q.db.Query(ctx, getInventoryVariants, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInventoryVariantsRow
+	for rows.Next() {
+		var i GetInventoryVariantsRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.Size,
+			&i.Color,
+			&i.Quantity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getItemQuantity = `-- name: GetItemQuantity :one
+SELECT COALESCE(SUM(quantity), 0)::BIGINT AS quantity
+FROM inventory
+WHERE username = $1
+  AND type = $2
+  AND variant_id IS NOT DISTINCT FROM $3
+`
+
+type GetItemQuantityParams struct {
+	Username  string
+	Type      string
+	VariantID pgtype.Int4
+}
+
+func (q *Queries) GetItemQuantity(ctx context.Context, arg GetItemQuantityParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getItemQuantity, arg.Username, arg.Type, arg.VariantID)
+	var quantity int64
+	err := row.Scan(&quantity)
+	return quantity, err
+}
+
+const getItemTransfers = `-- name: GetItemTransfers :many
+SELECT id, from_user, to_user, type, quantity, created_at
+FROM item_transfers
+WHERE from_user = $1
+   OR to_user = $1
+ORDER BY id
+`
+
+func (q *Queries) GetItemTransfers(ctx context.Context, fromUser string) ([]ItemTransfer, error) {
+	rows, err := q.db.Query(ctx, getItemTransfers, fromUser)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ItemTransfer
+	for rows.Next() {
+		var i ItemTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromUser,
+			&i.ToUser,
+			&i.Type,
+			&i.Quantity,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingForUpdate = `-- name: GetListingForUpdate :one
+SELECT id, seller, type, price, status, buyer, created_at, closed_at, variant_id
+FROM listings
+WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetListingForUpdate(ctx context.Context, id int32) (Listing, error) {
+	row := q.db.QueryRow(ctx, getListingForUpdate, id)
+	var i Listing
+	err := row.Scan(
+		&i.ID,
+		&i.Seller,
+		&i.Type,
+		&i.Price,
+		&i.Status,
+		&i.Buyer,
+		&i.CreatedAt,
+		&i.ClosedAt,
+		&i.VariantID,
+	)
+	return i, err
+}
+
+const getLowStockMerch = `-- name: GetLowStockMerch :many
+SELECT id, type, price, description, available, stock
+FROM merch_current
+WHERE stock <= $1
+  AND available
+ORDER BY stock, type
+`
+
+func (q *Queries) GetLowStockMerch(ctx context.Context, stock pgtype.Int4) ([]MerchCurrent, error) {
+	rows, err := q.db.Query(ctx, getLowStockMerch, stock)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchCurrent
+	for rows.Next() {
+		var i MerchCurrent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Price,
+			&i.Description,
+			&i.Available,
+			&i.Stock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMerch = `-- name: GetMerch :one
+SELECT id, type, price, description, available, stock
+FROM merch_current m
+WHERE type = $1
+  AND available
+  AND NOT EXISTS (SELECT 1 FROM merch_drops d WHERE d.type = m.type) LIMIT 1
+`
+
+func (q *Queries) GetMerch(ctx context.Context, type_ string) (MerchCurrent, error) {
+	row := q.db.QueryRow(ctx, getMerch, type_)
+	var i MerchCurrent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Price,
+		&i.Description,
+		&i.Available,
+		&i.Stock,
+	)
+	return i, err
+}
+
+const getMerchAudit = `-- name: GetMerchAudit :many
+SELECT id, type, action, price, description, available, actor, created_at, stock
+FROM merch_audit
+WHERE $1::VARCHAR = ''
+   OR type = $1::VARCHAR
+ORDER BY id DESC
+`
+
+func (q *Queries) GetMerchAudit(ctx context.Context, type_ string) ([]MerchAudit, error) {
+	rows, err := q.db.Query(ctx, getMerchAudit, type_)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchAudit
+	for rows.Next() {
+		var i MerchAudit
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Action,
+			&i.Price,
+			&i.Description,
+			&i.Available,
+			&i.Actor,
+			&i.CreatedAt,
+			&i.Stock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMerchDrops = `-- name: GetMerchDrops :many
+SELECT id, type, starts_at, ends_at, price, stock, actor, created_at
+FROM merch_drops
+WHERE ends_at > NOW()
+ORDER BY starts_at, type
+`
+
+func (q *Queries) GetMerchDrops(ctx context.Context) ([]MerchDrop, error) {
+	rows, err := q.db.Query(ctx, getMerchDrops)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchDrop
+	for rows.Next() {
+		var i MerchDrop
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Price,
+			&i.Stock,
+			&i.Actor,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMerchForUpdate = `-- name: GetMerchForUpdate :one
+SELECT id, type, price, description, available, stock
+FROM merch_current
+WHERE type = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetMerchForUpdate(ctx context.Context, type_ string) (MerchCurrent, error) {
+	row := q.db.QueryRow(ctx, getMerchForUpdate, type_)
+	var i MerchCurrent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Price,
+		&i.Description,
+		&i.Available,
+		&i.Stock,
+	)
+	return i, err
+}
+
+const getMerchPrices = `-- name: GetMerchPrices :many
+SELECT id, type, price, valid_from, valid_to, actor, created_at
+FROM merch_prices
+WHERE type = $1
+ORDER BY valid_from
+`
+
+func (q *Queries) GetMerchPrices(ctx context.Context, type_ string) ([]MerchPrice, error) {
+	rows, err := q.db.Query(ctx, getMerchPrices, type_)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchPrice
+	for rows.Next() {
+		var i MerchPrice
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Price,
+			&i.ValidFrom,
+			&i.ValidTo,
+			&i.Actor,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMerchVariant = `-- name: GetMerchVariant :one
+SELECT id, type, size, color, stock, created_at
+FROM merch_variants
+WHERE type = $1
+  AND size = $2
+  AND color = $3 LIMIT 1
+`
+
+type GetMerchVariantParams struct {
+	Type  string
+	Size  string
+	Color string
+}
+
+func (q *Queries) GetMerchVariant(ctx context.Context, arg GetMerchVariantParams) (MerchVariant, error) {
+	row := q.db.QueryRow(ctx, getMerchVariant, arg.Type, arg.Size, arg.Color)
+	var i MerchVariant
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Size,
+		&i.Color,
+		&i.Stock,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getMerchVariants = `-- name: GetMerchVariants :many
+SELECT id, type, size, color, stock, created_at
+FROM merch_variants
+WHERE type = $1
+ORDER BY id
+`
+
+func (q *Queries) GetMerchVariants(ctx context.Context, type_ string) ([]MerchVariant, error) {
+	rows, err := q.db.Query(ctx, getMerchVariants, type_)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchVariant
+	for rows.Next() {
+		var i MerchVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Size,
+			&i.Color,
+			&i.Stock,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMoneySupply = `-- name: GetMoneySupply :one
+SELECT (COALESCE(SUM(coins), 0) + (SELECT COALESCE(SUM(coins), 0) FROM wallets))::BIGINT AS coins,
+       COUNT(*)::INTEGER                                                               AS users
+FROM balance
+`
+
+type GetMoneySupplyRow struct {
+	Coins int64
+	Users int32
+}
+
+func (q *Queries) GetMoneySupply(ctx context.Context) (GetMoneySupplyRow, error) {
+	row := q.db.QueryRow(ctx, getMoneySupply)
+	var i GetMoneySupplyRow
+	err := row.Scan(&i.Coins, &i.Users)
+	return i, err
+}
+
+const getNextMerchPriceStart = `-- name: GetNextMerchPriceStart :one
+SELECT valid_from
+FROM merch_prices
+WHERE type = $1
+  AND valid_from > $2
+ORDER BY valid_from LIMIT 1
+`
+
+type GetNextMerchPriceStartParams struct {
+	Type      string
+	ValidFrom time.Time
+}
+
+func (q *Queries) GetNextMerchPriceStart(ctx context.Context, arg GetNextMerchPriceStartParams) (time.Time, error) {
+	row := q.db.QueryRow(ctx, getNextMerchPriceStart, arg.Type, arg.ValidFrom)
+	var validFrom time.Time
+	err := row.Scan(&validFrom)
+	return validFrom, err
+}
+
+const getOrderForUpdate = `-- name: GetOrderForUpdate :one
+SELECT id, inventory_id, username, type, quantity, status, updated_by, placed_at, ready_at, delivered_at, cancelled_at
+FROM orders
+WHERE id = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetOrderForUpdate(ctx context.Context, id int32) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.InventoryID,
+		&i.Username,
+		&i.Type,
+		&i.Quantity,
+		&i.Status,
+		&i.UpdatedBy,
+		&i.PlacedAt,
+		&i.ReadyAt,
+		&i.DeliveredAt,
+		&i.CancelledAt,
+	)
+	return i, err
+}
+
+const getOrders = `-- name: GetOrders :many
+SELECT id, inventory_id, username, type, quantity, status, updated_by, placed_at, ready_at, delivered_at, cancelled_at
+FROM orders
+WHERE $1::VARCHAR IS NULL
+   OR status = $1::VARCHAR
+ORDER BY id
+`
+
+func (q *Queries) GetOrders(ctx context.Context, status pgtype.Text) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getOrders, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.InventoryID,
+			&i.Username,
+			&i.Type,
+			&i.Quantity,
+			&i.Status,
+			&i.UpdatedBy,
+			&i.PlacedAt,
+			&i.ReadyAt,
+			&i.DeliveredAt,
+			&i.CancelledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPromoCodeForUpdate = `-- name: GetPromoCodeForUpdate :one
+SELECT code, promotion_id, single_use
+FROM promo_codes
+WHERE code = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetPromoCodeForUpdate(ctx context.Context, code string) (PromoCode, error) {
+	row := q.db.QueryRow(ctx, getPromoCodeForUpdate, code)
+	var i PromoCode
+	err := row.Scan(&i.Code, &i.PromotionID, &i.SingleUse)
+	return i, err
+}
+
+const getPromoCodes = `-- name: GetPromoCodes :many
+SELECT code, promotion_id, single_use
+FROM promo_codes
+ORDER BY promotion_id, code
+`
+
+func (q *Queries) GetPromoCodes(ctx context.Context) ([]PromoCode, error) {
+	rows, err := q.db.Query(ctx, getPromoCodes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PromoCode
+	for rows.Next() {
+		var i PromoCode
+		if err := rows.Scan(&i.Code, &i.PromotionID, &i.SingleUse); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPromoRedemptionCounts = `-- name: GetPromoRedemptionCounts :many
+SELECT promotion_id, COUNT(*) AS uses
+FROM promo_redemptions
+GROUP BY promotion_id
+`
+
+type GetPromoRedemptionCountsRow struct {
+	PromotionID int32
+	Uses        int64
+}
+
+func (q *Queries) GetPromoRedemptionCounts(ctx context.Context) ([]GetPromoRedemptionCountsRow, error) {
+	rows, err := q.db.Query(ctx, getPromoRedemptionCounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPromoRedemptionCountsRow
+	for rows.Next() {
+		var i GetPromoRedemptionCountsRow
+		if err := rows.Scan(&i.PromotionID, &i.Uses); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPromotionForUpdate = `-- name: GetPromotionForUpdate :one
+SELECT id, name, kind, value, type, valid_from, valid_to, max_uses, max_uses_per_user, actor, created_at
+FROM promotions
+WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetPromotionForUpdate(ctx context.Context, id int32) (Promotion, error) {
+	row := q.db.QueryRow(ctx, getPromotionForUpdate, id)
+	var i Promotion
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Kind,
+		&i.Value,
+		&i.Type,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.MaxUses,
+		&i.MaxUsesPerUser,
+		&i.Actor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPromotions = `-- name: GetPromotions :many
+SELECT id, name, kind, value, type, valid_from, valid_to, max_uses, max_uses_per_user, actor, created_at
+FROM promotions
+ORDER BY id
+`
+
+func (q *Queries) GetPromotions(ctx context.Context) ([]Promotion, error) {
+	rows, err := q.db.Query(ctx, getPromotions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Promotion
+	for rows.Next() {
+		var i Promotion
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Kind,
+			&i.Value,
+			&i.Type,
+			&i.ValidFrom,
+			&i.ValidTo,
+			&i.MaxUses,
+			&i.MaxUsesPerUser,
+			&i.Actor,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPurchaseLimit = `-- name: GetPurchaseLimit :one
+SELECT type, max_quantity, period_days, actor, updated_at
+FROM purchase_limits
+WHERE type = $1 LIMIT 1
+`
+
+func (q *Queries) GetPurchaseLimit(ctx context.Context, type_ string) (PurchaseLimit, error) {
+	row := q.db.QueryRow(ctx, getPurchaseLimit, type_)
+	var i PurchaseLimit
+	err := row.Scan(
+		&i.Type,
+		&i.MaxQuantity,
+		&i.PeriodDays,
+		&i.Actor,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPurchaseLimits = `-- name: GetPurchaseLimits :many
+SELECT type, max_quantity, period_days, actor, updated_at
+FROM purchase_limits
+ORDER BY type
+`
+
+func (q *Queries) GetPurchaseLimits(ctx context.Context) ([]PurchaseLimit, error) {
+	rows, err := q.db.Query(ctx, getPurchaseLimits)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PurchaseLimit
+	for rows.Next() {
+		var i PurchaseLimit
+		if err := rows.Scan(
+			&i.Type,
+			&i.MaxQuantity,
+			&i.PeriodDays,
+			&i.Actor,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPurchases = `-- name: GetPurchases :many
+SELECT i.id, i.type, i.quantity, i.price_paid, i.discount, i.bought_at,
+       EXISTS (SELECT 1 FROM inventory r WHERE r.reversal_of = i.id) AS reversed
+FROM inventory i
+WHERE i.username = $1
+  AND i.kind = 'purchase'
+ORDER BY i.id
+`
+
+type GetPurchasesRow struct {
+	ID        int32
+	Type      string
+	Quantity  int32
+	PricePaid int64
+	Discount  int64
+	BoughtAt  time.Time
+	Reversed  bool
+}
+
+func (q *Queries) GetPurchases(ctx context.Context, username string) ([]GetPurchasesRow, error) {
+	rows, err := q.db.Query(ctx, getPurchases, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPurchasesRow
+	for rows.Next() {
+		var i GetPurchasesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Quantity,
+			&i.PricePaid,
+			&i.Discount,
+			&i.BoughtAt,
+			&i.Reversed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReturnablePurchase = `-- name: GetReturnablePurchase :one
+SELECT p.id, p.username, p.type, p.quantity, p.bought_at, p.kind, p.price_paid, p.reversal_of, p.discount, p.variant_id
+FROM inventory p
+WHERE p.username = $1
+  AND p.type = $2
+  AND p.kind = 'purchase'
+  AND p.bought_at >= $3
+  AND NOT EXISTS (SELECT 1 FROM inventory r WHERE r.reversal_of = p.id)
+  AND NOT EXISTS (SELECT 1
+                  FROM inventory o
+                  WHERE o.username = p.username
+                    AND o.type = p.type
+                    AND o.quantity < 0
+                    AND o.reversal_of IS NULL
+                    AND o.id > p.id)
+ORDER BY p.id DESC
+LIMIT 1 FOR UPDATE OF p
+`
+
+type GetReturnablePurchaseParams struct {
+	Username string
+	Type     string
+	BoughtAt time.Time
+}
+
+func (q *Queries) GetReturnablePurchase(ctx context.Context, arg GetReturnablePurchaseParams) (Inventory, error) {
+	row := q.db.QueryRow(ctx, getReturnablePurchase, arg.Username, arg.Type, arg.BoughtAt)
+	var i Inventory
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Type,
+		&i.Quantity,
+		&i.BoughtAt,
+		&i.Kind,
+		&i.PricePaid,
+		&i.ReversalOf,
+		&i.Discount,
+		&i.VariantID,
+	)
+	return i, err
+}
+
+const getSettings = `-- name: GetSettings :many
+SELECT name, value
+FROM settings
+`
+
+type GetSettingsRow struct {
+	Name  string
+	Value int64
+}
+
+func (q *Queries) GetSettings(ctx context.Context) ([]GetSettingsRow, error) {
+	rows, err := q.db.Query(ctx, getSettings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSettingsRow
+	for rows.Next() {
+		var i GetSettingsRow
+		if err := rows.Scan(&i.Name, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransfers = `-- name: GetTransfers :many
+SELECT h.id, h.username, h.to_user, h.amount, h.sent_at,
+       EXISTS (SELECT 1 FROM history r WHERE r.reversal_of = h.id) AS reversed
+FROM history h
+WHERE h.kind = 'transfer'
+  AND h.to_user <> ''
+  AND (h.username = $1 OR h.to_user = $1)
+ORDER BY h.id
+`
+
+type GetTransfersRow struct {
+	ID       int32
+	Username string
+	ToUser   string
+	Amount   int64
+	SentAt   time.Time
+	Reversed bool
+}
+
+func (q *Queries) GetTransfers(ctx context.Context, username string) ([]GetTransfersRow, error) {
+	rows, err := q.db.Query(ctx, getTransfers, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTransfersRow
+	for rows.Next() {
+		var i GetTransfersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.ToUser,
+			&i.Amount,
+			&i.SentAt,
+			&i.Reversed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, username, password, created_at, referrer
+FROM users
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUser, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.Referrer,
+	)
+	return i, err
+}
+
+const getUserGifts = `-- name: GetUserGifts :many
+SELECT id, from_user, to_user, type, price_paid, message, status, inventory_id, created_at, declined_at, variant_id
+FROM gifts
+WHERE from_user = $1
+   OR to_user = $1
+ORDER BY id
+`
+
+func (q *Queries) GetUserGifts(ctx context.Context, fromUser string) ([]Gift, error) {
+	rows, err := q.db.Query(ctx, getUserGifts, fromUser)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gift
+	for rows.Next() {
+		var i Gift
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromUser,
+			&i.ToUser,
+			&i.Type,
+			&i.PricePaid,
+			&i.Message,
+			&i.Status,
+			&i.InventoryID,
+			&i.CreatedAt,
+			&i.DeclinedAt,
+			&i.VariantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserListings = `-- name: GetUserListings :many
+SELECT id, seller, type, price, status, buyer, created_at, closed_at, variant_id
+FROM listings
+WHERE seller = $1
+ORDER BY id
+`
+
+func (q *Queries) GetUserListings(ctx context.Context, seller string) ([]Listing, error) {
+	rows, err := q.db.Query(ctx, getUserListings, seller)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Listing
+	for rows.Next() {
+		var i Listing
+		if err := rows.Scan(
+			&i.ID,
+			&i.Seller,
+			&i.Type,
+			&i.Price,
+			&i.Status,
+			&i.Buyer,
+			&i.CreatedAt,
+			&i.ClosedAt,
+			&i.VariantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserOrders = `-- name: GetUserOrders :many
+SELECT id, inventory_id, username, type, quantity, status, updated_by, placed_at, ready_at, delivered_at, cancelled_at
+FROM orders
+WHERE username = $1
+ORDER BY id DESC
+`
+
+func (q *Queries) GetUserOrders(ctx context.Context, username string) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getUserOrders, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.InventoryID,
+			&i.Username,
+			&i.Type,
+			&i.Quantity,
+			&i.Status,
+			&i.UpdatedBy,
+			&i.PlacedAt,
+			&i.ReadyAt,
+			&i.DeliveredAt,
+			&i.CancelledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserWallets = `-- name: GetUserWallets :many
+SELECT w.id, w.name, w.coins, w.created_by, w.created_at, m.role
+FROM wallets w
+         JOIN wallet_members m ON m.wallet_id = w.id
+WHERE m.username = $1
+ORDER BY w.id
+`
+
+type GetUserWalletsRow struct {
+	ID        int32
+	Name      string
+	Coins     int64
+	CreatedBy string
+	CreatedAt time.Time
+	Role      string
+}
+
+func (q *Queries) GetUserWallets(ctx context.Context, username string) ([]GetUserWalletsRow, error) {
+	rows, err := q.db.Query(ctx, getUserWallets, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserWalletsRow
+	for rows.Next() {
+		var i GetUserWalletsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Coins,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersWithExpiredCoinLots = `-- name: GetUsersWithExpiredCoinLots :many
+SELECT DISTINCT l.username
+FROM coin_lots l
+         JOIN balance b ON b.username = l.username
+WHERE l.remaining > 0
+  AND l.expires_at <= NOW()
+  AND b.coins > b.held
+LIMIT $1
+`
+
+func (q *Queries) GetUsersWithExpiredCoinLots(ctx context.Context, limit int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, getUsersWithExpiredCoinLots, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		items = append(items, username)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWallet = `-- name: GetWallet :one
+SELECT id, name, coins, created_by, created_at
+FROM wallets
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWallet(ctx context.Context, id int32) (Wallet, error) {
+	row := q.db.QueryRow(ctx, getWallet, id)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Coins,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWalletHistory = `-- name: GetWalletHistory :many
+SELECT id, wallet_id, username, kind, amount, to_user, item, created_at, inventory_id
+FROM wallet_history
+WHERE wallet_id = $1
+ORDER BY id
+`
+
+func (q *Queries) GetWalletHistory(ctx context.Context, walletID int32) ([]WalletHistory, error) {
+	rows, err := q.db.Query(ctx, getWalletHistory, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WalletHistory
+	for rows.Next() {
+		var i WalletHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.WalletID,
+			&i.Username,
+			&i.Kind,
+			&i.Amount,
+			&i.ToUser,
+			&i.Item,
+			&i.CreatedAt,
+			&i.InventoryID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWalletMembers = `-- name: GetWalletMembers :many
+SELECT username, role, added_by, added_at
+FROM wallet_members
+WHERE wallet_id = $1
+ORDER BY added_at, username
+`
+
+type GetWalletMembersRow struct {
+	Username string
+	Role     string
+	AddedBy  string
+	AddedAt  time.Time
+}
+
+func (q *Queries) GetWalletMembers(ctx context.Context, walletID int32) ([]GetWalletMembersRow, error) {
+	rows, err := q.db.Query(ctx, getWalletMembers, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWalletMembersRow
+	for rows.Next() {
+		var i GetWalletMembersRow
+		if err := rows.Scan(
+			&i.Username,
+			&i.Role,
+			&i.AddedBy,
+			&i.AddedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWalletPurchaseWallet = `-- name: GetWalletPurchaseWallet :one
+SELECT wallet_id
+FROM wallet_history
+WHERE inventory_id = $1
+  AND kind = 'purchase' LIMIT 1
+`
+
+func (q *Queries) GetWalletPurchaseWallet(ctx context.Context, inventoryID pgtype.Int4) (int32, error) {
+	row := q.db.QueryRow(ctx, getWalletPurchaseWallet, inventoryID)
+	var walletID int32
+	err := row.Scan(&walletID)
+	return walletID, err
+}
+
+const getWalletRole = `-- name: GetWalletRole :one
+SELECT role
+FROM wallet_members
+WHERE wallet_id = $1
+  AND username = $2 LIMIT 1
+`
+
+type GetWalletRoleParams struct {
+	WalletID int32
+	Username string
+}
+
+func (q *Queries) GetWalletRole(ctx context.Context, arg GetWalletRoleParams) (string, error) {
+	row := q.db.QueryRow(ctx, getWalletRole, arg.WalletID, arg.Username)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getWishlist = `-- name: GetWishlist :many
+SELECT w.type, m.price, m.available, m.stock, w.added_at
+FROM wishlist_items w
+         JOIN merch_current m ON m.type = w.type
+WHERE w.username = $1
+ORDER BY w.added_at, w.type
+`
+
+type GetWishlistRow struct {
+	Type      string
+	Price     int64
+	Available bool
+	Stock     pgtype.Int4
+	AddedAt   time.Time
+}
+
+func (q *Queries) GetWishlist(ctx context.Context, username string) ([]GetWishlistRow, error) {
+	rows, err := q.db.Query(ctx, getWishlist, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWishlistRow
+	for rows.Next() {
+		var i GetWishlistRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.Price,
+			&i.Available,
+			&i.Stock,
+			&i.AddedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
 
-const createBalance = `-- name: CreateBalance :one
-INSERT INTO balance (username, coins)
-VALUES ($1, 1000) RETURNING id
+const getWishlistEvents = `-- name: GetWishlistEvents :many
+SELECT p.type, 'price_drop'::VARCHAR AS kind, p.price, p.valid_from AS occurred_at
+FROM wishlist_items w
+         JOIN (SELECT type, price, valid_from, LAG(price) OVER (PARTITION BY type ORDER BY valid_from) AS previous_price
+               FROM merch_prices) p ON p.type = w.type
+WHERE w.username = $1
+  AND p.valid_from > w.added_at
+  AND p.valid_from <= NOW()
+  AND p.price < p.previous_price
+UNION ALL
+SELECT b.type, 'back_in_stock'::VARCHAR AS kind, b.price, b.created_at AS occurred_at
+FROM wishlist_items w
+         JOIN merch_back_in_stock b ON b.type = w.type
+WHERE w.username = $1
+  AND b.created_at > w.added_at
+ORDER BY occurred_at DESC
 `
 
-func (q *Queries) CreateBalance(ctx context.Context, username string) (int32, error) {
-	row := q.db.QueryRow(ctx, createBalance, username)
-	var id int32
-	err := row.Scan(&id)
-	return id, err
+type GetWishlistEventsRow struct {
+	Type       string
+	Kind       string
+	Price      int64
+	OccurredAt time.Time
 }
 
-const createHistoryRecord = `-- name: CreateHistoryRecord :one
-INSERT INTO history (username, from_user, to_user, amount)
-VALUES ($1, $2, $3, $4) RETURNING id
+func (q *Queries) GetWishlistEvents(ctx context.Context, username string) ([]GetWishlistEventsRow, error) {
+	rows, err := q.db.Query(ctx, getWishlistEvents, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWishlistEventsRow
+	for rows.Next() {
+		var i GetWishlistEventsRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.Kind,
+			&i.Price,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const grantCoinsBatch = `-- name: GrantCoinsBatch :one
+WITH granted AS (
+    UPDATE balance
+    SET coins = coins + $1
+    WHERE id IN (SELECT id
+                 FROM balance
+                 WHERE id > $2
+                 ORDER BY id LIMIT $3)
+    RETURNING id, username
+), recorded AS (
+    INSERT INTO history (username, amount, kind, coin_operation_id)
+    SELECT username, $1, 'mint', $4::INTEGER
+    FROM granted
+), lots AS (
+    INSERT INTO coin_lots (username, source, amount, remaining, expires_at)
+    SELECT username, 'mint', $1, $1, $5
+    FROM granted
+)
+SELECT COUNT(*)::INTEGER AS recipients, COALESCE(MAX(id), 0)::INTEGER AS last_balance_id
+FROM granted
 `
 
-type CreateHistoryRecordParams struct {
-	Username string
-	FromUser string
-	ToUser   string
-	Amount   int32
+type GrantCoinsBatchParams struct {
+	Amount          int64
+	AfterID         int32
+	BatchSize       int32
+	CoinOperationID int32
+	ExpiresAt       time.Time
 }
 
-func (q *Queries) CreateHistoryRecord(ctx context.Context, arg CreateHistoryRecordParams) (int32, error) {
-	row := q.db.QueryRow(ctx, createHistoryRecord,
-		arg.Username,
-		arg.FromUser,
-		arg.ToUser,
+type GrantCoinsBatchRow struct {
+	Recipients    int32
+	LastBalanceID int32
+}
+
+func (q *Queries) GrantCoinsBatch(ctx context.Context, arg GrantCoinsBatchParams) (GrantCoinsBatchRow, error) {
+	row := q.db.QueryRow(ctx, grantCoinsBatch,
 		arg.Amount,
+		arg.AfterID,
+		arg.BatchSize,
+		arg.CoinOperationID,
+		arg.ExpiresAt,
 	)
-	var id int32
-	err := row.Scan(&id)
-	return id, err
+	var i GrantCoinsBatchRow
+	err := row.Scan(&i.Recipients, &i.LastBalanceID)
+	return i, err
 }
 
-const createInventory = `-- name: CreateInventory :one
-INSERT INTO inventory (username, type, quantity)
-VALUES ($1, $2, 1) RETURNING id
+const hasMerchVariants = `-- name: HasMerchVariants :one
+SELECT EXISTS (SELECT 1 FROM merch_variants WHERE type = $1) AS has_variants
 `
 
-type CreateInventoryParams struct {
-	Username string
+func (q *Queries) HasMerchVariants(ctx context.Context, type_ string) (bool, error) {
+	row := q.db.QueryRow(ctx, hasMerchVariants, type_)
+	var hasVariants bool
+	err := row.Scan(&hasVariants)
+	return hasVariants, err
+}
+
+const hasOverlappingMerchDrop = `-- name: HasOverlappingMerchDrop :one
+SELECT EXISTS (SELECT 1
+               FROM merch_drops
+               WHERE type = $1
+                 AND starts_at < $2
+                 AND ends_at > $3) AS overlapping
+`
+
+type HasOverlappingMerchDropParams struct {
 	Type     string
+	EndsAt   time.Time
+	StartsAt time.Time
 }
 
-func (q *Queries) CreateInventory(ctx context.Context, arg CreateInventoryParams) (int32, error) {
-	row := q.db.QueryRow(ctx, createInventory, arg.Username, arg.Type)
-	var id int32
-	err := row.Scan(&id)
-	return id, err
+func (q *Queries) HasOverlappingMerchDrop(ctx context.Context, arg HasOverlappingMerchDropParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasOverlappingMerchDrop, arg.Type, arg.EndsAt, arg.StartsAt)
+	var overlapping bool
+	err := row.Scan(&overlapping)
+	return overlapping, err
 }
 
-const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, password)
-VALUES ($1, $2) RETURNING id
+const holdBalance = `-- name: HoldBalance :one
+UPDATE balance
+SET held = held + $2
+WHERE username = $1 RETURNING held
 `
 
-type CreateUserParams struct {
+type HoldBalanceParams struct {
 	Username string
-	Password string
+	Held     int64
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int32, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.Password)
-	var id int32
-	err := row.Scan(&id)
-	return id, err
+func (q *Queries) HoldBalance(ctx context.Context, arg HoldBalanceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, holdBalance, arg.Username, arg.Held)
+	var held int64
+	err := row.Scan(&held)
+	return held, err
 }
 
-const getBalance = `-- name: GetBalance :one
-SELECT id, username, coins
+const isHistoryRecordReversed = `-- name: IsHistoryRecordReversed :one
+SELECT EXISTS (SELECT 1 FROM history WHERE reversal_of = $1) AS reversed
+`
+
+func (q *Queries) IsHistoryRecordReversed(ctx context.Context, reversalOf pgtype.Int4) (bool, error) {
+	row := q.db.QueryRow(ctx, isHistoryRecordReversed, reversalOf)
+	var reversed bool
+	err := row.Scan(&reversed)
+	return reversed, err
+}
+
+const isInventoryRecordReversed = `-- name: IsInventoryRecordReversed :one
+SELECT EXISTS (SELECT 1 FROM inventory WHERE reversal_of = $1) AS reversed
+`
+
+func (q *Queries) IsInventoryRecordReversed(ctx context.Context, reversalOf pgtype.Int4) (bool, error) {
+	row := q.db.QueryRow(ctx, isInventoryRecordReversed, reversalOf)
+	var reversed bool
+	err := row.Scan(&reversed)
+	return reversed, err
+}
+
+const lockBalance = `-- name: LockBalance :one
+SELECT coins, held
 FROM balance
-WHERE username = $1 LIMIT 1
+WHERE username = $1 LIMIT 1 FOR UPDATE
 `
 
-func (q *Queries) GetBalance(ctx context.Context, username string) (Balance, error) {
-	row := q.db.QueryRow(ctx, getBalance, username)
-	var i Balance
-	err := row.Scan(&i.ID, &i.Username, &i.Coins)
+type LockBalanceRow struct {
+	Coins int64
+	Held  int64
+}
+
+func (q *Queries) LockBalance(ctx context.Context, username string) (LockBalanceRow, error) {
+	row := q.db.QueryRow(ctx, lockBalance, username)
+	var i LockBalanceRow
+	err := row.Scan(&i.Coins, &i.Held)
 	return i, err
 }
 
-const getHistory = `-- name: GetHistory :many
-SELECT from_user, to_user, SUM(amount) AS amount
-FROM history
+const lockWallet = `-- name: LockWallet :one
+SELECT coins
+FROM wallets
+WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) LockWallet(ctx context.Context, id int32) (int64, error) {
+	row := q.db.QueryRow(ctx, lockWallet, id)
+	var coins int64
+	err := row.Scan(&coins)
+	return coins, err
+}
+
+const releaseMerchStock = `-- name: ReleaseMerchStock :exec
+UPDATE merch
+SET stock = stock + $2
+WHERE type = $1
+  AND stock IS NOT NULL
+`
+
+type ReleaseMerchStockParams struct {
+	Type  string
+	Stock pgtype.Int4
+}
+
+func (q *Queries) ReleaseMerchStock(ctx context.Context, arg ReleaseMerchStockParams) error {
+	_, err := q.db.Exec(ctx, releaseMerchStock, arg.Type, arg.Stock)
+	return err
+}
+
+const releaseVariantStock = `-- name: ReleaseVariantStock :exec
+UPDATE merch_variants
+SET stock = stock + $2
+WHERE id = $1
+  AND stock IS NOT NULL
+`
+
+type ReleaseVariantStockParams struct {
+	ID    int32
+	Stock pgtype.Int4
+}
+
+func (q *Queries) ReleaseVariantStock(ctx context.Context, arg ReleaseVariantStockParams) error {
+	_, err := q.db.Exec(ctx, releaseVariantStock, arg.ID, arg.Stock)
+	return err
+}
+
+const removeCartItem = `-- name: RemoveCartItem :execrows
+DELETE
+FROM cart_items
 WHERE username = $1
-GROUP BY from_user, to_user
+  AND type = $2
 `
 
-type GetHistoryRow struct {
-	FromUser string
-	ToUser   string
-	Amount   int64
+type RemoveCartItemParams struct {
+	Username string
+	Type     string
 }
 
-func (q *Queries) GetHistory(ctx context.Context, username string) ([]GetHistoryRow, error) {
-	rows, err := q.db.Query(ctx, getHistory, username)
+func (q *Queries) RemoveCartItem(ctx context.Context, arg RemoveCartItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeCartItem, arg.Username, arg.Type)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetHistoryRow
-	for rows.Next() {
-		var i GetHistoryRow
-		if err := rows.Scan(&i.FromUser, &i.ToUser, &i.Amount); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getInventory = `-- name: GetInventory :many
-SELECT type, SUM(quantity) AS quantity
-FROM inventory
+const removeWishlistItem = `-- name: RemoveWishlistItem :execrows
+DELETE
+FROM wishlist_items
 WHERE username = $1
-GROUP BY type
+  AND type = $2
 `
 
-type GetInventoryRow struct {
+type RemoveWishlistItemParams struct {
+	Username string
 	Type     string
-	Quantity int64
 }
 
-func (q *Queries) GetInventory(ctx context.Context, username string) ([]GetInventoryRow, error) {
-	rows, err := q.db.Query(ctx, getInventory, username)
+func (q *Queries) RemoveWishlistItem(ctx context.Context, arg RemoveWishlistItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeWishlistItem, arg.Username, arg.Type)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetInventoryRow
-	for rows.Next() {
-		var i GetInventoryRow
-		if err := rows.Scan(&i.Type, &i.Quantity); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected(), nil
+}
+
+const reserveMerchDropStock = `-- name: ReserveMerchDropStock :execrows
+UPDATE merch_drops
+SET stock = stock - $2
+WHERE id = $1
+  AND starts_at <= NOW()
+  AND ends_at > NOW()
+`
+
+type ReserveMerchDropStockParams struct {
+	ID    int32
+	Stock pgtype.Int4
+}
+
+func (q *Queries) ReserveMerchDropStock(ctx context.Context, arg ReserveMerchDropStockParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reserveMerchDropStock, arg.ID, arg.Stock)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getMerch = `-- name: GetMerch :one
-SELECT id, type, price
-FROM merch
-WHERE type = $1 LIMIT 1
+const reserveMerchStock = `-- name: ReserveMerchStock :exec
+UPDATE merch
+SET stock = stock - $2
+WHERE type = $1
+  AND stock IS NOT NULL
 `
 
-func (q *Queries) GetMerch(ctx context.Context, type_ string) (Merch, error) {
-	row := q.db.QueryRow(ctx, getMerch, type_)
-	var i Merch
-	err := row.Scan(&i.ID, &i.Type, &i.Price)
-	return i, err
+type ReserveMerchStockParams struct {
+	Type  string
+	Stock pgtype.Int4
 }
 
-const getUser = `-- name: GetUser :one
-SELECT id, username, password, created_at
-FROM users
-WHERE username = $1 LIMIT 1
+func (q *Queries) ReserveMerchStock(ctx context.Context, arg ReserveMerchStockParams) error {
+	_, err := q.db.Exec(ctx, reserveMerchStock, arg.Type, arg.Stock)
+	return err
+}
+
+const reserveVariantStock = `-- name: ReserveVariantStock :exec
+UPDATE merch_variants
+SET stock = stock - $2
+WHERE id = $1
+  AND stock IS NOT NULL
 `
 
-func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
-	row := q.db.QueryRow(ctx, getUser, username)
-	var i User
+type ReserveVariantStockParams struct {
+	ID    int32
+	Stock pgtype.Int4
+}
+
+func (q *Queries) ReserveVariantStock(ctx context.Context, arg ReserveVariantStockParams) error {
+	_, err := q.db.Exec(ctx, reserveVariantStock, arg.ID, arg.Stock)
+	return err
+}
+
+const restockMerchVariant = `-- name: RestockMerchVariant :one
+UPDATE merch_variants
+SET stock = COALESCE(stock, 0) + $3
+WHERE id = $1
+  AND type = $2
+RETURNING id, type, size, color, stock, created_at
+`
+
+type RestockMerchVariantParams struct {
+	ID    int32
+	Type  string
+	Stock pgtype.Int4
+}
+
+func (q *Queries) RestockMerchVariant(ctx context.Context, arg RestockMerchVariantParams) (MerchVariant, error) {
+	row := q.db.QueryRow(ctx, restockMerchVariant, arg.ID, arg.Type, arg.Stock)
+	var i MerchVariant
 	err := row.Scan(
 		&i.ID,
+		&i.Type,
+		&i.Size,
+		&i.Color,
+		&i.Stock,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const takePurchaseQuote = `-- name: TakePurchaseQuote :one
+DELETE
+FROM purchase_quotes
+WHERE token = $1
+  AND username = $2
+  AND expires_at > NOW()
+RETURNING token, username, type, size, color, quantity, promo_code, price, expires_at, created_at
+`
+
+type TakePurchaseQuoteParams struct {
+	Token    string
+	Username string
+}
+
+func (q *Queries) TakePurchaseQuote(ctx context.Context, arg TakePurchaseQuoteParams) (PurchaseQuote, error) {
+	row := q.db.QueryRow(ctx, takePurchaseQuote, arg.Token, arg.Username)
+	var i PurchaseQuote
+	err := row.Scan(
+		&i.Token,
 		&i.Username,
-		&i.Password,
+		&i.Type,
+		&i.Size,
+		&i.Color,
+		&i.Quantity,
+		&i.PromoCode,
+		&i.Price,
+		&i.ExpiresAt,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const unholdBalance = `-- name: UnholdBalance :exec
+UPDATE balance
+SET held = held - $2
+WHERE username = $1
+`
+
+type UnholdBalanceParams struct {
+	Username string
+	Held     int64
+}
+
+func (q *Queries) UnholdBalance(ctx context.Context, arg UnholdBalanceParams) error {
+	_, err := q.db.Exec(ctx, unholdBalance, arg.Username, arg.Held)
+	return err
+}
+
 const updateBalance = `-- name: UpdateBalance :one
 UPDATE balance
 SET coins = coins + $2
@@ -196,12 +3412,192 @@ WHERE username = $1 RETURNING coins
 
 type UpdateBalanceParams struct {
 	Username string
-	Coins    int32
+	Coins    int64
 }
 
-func (q *Queries) UpdateBalance(ctx context.Context, arg UpdateBalanceParams) (int32, error) {
+func (q *Queries) UpdateBalance(ctx context.Context, arg UpdateBalanceParams) (int64, error) {
 	row := q.db.QueryRow(ctx, updateBalance, arg.Username, arg.Coins)
-	var coins int32
+	var coins int64
+	err := row.Scan(&coins)
+	return coins, err
+}
+
+const updateCoinOperationProgress = `-- name: UpdateCoinOperationProgress :exec
+UPDATE coin_operations
+SET recipients      = recipients + $1,
+    last_balance_id = $2
+WHERE id = $3
+`
+
+type UpdateCoinOperationProgressParams struct {
+	Recipients    int32
+	LastBalanceID int32
+	ID            int32
+}
+
+func (q *Queries) UpdateCoinOperationProgress(ctx context.Context, arg UpdateCoinOperationProgressParams) error {
+	_, err := q.db.Exec(ctx, updateCoinOperationProgress, arg.Recipients, arg.LastBalanceID, arg.ID)
+	return err
+}
+
+const updateMerch = `-- name: UpdateMerch :exec
+UPDATE merch
+SET price       = $2,
+    description = $3,
+    available   = $4,
+    stock       = $5
+WHERE type = $1
+`
+
+type UpdateMerchParams struct {
+	Type        string
+	Price       int64
+	Description string
+	Available   bool
+	Stock       pgtype.Int4
+}
+
+func (q *Queries) UpdateMerch(ctx context.Context, arg UpdateMerchParams) error {
+	_, err := q.db.Exec(ctx, updateMerch,
+		arg.Type,
+		arg.Price,
+		arg.Description,
+		arg.Available,
+		arg.Stock,
+	)
+	return err
+}
+
+const updateOrderStatus = `-- name: UpdateOrderStatus :one
+UPDATE orders
+SET status       = $1,
+    updated_by   = $2,
+    ready_at     = CASE WHEN $1 = 'ready' THEN NOW() ELSE ready_at END,
+    delivered_at = CASE WHEN $1 = 'delivered' THEN NOW() ELSE delivered_at END,
+    cancelled_at = CASE WHEN $1 = 'cancelled' THEN NOW() ELSE cancelled_at END
+WHERE id = $3
+RETURNING id, inventory_id, username, type, quantity, status, updated_by, placed_at, ready_at, delivered_at, cancelled_at
+`
+
+type UpdateOrderStatusParams struct {
+	Status    string
+	UpdatedBy string
+	ID        int32
+}
+
+func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error) {
+	row := q.db.QueryRow(ctx, updateOrderStatus, arg.Status, arg.UpdatedBy, arg.ID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.InventoryID,
+		&i.Username,
+		&i.Type,
+		&i.Quantity,
+		&i.Status,
+		&i.UpdatedBy,
+		&i.PlacedAt,
+		&i.ReadyAt,
+		&i.DeliveredAt,
+		&i.CancelledAt,
+	)
+	return i, err
+}
+
+const updateWalletCoins = `-- name: UpdateWalletCoins :one
+UPDATE wallets
+SET coins = coins + $2
+WHERE id = $1 RETURNING coins
+`
+
+type UpdateWalletCoinsParams struct {
+	ID    int32
+	Coins int64
+}
+
+func (q *Queries) UpdateWalletCoins(ctx context.Context, arg UpdateWalletCoinsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, updateWalletCoins, arg.ID, arg.Coins)
+	var coins int64
 	err := row.Scan(&coins)
 	return coins, err
 }
+
+const upsertPurchaseLimit = `-- name: UpsertPurchaseLimit :one
+INSERT INTO purchase_limits (type, max_quantity, period_days, actor)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (type) DO UPDATE
+    SET max_quantity = EXCLUDED.max_quantity,
+        period_days  = EXCLUDED.period_days,
+        actor        = EXCLUDED.actor,
+        updated_at   = NOW()
+RETURNING type, max_quantity, period_days, actor, updated_at
+`
+
+type UpsertPurchaseLimitParams struct {
+	Type        string
+	MaxQuantity int32
+	PeriodDays  pgtype.Int4
+	Actor       string
+}
+
+func (q *Queries) UpsertPurchaseLimit(ctx context.Context, arg UpsertPurchaseLimitParams) (PurchaseLimit, error) {
+	row := q.db.QueryRow(ctx, upsertPurchaseLimit,
+		arg.Type,
+		arg.MaxQuantity,
+		arg.PeriodDays,
+		arg.Actor,
+	)
+	var i PurchaseLimit
+	err := row.Scan(
+		&i.Type,
+		&i.MaxQuantity,
+		&i.PeriodDays,
+		&i.Actor,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertSetting = `-- name: UpsertSetting :exec
+INSERT INTO settings (name, value, updated_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+    SET value      = EXCLUDED.value,
+        updated_by = EXCLUDED.updated_by,
+        updated_at = NOW()
+`
+
+type UpsertSettingParams struct {
+	Name      string
+	Value     int64
+	UpdatedBy string
+}
+
+func (q *Queries) UpsertSetting(ctx context.Context, arg UpsertSettingParams) error {
+	_, err := q.db.Exec(ctx, upsertSetting, arg.Name, arg.Value, arg.UpdatedBy)
+	return err
+}
+
+const upsertWalletMember = `-- name: UpsertWalletMember :exec
+INSERT INTO wallet_members (wallet_id, username, role, added_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (wallet_id, username) DO UPDATE
+    SET role = EXCLUDED.role
+`
+
+type UpsertWalletMemberParams struct {
+	WalletID int32
+	Username string
+	Role     string
+	AddedBy  string
+}
+
+func (q *Queries) UpsertWalletMember(ctx context.Context, arg UpsertWalletMemberParams) error {
+	_, err := q.db.Exec(ctx, upsertWalletMember,
+		arg.WalletID,
+		arg.Username,
+		arg.Role,
+		arg.AddedBy,
+	)
+	return err
+}
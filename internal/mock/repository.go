@@ -12,6 +12,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	v4 "github.com/cenkalti/backoff/v4"
 	gomock "go.uber.org/mock/gomock"
@@ -43,32 +44,299 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddCartItem mocks base method.
+func (m *MockRepository) AddCartItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, item model.InventoryItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCartItem", ctx, bo, user, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCartItem indicates an expected call of AddCartItem.
+func (mr *MockRepositoryMockRecorder) AddCartItem(ctx, bo, user, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCartItem", reflect.TypeOf((*MockRepository)(nil).AddCartItem), ctx, bo, user, item)
+}
+
+// AddWishlistItem mocks base method.
+func (m *MockRepository) AddWishlistItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, itemType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWishlistItem", ctx, bo, user, itemType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWishlistItem indicates an expected call of AddWishlistItem.
+func (mr *MockRepositoryMockRecorder) AddWishlistItem(ctx, bo, user, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWishlistItem", reflect.TypeOf((*MockRepository)(nil).AddWishlistItem), ctx, bo, user, itemType)
+}
+
+// AdvanceOrder mocks base method.
+func (m *MockRepository) AdvanceOrder(ctx context.Context, bo *v4.ExponentialBackOff, id int, status, actor string) (model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceOrder", ctx, bo, id, status, actor)
+	ret0, _ := ret[0].(model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceOrder indicates an expected call of AdvanceOrder.
+func (mr *MockRepositoryMockRecorder) AdvanceOrder(ctx, bo, id, status, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceOrder", reflect.TypeOf((*MockRepository)(nil).AdvanceOrder), ctx, bo, id, status, actor)
+}
+
+// ArchiveMerch mocks base method.
+func (m *MockRepository) ArchiveMerch(ctx context.Context, bo *v4.ExponentialBackOff, itemType, actor string) (model.MerchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveMerch", ctx, bo, itemType, actor)
+	ret0, _ := ret[0].(model.MerchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveMerch indicates an expected call of ArchiveMerch.
+func (mr *MockRepositoryMockRecorder) ArchiveMerch(ctx, bo, itemType, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveMerch", reflect.TypeOf((*MockRepository)(nil).ArchiveMerch), ctx, bo, itemType, actor)
+}
+
+// BurnCoins mocks base method.
+func (m *MockRepository) BurnCoins(ctx context.Context, bo *v4.ExponentialBackOff, op model.CoinOperation) (model.CoinOperation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BurnCoins", ctx, bo, op)
+	ret0, _ := ret[0].(model.CoinOperation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BurnCoins indicates an expected call of BurnCoins.
+func (mr *MockRepositoryMockRecorder) BurnCoins(ctx, bo, op any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BurnCoins", reflect.TypeOf((*MockRepository)(nil).BurnCoins), ctx, bo, op)
+}
+
+// BuyGift mocks base method.
+func (m *MockRepository) BuyGift(ctx context.Context, bo *v4.ExponentialBackOff, buyer, recipient model.User, item model.InventoryItem, message string) (model.Gift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyGift", ctx, bo, buyer, recipient, item, message)
+	ret0, _ := ret[0].(model.Gift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuyGift indicates an expected call of BuyGift.
+func (mr *MockRepositoryMockRecorder) BuyGift(ctx, bo, buyer, recipient, item, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyGift", reflect.TypeOf((*MockRepository)(nil).BuyGift), ctx, bo, buyer, recipient, item, message)
+}
+
 // BuyItem mocks base method.
-func (m *MockRepository) BuyItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, item model.InventoryItem) error {
+func (m *MockRepository) BuyItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BuyItem", ctx, bo, user, item)
+	ret := m.ctrl.Call(m, "BuyItem", ctx, bo, user, item, promoCode)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BuyItem indicates an expected call of BuyItem.
-func (mr *MockRepositoryMockRecorder) BuyItem(ctx, bo, user, item any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) BuyItem(ctx, bo, user, item, promoCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockRepository)(nil).BuyItem), ctx, bo, user, item, promoCode)
+}
+
+// BuyListing mocks base method.
+func (m *MockRepository) BuyListing(ctx context.Context, bo *v4.ExponentialBackOff, id int, buyer model.User) (model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyListing", ctx, bo, id, buyer)
+	ret0, _ := ret[0].(model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuyListing indicates an expected call of BuyListing.
+func (mr *MockRepositoryMockRecorder) BuyListing(ctx, bo, id, buyer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyListing", reflect.TypeOf((*MockRepository)(nil).BuyListing), ctx, bo, id, buyer)
+}
+
+// BuyQuotedItem mocks base method.
+func (m *MockRepository) BuyQuotedItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode, quoteToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyQuotedItem", ctx, bo, user, item, promoCode, quoteToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BuyQuotedItem indicates an expected call of BuyQuotedItem.
+func (mr *MockRepositoryMockRecorder) BuyQuotedItem(ctx, bo, user, item, promoCode, quoteToken any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockRepository)(nil).BuyItem), ctx, bo, user, item)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyQuotedItem", reflect.TypeOf((*MockRepository)(nil).BuyQuotedItem), ctx, bo, user, item, promoCode, quoteToken)
+}
+
+// CancelListing mocks base method.
+func (m *MockRepository) CancelListing(ctx context.Context, bo *v4.ExponentialBackOff, id int, seller model.User) (model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelListing", ctx, bo, id, seller)
+	ret0, _ := ret[0].(model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelListing indicates an expected call of CancelListing.
+func (mr *MockRepositoryMockRecorder) CancelListing(ctx, bo, id, seller any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelListing", reflect.TypeOf((*MockRepository)(nil).CancelListing), ctx, bo, id, seller)
+}
+
+// CancelOrder mocks base method.
+func (m *MockRepository) CancelOrder(ctx context.Context, bo *v4.ExponentialBackOff, id int, actor string) (model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelOrder", ctx, bo, id, actor)
+	ret0, _ := ret[0].(model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelOrder indicates an expected call of CancelOrder.
+func (mr *MockRepositoryMockRecorder) CancelOrder(ctx, bo, id, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockRepository)(nil).CancelOrder), ctx, bo, id, actor)
+}
+
+// CaptureHold mocks base method.
+func (m *MockRepository) CaptureHold(ctx context.Context, bo *v4.ExponentialBackOff, id int, amount int64) (model.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", ctx, bo, id, amount)
+	ret0, _ := ret[0].(model.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockRepositoryMockRecorder) CaptureHold(ctx, bo, id, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockRepository)(nil).CaptureHold), ctx, bo, id, amount)
+}
+
+// Checkout mocks base method.
+func (m *MockRepository) Checkout(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (model.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkout", ctx, bo, user)
+	ret0, _ := ret[0].(model.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkout indicates an expected call of Checkout.
+func (mr *MockRepositoryMockRecorder) Checkout(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkout", reflect.TypeOf((*MockRepository)(nil).Checkout), ctx, bo, user)
 }
 
 // CreateBalance mocks base method.
-func (m *MockRepository) CreateBalance(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) error {
+func (m *MockRepository) CreateBalance(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, coins int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateBalance", ctx, bo, user)
+	ret := m.ctrl.Call(m, "CreateBalance", ctx, bo, user, coins)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateBalance indicates an expected call of CreateBalance.
-func (mr *MockRepositoryMockRecorder) CreateBalance(ctx, bo, user any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateBalance(ctx, bo, user, coins any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBalance", reflect.TypeOf((*MockRepository)(nil).CreateBalance), ctx, bo, user, coins)
+}
+
+// CreateHold mocks base method.
+func (m *MockRepository) CreateHold(ctx context.Context, bo *v4.ExponentialBackOff, hold model.Hold) (model.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", ctx, bo, hold)
+	ret0, _ := ret[0].(model.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockRepositoryMockRecorder) CreateHold(ctx, bo, hold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockRepository)(nil).CreateHold), ctx, bo, hold)
+}
+
+// CreateListing mocks base method.
+func (m *MockRepository) CreateListing(ctx context.Context, bo *v4.ExponentialBackOff, seller model.User, item model.InventoryItem, price int64) (model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateListing", ctx, bo, seller, item, price)
+	ret0, _ := ret[0].(model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateListing indicates an expected call of CreateListing.
+func (mr *MockRepositoryMockRecorder) CreateListing(ctx, bo, seller, item, price any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateListing", reflect.TypeOf((*MockRepository)(nil).CreateListing), ctx, bo, seller, item, price)
+}
+
+// CreateMerch mocks base method.
+func (m *MockRepository) CreateMerch(ctx context.Context, bo *v4.ExponentialBackOff, item model.MerchItem, actor string) (model.MerchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMerch", ctx, bo, item, actor)
+	ret0, _ := ret[0].(model.MerchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMerch indicates an expected call of CreateMerch.
+func (mr *MockRepositoryMockRecorder) CreateMerch(ctx, bo, item, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMerch", reflect.TypeOf((*MockRepository)(nil).CreateMerch), ctx, bo, item, actor)
+}
+
+// CreateMerchDrop mocks base method.
+func (m *MockRepository) CreateMerchDrop(ctx context.Context, bo *v4.ExponentialBackOff, drop model.MerchDrop, actor string) (model.MerchDrop, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMerchDrop", ctx, bo, drop, actor)
+	ret0, _ := ret[0].(model.MerchDrop)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMerchDrop indicates an expected call of CreateMerchDrop.
+func (mr *MockRepositoryMockRecorder) CreateMerchDrop(ctx, bo, drop, actor any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBalance", reflect.TypeOf((*MockRepository)(nil).CreateBalance), ctx, bo, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMerchDrop", reflect.TypeOf((*MockRepository)(nil).CreateMerchDrop), ctx, bo, drop, actor)
+}
+
+// CreateMerchVariant mocks base method.
+func (m *MockRepository) CreateMerchVariant(ctx context.Context, bo *v4.ExponentialBackOff, variant model.MerchVariant) (model.MerchVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMerchVariant", ctx, bo, variant)
+	ret0, _ := ret[0].(model.MerchVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMerchVariant indicates an expected call of CreateMerchVariant.
+func (mr *MockRepositoryMockRecorder) CreateMerchVariant(ctx, bo, variant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMerchVariant", reflect.TypeOf((*MockRepository)(nil).CreateMerchVariant), ctx, bo, variant)
+}
+
+// CreatePromotion mocks base method.
+func (m *MockRepository) CreatePromotion(ctx context.Context, bo *v4.ExponentialBackOff, promotion model.Promotion, actor string) (model.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePromotion", ctx, bo, promotion, actor)
+	ret0, _ := ret[0].(model.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePromotion indicates an expected call of CreatePromotion.
+func (mr *MockRepositoryMockRecorder) CreatePromotion(ctx, bo, promotion, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePromotion", reflect.TypeOf((*MockRepository)(nil).CreatePromotion), ctx, bo, promotion, actor)
 }
 
 // CreateUser mocks base method.
@@ -86,11 +354,99 @@ func (mr *MockRepositoryMockRecorder) CreateUser(ctx, bo, user any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRepository)(nil).CreateUser), ctx, bo, user)
 }
 
+// CreateWallet mocks base method.
+func (m *MockRepository) CreateWallet(ctx context.Context, bo *v4.ExponentialBackOff, wallet model.Wallet) (model.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWallet", ctx, bo, wallet)
+	ret0, _ := ret[0].(model.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWallet indicates an expected call of CreateWallet.
+func (mr *MockRepositoryMockRecorder) CreateWallet(ctx, bo, wallet any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWallet", reflect.TypeOf((*MockRepository)(nil).CreateWallet), ctx, bo, wallet)
+}
+
+// DeclineGift mocks base method.
+func (m *MockRepository) DeclineGift(ctx context.Context, bo *v4.ExponentialBackOff, id int, recipient model.User) (model.Gift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclineGift", ctx, bo, id, recipient)
+	ret0, _ := ret[0].(model.Gift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeclineGift indicates an expected call of DeclineGift.
+func (mr *MockRepositoryMockRecorder) DeclineGift(ctx, bo, id, recipient any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclineGift", reflect.TypeOf((*MockRepository)(nil).DeclineGift), ctx, bo, id, recipient)
+}
+
+// DeletePurchaseLimit mocks base method.
+func (m *MockRepository) DeletePurchaseLimit(ctx context.Context, bo *v4.ExponentialBackOff, itemType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePurchaseLimit", ctx, bo, itemType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePurchaseLimit indicates an expected call of DeletePurchaseLimit.
+func (mr *MockRepositoryMockRecorder) DeletePurchaseLimit(ctx, bo, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePurchaseLimit", reflect.TypeOf((*MockRepository)(nil).DeletePurchaseLimit), ctx, bo, itemType)
+}
+
+// ExpireCoins mocks base method.
+func (m *MockRepository) ExpireCoins(ctx context.Context, bo *v4.ExponentialBackOff, batchSize int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireCoins", ctx, bo, batchSize)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireCoins indicates an expected call of ExpireCoins.
+func (mr *MockRepositoryMockRecorder) ExpireCoins(ctx, bo, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireCoins", reflect.TypeOf((*MockRepository)(nil).ExpireCoins), ctx, bo, batchSize)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockRepository) ExpireHolds(ctx context.Context, bo *v4.ExponentialBackOff, batchSize int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", ctx, bo, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockRepositoryMockRecorder) ExpireHolds(ctx, bo, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockRepository)(nil).ExpireHolds), ctx, bo, batchSize)
+}
+
+// FundWallet mocks base method.
+func (m *MockRepository) FundWallet(ctx context.Context, bo *v4.ExponentialBackOff, id int, user model.User, amount int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FundWallet", ctx, bo, id, user, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FundWallet indicates an expected call of FundWallet.
+func (mr *MockRepositoryMockRecorder) FundWallet(ctx, bo, id, user, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FundWallet", reflect.TypeOf((*MockRepository)(nil).FundWallet), ctx, bo, id, user, amount)
+}
+
 // GetBalance mocks base method.
-func (m *MockRepository) GetBalance(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (int, error) {
+func (m *MockRepository) GetBalance(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (model.Balance, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetBalance", ctx, bo, user)
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(model.Balance)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -101,6 +457,81 @@ func (mr *MockRepositoryMockRecorder) GetBalance(ctx, bo, user any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockRepository)(nil).GetBalance), ctx, bo, user)
 }
 
+// GetCart mocks base method.
+func (m *MockRepository) GetCart(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (model.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCart", ctx, bo, user)
+	ret0, _ := ret[0].(model.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCart indicates an expected call of GetCart.
+func (mr *MockRepositoryMockRecorder) GetCart(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCart", reflect.TypeOf((*MockRepository)(nil).GetCart), ctx, bo, user)
+}
+
+// GetCatalog mocks base method.
+func (m *MockRepository) GetCatalog(ctx context.Context, bo *v4.ExponentialBackOff, filter model.CatalogFilter) ([]model.MerchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCatalog", ctx, bo, filter)
+	ret0, _ := ret[0].([]model.MerchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCatalog indicates an expected call of GetCatalog.
+func (mr *MockRepositoryMockRecorder) GetCatalog(ctx, bo, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCatalog", reflect.TypeOf((*MockRepository)(nil).GetCatalog), ctx, bo, filter)
+}
+
+// GetCatalogItem mocks base method.
+func (m *MockRepository) GetCatalogItem(ctx context.Context, bo *v4.ExponentialBackOff, itemType string) (model.MerchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCatalogItem", ctx, bo, itemType)
+	ret0, _ := ret[0].(model.MerchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCatalogItem indicates an expected call of GetCatalogItem.
+func (mr *MockRepositoryMockRecorder) GetCatalogItem(ctx, bo, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCatalogItem", reflect.TypeOf((*MockRepository)(nil).GetCatalogItem), ctx, bo, itemType)
+}
+
+// GetExpiringCoins mocks base method.
+func (m *MockRepository) GetExpiringCoins(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, before time.Time) ([]model.ExpiringCoins, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiringCoins", ctx, bo, user, before)
+	ret0, _ := ret[0].([]model.ExpiringCoins)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiringCoins indicates an expected call of GetExpiringCoins.
+func (mr *MockRepositoryMockRecorder) GetExpiringCoins(ctx, bo, user, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiringCoins", reflect.TypeOf((*MockRepository)(nil).GetExpiringCoins), ctx, bo, user, before)
+}
+
+// GetGifts mocks base method.
+func (m *MockRepository) GetGifts(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (model.Gifts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGifts", ctx, bo, user)
+	ret0, _ := ret[0].(model.Gifts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGifts indicates an expected call of GetGifts.
+func (mr *MockRepositoryMockRecorder) GetGifts(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGifts", reflect.TypeOf((*MockRepository)(nil).GetGifts), ctx, bo, user)
+}
+
 // GetHistory mocks base method.
 func (m *MockRepository) GetHistory(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (model.CoinsHistory, error) {
 	m.ctrl.T.Helper()
@@ -116,6 +547,21 @@ func (mr *MockRepositoryMockRecorder) GetHistory(ctx, bo, user any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockRepository)(nil).GetHistory), ctx, bo, user)
 }
 
+// GetHold mocks base method.
+func (m *MockRepository) GetHold(ctx context.Context, bo *v4.ExponentialBackOff, id int) (model.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, bo, id)
+	ret0, _ := ret[0].(model.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockRepositoryMockRecorder) GetHold(ctx, bo, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockRepository)(nil).GetHold), ctx, bo, id)
+}
+
 // GetInventory mocks base method.
 func (m *MockRepository) GetInventory(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) ([]model.InventoryItem, error) {
 	m.ctrl.T.Helper()
@@ -131,16 +577,683 @@ func (mr *MockRepositoryMockRecorder) GetInventory(ctx, bo, user any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInventory", reflect.TypeOf((*MockRepository)(nil).GetInventory), ctx, bo, user)
 }
 
-// SendCoins mocks base method.
-func (m *MockRepository) SendCoins(ctx context.Context, bo *v4.ExponentialBackOff, fromUser, toUser model.User, amount int) error {
+// GetInventoryVariants mocks base method.
+func (m *MockRepository) GetInventoryVariants(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) ([]model.InventoryItem, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendCoins", ctx, bo, fromUser, toUser, amount)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetInventoryVariants", ctx, bo, user)
+	ret0, _ := ret[0].([]model.InventoryItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// SendCoins indicates an expected call of SendCoins.
-func (mr *MockRepositoryMockRecorder) SendCoins(ctx, bo, fromUser, toUser, amount any) *gomock.Call {
+// GetInventoryVariants indicates an expected call of GetInventoryVariants.
+func (mr *MockRepositoryMockRecorder) GetInventoryVariants(ctx, bo, user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCoins", reflect.TypeOf((*MockRepository)(nil).SendCoins), ctx, bo, fromUser, toUser, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInventoryVariants", reflect.TypeOf((*MockRepository)(nil).GetInventoryVariants), ctx, bo, user)
+}
+
+// GetItemsHistory mocks base method.
+func (m *MockRepository) GetItemsHistory(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (model.ItemsHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItemsHistory", ctx, bo, user)
+	ret0, _ := ret[0].(model.ItemsHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItemsHistory indicates an expected call of GetItemsHistory.
+func (mr *MockRepositoryMockRecorder) GetItemsHistory(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemsHistory", reflect.TypeOf((*MockRepository)(nil).GetItemsHistory), ctx, bo, user)
+}
+
+// GetListings mocks base method.
+func (m *MockRepository) GetListings(ctx context.Context, bo *v4.ExponentialBackOff, itemType string) ([]model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListings", ctx, bo, itemType)
+	ret0, _ := ret[0].([]model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListings indicates an expected call of GetListings.
+func (mr *MockRepositoryMockRecorder) GetListings(ctx, bo, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListings", reflect.TypeOf((*MockRepository)(nil).GetListings), ctx, bo, itemType)
+}
+
+// GetLowStockMerch mocks base method.
+func (m *MockRepository) GetLowStockMerch(ctx context.Context, bo *v4.ExponentialBackOff, threshold int) ([]model.MerchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLowStockMerch", ctx, bo, threshold)
+	ret0, _ := ret[0].([]model.MerchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLowStockMerch indicates an expected call of GetLowStockMerch.
+func (mr *MockRepositoryMockRecorder) GetLowStockMerch(ctx, bo, threshold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLowStockMerch", reflect.TypeOf((*MockRepository)(nil).GetLowStockMerch), ctx, bo, threshold)
+}
+
+// GetMerchAudit mocks base method.
+func (m *MockRepository) GetMerchAudit(ctx context.Context, bo *v4.ExponentialBackOff, itemType string) ([]model.MerchAuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMerchAudit", ctx, bo, itemType)
+	ret0, _ := ret[0].([]model.MerchAuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMerchAudit indicates an expected call of GetMerchAudit.
+func (mr *MockRepositoryMockRecorder) GetMerchAudit(ctx, bo, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMerchAudit", reflect.TypeOf((*MockRepository)(nil).GetMerchAudit), ctx, bo, itemType)
+}
+
+// GetMerchDrops mocks base method.
+func (m *MockRepository) GetMerchDrops(ctx context.Context, bo *v4.ExponentialBackOff) ([]model.MerchDrop, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMerchDrops", ctx, bo)
+	ret0, _ := ret[0].([]model.MerchDrop)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMerchDrops indicates an expected call of GetMerchDrops.
+func (mr *MockRepositoryMockRecorder) GetMerchDrops(ctx, bo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMerchDrops", reflect.TypeOf((*MockRepository)(nil).GetMerchDrops), ctx, bo)
+}
+
+// GetMerchPrices mocks base method.
+func (m *MockRepository) GetMerchPrices(ctx context.Context, bo *v4.ExponentialBackOff, itemType string) ([]model.MerchPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMerchPrices", ctx, bo, itemType)
+	ret0, _ := ret[0].([]model.MerchPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMerchPrices indicates an expected call of GetMerchPrices.
+func (mr *MockRepositoryMockRecorder) GetMerchPrices(ctx, bo, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMerchPrices", reflect.TypeOf((*MockRepository)(nil).GetMerchPrices), ctx, bo, itemType)
+}
+
+// GetMerchVariants mocks base method.
+func (m *MockRepository) GetMerchVariants(ctx context.Context, bo *v4.ExponentialBackOff, itemType string) ([]model.MerchVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMerchVariants", ctx, bo, itemType)
+	ret0, _ := ret[0].([]model.MerchVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMerchVariants indicates an expected call of GetMerchVariants.
+func (mr *MockRepositoryMockRecorder) GetMerchVariants(ctx, bo, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMerchVariants", reflect.TypeOf((*MockRepository)(nil).GetMerchVariants), ctx, bo, itemType)
+}
+
+// GetMoneySupply mocks base method.
+func (m *MockRepository) GetMoneySupply(ctx context.Context, bo *v4.ExponentialBackOff) (model.MoneySupply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMoneySupply", ctx, bo)
+	ret0, _ := ret[0].(model.MoneySupply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMoneySupply indicates an expected call of GetMoneySupply.
+func (mr *MockRepositoryMockRecorder) GetMoneySupply(ctx, bo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMoneySupply", reflect.TypeOf((*MockRepository)(nil).GetMoneySupply), ctx, bo)
+}
+
+// GetOperations mocks base method.
+func (m *MockRepository) GetOperations(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (model.Operations, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOperations", ctx, bo, user)
+	ret0, _ := ret[0].(model.Operations)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOperations indicates an expected call of GetOperations.
+func (mr *MockRepositoryMockRecorder) GetOperations(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOperations", reflect.TypeOf((*MockRepository)(nil).GetOperations), ctx, bo, user)
+}
+
+// GetOrders mocks base method.
+func (m *MockRepository) GetOrders(ctx context.Context, bo *v4.ExponentialBackOff, status string) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrders", ctx, bo, status)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrders indicates an expected call of GetOrders.
+func (mr *MockRepositoryMockRecorder) GetOrders(ctx, bo, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockRepository)(nil).GetOrders), ctx, bo, status)
+}
+
+// GetPromotions mocks base method.
+func (m *MockRepository) GetPromotions(ctx context.Context, bo *v4.ExponentialBackOff) ([]model.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotions", ctx, bo)
+	ret0, _ := ret[0].([]model.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotions indicates an expected call of GetPromotions.
+func (mr *MockRepositoryMockRecorder) GetPromotions(ctx, bo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotions", reflect.TypeOf((*MockRepository)(nil).GetPromotions), ctx, bo)
+}
+
+// GetPurchaseLimits mocks base method.
+func (m *MockRepository) GetPurchaseLimits(ctx context.Context, bo *v4.ExponentialBackOff) ([]model.PurchaseLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchaseLimits", ctx, bo)
+	ret0, _ := ret[0].([]model.PurchaseLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchaseLimits indicates an expected call of GetPurchaseLimits.
+func (mr *MockRepositoryMockRecorder) GetPurchaseLimits(ctx, bo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchaseLimits", reflect.TypeOf((*MockRepository)(nil).GetPurchaseLimits), ctx, bo)
+}
+
+// GetPurchases mocks base method.
+func (m *MockRepository) GetPurchases(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) ([]model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchases", ctx, bo, user)
+	ret0, _ := ret[0].([]model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchases indicates an expected call of GetPurchases.
+func (mr *MockRepositoryMockRecorder) GetPurchases(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchases", reflect.TypeOf((*MockRepository)(nil).GetPurchases), ctx, bo, user)
+}
+
+// GetReversals mocks base method.
+func (m *MockRepository) GetReversals(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) ([]model.Reversal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReversals", ctx, bo, user)
+	ret0, _ := ret[0].([]model.Reversal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReversals indicates an expected call of GetReversals.
+func (mr *MockRepositoryMockRecorder) GetReversals(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReversals", reflect.TypeOf((*MockRepository)(nil).GetReversals), ctx, bo, user)
+}
+
+// GetSettings mocks base method.
+func (m *MockRepository) GetSettings(ctx context.Context, bo *v4.ExponentialBackOff, defaults model.Settings) (model.Settings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", ctx, bo, defaults)
+	ret0, _ := ret[0].(model.Settings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings.
+func (mr *MockRepositoryMockRecorder) GetSettings(ctx, bo, defaults any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockRepository)(nil).GetSettings), ctx, bo, defaults)
+}
+
+// GetUser mocks base method.
+func (m *MockRepository) GetUser(ctx context.Context, bo *v4.ExponentialBackOff, userName string) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, bo, userName)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockRepositoryMockRecorder) GetUser(ctx, bo, userName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepository)(nil).GetUser), ctx, bo, userName)
+}
+
+// GetUserListings mocks base method.
+func (m *MockRepository) GetUserListings(ctx context.Context, bo *v4.ExponentialBackOff, seller model.User) ([]model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserListings", ctx, bo, seller)
+	ret0, _ := ret[0].([]model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserListings indicates an expected call of GetUserListings.
+func (mr *MockRepositoryMockRecorder) GetUserListings(ctx, bo, seller any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserListings", reflect.TypeOf((*MockRepository)(nil).GetUserListings), ctx, bo, seller)
+}
+
+// GetUserOrders mocks base method.
+func (m *MockRepository) GetUserOrders(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrders", ctx, bo, user)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrders indicates an expected call of GetUserOrders.
+func (mr *MockRepositoryMockRecorder) GetUserOrders(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockRepository)(nil).GetUserOrders), ctx, bo, user)
+}
+
+// GetWallet mocks base method.
+func (m *MockRepository) GetWallet(ctx context.Context, bo *v4.ExponentialBackOff, id int, user model.User) (model.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWallet", ctx, bo, id, user)
+	ret0, _ := ret[0].(model.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWallet indicates an expected call of GetWallet.
+func (mr *MockRepositoryMockRecorder) GetWallet(ctx, bo, id, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWallet", reflect.TypeOf((*MockRepository)(nil).GetWallet), ctx, bo, id, user)
+}
+
+// GetWallets mocks base method.
+func (m *MockRepository) GetWallets(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) ([]model.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWallets", ctx, bo, user)
+	ret0, _ := ret[0].([]model.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWallets indicates an expected call of GetWallets.
+func (mr *MockRepositoryMockRecorder) GetWallets(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWallets", reflect.TypeOf((*MockRepository)(nil).GetWallets), ctx, bo, user)
+}
+
+// GetWishlist mocks base method.
+func (m *MockRepository) GetWishlist(ctx context.Context, bo *v4.ExponentialBackOff, user model.User) (model.Wishlist, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWishlist", ctx, bo, user)
+	ret0, _ := ret[0].(model.Wishlist)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWishlist indicates an expected call of GetWishlist.
+func (mr *MockRepositoryMockRecorder) GetWishlist(ctx, bo, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWishlist", reflect.TypeOf((*MockRepository)(nil).GetWishlist), ctx, bo, user)
+}
+
+// GrantCoins mocks base method.
+func (m *MockRepository) GrantCoins(ctx context.Context, bo *v4.ExponentialBackOff, op model.CoinOperation, batchSize int) (model.CoinOperation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantCoins", ctx, bo, op, batchSize)
+	ret0, _ := ret[0].(model.CoinOperation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantCoins indicates an expected call of GrantCoins.
+func (mr *MockRepositoryMockRecorder) GrantCoins(ctx, bo, op, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantCoins", reflect.TypeOf((*MockRepository)(nil).GrantCoins), ctx, bo, op, batchSize)
+}
+
+// GrantReferralBonus mocks base method.
+func (m *MockRepository) GrantReferralBonus(ctx context.Context, bo *v4.ExponentialBackOff, referrer model.User, amount int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantReferralBonus", ctx, bo, referrer, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GrantReferralBonus indicates an expected call of GrantReferralBonus.
+func (mr *MockRepositoryMockRecorder) GrantReferralBonus(ctx, bo, referrer, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantReferralBonus", reflect.TypeOf((*MockRepository)(nil).GrantReferralBonus), ctx, bo, referrer, amount)
+}
+
+// MintCoins mocks base method.
+func (m *MockRepository) MintCoins(ctx context.Context, bo *v4.ExponentialBackOff, op model.CoinOperation) (model.CoinOperation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MintCoins", ctx, bo, op)
+	ret0, _ := ret[0].(model.CoinOperation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MintCoins indicates an expected call of MintCoins.
+func (mr *MockRepositoryMockRecorder) MintCoins(ctx, bo, op any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MintCoins", reflect.TypeOf((*MockRepository)(nil).MintCoins), ctx, bo, op)
+}
+
+// QuoteItem mocks base method.
+func (m *MockRepository) QuoteItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, item model.InventoryItem, promoCode string, ttl time.Duration) (model.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteItem", ctx, bo, user, item, promoCode, ttl)
+	ret0, _ := ret[0].(model.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteItem indicates an expected call of QuoteItem.
+func (mr *MockRepositoryMockRecorder) QuoteItem(ctx, bo, user, item, promoCode, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteItem", reflect.TypeOf((*MockRepository)(nil).QuoteItem), ctx, bo, user, item, promoCode, ttl)
+}
+
+// QuoteSendCoins mocks base method.
+func (m *MockRepository) QuoteSendCoins(ctx context.Context, bo *v4.ExponentialBackOff, fromUser, toUser model.User, amount int64) (model.TransferQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteSendCoins", ctx, bo, fromUser, toUser, amount)
+	ret0, _ := ret[0].(model.TransferQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteSendCoins indicates an expected call of QuoteSendCoins.
+func (mr *MockRepositoryMockRecorder) QuoteSendCoins(ctx, bo, fromUser, toUser, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteSendCoins", reflect.TypeOf((*MockRepository)(nil).QuoteSendCoins), ctx, bo, fromUser, toUser, amount)
+}
+
+// ReleaseHold mocks base method.
+func (m *MockRepository) ReleaseHold(ctx context.Context, bo *v4.ExponentialBackOff, id int) (model.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHold", ctx, bo, id)
+	ret0, _ := ret[0].(model.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseHold indicates an expected call of ReleaseHold.
+func (mr *MockRepositoryMockRecorder) ReleaseHold(ctx, bo, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHold", reflect.TypeOf((*MockRepository)(nil).ReleaseHold), ctx, bo, id)
+}
+
+// RemoveCartItem mocks base method.
+func (m *MockRepository) RemoveCartItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, itemType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveCartItem", ctx, bo, user, itemType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveCartItem indicates an expected call of RemoveCartItem.
+func (mr *MockRepositoryMockRecorder) RemoveCartItem(ctx, bo, user, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveCartItem", reflect.TypeOf((*MockRepository)(nil).RemoveCartItem), ctx, bo, user, itemType)
+}
+
+// RemoveWalletMember mocks base method.
+func (m *MockRepository) RemoveWalletMember(ctx context.Context, bo *v4.ExponentialBackOff, id int, username, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveWalletMember", ctx, bo, id, username, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveWalletMember indicates an expected call of RemoveWalletMember.
+func (mr *MockRepositoryMockRecorder) RemoveWalletMember(ctx, bo, id, username, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveWalletMember", reflect.TypeOf((*MockRepository)(nil).RemoveWalletMember), ctx, bo, id, username, actor)
+}
+
+// RemoveWishlistItem mocks base method.
+func (m *MockRepository) RemoveWishlistItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, itemType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveWishlistItem", ctx, bo, user, itemType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveWishlistItem indicates an expected call of RemoveWishlistItem.
+func (mr *MockRepositoryMockRecorder) RemoveWishlistItem(ctx, bo, user, itemType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveWishlistItem", reflect.TypeOf((*MockRepository)(nil).RemoveWishlistItem), ctx, bo, user, itemType)
+}
+
+// RestockMerch mocks base method.
+func (m *MockRepository) RestockMerch(ctx context.Context, bo *v4.ExponentialBackOff, itemType string, quantity int, actor string) (model.MerchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestockMerch", ctx, bo, itemType, quantity, actor)
+	ret0, _ := ret[0].(model.MerchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestockMerch indicates an expected call of RestockMerch.
+func (mr *MockRepositoryMockRecorder) RestockMerch(ctx, bo, itemType, quantity, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestockMerch", reflect.TypeOf((*MockRepository)(nil).RestockMerch), ctx, bo, itemType, quantity, actor)
+}
+
+// RestockMerchVariant mocks base method.
+func (m *MockRepository) RestockMerchVariant(ctx context.Context, bo *v4.ExponentialBackOff, itemType string, id, quantity int) (model.MerchVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestockMerchVariant", ctx, bo, itemType, id, quantity)
+	ret0, _ := ret[0].(model.MerchVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestockMerchVariant indicates an expected call of RestockMerchVariant.
+func (mr *MockRepositoryMockRecorder) RestockMerchVariant(ctx, bo, itemType, id, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestockMerchVariant", reflect.TypeOf((*MockRepository)(nil).RestockMerchVariant), ctx, bo, itemType, id, quantity)
+}
+
+// RestoreMerch mocks base method.
+func (m *MockRepository) RestoreMerch(ctx context.Context, bo *v4.ExponentialBackOff, itemType, actor string) (model.MerchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreMerch", ctx, bo, itemType, actor)
+	ret0, _ := ret[0].(model.MerchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreMerch indicates an expected call of RestoreMerch.
+func (mr *MockRepositoryMockRecorder) RestoreMerch(ctx, bo, itemType, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreMerch", reflect.TypeOf((*MockRepository)(nil).RestoreMerch), ctx, bo, itemType, actor)
+}
+
+// ReturnItem mocks base method.
+func (m *MockRepository) ReturnItem(ctx context.Context, bo *v4.ExponentialBackOff, user model.User, itemType string, boughtAfter time.Time) (model.ItemReturn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReturnItem", ctx, bo, user, itemType, boughtAfter)
+	ret0, _ := ret[0].(model.ItemReturn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReturnItem indicates an expected call of ReturnItem.
+func (mr *MockRepositoryMockRecorder) ReturnItem(ctx, bo, user, itemType, boughtAfter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReturnItem", reflect.TypeOf((*MockRepository)(nil).ReturnItem), ctx, bo, user, itemType, boughtAfter)
+}
+
+// ReversePurchase mocks base method.
+func (m *MockRepository) ReversePurchase(ctx context.Context, bo *v4.ExponentialBackOff, id int) (model.Reversal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReversePurchase", ctx, bo, id)
+	ret0, _ := ret[0].(model.Reversal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReversePurchase indicates an expected call of ReversePurchase.
+func (mr *MockRepositoryMockRecorder) ReversePurchase(ctx, bo, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReversePurchase", reflect.TypeOf((*MockRepository)(nil).ReversePurchase), ctx, bo, id)
+}
+
+// ReverseTransfer mocks base method.
+func (m *MockRepository) ReverseTransfer(ctx context.Context, bo *v4.ExponentialBackOff, id int, force bool) (model.Reversal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransfer", ctx, bo, id, force)
+	ret0, _ := ret[0].(model.Reversal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransfer indicates an expected call of ReverseTransfer.
+func (mr *MockRepositoryMockRecorder) ReverseTransfer(ctx, bo, id, force any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransfer", reflect.TypeOf((*MockRepository)(nil).ReverseTransfer), ctx, bo, id, force)
+}
+
+// SendCoins mocks base method.
+func (m *MockRepository) SendCoins(ctx context.Context, bo *v4.ExponentialBackOff, fromUser, toUser model.User, amount int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendCoins", ctx, bo, fromUser, toUser, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendCoins indicates an expected call of SendCoins.
+func (mr *MockRepositoryMockRecorder) SendCoins(ctx, bo, fromUser, toUser, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCoins", reflect.TypeOf((*MockRepository)(nil).SendCoins), ctx, bo, fromUser, toUser, amount)
+}
+
+// SetMerchPrice mocks base method.
+func (m *MockRepository) SetMerchPrice(ctx context.Context, bo *v4.ExponentialBackOff, itemType string, price int64, validFrom time.Time, actor string) (model.MerchPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMerchPrice", ctx, bo, itemType, price, validFrom, actor)
+	ret0, _ := ret[0].(model.MerchPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetMerchPrice indicates an expected call of SetMerchPrice.
+func (mr *MockRepositoryMockRecorder) SetMerchPrice(ctx, bo, itemType, price, validFrom, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMerchPrice", reflect.TypeOf((*MockRepository)(nil).SetMerchPrice), ctx, bo, itemType, price, validFrom, actor)
+}
+
+// SetPurchaseLimit mocks base method.
+func (m *MockRepository) SetPurchaseLimit(ctx context.Context, bo *v4.ExponentialBackOff, limit model.PurchaseLimit, actor string) (model.PurchaseLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPurchaseLimit", ctx, bo, limit, actor)
+	ret0, _ := ret[0].(model.PurchaseLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPurchaseLimit indicates an expected call of SetPurchaseLimit.
+func (mr *MockRepositoryMockRecorder) SetPurchaseLimit(ctx, bo, limit, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPurchaseLimit", reflect.TypeOf((*MockRepository)(nil).SetPurchaseLimit), ctx, bo, limit, actor)
+}
+
+// SetWalletMember mocks base method.
+func (m *MockRepository) SetWalletMember(ctx context.Context, bo *v4.ExponentialBackOff, id int, member model.WalletMember, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWalletMember", ctx, bo, id, member, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWalletMember indicates an expected call of SetWalletMember.
+func (mr *MockRepositoryMockRecorder) SetWalletMember(ctx, bo, id, member, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWalletMember", reflect.TypeOf((*MockRepository)(nil).SetWalletMember), ctx, bo, id, member, actor)
+}
+
+// TransferItems mocks base method.
+func (m *MockRepository) TransferItems(ctx context.Context, bo *v4.ExponentialBackOff, fromUser, toUser model.User, item model.InventoryItem) (model.ItemTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferItems", ctx, bo, fromUser, toUser, item)
+	ret0, _ := ret[0].(model.ItemTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferItems indicates an expected call of TransferItems.
+func (mr *MockRepositoryMockRecorder) TransferItems(ctx, bo, fromUser, toUser, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferItems", reflect.TypeOf((*MockRepository)(nil).TransferItems), ctx, bo, fromUser, toUser, item)
+}
+
+// UpdateMerch mocks base method.
+func (m *MockRepository) UpdateMerch(ctx context.Context, bo *v4.ExponentialBackOff, itemType string, update model.MerchUpdate, actor string) (model.MerchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMerch", ctx, bo, itemType, update, actor)
+	ret0, _ := ret[0].(model.MerchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMerch indicates an expected call of UpdateMerch.
+func (mr *MockRepositoryMockRecorder) UpdateMerch(ctx, bo, itemType, update, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMerch", reflect.TypeOf((*MockRepository)(nil).UpdateMerch), ctx, bo, itemType, update, actor)
+}
+
+// UpdateSettings mocks base method.
+func (m *MockRepository) UpdateSettings(ctx context.Context, bo *v4.ExponentialBackOff, update model.SettingsUpdate, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", ctx, bo, update, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockRepositoryMockRecorder) UpdateSettings(ctx, bo, update, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockRepository)(nil).UpdateSettings), ctx, bo, update, actor)
+}
+
+// WalletBuyItem mocks base method.
+func (m *MockRepository) WalletBuyItem(ctx context.Context, bo *v4.ExponentialBackOff, id int, member model.User, item model.InventoryItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletBuyItem", ctx, bo, id, member, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletBuyItem indicates an expected call of WalletBuyItem.
+func (mr *MockRepositoryMockRecorder) WalletBuyItem(ctx, bo, id, member, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletBuyItem", reflect.TypeOf((*MockRepository)(nil).WalletBuyItem), ctx, bo, id, member, item)
+}
+
+// WalletSendCoins mocks base method.
+func (m *MockRepository) WalletSendCoins(ctx context.Context, bo *v4.ExponentialBackOff, id int, member, toUser model.User, amount int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletSendCoins", ctx, bo, id, member, toUser, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletSendCoins indicates an expected call of WalletSendCoins.
+func (mr *MockRepositoryMockRecorder) WalletSendCoins(ctx, bo, id, member, toUser, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletSendCoins", reflect.TypeOf((*MockRepository)(nil).WalletSendCoins), ctx, bo, id, member, toUser, amount)
 }
@@ -3,13 +3,103 @@ package model
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"time"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/coins"
+)
+
+const (
+	// ReversalKindTransfer is a kind of reversal of coins transfer.
+	ReversalKindTransfer = "transfer"
+
+	// ReversalKindPurchase is a kind of reversal of item purchase.
+	ReversalKindPurchase = "purchase"
+
+	// CoinOperationMint is a kind of coin operation, that brings new coins into the system.
+	CoinOperationMint = "mint"
+
+	// CoinOperationBurn is a kind of coin operation, that takes coins out of the system.
+	CoinOperationBurn = "burn"
+
+	// maxReasonLength is the maximum length of coin operation reason.
+	maxReasonLength = 255
+
+	// WalletRoleView allows a member to view the wallet and fund it.
+	WalletRoleView = "view"
+
+	// WalletRoleSpend allows a member to spend coins of the wallet as well.
+	WalletRoleSpend = "spend"
+
+	// WalletRoleAdmin allows a member to manage members of the wallet as well.
+	WalletRoleAdmin = "admin"
+
+	// maxWalletNameLength is the maximum length of wallet name.
+	maxWalletNameLength = 50
+
+	// GiftStatusSent is a status of gift, that has been sent to the recipient.
+	GiftStatusSent = "sent"
+
+	// GiftStatusDeclined is a status of gift, that has been declined by the recipient.
+	GiftStatusDeclined = "declined"
+
+	// maxGiftMessageLength is the maximum length of gift message.
+	maxGiftMessageLength = 255
+
+	// ListingStatusActive is a status of listing, that can be bought.
+	ListingStatusActive = "active"
+
+	// ListingStatusSold is a status of listing, that has been bought.
+	ListingStatusSold = "sold"
+
+	// ListingStatusCancelled is a status of listing, that has been cancelled by the seller.
+	ListingStatusCancelled = "cancelled"
+
+	// OrderStatusPlaced is a status of order, that has been placed by a purchase.
+	OrderStatusPlaced = "placed"
+
+	// OrderStatusReady is a status of order, that is ready for pickup at the office.
+	OrderStatusReady = "ready"
+
+	// OrderStatusDelivered is a status of order, that has been handed over to the user.
+	OrderStatusDelivered = "delivered"
+
+	// OrderStatusCancelled is a status of order, that has been cancelled with refund.
+	OrderStatusCancelled = "cancelled"
+
+	// WishlistEventPriceDrop is a kind of wishlist event, when the price of a wished item drops.
+	WishlistEventPriceDrop = "price_drop"
+
+	// WishlistEventBackInStock is a kind of wishlist event, when a wished item can be bought again.
+	WishlistEventBackInStock = "back_in_stock"
+
+	// HoldStatusHeld is a status of hold, that keeps coins of the user from being spent.
+	HoldStatusHeld = "held"
+
+	// HoldStatusCaptured is a status of hold, that has been settled by withdrawal of the captured coins.
+	HoldStatusCaptured = "captured"
+
+	// HoldStatusReleased is a status of hold, that has been released without withdrawal.
+	HoldStatusReleased = "released"
+
+	// HoldStatusExpired is a status of hold, that has been released on expiry.
+	HoldStatusExpired = "expired"
 )
 
+// walletRoleLevels orders wallet roles by the permissions they grant.
+var walletRoleLevels = map[string]int{
+	WalletRoleView:  1,
+	WalletRoleSpend: 2,
+	WalletRoleAdmin: 3,
+}
+
 // User is a user structure.
 type User struct {
 	UserName string `json:"username"`
 	Password string `json:"password"`
+	Referrer string `json:"referrer,omitempty"`
 }
 
 // Bind validates user structure.
@@ -20,6 +110,9 @@ func (u *User) Bind(r *http.Request) error {
 	if u.Password == "" {
 		return fmt.Errorf("password is a required field")
 	}
+	if u.Referrer == u.UserName {
+		return fmt.Errorf("user can't be the referrer of himself")
+	}
 	return nil
 }
 
@@ -37,9 +130,20 @@ func (ar *AuthResponse) Render(w http.ResponseWriter, r *http.Request) error {
 // Info is a structure, that contains information about users
 // coins, inventory and transaction history.
 type Info struct {
-	Coins        int             `json:"coins"`
-	Inventory    []InventoryItem `json:"inventory,omitempty"`
-	CoinsHistory CoinsHistory    `json:"coinHistory,omitempty"`
+	Coins          int64           `json:"coins"`
+	AvailableCoins int64           `json:"availableCoins"`
+	Inventory      []InventoryItem `json:"inventory,omitempty"`
+	CoinsHistory   CoinsHistory    `json:"coinHistory,omitempty"`
+	Reversals      []Reversal      `json:"reversals,omitempty"`
+	ExpiringCoins  []ExpiringCoins `json:"expiringCoins,omitempty"`
+	Gifts          Gifts           `json:"gifts,omitempty"`
+	ItemsHistory   ItemsHistory    `json:"itemHistory,omitempty"`
+}
+
+// ExpiringCoins is the amount of coins, that expire at a given time.
+type ExpiringCoins struct {
+	Amount    int64     `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 // Render tunes rendering of AuthResponse structure.
@@ -48,27 +152,122 @@ func (i *Info) Render(w http.ResponseWriter, r *http.Request) error {
 }
 
 // InventoryItem is an inventory item structure.
+// Size and color are given for an item of a variant of merch only.
 type InventoryItem struct {
 	Type     string `json:"type"`
+	Size     string `json:"size,omitempty"`
+	Color    string `json:"color,omitempty"`
 	Quantity int    `json:"quantity"`
 }
 
+// Inventory is a list of inventory items.
+type Inventory []InventoryItem
+
+// Render tunes rendering of Inventory structure.
+func (i Inventory) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// BuyRequest is a request to buy a quantity of an item, one item is bought if the quantity is not given.
+// Size and color select a variant of merch, that has variants. The promo code is optional.
+// The quote token is optional too, the item is bought at the price of the quote, if it is given.
+type BuyRequest struct {
+	Item       string `json:"item"`
+	Size       string `json:"size,omitempty"`
+	Color      string `json:"color,omitempty"`
+	Quantity   int    `json:"quantity"`
+	PromoCode  string `json:"promoCode,omitempty"`
+	QuoteToken string `json:"quoteToken,omitempty"`
+}
+
+// Bind validates buy request structure.
+func (br *BuyRequest) Bind(r *http.Request) error {
+	if br.Item == "" {
+		return fmt.Errorf("item is a required field")
+	}
+	if br.Quantity == 0 {
+		br.Quantity = 1
+	}
+	if br.Quantity < 0 {
+		return fmt.Errorf("quantity is negative")
+	}
+	if br.Quantity > math.MaxInt32 {
+		return fmt.Errorf("quantity is out of range")
+	}
+	return nil
+}
+
+// Reasons, why a line of the cart can't be bought.
+const (
+	CartReasonUnknownItem         = "unknown item"
+	CartReasonUnknownVariant      = "unknown variant"
+	CartReasonOutOfStock          = "out of stock"
+	CartReasonInsufficientBalance = "insufficient balance"
+	CartReasonLimitReached        = "purchase limit reached"
+)
+
+// CartLine is a quantity of an item in the cart of a user, size and color select a variant of merch.
+// Total is the running total of the cart up to and including the line.
+// Reason tells why the line can't be bought, it is empty if the line can be bought.
+type CartLine struct {
+	Item     string `json:"item"`
+	Size     string `json:"size,omitempty"`
+	Color    string `json:"color,omitempty"`
+	Quantity int    `json:"quantity"`
+	Price    int64  `json:"price"`
+	Cost     int64  `json:"cost"`
+	Total    int64  `json:"total"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Cart is a cart of a user with the total cost of the lines against the available balance of the user,
+// that is the coins of the balance less the held coins.
+type Cart struct {
+	Lines   []CartLine `json:"lines"`
+	Total   int64      `json:"total"`
+	Balance int64      `json:"balance"`
+}
+
+// Render tunes rendering of Cart structure.
+func (c *Cart) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Failed reports whether any line of the cart can't be bought.
+func (c *Cart) Failed() bool {
+	for _, line := range c.Lines {
+		if line.Reason != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // CoinsHistory contains users coin transaction history.
 type CoinsHistory struct {
-	Received []CoinsReceiving `json:"received,omitempty"`
-	Sent     []CoinsSending   `json:"sent,omitempty"`
+	Received    []CoinsReceiving  `json:"received,omitempty"`
+	Sent        []CoinsSending    `json:"sent,omitempty"`
+	Adjustments []CoinsAdjustment `json:"adjustments,omitempty"`
+}
+
+// CoinsAdjustment is a change of the balance without a counterparty - a refund, a bonus, a mint, a burn, an expiry,
+// a capture of a hold or a wallet funding.
+// The amount is positive, if coins have been credited, and negative, if coins have been withdrawn.
+type CoinsAdjustment struct {
+	Kind   string `json:"kind"`
+	Amount int64  `json:"amount"`
 }
 
 // CoinsReceiving is a coins receiving structure.
 type CoinsReceiving struct {
 	FromUser string `json:"fromUser"`
-	Amount   int    `json:"amount"`
+	Amount   int64  `json:"amount"`
 }
 
 // CoinsSending is a coins sending structure.
 type CoinsSending struct {
 	ToUser string `json:"toUser"`
-	Amount int    `json:"amount"`
+	Amount int64  `json:"amount"`
 }
 
 // Bind validates user structure.
@@ -82,5 +281,1082 @@ func (cs *CoinsSending) Bind(r *http.Request) error {
 	if cs.Amount < 0 {
 		return fmt.Errorf("amount is negative")
 	}
+	if cs.Amount > coins.MaxAmount {
+		return fmt.Errorf("amount is out of range")
+	}
+	return nil
+}
+
+// Quote is a quote of a purchase - the price breakdown and the balance of the user before and after the purchase.
+// Shortfall is the amount of coins the available balance lacks for the purchase, zero if the balance is enough.
+// The purchase with the token of the quote is made at the price of the quote, until the quote expires.
+type Quote struct {
+	Item         string    `json:"item"`
+	Size         string    `json:"size,omitempty"`
+	Color        string    `json:"color,omitempty"`
+	Quantity     int       `json:"quantity"`
+	Price        int64     `json:"price"`
+	Cost         int64     `json:"cost"`
+	Discount     int64     `json:"discount"`
+	Total        int64     `json:"total"`
+	Balance      int64     `json:"balance"`
+	BalanceAfter int64     `json:"balanceAfter"`
+	Shortfall    int64     `json:"shortfall"`
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// Render tunes rendering of Quote structure.
+func (q *Quote) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// TransferQuote is a quote of coins sending - the balance of the user that sends before and after the sending.
+// Shortfall is the amount of coins the available balance lacks for the sending, zero if the balance is enough.
+type TransferQuote struct {
+	ToUser       string `json:"toUser"`
+	Amount       int64  `json:"amount"`
+	Balance      int64  `json:"balance"`
+	BalanceAfter int64  `json:"balanceAfter"`
+	Shortfall    int64  `json:"shortfall"`
+}
+
+// Render tunes rendering of TransferQuote structure.
+func (tq *TransferQuote) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Balance is a balance of a user with the amount of coins held on it.
+type Balance struct {
+	Coins int64
+	Held  int64
+}
+
+// Hold is a hold of coins on the balance of a user, created by an integration.
+// Held coins stay on the balance, but can't be spent, until the hold is captured, released or expires.
+type Hold struct {
+	ID        int        `json:"id"`
+	UserName  string     `json:"username"`
+	Amount    int64      `json:"amount"`
+	Captured  int64      `json:"captured"`
+	Status    string     `json:"status"`
+	Reason    string     `json:"reason,omitempty"`
+	CreatedBy string     `json:"createdBy"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+}
+
+// Render tunes rendering of Hold structure.
+func (h *Hold) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// HoldRequest is a request to hold coins on the balance of a user.
+// The hold expires after the default time to live, if the expiry time is not given.
+type HoldRequest struct {
+	UserName  string    `json:"username"`
+	Amount    int64     `json:"amount"`
+	Reason    string    `json:"reason,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
+
+// Bind validates hold request structure.
+func (hr *HoldRequest) Bind(r *http.Request) error {
+	if hr.UserName == "" {
+		return fmt.Errorf("username is a required field")
+	}
+	if hr.Amount == 0 {
+		return fmt.Errorf("amount is a required field")
+	}
+	if hr.Amount < 0 {
+		return fmt.Errorf("amount is negative")
+	}
+	if hr.Amount > coins.MaxAmount {
+		return fmt.Errorf("amount is out of range")
+	}
+	if len(hr.Reason) > maxReasonLength {
+		return fmt.Errorf("reason is too long")
+	}
+	if !hr.ExpiresAt.IsZero() && !hr.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expiresAt is in the past")
+	}
+	return nil
+}
+
+// CaptureRequest is a request to capture coins of a hold, the whole hold is captured if the amount is not given.
+type CaptureRequest struct {
+	Amount int64 `json:"amount,omitempty"`
+}
+
+// Bind validates capture request structure.
+func (cr *CaptureRequest) Bind(r *http.Request) error {
+	if cr.Amount < 0 {
+		return fmt.Errorf("amount is negative")
+	}
+	if cr.Amount > coins.MaxAmount {
+		return fmt.Errorf("amount is out of range")
+	}
+	return nil
+}
+
+// Reversal is a reversal of coins transfer or item purchase,
+// linked to the original operation.
+type Reversal struct {
+	ID         int       `json:"id"`
+	ReversalOf int       `json:"reversalOf"`
+	Kind       string    `json:"kind"`
+	FromUser   string    `json:"fromUser,omitempty"`
+	ToUser     string    `json:"toUser,omitempty"`
+	Item       string    `json:"item,omitempty"`
+	Quantity   int       `json:"quantity,omitempty"`
+	Amount     int64     `json:"amount"`
+	ReversedAt time.Time `json:"reversedAt"`
+}
+
+// Render tunes rendering of Reversal structure.
+func (rv *Reversal) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// ReversalRequest is a request to reverse a transfer or a purchase.
+type ReversalRequest struct {
+	ID    int  `json:"id"`
+	Force bool `json:"force"`
+}
+
+// Bind validates reversal request structure.
+func (rr *ReversalRequest) Bind(r *http.Request) error {
+	if rr.ID == 0 {
+		return fmt.Errorf("id is a required field")
+	}
+	if rr.ID < 0 {
+		return fmt.Errorf("id is negative")
+	}
+	return nil
+}
+
+// Operations contains users transfers and purchases available for reversal.
+type Operations struct {
+	Transfers []Transfer `json:"transfers"`
+	Purchases []Purchase `json:"purchases"`
+}
+
+// Render tunes rendering of Operations structure.
+func (o *Operations) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Transfer is a coins transfer from one user to another.
+type Transfer struct {
+	ID       int       `json:"id"`
+	FromUser string    `json:"fromUser"`
+	ToUser   string    `json:"toUser"`
+	Amount   int64     `json:"amount"`
+	SentAt   time.Time `json:"sentAt"`
+	Reversed bool      `json:"reversed"`
+}
+
+// Purchase is a purchase of inventory item.
+type Purchase struct {
+	ID        int       `json:"id"`
+	Type      string    `json:"type"`
+	Quantity  int       `json:"quantity"`
+	PricePaid int64     `json:"pricePaid"`
+	Discount  int64     `json:"discount"`
+	BoughtAt  time.Time `json:"boughtAt"`
+	Reversed  bool      `json:"reversed"`
+}
+
+// Purchases is a purchase history of a user.
+type Purchases []Purchase
+
+// Render tunes rendering of Purchases structure.
+func (p Purchases) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// CoinOperation is an administrative minting or burning of coins.
+// Minting without a user name grants coins to every user.
+type CoinOperation struct {
+	ID         int       `json:"id"`
+	Kind       string    `json:"kind"`
+	UserName   string    `json:"username,omitempty"`
+	Amount     int64     `json:"amount"`
+	Recipients int       `json:"recipients"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Render tunes rendering of CoinOperation structure.
+func (co *CoinOperation) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// CoinOperationRequest is a request to mint or burn coins.
+type CoinOperationRequest struct {
+	UserName string `json:"username"`
+	Amount   int64  `json:"amount"`
+	Reason   string `json:"reason"`
+}
+
+// Bind validates coin operation request structure.
+func (cor *CoinOperationRequest) Bind(r *http.Request) error {
+	if cor.Amount == 0 {
+		return fmt.Errorf("amount is a required field")
+	}
+	if cor.Amount < 0 {
+		return fmt.Errorf("amount is negative")
+	}
+	if cor.Amount > coins.MaxAmount {
+		return fmt.Errorf("amount is out of range")
+	}
+	if cor.Reason == "" {
+		return fmt.Errorf("reason is a required field")
+	}
+	if len(cor.Reason) > maxReasonLength {
+		return fmt.Errorf("reason is too long")
+	}
+	return nil
+}
+
+// MoneySupply contains total amount of coins in the system
+// and amounts of coins minted and burned by administrators.
+type MoneySupply struct {
+	Coins  int64 `json:"coins"`
+	Users  int   `json:"users"`
+	Minted int64 `json:"minted"`
+	Burned int64 `json:"burned"`
+}
+
+// Render tunes rendering of MoneySupply structure.
+func (ms *MoneySupply) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Settings contains economy parameters of the shop.
+type Settings struct {
+	WelcomeBonus      int64 `json:"welcomeBonus"`
+	ReferralBonus     int64 `json:"referralBonus"`
+	MinTransferAmount int64 `json:"minTransferAmount"`
+}
+
+// Render tunes rendering of Settings structure.
+func (st *Settings) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// SettingsUpdate is a request to change economy parameters of the shop.
+// Only given parameters are changed.
+type SettingsUpdate struct {
+	WelcomeBonus      *int64 `json:"welcomeBonus,omitempty"`
+	ReferralBonus     *int64 `json:"referralBonus,omitempty"`
+	MinTransferAmount *int64 `json:"minTransferAmount,omitempty"`
+}
+
+// Bind validates settings update structure.
+func (su *SettingsUpdate) Bind(r *http.Request) error {
+	if su.WelcomeBonus == nil && su.ReferralBonus == nil && su.MinTransferAmount == nil {
+		return fmt.Errorf("no settings to update")
+	}
+	if su.WelcomeBonus != nil && (*su.WelcomeBonus < 0 || *su.WelcomeBonus > coins.MaxAmount) {
+		return fmt.Errorf("welcomeBonus is out of range")
+	}
+	if su.ReferralBonus != nil && (*su.ReferralBonus < 0 || *su.ReferralBonus > coins.MaxAmount) {
+		return fmt.Errorf("referralBonus is out of range")
+	}
+	if su.MinTransferAmount != nil && (*su.MinTransferAmount < 1 || *su.MinTransferAmount > coins.MaxAmount) {
+		return fmt.Errorf("minTransferAmount is out of range")
+	}
+	return nil
+}
+
+// WalletRoleAllows checks if a given wallet role grants permissions of the required one.
+func WalletRoleAllows(role string, required string) bool {
+	level, ok := walletRoleLevels[role]
+	return ok && level >= walletRoleLevels[required]
+}
+
+// Wallet is a shared wallet, that several members can fund and spend from.
+// Role is the role of the user, who requests the wallet.
+type Wallet struct {
+	ID        int                  `json:"id"`
+	Name      string               `json:"name"`
+	Coins     int64                `json:"coins"`
+	Role      string               `json:"role,omitempty"`
+	CreatedBy string               `json:"createdBy"`
+	CreatedAt time.Time            `json:"createdAt"`
+	Members   []WalletMember       `json:"members,omitempty"`
+	History   []WalletHistoryEntry `json:"history,omitempty"`
+}
+
+// Render tunes rendering of Wallet structure.
+func (wl *Wallet) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Wallets is a list of wallets.
+type Wallets []Wallet
+
+// Render tunes rendering of Wallets structure.
+func (wls Wallets) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// WalletRequest is a request to create new wallet.
+type WalletRequest struct {
+	Name string `json:"name"`
+}
+
+// Bind validates wallet request structure.
+func (wr *WalletRequest) Bind(r *http.Request) error {
+	if wr.Name == "" {
+		return fmt.Errorf("name is a required field")
+	}
+	if len(wr.Name) > maxWalletNameLength {
+		return fmt.Errorf("name is too long")
+	}
+	return nil
+}
+
+// WalletMember is a member of wallet with a role.
+type WalletMember struct {
+	UserName string    `json:"username"`
+	Role     string    `json:"role"`
+	AddedBy  string    `json:"addedBy,omitempty"`
+	AddedAt  time.Time `json:"addedAt,omitempty"`
+}
+
+// Bind validates wallet member structure.
+func (wm *WalletMember) Bind(r *http.Request) error {
+	if wm.UserName == "" {
+		return fmt.Errorf("username is a required field")
+	}
+	if _, ok := walletRoleLevels[wm.Role]; !ok {
+		return fmt.Errorf("role must be one of view, spend, admin")
+	}
+	return nil
+}
+
+// WalletHistoryEntry is a record of wallet funding, spending or refund of a cancelled purchase,
+// UserName is the member, who has initiated the operation.
+type WalletHistoryEntry struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	UserName  string    `json:"username"`
+	Amount    int64     `json:"amount"`
+	ToUser    string    `json:"toUser,omitempty"`
+	Item      string    `json:"item,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WalletFunding is a request to fund a wallet from the balance of a member.
+type WalletFunding struct {
+	Amount int64 `json:"amount"`
+}
+
+// Bind validates wallet funding structure.
+func (wf *WalletFunding) Bind(r *http.Request) error {
+	if wf.Amount == 0 {
+		return fmt.Errorf("amount is a required field")
+	}
+	if wf.Amount < 0 {
+		return fmt.Errorf("amount is negative")
+	}
+	if wf.Amount > coins.MaxAmount {
+		return fmt.Errorf("amount is out of range")
+	}
+	return nil
+}
+
+// Gift is an item, that has been bought by one user for another.
+type Gift struct {
+	ID         int        `json:"id"`
+	FromUser   string     `json:"fromUser"`
+	ToUser     string     `json:"toUser"`
+	Item       string     `json:"item"`
+	Message    string     `json:"message,omitempty"`
+	PricePaid  int64      `json:"pricePaid"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	DeclinedAt *time.Time `json:"declinedAt,omitempty"`
+}
+
+// Render tunes rendering of Gift structure.
+func (g *Gift) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Gifts is a structure of gifts sent and received by a user.
+type Gifts struct {
+	Sent     []Gift `json:"sent,omitempty"`
+	Received []Gift `json:"received,omitempty"`
+}
+
+// GiftRequest is a request to buy an item as a gift.
+type GiftRequest struct {
+	ToUser  string `json:"toUser"`
+	Item    string `json:"item"`
+	Size    string `json:"size,omitempty"`
+	Color   string `json:"color,omitempty"`
+	Message string `json:"message"`
+}
+
+// Bind validates gift request structure.
+func (gr *GiftRequest) Bind(r *http.Request) error {
+	if gr.ToUser == "" {
+		return fmt.Errorf("toUser is a required field")
+	}
+	if gr.Item == "" {
+		return fmt.Errorf("item is a required field")
+	}
+	if len(gr.Message) > maxGiftMessageLength {
+		return fmt.Errorf("message is too long")
+	}
+	return nil
+}
+
+// ItemTransfer is a transfer of owned items from one user to another.
+type ItemTransfer struct {
+	ID        int       `json:"id"`
+	FromUser  string    `json:"fromUser"`
+	ToUser    string    `json:"toUser"`
+	Item      string    `json:"item"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Render tunes rendering of ItemTransfer structure.
+func (it *ItemTransfer) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// ItemsHistory is a structure of item transfers sent and received by a user.
+type ItemsHistory struct {
+	Sent     []ItemTransfer `json:"sent,omitempty"`
+	Received []ItemTransfer `json:"received,omitempty"`
+}
+
+// ItemTransferRequest is a request to transfer owned items to another user.
+type ItemTransferRequest struct {
+	ToUser   string `json:"toUser"`
+	Item     string `json:"item"`
+	Size     string `json:"size,omitempty"`
+	Color    string `json:"color,omitempty"`
+	Quantity int    `json:"quantity"`
+}
+
+// Bind validates item transfer request structure.
+func (itr *ItemTransferRequest) Bind(r *http.Request) error {
+	if itr.ToUser == "" {
+		return fmt.Errorf("toUser is a required field")
+	}
+	if itr.Item == "" {
+		return fmt.Errorf("item is a required field")
+	}
+	if itr.Quantity == 0 {
+		return fmt.Errorf("quantity is a required field")
+	}
+	if itr.Quantity < 0 {
+		return fmt.Errorf("quantity is negative")
+	}
+	if itr.Quantity > math.MaxInt32 {
+		return fmt.Errorf("quantity is out of range")
+	}
+	return nil
+}
+
+// Listing is an owned item, that is put up for sale on the marketplace by a seller.
+type Listing struct {
+	ID        int        `json:"id"`
+	Seller    string     `json:"seller"`
+	Item      string     `json:"item"`
+	Price     int64      `json:"price"`
+	Status    string     `json:"status"`
+	Buyer     string     `json:"buyer,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+}
+
+// Render tunes rendering of Listing structure.
+func (l *Listing) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Listings is a list of marketplace listings.
+type Listings []Listing
+
+// Render tunes rendering of Listings structure.
+func (ls Listings) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// ListingRequest is a request to put an owned item up for sale.
+type ListingRequest struct {
+	Item  string `json:"item"`
+	Size  string `json:"size,omitempty"`
+	Color string `json:"color,omitempty"`
+	Price int64  `json:"price"`
+}
+
+// Bind validates listing request structure.
+func (lr *ListingRequest) Bind(r *http.Request) error {
+	if lr.Item == "" {
+		return fmt.Errorf("item is a required field")
+	}
+	if lr.Price == 0 {
+		return fmt.Errorf("price is a required field")
+	}
+	if lr.Price < 0 {
+		return fmt.Errorf("price is negative")
+	}
+	if lr.Price > coins.MaxAmount {
+		return fmt.Errorf("price is out of range")
+	}
+	return nil
+}
+
+// ItemReturn is a return of purchased item with the refund of the price paid.
+type ItemReturn struct {
+	ID         int       `json:"id"`
+	PurchaseID int       `json:"purchaseId"`
+	Item       string    `json:"item"`
+	Refund     int64     `json:"refund"`
+	ReturnedAt time.Time `json:"returnedAt"`
+}
+
+// Render tunes rendering of ItemReturn structure.
+func (ir *ItemReturn) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// ItemReturnRequest is a request to return a purchased item.
+type ItemReturnRequest struct {
+	Item string `json:"item"`
+}
+
+// Bind validates item return request structure.
+func (irr *ItemReturnRequest) Bind(r *http.Request) error {
+	if irr.Item == "" {
+		return fmt.Errorf("item is a required field")
+	}
+	return nil
+}
+
+// Orders of merch catalog.
+var catalogSorts = []string{"", "type", "-type", "price", "-price"}
+
+// MerchItem is an item of merch catalog.
+// Stock is nil for merch with unlimited supply.
+type MerchItem struct {
+	Type        string `json:"type"`
+	Price       int64  `json:"price"`
+	Description string `json:"description"`
+	Available   bool   `json:"available"`
+	Stock       *int   `json:"stock,omitempty"`
+}
+
+// Render tunes rendering of MerchItem structure.
+func (mi *MerchItem) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Catalog is a list of merch catalog items.
+type Catalog []MerchItem
+
+// Render tunes rendering of Catalog structure.
+func (c Catalog) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// CatalogFilter is a filter and an order of merch catalog items.
+// Sort is one of "type", "-type", "price" or "-price", where "-" means descending order.
+type CatalogFilter struct {
+	MinPrice int64
+	MaxPrice int64
+	Sort     string
+}
+
+// Validate validates catalog filter structure.
+func (cf *CatalogFilter) Validate() error {
+	if cf.MinPrice < 0 || cf.MaxPrice < 0 {
+		return fmt.Errorf("price is negative")
+	}
+	if cf.MaxPrice < cf.MinPrice {
+		return fmt.Errorf("maxPrice is less than minPrice")
+	}
+	if !slices.Contains(catalogSorts, cf.Sort) {
+		return fmt.Errorf("unknown sort: %s", cf.Sort)
+	}
+	return nil
+}
+
+// Actions of merch catalog changes.
+const (
+	MerchActionCreate  = "create"
+	MerchActionUpdate  = "update"
+	MerchActionArchive = "archive"
+	MerchActionRestore = "restore"
+	MerchActionRestock = "restock"
+)
+
+// MerchRequest is a request to add new merch to the catalog.
+// Stock is not tracked for merch without stock.
+type MerchRequest struct {
+	Type        string `json:"type"`
+	Price       int64  `json:"price"`
+	Description string `json:"description"`
+	Stock       *int   `json:"stock,omitempty"`
+}
+
+// Bind validates merch request structure.
+func (mr *MerchRequest) Bind(r *http.Request) error {
+	if mr.Type == "" {
+		return fmt.Errorf("type is a required field")
+	}
+	if len(mr.Type) > 20 {
+		return fmt.Errorf("type is too long")
+	}
+	if mr.Price < 0 || mr.Price > coins.MaxAmount {
+		return fmt.Errorf("price is out of range")
+	}
+	if len(mr.Description) > 255 {
+		return fmt.Errorf("description is too long")
+	}
+	if mr.Stock != nil && (*mr.Stock < 0 || *mr.Stock > math.MaxInt32) {
+		return fmt.Errorf("stock is out of range")
+	}
+	return nil
+}
+
+// MerchDrop is a period, during which merch sold in drops only can be bought.
+// Price is the sale price of the drop, the current price of merch applies if it is nil.
+// Stock caps the quantity sold in the drop, the quantity is not capped if it is nil.
+type MerchDrop struct {
+	ID        int       `json:"id"`
+	Type      string    `json:"type"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	Price     *int64    `json:"price,omitempty"`
+	Stock     *int      `json:"stock,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Render tunes rendering of MerchDrop structure.
+func (md *MerchDrop) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// MerchDrops is a list of merch drops.
+type MerchDrops []MerchDrop
+
+// Render tunes rendering of MerchDrops structure.
+func (md MerchDrops) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// MerchDropRequest is a request to schedule a drop of merch.
+type MerchDropRequest struct {
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+	Price    *int64    `json:"price,omitempty"`
+	Stock    *int      `json:"stock,omitempty"`
+}
+
+// Bind validates merch drop request structure.
+func (mdr *MerchDropRequest) Bind(r *http.Request) error {
+	if mdr.StartsAt.IsZero() || mdr.EndsAt.IsZero() {
+		return fmt.Errorf("startsAt and endsAt are required fields")
+	}
+	if !mdr.EndsAt.After(mdr.StartsAt) {
+		return fmt.Errorf("endsAt is not after startsAt")
+	}
+	if !mdr.EndsAt.After(time.Now()) {
+		return fmt.Errorf("endsAt is in the past")
+	}
+	if mdr.Price != nil && (*mdr.Price < 0 || *mdr.Price > coins.MaxAmount) {
+		return fmt.Errorf("price is out of range")
+	}
+	if mdr.Stock != nil && (*mdr.Stock < 0 || *mdr.Stock > math.MaxInt32) {
+		return fmt.Errorf("stock is out of range")
+	}
+	return nil
+}
+
+// CatalogChange is a change of merch catalog, that brings the catalog to its declared state.
+// Previous values are set for the changed fields of updated merch only.
+type CatalogChange struct {
+	Action              string  `json:"action"`
+	Type                string  `json:"type"`
+	Price               int64   `json:"price"`
+	Description         string  `json:"description"`
+	PreviousPrice       *int64  `json:"previousPrice,omitempty"`
+	PreviousDescription *string `json:"previousDescription,omitempty"`
+}
+
+// MerchUpdate is a request to change price or description of merch.
+// Only given fields are changed.
+type MerchUpdate struct {
+	Price       *int64  `json:"price,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// Bind validates merch update structure.
+func (mu *MerchUpdate) Bind(r *http.Request) error {
+	if mu.Price == nil && mu.Description == nil {
+		return fmt.Errorf("nothing to update")
+	}
+	if mu.Price != nil && (*mu.Price < 0 || *mu.Price > coins.MaxAmount) {
+		return fmt.Errorf("price is out of range")
+	}
+	if mu.Description != nil && len(*mu.Description) > 255 {
+		return fmt.Errorf("description is too long")
+	}
+	return nil
+}
+
+// MerchAuditEntry is a record of merch catalog change with the state of merch after the change.
+type MerchAuditEntry struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"`
+	Action      string    `json:"action"`
+	Price       int64     `json:"price"`
+	Description string    `json:"description"`
+	Available   bool      `json:"available"`
+	Stock       *int      `json:"stock,omitempty"`
+	Actor       string    `json:"actor"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// MerchAudit is a list of merch catalog changes.
+type MerchAudit []MerchAuditEntry
+
+// Render tunes rendering of MerchAudit structure.
+func (ma MerchAudit) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// MerchPrice is a price of merch valid within a period, the period of the latest price has no end.
+type MerchPrice struct {
+	Type      string     `json:"type"`
+	Price     int64      `json:"price"`
+	ValidFrom time.Time  `json:"validFrom"`
+	ValidTo   *time.Time `json:"validTo,omitempty"`
+	Actor     string     `json:"actor"`
+}
+
+// Render tunes rendering of MerchPrice structure.
+func (mp *MerchPrice) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// MerchPrices is a price history of merch.
+type MerchPrices []MerchPrice
+
+// Render tunes rendering of MerchPrices structure.
+func (mp MerchPrices) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// MerchPriceRequest is a request to change price of merch from a given time.
+// The price is changed immediately, if the time is not given.
+type MerchPriceRequest struct {
+	Price     int64      `json:"price"`
+	ValidFrom *time.Time `json:"validFrom,omitempty"`
+}
+
+// Bind validates merch price request structure.
+func (mpr *MerchPriceRequest) Bind(r *http.Request) error {
+	if mpr.Price < 0 || mpr.Price > coins.MaxAmount {
+		return fmt.Errorf("price is out of range")
+	}
+	if mpr.ValidFrom != nil && mpr.ValidFrom.Before(time.Now()) {
+		return fmt.Errorf("validFrom is in the past")
+	}
+	return nil
+}
+
+// MerchVariant is a variant of merch with its attributes.
+// Stock is nil for a variant, that is limited by the stock of merch only.
+type MerchVariant struct {
+	ID    int    `json:"id"`
+	Type  string `json:"type"`
+	Size  string `json:"size,omitempty"`
+	Color string `json:"color,omitempty"`
+	Stock *int   `json:"stock,omitempty"`
+}
+
+// Render tunes rendering of MerchVariant structure.
+func (mv *MerchVariant) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// MerchVariants is a list of variants of merch.
+type MerchVariants []MerchVariant
+
+// Render tunes rendering of MerchVariants structure.
+func (mv MerchVariants) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// MerchVariantRequest is a request to add a variant of merch.
+// Stock is not tracked for a variant without stock.
+type MerchVariantRequest struct {
+	Size  string `json:"size"`
+	Color string `json:"color"`
+	Stock *int   `json:"stock,omitempty"`
+}
+
+// Bind validates merch variant request structure.
+func (mvr *MerchVariantRequest) Bind(r *http.Request) error {
+	if mvr.Size == "" && mvr.Color == "" {
+		return fmt.Errorf("size or color is a required field")
+	}
+	if len(mvr.Size) > 20 || len(mvr.Color) > 20 {
+		return fmt.Errorf("size or color is too long")
+	}
+	if mvr.Stock != nil && (*mvr.Stock < 0 || *mvr.Stock > math.MaxInt32) {
+		return fmt.Errorf("stock is out of range")
+	}
+	return nil
+}
+
+// RestockRequest is a request to add a quantity of merch to its stock.
+type RestockRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// Bind validates restock request structure.
+func (rr *RestockRequest) Bind(r *http.Request) error {
+	if rr.Quantity < 1 || rr.Quantity > math.MaxInt32 {
+		return fmt.Errorf("quantity is out of range")
+	}
+	return nil
+}
+
+// Kinds of promotion discounts.
+const (
+	PromotionPercent = "percent"
+	PromotionFixed   = "fixed"
+)
+
+// Promotion is a discount campaign, that is applied to a purchase with one of its promo codes.
+// Item is empty for catalog-wide promotions, nil caps mean unlimited uses.
+type Promotion struct {
+	ID             int         `json:"id"`
+	Name           string      `json:"name"`
+	Kind           string      `json:"kind"`
+	Value          int64       `json:"value"`
+	Item           string      `json:"item,omitempty"`
+	ValidFrom      time.Time   `json:"validFrom"`
+	ValidTo        *time.Time  `json:"validTo,omitempty"`
+	MaxUses        *int        `json:"maxUses,omitempty"`
+	MaxUsesPerUser *int        `json:"maxUsesPerUser,omitempty"`
+	Codes          []PromoCode `json:"codes"`
+	Uses           int         `json:"uses"`
+	Actor          string      `json:"actor"`
+	CreatedAt      time.Time   `json:"createdAt"`
+}
+
+// Render tunes rendering of Promotion structure.
+func (p *Promotion) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Discount returns the discount of the promotion on a given cost, the discount never exceeds the cost.
+// Percentage discounts are rounded down.
+func (p *Promotion) Discount(cost int64) int64 {
+	if p.Kind == PromotionPercent {
+		return cost/100*p.Value + cost%100*p.Value/100
+	}
+	return min(p.Value, cost)
+}
+
+// PromoCode is a code of a promotion. A single-use code can be used once by anyone.
+type PromoCode struct {
+	Code      string `json:"code"`
+	SingleUse bool   `json:"singleUse"`
+}
+
+// Promotions is a list of promotions.
+type Promotions []Promotion
+
+// Render tunes rendering of Promotions structure.
+func (p Promotions) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// PromotionRequest is a request to create a promotion with a list of codes.
+// The promotion starts immediately, if the start is not given.
+type PromotionRequest struct {
+	Name           string     `json:"name"`
+	Kind           string     `json:"kind"`
+	Value          int64      `json:"value"`
+	Item           string     `json:"item,omitempty"`
+	ValidFrom      *time.Time `json:"validFrom,omitempty"`
+	ValidTo        *time.Time `json:"validTo,omitempty"`
+	MaxUses        *int       `json:"maxUses,omitempty"`
+	MaxUsesPerUser *int       `json:"maxUsesPerUser,omitempty"`
+	Codes          []string   `json:"codes"`
+	SingleUse      bool       `json:"singleUse"`
+}
+
+// Bind validates promotion request structure.
+func (pr *PromotionRequest) Bind(r *http.Request) error {
+	if pr.Name == "" {
+		return fmt.Errorf("name is a required field")
+	}
+	switch pr.Kind {
+	case PromotionPercent:
+		if pr.Value < 1 || pr.Value > 100 {
+			return fmt.Errorf("percent value is out of range")
+		}
+	case PromotionFixed:
+		if pr.Value < 1 || pr.Value > coins.MaxAmount {
+			return fmt.Errorf("value is out of range")
+		}
+	default:
+		return fmt.Errorf("unknown kind: %s", pr.Kind)
+	}
+	if pr.ValidFrom != nil && pr.ValidTo != nil && !pr.ValidTo.After(*pr.ValidFrom) {
+		return fmt.Errorf("validTo is not after validFrom")
+	}
+	if pr.ValidTo != nil && pr.ValidTo.Before(time.Now()) {
+		return fmt.Errorf("validTo is in the past")
+	}
+	if pr.MaxUses != nil && (*pr.MaxUses < 1 || *pr.MaxUses > math.MaxInt32) {
+		return fmt.Errorf("maxUses is out of range")
+	}
+	if pr.MaxUsesPerUser != nil && (*pr.MaxUsesPerUser < 1 || *pr.MaxUsesPerUser > math.MaxInt32) {
+		return fmt.Errorf("maxUsesPerUser is out of range")
+	}
+	if len(pr.Codes) == 0 {
+		return fmt.Errorf("codes is a required field")
+	}
+	for i, code := range pr.Codes {
+		if code == "" || len(code) > 40 {
+			return fmt.Errorf("code is empty or too long")
+		}
+		if slices.Contains(pr.Codes[:i], code) {
+			return fmt.Errorf("duplicate code: %s", code)
+		}
+	}
+	return nil
+}
+
+// MaxPurchaseLimitPeriodDays is the longest period of a purchase limit, longer limits are set without a period.
+const MaxPurchaseLimitPeriodDays = 36500
+
+// PurchaseLimit caps the quantity of merch a user can buy.
+// The quantity is counted over the last PeriodDays days, or over all time if the period is nil.
+type PurchaseLimit struct {
+	Type        string    `json:"type"`
+	MaxQuantity int       `json:"maxQuantity"`
+	PeriodDays  *int      `json:"periodDays,omitempty"`
+	Actor       string    `json:"actor"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Render tunes rendering of PurchaseLimit structure.
+func (pl *PurchaseLimit) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// PurchaseLimits is a list of purchase limits.
+type PurchaseLimits []PurchaseLimit
+
+// Render tunes rendering of PurchaseLimits structure.
+func (pl PurchaseLimits) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// PurchaseLimitRequest is a request to set a purchase limit of merch.
+type PurchaseLimitRequest struct {
+	MaxQuantity int  `json:"maxQuantity"`
+	PeriodDays  *int `json:"periodDays,omitempty"`
+}
+
+// Bind validates purchase limit request structure.
+func (plr *PurchaseLimitRequest) Bind(r *http.Request) error {
+	if plr.MaxQuantity < 1 || plr.MaxQuantity > math.MaxInt32 {
+		return fmt.Errorf("maxQuantity is out of range")
+	}
+	if plr.PeriodDays != nil && (*plr.PeriodDays < 1 || *plr.PeriodDays > MaxPurchaseLimitPeriodDays) {
+		return fmt.Errorf("periodDays is out of range")
+	}
+	return nil
+}
+
+// Order is a fulfilment order of a purchase of merch, that has to be handed over to the user.
+type Order struct {
+	ID          int        `json:"id"`
+	PurchaseID  int        `json:"purchaseId"`
+	User        string     `json:"user"`
+	Item        string     `json:"item"`
+	Quantity    int        `json:"quantity"`
+	Status      string     `json:"status"`
+	UpdatedBy   string     `json:"updatedBy,omitempty"`
+	PlacedAt    time.Time  `json:"placedAt"`
+	ReadyAt     *time.Time `json:"readyAt,omitempty"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+}
+
+// Render tunes rendering of Order structure.
+func (o *Order) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// Orders is a list of orders.
+type Orders []Order
+
+// Render tunes rendering of Orders structure.
+func (o Orders) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// WishlistEvent is an event of a wished item, that happened after the item was added to the wishlist.
+// Price is the price of the item at the moment of the event.
+type WishlistEvent struct {
+	Kind       string    `json:"kind"`
+	Price      int64     `json:"price"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// WishlistItem is an item in the wishlist of a user.
+// Missing is the amount of coins the user still lacks to buy the item, Stock is nil for merch with unlimited supply.
+type WishlistItem struct {
+	Item      string          `json:"item"`
+	Price     int64           `json:"price"`
+	Available bool            `json:"available"`
+	Stock     *int            `json:"stock,omitempty"`
+	Missing   int64           `json:"missing"`
+	AddedAt   time.Time       `json:"addedAt"`
+	Events    []WishlistEvent `json:"events"`
+}
+
+// Wishlist is a wishlist of a user against the balance of the user.
+type Wishlist struct {
+	Items   []WishlistItem `json:"items"`
+	Balance int64          `json:"balance"`
+}
+
+// Render tunes rendering of Wishlist structure.
+func (wl *Wishlist) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// WishlistRequest is a request to add an item to the wishlist.
+type WishlistRequest struct {
+	Item string `json:"item"`
+}
+
+// Bind validates wishlist request structure.
+func (wr *WishlistRequest) Bind(r *http.Request) error {
+	if wr.Item == "" {
+		return fmt.Errorf("item is a required field")
+	}
 	return nil
 }
@@ -0,0 +1,141 @@
+// Package catalog provides the declarative merch catalog file and its reconciliation with the merch catalog.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+)
+
+// Actor is the name, on behalf of which the catalog file changes merch catalog.
+const Actor = "catalog-file"
+
+// Load reads merch of a catalog file.
+// The file is a JSON list of merch with type, price, description and optional initial stock.
+func Load(path string) ([]model.MerchRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read catalog file: %w", err)
+	}
+
+	var items []model.MerchRequest
+	if err = json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parse catalog file: %w", err)
+	}
+
+	types := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if err = item.Bind(nil); err != nil {
+			return nil, fmt.Errorf("merch %q: %w", item.Type, err)
+		}
+		if _, ok := types[item.Type]; ok {
+			return nil, fmt.Errorf("merch %q: type is duplicated", item.Type)
+		}
+		types[item.Type] = struct{}{}
+	}
+
+	return items, nil
+}
+
+// Diff returns changes, that bring the current merch catalog to the declared one:
+// new merch is created, archived merch is restored, changed price or description is updated,
+// and available merch missing in the declared catalog is archived. Stock of existing merch is not changed.
+func Diff(current []model.MerchItem, declared []model.MerchRequest) []model.CatalogChange {
+	existing := make(map[string]model.MerchItem, len(current))
+	for _, item := range current {
+		existing[item.Type] = item
+	}
+
+	changes := make([]model.CatalogChange, 0)
+	declaredTypes := make(map[string]struct{}, len(declared))
+
+	for _, item := range declared {
+		declaredTypes[item.Type] = struct{}{}
+
+		merch, ok := existing[item.Type]
+		if !ok {
+			changes = append(changes, model.CatalogChange{
+				Action:      model.MerchActionCreate,
+				Type:        item.Type,
+				Price:       item.Price,
+				Description: item.Description,
+			})
+			continue
+		}
+
+		if !merch.Available {
+			changes = append(changes, model.CatalogChange{
+				Action:      model.MerchActionRestore,
+				Type:        merch.Type,
+				Price:       merch.Price,
+				Description: merch.Description,
+			})
+		}
+
+		if merch.Price != item.Price || merch.Description != item.Description {
+			change := model.CatalogChange{
+				Action:      model.MerchActionUpdate,
+				Type:        item.Type,
+				Price:       item.Price,
+				Description: item.Description,
+			}
+			if merch.Price != item.Price {
+				change.PreviousPrice = &merch.Price
+			}
+			if merch.Description != item.Description {
+				change.PreviousDescription = &merch.Description
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	for _, merch := range current {
+		if _, ok := declaredTypes[merch.Type]; ok || !merch.Available {
+			continue
+		}
+		changes = append(changes, model.CatalogChange{
+			Action:      model.MerchActionArchive,
+			Type:        merch.Type,
+			Price:       merch.Price,
+			Description: merch.Description,
+		})
+	}
+
+	slices.SortStableFunc(changes, func(a, b model.CatalogChange) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+
+	return changes
+}
+
+// Format returns a human readable diff of catalog changes, one change per line.
+func Format(changes []model.CatalogChange) string {
+	if len(changes) == 0 {
+		return "catalog is up to date\n"
+	}
+
+	var sb strings.Builder
+	for _, change := range changes {
+		switch change.Action {
+		case model.MerchActionCreate:
+			fmt.Fprintf(&sb, "+ %s: price %d, description %q\n", change.Type, change.Price, change.Description)
+		case model.MerchActionRestore:
+			fmt.Fprintf(&sb, "+ %s: restore\n", change.Type)
+		case model.MerchActionArchive:
+			fmt.Fprintf(&sb, "- %s: archive\n", change.Type)
+		case model.MerchActionUpdate:
+			if change.PreviousPrice != nil {
+				fmt.Fprintf(&sb, "~ %s: price %d -> %d\n", change.Type, *change.PreviousPrice, change.Price)
+			}
+			if change.PreviousDescription != nil {
+				fmt.Fprintf(&sb, "~ %s: description %q -> %q\n", change.Type, *change.PreviousDescription, change.Description)
+			}
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+package catalog_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/model"
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/catalog"
+)
+
+var _ = Describe("Catalog", func() {
+	Context("Loading catalog file", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "catalog.json")
+		})
+
+		When("the file is valid", func() {
+			It("returns the declared merch", func() {
+				err := os.WriteFile(path, []byte(`[{"type": "cup", "price": 20, "description": "Ceramic cup"}, {"type": "pen", "price": 10, "stock": 5}]`), 0o600)
+				Expect(err).NotTo(HaveOccurred())
+
+				items, err := catalog.Load(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(2))
+				Expect(items[0].Description).To(Equal("Ceramic cup"))
+				Expect(*items[1].Stock).To(Equal(5))
+			})
+		})
+
+		When("a type is duplicated", func() {
+			It("returns an error", func() {
+				err := os.WriteFile(path, []byte(`[{"type": "cup", "price": 20}, {"type": "cup", "price": 25}]`), 0o600)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = catalog.Load(path)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("a price is negative", func() {
+			It("returns an error", func() {
+				err := os.WriteFile(path, []byte(`[{"type": "cup", "price": -1}]`), 0o600)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = catalog.Load(path)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Comparing the current catalog with the declared one", func() {
+		current := []model.MerchItem{
+			{Type: "book", Price: 50, Description: "Notebook", Available: true},
+			{Type: "cup", Price: 20, Description: "Ceramic cup", Available: true},
+			{Type: "pen", Price: 10, Description: "Pen", Available: false},
+			{Type: "socks", Price: 10, Description: "Socks", Available: true},
+			{Type: "wallet", Price: 50, Description: "Wallet", Available: false},
+		}
+		declared := []model.MerchRequest{
+			{Type: "book", Price: 50, Description: "Notebook"},
+			{Type: "cup", Price: 25, Description: "Ceramic cup"},
+			{Type: "pen", Price: 10, Description: "Pen"},
+			{Type: "umbrella", Price: 200, Description: "Umbrella"},
+		}
+
+		It("returns the changes ordered by type", func() {
+			changes := catalog.Diff(current, declared)
+			Expect(changes).To(HaveLen(4))
+
+			Expect(changes[0].Action).To(Equal(model.MerchActionUpdate))
+			Expect(changes[0].Type).To(Equal("cup"))
+			Expect(*changes[0].PreviousPrice).To(Equal(int64(20)))
+			Expect(changes[0].Price).To(Equal(int64(25)))
+			Expect(changes[0].PreviousDescription).To(BeNil())
+
+			Expect(changes[1].Action).To(Equal(model.MerchActionRestore))
+			Expect(changes[1].Type).To(Equal("pen"))
+
+			Expect(changes[2].Action).To(Equal(model.MerchActionArchive))
+			Expect(changes[2].Type).To(Equal("socks"))
+
+			Expect(changes[3].Action).To(Equal(model.MerchActionCreate))
+			Expect(changes[3].Type).To(Equal("umbrella"))
+		})
+
+		It("formats the changes as a diff", func() {
+			Expect(catalog.Format(catalog.Diff(current, declared))).To(Equal(
+				"~ cup: price 20 -> 25\n" +
+					"+ pen: restore\n" +
+					"- socks: archive\n" +
+					"+ umbrella: price 200, description \"Umbrella\"\n"))
+		})
+
+		It("returns no changes for the catalog in the declared state", func() {
+			changes := catalog.Diff(current[:2], []model.MerchRequest{
+				{Type: "book", Price: 50, Description: "Notebook"},
+				{Type: "cup", Price: 20, Description: "Ceramic cup"},
+			})
+			Expect(changes).To(BeEmpty())
+			Expect(catalog.Format(changes)).To(Equal("catalog is up to date\n"))
+		})
+	})
+})
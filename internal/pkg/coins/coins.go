@@ -0,0 +1,33 @@
+// Package coins provides overflow-safe arithmetic for coin amounts.
+package coins
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxAmount is the maximum amount of coins, that can be used in a single operation.
+const MaxAmount int64 = 1_000_000_000_000
+
+// ErrOverflow error means that the result of an operation is out of coins amount range.
+var ErrOverflow = fmt.Errorf("coins amount overflow")
+
+// Add returns the sum of two amounts or an error if the sum overflows.
+func Add(a, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrOverflow
+	}
+	return a + b, nil
+}
+
+// Mul returns the product of two amounts or an error if the product overflows.
+func Mul(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, ErrOverflow
+	}
+	return product, nil
+}
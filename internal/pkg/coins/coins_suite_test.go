@@ -0,0 +1,13 @@
+package coins_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCoins(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Coins Suite")
+}
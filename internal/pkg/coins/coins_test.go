@@ -0,0 +1,49 @@
+package coins_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/RomanAgaltsev/avito-shop/internal/pkg/coins"
+)
+
+var _ = Describe("Coins", func() {
+	DescribeTable("Adding amounts",
+		func(a, b, expected int64, expectedErr error) {
+			sum, err := coins.Add(a, b)
+			if expectedErr != nil {
+				Expect(err).To(Equal(expectedErr))
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sum).To(Equal(expected))
+		},
+
+		EntryDescription("When a=%d and b=%d"),
+		Entry(nil, int64(100), int64(200), int64(300), nil),
+		Entry(nil, int64(100), int64(-200), int64(-100), nil),
+		Entry(nil, int64(math.MaxInt64), int64(1), int64(0), coins.ErrOverflow),
+		Entry(nil, int64(math.MinInt64), int64(-1), int64(0), coins.ErrOverflow),
+	)
+
+	DescribeTable("Multiplying amounts",
+		func(a, b, expected int64, expectedErr error) {
+			product, err := coins.Mul(a, b)
+			if expectedErr != nil {
+				Expect(err).To(Equal(expectedErr))
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(product).To(Equal(expected))
+		},
+
+		EntryDescription("When a=%d and b=%d"),
+		Entry(nil, int64(50), int64(3), int64(150), nil),
+		Entry(nil, int64(50), int64(0), int64(0), nil),
+		Entry(nil, int64(-50), int64(3), int64(-150), nil),
+		Entry(nil, int64(math.MaxInt64), int64(2), int64(0), coins.ErrOverflow),
+		Entry(nil, int64(math.MinInt64), int64(-1), int64(0), coins.ErrOverflow),
+	)
+})
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE history
+    ADD COLUMN kind        VARCHAR(20) NOT NULL DEFAULT 'transfer',
+    ADD COLUMN reversal_of INTEGER REFERENCES history (id);
+
+ALTER TABLE inventory
+    ADD COLUMN kind        VARCHAR(20) NOT NULL DEFAULT 'purchase',
+    ADD COLUMN price_paid  INTEGER     NOT NULL DEFAULT 0,
+    ADD COLUMN reversal_of INTEGER REFERENCES inventory (id);
+
+UPDATE inventory
+SET price_paid = merch.price
+FROM merch
+WHERE merch.type = inventory.type;
+
+CREATE UNIQUE INDEX history_reversal_of_idx ON history (reversal_of, username);
+CREATE UNIQUE INDEX inventory_reversal_of_idx ON inventory (reversal_of);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX inventory_reversal_of_idx;
+DROP INDEX history_reversal_of_idx;
+
+ALTER TABLE inventory
+    DROP COLUMN reversal_of,
+    DROP COLUMN price_paid,
+    DROP COLUMN kind;
+
+ALTER TABLE history
+    DROP COLUMN reversal_of,
+    DROP COLUMN kind;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE merch
+    ALTER COLUMN price TYPE BIGINT;
+
+ALTER TABLE balance
+    ALTER COLUMN coins TYPE BIGINT;
+
+ALTER TABLE history
+    ALTER COLUMN amount TYPE BIGINT;
+
+ALTER TABLE inventory
+    ALTER COLUMN price_paid TYPE BIGINT;
+
+-- Forced reversals were allowed to make balances negative before the constraint,
+-- such balances are brought to zero by compensating history records, so balances keep matching the history
+INSERT INTO history (username, amount, kind)
+SELECT username, -coins, 'adjustment'
+FROM balance
+WHERE coins < 0;
+
+UPDATE balance
+SET coins = 0
+WHERE coins < 0;
+
+ALTER TABLE balance
+    ADD CONSTRAINT balance_coins_check CHECK (coins >= 0);
+
+ALTER TABLE history
+    ADD CONSTRAINT history_amount_check CHECK (amount > 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE history
+    DROP CONSTRAINT history_amount_check;
+
+ALTER TABLE balance
+    DROP CONSTRAINT balance_coins_check;
+
+ALTER TABLE inventory
+    ALTER COLUMN price_paid TYPE INTEGER;
+
+ALTER TABLE history
+    ALTER COLUMN amount TYPE INTEGER;
+
+ALTER TABLE balance
+    ALTER COLUMN coins TYPE INTEGER;
+
+ALTER TABLE merch
+    ALTER COLUMN price TYPE INTEGER;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE coin_operations (
+    id              SERIAL PRIMARY KEY,
+    kind            VARCHAR(20)  NOT NULL,
+    username        VARCHAR(20)  NOT NULL DEFAULT '',
+    amount          BIGINT       NOT NULL CHECK (amount > 0),
+    recipients      INTEGER      NOT NULL DEFAULT 0,
+    last_balance_id INTEGER      NOT NULL DEFAULT 0,
+    actor           VARCHAR(20)  NOT NULL,
+    reason          VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE history
+    ADD COLUMN coin_operation_id INTEGER REFERENCES coin_operations (id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE history
+    DROP COLUMN coin_operation_id;
+
+DROP TABLE coin_operations;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE settings (
+    name       VARCHAR(50) PRIMARY KEY,
+    value      BIGINT      NOT NULL CHECK (value >= 0),
+    updated_by VARCHAR(20) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE users
+    ADD COLUMN referrer VARCHAR(20) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN referrer;
+
+DROP TABLE settings;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE coin_lots (
+    id          SERIAL PRIMARY KEY,
+    username    VARCHAR(20) NOT NULL,
+    source      VARCHAR(20) NOT NULL,
+    amount      BIGINT      NOT NULL CHECK (amount > 0),
+    remaining   BIGINT      NOT NULL CHECK (remaining >= 0),
+    received_at TIMESTAMP   NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX coin_lots_username_idx ON coin_lots (username, received_at, id) WHERE remaining > 0;
+CREATE INDEX coin_lots_expires_at_idx ON coin_lots (expires_at) WHERE remaining > 0;
+
+-- Coins on balances before lot tracking are considered to be received at the time of migration
+INSERT INTO coin_lots (username, source, amount, remaining, received_at, expires_at)
+SELECT username, 'migration', coins, coins, NOW(), NOW() + INTERVAL '12 months'
+FROM balance
+WHERE coins > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE coin_lots;
+-- +goose StatementEnd
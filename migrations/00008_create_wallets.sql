@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE wallets (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(50) NOT NULL,
+    coins      BIGINT      NOT NULL DEFAULT 0,
+    created_by VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW(),
+    CONSTRAINT wallets_coins_check CHECK (coins >= 0)
+);
+
+CREATE TABLE wallet_members (
+    wallet_id INTEGER     NOT NULL REFERENCES wallets (id),
+    username  VARCHAR(20) NOT NULL,
+    role      VARCHAR(10) NOT NULL CHECK (role IN ('view', 'spend', 'admin')),
+    added_by  VARCHAR(20) NOT NULL,
+    added_at  TIMESTAMP   NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (wallet_id, username)
+);
+
+CREATE INDEX wallet_members_username_idx ON wallet_members (username);
+
+CREATE TABLE wallet_history (
+    id         SERIAL PRIMARY KEY,
+    wallet_id  INTEGER     NOT NULL REFERENCES wallets (id),
+    username   VARCHAR(20) NOT NULL,
+    kind       VARCHAR(20) NOT NULL,
+    amount     BIGINT      NOT NULL CHECK (amount > 0),
+    to_user    VARCHAR(20) NOT NULL DEFAULT '',
+    item       VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX wallet_history_wallet_id_idx ON wallet_history (wallet_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE wallet_history;
+DROP TABLE wallet_members;
+DROP TABLE wallets;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE gifts (
+    id           SERIAL PRIMARY KEY,
+    from_user    VARCHAR(20)  NOT NULL,
+    to_user      VARCHAR(20)  NOT NULL,
+    type         VARCHAR(20)  NOT NULL,
+    price_paid   BIGINT       NOT NULL,
+    message      VARCHAR(255) NOT NULL DEFAULT '',
+    status       VARCHAR(10)  NOT NULL DEFAULT 'sent' CHECK (status IN ('sent', 'declined')),
+    inventory_id INTEGER      NOT NULL REFERENCES inventory (id),
+    created_at   TIMESTAMP    NOT NULL DEFAULT NOW(),
+    declined_at  TIMESTAMP
+);
+
+CREATE INDEX gifts_from_user_idx ON gifts (from_user);
+CREATE INDEX gifts_to_user_idx ON gifts (to_user);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE gifts;
+-- +goose StatementEnd
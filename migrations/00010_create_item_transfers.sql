@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE item_transfers (
+    id         SERIAL PRIMARY KEY,
+    from_user  VARCHAR(20) NOT NULL,
+    to_user    VARCHAR(20) NOT NULL,
+    type       VARCHAR(20) NOT NULL,
+    quantity   INTEGER     NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX item_transfers_from_user_idx ON item_transfers (from_user);
+CREATE INDEX item_transfers_to_user_idx ON item_transfers (to_user);
+CREATE INDEX inventory_username_type_idx ON inventory (username, type);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX inventory_username_type_idx;
+DROP TABLE item_transfers;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE listings (
+    id         SERIAL PRIMARY KEY,
+    seller     VARCHAR(20) NOT NULL,
+    type       VARCHAR(20) NOT NULL,
+    price      BIGINT      NOT NULL CHECK (price > 0),
+    status     VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'sold', 'cancelled')),
+    buyer      VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW(),
+    closed_at  TIMESTAMP
+);
+
+CREATE INDEX listings_active_idx ON listings (type, id) WHERE status = 'active';
+CREATE INDEX listings_seller_idx ON listings (seller);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE listings;
+-- +goose StatementEnd
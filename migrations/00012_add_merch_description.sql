@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE merch
+    ADD COLUMN description VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN available   BOOLEAN      NOT NULL DEFAULT TRUE;
+
+UPDATE merch
+SET description = d.description
+FROM (VALUES ('t-shirt', 'T-shirt with the company logo'),
+             ('cup', 'Ceramic cup with the company logo'),
+             ('book', 'Notebook with the company logo'),
+             ('pen', 'Ballpoint pen with the company logo'),
+             ('powerbank', 'Powerbank with the company logo'),
+             ('hoody', 'Hoody with the company logo'),
+             ('umbrella', 'Umbrella with the company logo'),
+             ('socks', 'Pair of socks with the company logo'),
+             ('wallet', 'Leather wallet with the company logo'),
+             ('pink-hoody', 'Pink hoody with the company logo')) AS d (type, description)
+WHERE merch.type = d.type;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE merch
+    DROP COLUMN available,
+    DROP COLUMN description;
+-- +goose StatementEnd
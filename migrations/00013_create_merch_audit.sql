@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE merch_audit (
+    id          SERIAL PRIMARY KEY,
+    type        VARCHAR(20)  NOT NULL,
+    action      VARCHAR(20)  NOT NULL,
+    price       BIGINT       NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    available   BOOLEAN      NOT NULL,
+    actor       VARCHAR(20)  NOT NULL,
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX merch_audit_type_idx ON merch_audit (type, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE merch_audit;
+-- +goose StatementEnd
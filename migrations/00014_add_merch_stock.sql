@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Stock is not tracked for merch with NULL stock, the supply of such merch is unlimited
+ALTER TABLE merch
+    ADD COLUMN stock INTEGER CONSTRAINT merch_stock_check CHECK (stock >= 0);
+
+ALTER TABLE merch_audit
+    ADD COLUMN stock INTEGER;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE merch_audit
+    DROP COLUMN stock;
+
+ALTER TABLE merch
+    DROP COLUMN stock;
+-- +goose StatementEnd
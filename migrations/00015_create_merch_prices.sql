@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Price of merch is valid from valid_from until valid_to, the period of the latest price has no end
+CREATE TABLE merch_prices (
+    id         SERIAL PRIMARY KEY,
+    type       VARCHAR(20) NOT NULL,
+    price      BIGINT      NOT NULL CHECK (price >= 0),
+    valid_from TIMESTAMP   NOT NULL,
+    valid_to   TIMESTAMP,
+    actor      VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX merch_prices_type_valid_from_idx ON merch_prices (type, valid_from);
+
+INSERT INTO merch_prices (type, price, valid_from)
+SELECT type, price, NOW()
+FROM merch;
+
+-- Merch with the price valid at the moment, merch without price history keeps its own price
+CREATE VIEW merch_current AS
+SELECT m.id,
+       m.type,
+       COALESCE((SELECT p.price
+                 FROM merch_prices p
+                 WHERE p.type = m.type
+                   AND p.valid_from <= NOW()
+                   AND (p.valid_to IS NULL OR p.valid_to > NOW())), m.price)::BIGINT AS price,
+       m.description,
+       m.available,
+       m.stock
+FROM merch m;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW merch_current;
+DROP TABLE merch_prices;
+-- +goose StatementEnd
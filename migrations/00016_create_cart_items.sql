@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE cart_items (
+    username VARCHAR(20) NOT NULL,
+    type     VARCHAR(20) NOT NULL,
+    quantity INTEGER     NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMP   NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (username, type)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE cart_items;
+-- +goose StatementEnd
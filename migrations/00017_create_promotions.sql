@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE promotions (
+    id                SERIAL PRIMARY KEY,
+    name              VARCHAR(100) NOT NULL,
+    kind              VARCHAR(20)  NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value             BIGINT       NOT NULL CHECK (value > 0),
+    type              VARCHAR(20),
+    valid_from        TIMESTAMP    NOT NULL DEFAULT NOW(),
+    valid_to          TIMESTAMP,
+    max_uses          INTEGER CHECK (max_uses > 0),
+    max_uses_per_user INTEGER CHECK (max_uses_per_user > 0),
+    actor             VARCHAR(20)  NOT NULL,
+    created_at        TIMESTAMP    NOT NULL DEFAULT NOW(),
+    CHECK (kind <> 'percent' OR value <= 100),
+    CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+
+CREATE TABLE promo_codes (
+    code         VARCHAR(40) PRIMARY KEY,
+    promotion_id INTEGER     NOT NULL REFERENCES promotions (id),
+    single_use   BOOLEAN     NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE promo_redemptions (
+    id           SERIAL PRIMARY KEY,
+    promotion_id INTEGER     NOT NULL REFERENCES promotions (id),
+    code         VARCHAR(40) NOT NULL REFERENCES promo_codes (code),
+    username     VARCHAR(20) NOT NULL,
+    inventory_id INTEGER     NOT NULL REFERENCES inventory (id),
+    discount     BIGINT      NOT NULL CHECK (discount >= 0),
+    created_at   TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX promo_codes_promotion_id_idx ON promo_codes (promotion_id);
+CREATE INDEX promo_redemptions_promotion_id_idx ON promo_redemptions (promotion_id);
+
+ALTER TABLE inventory
+    ADD COLUMN discount BIGINT NOT NULL DEFAULT 0 CHECK (discount >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE inventory
+    DROP COLUMN discount;
+
+DROP TABLE promo_redemptions;
+DROP TABLE promo_codes;
+DROP TABLE promotions;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Stock is not tracked for variants with NULL stock, the supply of such variants is limited by the stock of merch only
+CREATE TABLE merch_variants (
+    id         SERIAL PRIMARY KEY,
+    type       VARCHAR(20) NOT NULL REFERENCES merch (type),
+    size       VARCHAR(20) NOT NULL DEFAULT '',
+    color      VARCHAR(20) NOT NULL DEFAULT '',
+    stock      INTEGER CONSTRAINT merch_variants_stock_check CHECK (stock >= 0),
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW(),
+    UNIQUE (type, size, color)
+);
+
+ALTER TABLE inventory
+    ADD COLUMN variant_id INTEGER REFERENCES merch_variants (id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE inventory
+    DROP COLUMN variant_id;
+
+DROP TABLE merch_variants;
+-- +goose StatementEnd
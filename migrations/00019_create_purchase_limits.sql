@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A limit with NULL period caps the quantity of merch a user can ever buy
+CREATE TABLE purchase_limits (
+    type         VARCHAR(20) PRIMARY KEY REFERENCES merch (type),
+    max_quantity INTEGER     NOT NULL CHECK (max_quantity > 0),
+    period_days  INTEGER CHECK (period_days > 0),
+    actor        VARCHAR(20) NOT NULL,
+    updated_at   TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE purchase_limits;
+-- +goose StatementEnd
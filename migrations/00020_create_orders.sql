@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE orders (
+    id           SERIAL PRIMARY KEY,
+    inventory_id INTEGER     NOT NULL UNIQUE REFERENCES inventory (id),
+    username     VARCHAR(20) NOT NULL,
+    type         VARCHAR(20) NOT NULL,
+    quantity     INTEGER     NOT NULL CHECK (quantity > 0),
+    status       VARCHAR(10) NOT NULL DEFAULT 'placed' CHECK (status IN ('placed', 'ready', 'delivered', 'cancelled')),
+    updated_by   VARCHAR(20) NOT NULL DEFAULT '',
+    placed_at    TIMESTAMP   NOT NULL DEFAULT NOW(),
+    ready_at     TIMESTAMP,
+    delivered_at TIMESTAMP,
+    cancelled_at TIMESTAMP
+);
+
+CREATE INDEX orders_username_idx ON orders (username, id);
+CREATE INDEX orders_open_idx ON orders (status, id) WHERE status IN ('placed', 'ready');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE orders;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE wishlist_items (
+    username VARCHAR(20) NOT NULL,
+    type     VARCHAR(20) NOT NULL REFERENCES merch (type),
+    added_at TIMESTAMP   NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (username, type)
+);
+
+-- Moments, when merch could be bought again after it was archived or out of stock
+CREATE TABLE merch_back_in_stock (
+    id         SERIAL PRIMARY KEY,
+    type       VARCHAR(20) NOT NULL,
+    price      BIGINT      NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX merch_back_in_stock_type_idx ON merch_back_in_stock (type, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE merch_back_in_stock;
+DROP TABLE wishlist_items;
+-- +goose StatementEnd
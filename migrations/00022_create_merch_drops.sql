@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Merch with drops is sold during its drops only, at the sale price of the drop, if it is set.
+-- Stock of a drop caps the quantity sold in the drop, the quantity is not capped if the stock is NULL
+CREATE TABLE merch_drops (
+    id         SERIAL PRIMARY KEY,
+    type       VARCHAR(20) NOT NULL REFERENCES merch (type),
+    starts_at  TIMESTAMP   NOT NULL,
+    ends_at    TIMESTAMP   NOT NULL,
+    price      BIGINT CHECK (price >= 0),
+    stock      INTEGER CONSTRAINT merch_drops_stock_check CHECK (stock >= 0),
+    actor      VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX merch_drops_type_idx ON merch_drops (type, starts_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE merch_drops;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Quote of a purchase locks the price of merch for a short time, the quote is removed, when the purchase honours it
+CREATE TABLE purchase_quotes (
+    token      VARCHAR(64) PRIMARY KEY,
+    username   VARCHAR(20) NOT NULL,
+    type       VARCHAR(20) NOT NULL REFERENCES merch (type),
+    size       VARCHAR(20) NOT NULL DEFAULT '',
+    color      VARCHAR(20) NOT NULL DEFAULT '',
+    quantity   INTEGER     NOT NULL CHECK (quantity > 0),
+    promo_code VARCHAR(40) NOT NULL DEFAULT '',
+    price      BIGINT      NOT NULL CHECK (price >= 0),
+    expires_at TIMESTAMP   NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX purchase_quotes_username_idx ON purchase_quotes (username, expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE purchase_quotes;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Held coins stay on the balance, but can't be spent until the hold is captured or released
+ALTER TABLE balance
+    ADD COLUMN held BIGINT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT balance_held_check CHECK (held >= 0 AND held <= coins);
+
+-- Hold of coins on the balance of a user, authorized by an integration and captured or released later
+CREATE TABLE balance_holds (
+    id         SERIAL PRIMARY KEY,
+    username   VARCHAR(20)  NOT NULL,
+    amount     BIGINT       NOT NULL CHECK (amount > 0),
+    captured   BIGINT       NOT NULL DEFAULT 0,
+    status     VARCHAR(10)  NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'captured', 'released', 'expired')),
+    reason     VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(20)  NOT NULL,
+    expires_at TIMESTAMP    NOT NULL,
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    closed_at  TIMESTAMP
+);
+
+CREATE INDEX balance_holds_expires_at_idx ON balance_holds (expires_at) WHERE status = 'held';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE balance_holds;
+
+ALTER TABLE balance
+    DROP CONSTRAINT balance_held_check,
+    DROP COLUMN held;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE gifts
+    ADD COLUMN variant_id INTEGER REFERENCES merch_variants (id);
+
+ALTER TABLE listings
+    ADD COLUMN variant_id INTEGER REFERENCES merch_variants (id);
+
+-- Gifts are bought as a single inventory record, that holds the variant of the gift
+UPDATE gifts
+SET variant_id = inventory.variant_id
+FROM inventory
+WHERE inventory.id = gifts.inventory_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listings
+    DROP COLUMN variant_id;
+
+ALTER TABLE gifts
+    DROP COLUMN variant_id;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE cart_items
+    ADD COLUMN size  VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN color VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Every variant of merch is a line of its own
+ALTER TABLE cart_items
+    DROP CONSTRAINT cart_items_pkey,
+    ADD PRIMARY KEY (username, type, size, color);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE
+FROM cart_items
+WHERE size <> ''
+   OR color <> '';
+
+ALTER TABLE cart_items
+    DROP CONSTRAINT cart_items_pkey,
+    ADD PRIMARY KEY (username, type);
+
+ALTER TABLE cart_items
+    DROP COLUMN color,
+    DROP COLUMN size;
+-- +goose StatementEnd
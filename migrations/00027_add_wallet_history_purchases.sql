@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallet_history
+    ADD COLUMN inventory_id INTEGER REFERENCES inventory (id);
+
+-- A wallet purchase is recorded in the same transaction as the inventory record of the purchase
+UPDATE wallet_history
+SET inventory_id = inventory.id
+FROM inventory
+WHERE wallet_history.kind = 'purchase'
+  AND inventory.kind = 'wallet'
+  AND inventory.username = wallet_history.username
+  AND inventory.type = wallet_history.item
+  AND inventory.bought_at = wallet_history.created_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallet_history
+    DROP COLUMN inventory_id;
+-- +goose StatementEnd
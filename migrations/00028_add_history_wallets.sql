@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE history
+    ADD COLUMN wallet_id INTEGER REFERENCES wallets (id);
+
+-- Funding of a wallet is withdrawn from the balance of the member
+INSERT INTO history (username, amount, sent_at, kind, wallet_id)
+SELECT username, amount, created_at, 'wallet_funding', wallet_id
+FROM wallet_history
+WHERE kind = 'fund';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE
+FROM history
+WHERE kind = 'wallet_funding';
+
+ALTER TABLE history
+    DROP COLUMN wallet_id;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Captured coins are withdrawn, not sent to the integration that has captured them
+UPDATE history
+SET to_user = ''
+WHERE kind = 'capture';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE history
+SET to_user = balance_holds.created_by
+FROM balance_holds
+WHERE history.kind = 'capture'
+  AND balance_holds.username = history.username
+  AND balance_holds.status = 'captured'
+  AND balance_holds.captured = history.amount
+  AND balance_holds.closed_at = history.sent_at;
+-- +goose StatementEnd
@@ -75,7 +75,7 @@ var _ = Describe("End2End", Serial, func() {
 				DeferCleanup(response.Body.Close)
 
 				Expect(err).ShouldNot(HaveOccurred())
-				Expect(info.Coins).To(Equal(1000))
+				Expect(info.Coins).To(Equal(int64(1000)))
 				Expect(info.Inventory).Should(HaveLen(0))
 				Expect(info.CoinsHistory.Received).Should(HaveLen(0))
 				Expect(info.CoinsHistory.Sent).Should(HaveLen(0))
@@ -84,7 +84,7 @@ var _ = Describe("End2End", Serial, func() {
 
 		When("registered user tries to buy an existed item and has enough coins", Ordered, func() {
 			var item string
-			var itemPrice int
+			var itemPrice int64
 
 			BeforeAll(func() {
 				httpClient = http.Client{}
@@ -122,7 +122,7 @@ var _ = Describe("End2End", Serial, func() {
 				DeferCleanup(response.Body.Close)
 
 				Expect(err).ShouldNot(HaveOccurred())
-				Expect(info.Coins).To(Equal(1000 - itemPrice))
+				Expect(info.Coins).To(Equal(int64(1000) - itemPrice))
 				Expect(info.Inventory).Should(HaveLen(1))
 				Expect(info.Inventory[0].Type).To(Equal(item))
 				Expect(info.Inventory[0].Quantity).To(Equal(1))
@@ -255,7 +255,7 @@ var _ = Describe("End2End", Serial, func() {
 				DeferCleanup(response.Body.Close)
 
 				Expect(err).ShouldNot(HaveOccurred())
-				Expect(info.Coins).To(Equal(1000))
+				Expect(info.Coins).To(Equal(int64(1000)))
 				Expect(info.Inventory).Should(HaveLen(0))
 				Expect(info.CoinsHistory.Received).Should(HaveLen(0))
 				Expect(info.CoinsHistory.Sent).Should(HaveLen(0))
@@ -265,7 +265,7 @@ var _ = Describe("End2End", Serial, func() {
 		When("registered user tries to send coins and has enough amount", Ordered, func() {
 			var fromUsername string
 			var toUsername string
-			var coins int
+			var coins int64
 
 			BeforeAll(func() {
 				httpClient = http.Client{}
@@ -311,7 +311,7 @@ var _ = Describe("End2End", Serial, func() {
 				DeferCleanup(response.Body.Close)
 
 				Expect(err).ShouldNot(HaveOccurred())
-				Expect(info.Coins).To(Equal(1000 - coins))
+				Expect(info.Coins).To(Equal(int64(1000) - coins))
 				Expect(info.CoinsHistory.Sent).Should(HaveLen(1))
 				Expect(info.CoinsHistory.Sent[0].ToUser).Should(Equal(toUsername))
 				Expect(info.CoinsHistory.Sent[0].Amount).Should(Equal(coins))
@@ -342,7 +342,7 @@ var _ = Describe("End2End", Serial, func() {
 
 		When("registered user tries to send coins and has not enough amount", Ordered, func() {
 			var toUsername string
-			var coins int
+			var coins int64
 
 			BeforeAll(func() {
 				httpClient = http.Client{}
@@ -382,7 +382,7 @@ var _ = Describe("End2End", Serial, func() {
 
 		When("registered user tries to send coins and receiving user does not exist", Ordered, func() {
 			var toUsername string
-			var coins int
+			var coins int64
 
 			BeforeAll(func() {
 				httpClient = http.Client{}